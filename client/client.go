@@ -0,0 +1,562 @@
+// Package client is a Go client for the scanner's WebSocket API. It decodes
+// every message into typed structs, reconnects with backoff and resumes from
+// the last message it saw, and restores its subscriptions on every new
+// connection.
+//
+//	c := client.New("ws://localhost:8082/ws", client.WithAPIKey(key))
+//	go c.Run(ctx)
+//	for o := range c.Opportunities(ctx, 64) {
+//		...
+//	}
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/scanner"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	DefaultMinBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+
+	// readTimeout is how long the connection may stay silent. The server
+	// pings well within it.
+	readTimeout = 90 * time.Second
+	writeWait   = 10 * time.Second
+)
+
+// ErrUnauthorized is returned by Run when the server rejects the credential.
+// The client does not retry it.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Handlers are called for each message as it arrives. Nil fields are
+// skipped. They run on the connection's read loop, so they should return
+// quickly and must not wait on the client, e.g. by calling Subscribe.
+type Handlers struct {
+	OnSnapshot     func(Snapshot)
+	OnPrices       func(Prices)
+	OnSpreads      func(Spreads)
+	OnArbitrage    func(Arbitrage)
+	OnBasis        func(Basis)
+	OnSourceHealth func(SourceHealth)
+	// OnConnect is called once a connection is up; resuming reports
+	// whether the client asked the server to resume where it left off.
+	OnConnect func(resuming bool)
+	// OnDisconnect is called when a connection drops or a connection
+	// attempt fails.
+	OnDisconnect func(err error)
+}
+
+type config struct {
+	header     http.Header
+	dialer     *websocket.Dialer
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	subs       *subscriptions
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+// WithAPIKey authenticates with an API key.
+func WithAPIKey(key string) Option {
+	return func(c *config) { c.header.Set("X-API-Key", key) }
+}
+
+// WithToken authenticates with a signed token.
+func WithToken(token string) Option {
+	return func(c *config) { c.header.Set("Authorization", "Bearer "+token) }
+}
+
+// WithHeader adds a header to every connection request.
+func WithHeader(key, value string) Option {
+	return func(c *config) { c.header.Add(key, value) }
+}
+
+// WithDialer replaces websocket.DefaultDialer.
+func WithDialer(d *websocket.Dialer) Option {
+	return func(c *config) { c.dialer = d }
+}
+
+// WithBackoff sets the reconnect delay range. The delay doubles after each
+// failed attempt and resets once a connection succeeds.
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *config) { c.minBackoff, c.maxBackoff = min, max }
+}
+
+// WithSubscription starts the client subscribed to channels for symbols,
+// or for every symbol if none are given. It can be repeated.
+func WithSubscription(channels []string, symbols ...string) Option {
+	return func(c *config) {
+		if err := c.subs.apply(command{Op: "subscribe", Channels: channels, Symbols: symbols}); err != nil {
+			log.Printf("client: ignoring subscription: %v", err)
+		}
+	}
+}
+
+// WithThrottle starts the client with prices and spreads rate limited per
+// symbol.
+func WithThrottle(d time.Duration) Option {
+	return func(c *config) { c.subs.throttle = d }
+}
+
+// WithMinProfit starts the client with alerts below pct after fees hidden.
+func WithMinProfit(pct float64) Option {
+	return func(c *config) { c.subs.minProfitPct = pct }
+}
+
+// pendingCommand is a command sent and waiting for the server's answer. cmd
+// joins the subscription state once the server acks it; it is nil for the
+// commands restoring the state after a connect, which nobody waits for.
+type pendingCommand struct {
+	cmd    *command
+	answer chan error
+}
+
+// Client is a connection to the scanner's /ws endpoint that survives
+// reconnects. Its methods are safe for concurrent use.
+type Client struct {
+	url string
+	cfg config
+
+	// mu guards the connection, the commands waiting for an answer, the
+	// subscription state and the resume point.
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	pending []pendingCommand
+	epoch   int64
+	lastSeq uint64
+
+	handlersMu sync.RWMutex
+	handlers   map[int]Handlers
+	nextID     int
+}
+
+// New returns a client for rawURL, the server's /ws endpoint. http and https
+// URLs are accepted too. Nothing connects until Run is called.
+func New(rawURL string, opts ...Option) *Client {
+	cfg := config{
+		header:     make(http.Header),
+		dialer:     websocket.DefaultDialer,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		subs:       newSubscriptions(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	switch {
+	case strings.HasPrefix(rawURL, "http://"):
+		rawURL = "ws://" + strings.TrimPrefix(rawURL, "http://")
+	case strings.HasPrefix(rawURL, "https://"):
+		rawURL = "wss://" + strings.TrimPrefix(rawURL, "https://")
+	}
+
+	return &Client{
+		url:      rawURL,
+		cfg:      cfg,
+		handlers: make(map[int]Handlers),
+	}
+}
+
+// Handle registers h for every message until the returned function is
+// called.
+func (c *Client) Handle(h Handlers) (remove func()) {
+	c.handlersMu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.handlers[id] = h
+	c.handlersMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.handlersMu.Lock()
+			delete(c.handlers, id)
+			c.handlersMu.Unlock()
+		})
+	}
+}
+
+// Opportunities returns a channel of arbitrage alerts that is closed when
+// ctx is done. Alerts are dropped while the channel is full.
+func (c *Client) Opportunities(ctx context.Context, buffer int) <-chan scanner.ArbitrageOpportunity {
+	return handleChan(ctx, c, buffer, func(h *Handlers, fn func(scanner.ArbitrageOpportunity)) {
+		h.OnArbitrage = func(m Arbitrage) { fn(m.Opportunity) }
+	})
+}
+
+// BasisTrades is Opportunities for basis trade alerts.
+func (c *Client) BasisTrades(ctx context.Context, buffer int) <-chan scanner.BasisTradeOpportunity {
+	return handleChan(ctx, c, buffer, func(h *Handlers, fn func(scanner.BasisTradeOpportunity)) {
+		h.OnBasis = func(m Basis) { fn(m.Opportunity) }
+	})
+}
+
+// SpreadUpdates is Opportunities for spread matrices.
+func (c *Client) SpreadUpdates(ctx context.Context, buffer int) <-chan Spreads {
+	return handleChan(ctx, c, buffer, func(h *Handlers, fn func(Spreads)) { h.OnSpreads = fn })
+}
+
+// handleChan delivers one kind of message to a channel without ever
+// blocking the read loop.
+func handleChan[T any](ctx context.Context, c *Client, buffer int, set func(*Handlers, func(T))) <-chan T {
+	ch := make(chan T, buffer)
+	var mu sync.Mutex
+	closed := false
+
+	var h Handlers
+	set(&h, func(v T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- v:
+		default:
+		}
+	})
+	remove := c.Handle(h)
+
+	go func() {
+		<-ctx.Done()
+		remove()
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	}()
+	return ch
+}
+
+func (c *Client) emit(fn func(h Handlers)) {
+	c.handlersMu.RLock()
+	handlers := make([]Handlers, 0, len(c.handlers))
+	for _, h := range c.handlers {
+		handlers = append(handlers, h)
+	}
+	c.handlersMu.RUnlock()
+
+	for _, h := range handlers {
+		fn(h)
+	}
+}
+
+// Subscribe adds channels for symbols, or for every symbol if none are
+// given. An empty channels list means all of them.
+func (c *Client) Subscribe(ctx context.Context, channels []string, symbols ...string) error {
+	return c.do(ctx, command{Op: "subscribe", Channels: channels, Symbols: symbols})
+}
+
+// Unsubscribe removes channels for symbols, or the whole channels if no
+// symbols are given.
+func (c *Client) Unsubscribe(ctx context.Context, channels []string, symbols ...string) error {
+	return c.do(ctx, command{Op: "unsubscribe", Channels: channels, Symbols: symbols})
+}
+
+// SetThrottle rate limits prices and spreads per symbol; zero turns it off.
+func (c *Client) SetThrottle(ctx context.Context, d time.Duration) error {
+	return c.do(ctx, command{Op: "set_throttle", IntervalMs: d.Milliseconds()})
+}
+
+// SetMinProfit hides alerts below pct after fees.
+func (c *Client) SetMinProfit(ctx context.Context, pct float64) error {
+	return c.do(ctx, command{Op: "set_min_profit", MinProfitPct: pct})
+}
+
+// RequestSnapshot asks the server for a fresh snapshot, delivered to
+// OnSnapshot. It fails when the client isn't connected.
+func (c *Client) RequestSnapshot() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return errors.New("client: not connected")
+	}
+	return c.writeLocked(command{Op: "snapshot"})
+}
+
+// do sends cmd when connected and waits for the server's answer; cmd joins
+// the subscription state once the server acks it, so a rejected command
+// isn't restored on the next connection. While disconnected cmd joins the
+// state right away and is applied on the next connection.
+func (c *Client) do(ctx context.Context, cmd command) error {
+	c.mu.Lock()
+	// Check cmd as the server will, after the commands still in flight.
+	next := c.cfg.subs.clone()
+	for _, p := range c.pending {
+		if p.cmd != nil {
+			next.apply(*p.cmd)
+		}
+	}
+	if err := next.apply(cmd); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if c.conn == nil {
+		c.cfg.subs.apply(cmd)
+		c.mu.Unlock()
+		return nil
+	}
+
+	answer := make(chan error, 1)
+	if err := c.writeLocked(cmd); err != nil {
+		// Let the read loop see the broken connection; the command waits
+		// with the others and joins the state the next one starts from.
+		c.conn.Close()
+	}
+	c.pending = append(c.pending, pendingCommand{cmd: &cmd, answer: answer})
+	c.mu.Unlock()
+
+	select {
+	case err := <-answer:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) writeLocked(cmd command) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Run connects and delivers messages until ctx is cancelled, reconnecting
+// whenever the connection drops. It returns ctx.Err(), or ErrUnauthorized
+// if the server rejects the credential.
+func (c *Client) Run(ctx context.Context) error {
+	backoff := c.cfg.minBackoff
+	for {
+		connected, err := c.connect(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			return err
+		}
+		c.emit(func(h Handlers) {
+			if h.OnDisconnect != nil {
+				h.OnDisconnect(err)
+			}
+		})
+
+		if connected {
+			backoff = c.cfg.minBackoff
+		}
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if backoff *= 2; backoff > c.cfg.maxBackoff {
+			backoff = c.cfg.maxBackoff
+		}
+	}
+}
+
+// connect dials the server and reads from it until the connection ends.
+// connected reports whether the dial succeeded.
+func (c *Client) connect(ctx context.Context) (connected bool, err error) {
+	c.mu.Lock()
+	u, err := url.Parse(c.url)
+	if err != nil {
+		c.mu.Unlock()
+		return false, err
+	}
+	q := u.Query()
+	c.cfg.subs.query(q)
+	resuming := c.epoch != 0
+	if resuming {
+		q.Set("resume_from", strconv.FormatUint(c.lastSeq, 10))
+		q.Set("epoch", strconv.FormatInt(c.epoch, 10))
+	}
+	u.RawQuery = q.Encode()
+	c.mu.Unlock()
+
+	conn, resp, err := c.cfg.dialer.DialContext(ctx, u.String(), c.cfg.header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return false, fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+		}
+		return false, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+	})
+
+	// Narrow the filter the query parameters set up, with the answers
+	// discarded.
+	c.mu.Lock()
+	c.conn = conn
+	for _, cmd := range c.cfg.subs.restore() {
+		if err := c.writeLocked(cmd); err != nil {
+			break
+		}
+		c.pending = append(c.pending, pendingCommand{})
+	}
+	c.mu.Unlock()
+
+	c.emit(func(h Handlers) {
+		if h.OnConnect != nil {
+			h.OnConnect(resuming)
+		}
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			c.disconnected(conn)
+			return true, err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		c.dispatch(data)
+	}
+}
+
+// disconnected forgets conn. Commands still waiting for an answer succeed:
+// they join the state the next connection starts from.
+func (c *Client) disconnected(conn *websocket.Conn) {
+	conn.Close()
+	c.mu.Lock()
+	c.conn = nil
+	for _, p := range c.pending {
+		if p.cmd != nil {
+			c.cfg.subs.apply(*p.cmd)
+		}
+		if p.answer != nil {
+			p.answer <- nil
+		}
+	}
+	c.pending = nil
+	c.mu.Unlock()
+}
+
+func (c *Client) dispatch(data []byte) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		log.Printf("client: invalid message: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	if env.Seq > c.lastSeq {
+		c.lastSeq = env.Seq
+	}
+	if env.Type == "ack" || env.Type == "error" {
+		var p pendingCommand
+		if len(c.pending) > 0 {
+			p, c.pending = c.pending[0], c.pending[1:]
+		}
+		// Only what the server accepted joins the state restored on the
+		// next connection.
+		if env.Type == "ack" && p.cmd != nil {
+			c.cfg.subs.apply(*p.cmd)
+		}
+		c.mu.Unlock()
+		if env.Type == "error" {
+			err := fmt.Errorf("client: %s rejected: %s", env.Op, env.Error)
+			if p.answer == nil {
+				log.Print(err)
+				return
+			}
+			p.answer <- err
+		} else if p.answer != nil {
+			p.answer <- nil
+		}
+		return
+	}
+	c.mu.Unlock()
+
+	switch env.Type {
+	case "snapshot":
+		var m Snapshot
+		if decode(data, &m) {
+			c.mu.Lock()
+			c.epoch, c.lastSeq = m.Epoch, m.Seq
+			c.mu.Unlock()
+			c.emit(func(h Handlers) {
+				if h.OnSnapshot != nil {
+					h.OnSnapshot(m)
+				}
+			})
+		}
+	case "prices":
+		var m Prices
+		if decode(data, &m) {
+			c.emit(func(h Handlers) {
+				if h.OnPrices != nil {
+					h.OnPrices(m)
+				}
+			})
+		}
+	case "spreads":
+		var m Spreads
+		if decode(data, &m) {
+			c.emit(func(h Handlers) {
+				if h.OnSpreads != nil {
+					h.OnSpreads(m)
+				}
+			})
+		}
+	case "arbitrage":
+		var m Arbitrage
+		if decode(data, &m) {
+			c.emit(func(h Handlers) {
+				if h.OnArbitrage != nil {
+					h.OnArbitrage(m)
+				}
+			})
+		}
+	case "basis_trade":
+		var m Basis
+		if decode(data, &m) {
+			c.emit(func(h Handlers) {
+				if h.OnBasis != nil {
+					h.OnBasis(m)
+				}
+			})
+		}
+	case "source_health":
+		var m SourceHealth
+		if decode(data, &m) {
+			c.emit(func(h Handlers) {
+				if h.OnSourceHealth != nil {
+					h.OnSourceHealth(m)
+				}
+			})
+		}
+	}
+}
+
+func decode(data []byte, v interface{}) bool {
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Printf("client: invalid message: %v", err)
+		return false
+	}
+	return true
+}
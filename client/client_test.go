@@ -0,0 +1,275 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+	"futures-arbitrage-scanner/server"
+
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a real scanner server whose connections can be cut.
+type testServer struct {
+	*httptest.Server
+	scanner *scanner.Scanner
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// hijackRecorder keeps the connections the WebSocket upgrade takes over,
+// which httptest no longer tracks.
+type hijackRecorder struct {
+	http.ResponseWriter
+	ts *testServer
+}
+
+func (h hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.ts.mu.Lock()
+		h.ts.conns = append(h.ts.conns, conn)
+		h.ts.mu.Unlock()
+	}
+	return conn, rw, err
+}
+
+func newTestServer(t *testing.T, opts server.Options) *testServer {
+	t.Helper()
+	ts := &testServer{scanner: scanner.New()}
+	mux := http.NewServeMux()
+	server.New(ts.scanner, opts).RegisterRoutes(mux)
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(hijackRecorder{w, ts}, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// cut closes every open WebSocket connection from the server side.
+func (ts *testServer) cut() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, conn := range ts.conns {
+		conn.Close()
+	}
+	ts.conns = nil
+}
+
+// opportunity feeds two prices for symbol far enough apart to alert.
+func (ts *testServer) opportunity(symbol string) {
+	now := time.Now().UnixMilli()
+	ts.scanner.UpdatePrice(exchanges.PriceData{Symbol: symbol, Source: "binance_futures", Price: 100, ReceivedAt: now})
+	ts.scanner.UpdatePrice(exchanges.PriceData{Symbol: symbol, Source: "okx_futures", Price: 101, ReceivedAt: now})
+}
+
+// event is what recorder saw, in order.
+type event struct {
+	kind     string
+	symbol   string
+	resuming bool
+	snapshot Snapshot
+}
+
+func recorder(c *Client) <-chan event {
+	events := make(chan event, 64)
+	c.Handle(Handlers{
+		OnConnect:  func(resuming bool) { events <- event{kind: "connect", resuming: resuming} },
+		OnSnapshot: func(m Snapshot) { events <- event{kind: "snapshot", snapshot: m} },
+		OnArbitrage: func(m Arbitrage) {
+			events <- event{kind: "arbitrage", symbol: m.Opportunity.Symbol}
+		},
+	})
+	return events
+}
+
+func next(t *testing.T, events <-chan event, kind string) event {
+	t.Helper()
+	select {
+	case e := <-events:
+		if e.kind != kind {
+			t.Fatalf("got %s event (%+v), want %s", e.kind, e, kind)
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s", kind)
+	}
+	return event{}
+}
+
+func TestOpportunitiesAreTyped(t *testing.T) {
+	ts := newTestServer(t, server.Options{})
+	c := New(ts.URL + "/ws")
+	events := recorder(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opportunities := c.Opportunities(ctx, 8)
+	go c.Run(ctx)
+
+	next(t, events, "connect")
+	next(t, events, "snapshot")
+	ts.opportunity("TONUSDT")
+
+	select {
+	case o := <-opportunities:
+		if o.Symbol != "TONUSDT" || o.BuySource != "binance_futures" || o.SellSource != "okx_futures" || o.ProfitPct < 0.9 {
+			t.Fatalf("unexpected opportunity: %+v", o)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for opportunity")
+	}
+}
+
+func TestSourceHealthIsTyped(t *testing.T) {
+	c := New("ws://unused/ws")
+	var got []SourceHealth
+	c.Handle(Handlers{OnSourceHealth: func(m SourceHealth) { got = append(got, m) }})
+
+	c.dispatch([]byte(`{"type":"source_health","seq":7,"health":{"source":"okx_futures","status":"degraded","reason":"no valid update for 30s","since":1000}}`))
+	if len(got) != 1 || got[0].Seq != 7 || got[0].Health.Source != "okx_futures" || got[0].Health.Status != scanner.HealthDegraded {
+		t.Fatalf("got %+v, want the okx_futures degraded event", got)
+	}
+}
+
+func TestSubscribeFilters(t *testing.T) {
+	ts := newTestServer(t, server.Options{})
+	c := New(ts.URL+"/ws", WithSubscription([]string{ChannelArbitrage}, "btcusdt"))
+	events := recorder(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	next(t, events, "connect")
+	next(t, events, "snapshot")
+	ts.opportunity("TONUSDT")
+	ts.opportunity("BTCUSDT")
+	if e := next(t, events, "arbitrage"); e.symbol != "BTCUSDT" {
+		t.Fatalf("got %s, want BTCUSDT", e.symbol)
+	}
+
+	if err := c.Subscribe(ctx, []string{ChannelArbitrage}, "ETHUSDT"); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := c.Unsubscribe(ctx, []string{ChannelArbitrage}, "BTCUSDT"); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if err := c.Subscribe(ctx, []string{"trades"}); err == nil {
+		t.Fatalf("expected an error for an unknown channel")
+	}
+
+	ts.opportunity("SOLUSDT")
+	ts.opportunity("ETHUSDT")
+	if e := next(t, events, "arbitrage"); e.symbol != "ETHUSDT" {
+		t.Fatalf("got %s, want ETHUSDT", e.symbol)
+	}
+}
+
+func TestReconnectResumes(t *testing.T) {
+	ts := newTestServer(t, server.Options{})
+	c := New(ts.URL+"/ws", WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	events := recorder(c)
+
+	// Publish while the client is disconnected; it should get the alert
+	// replayed rather than a fresh snapshot.
+	var once sync.Once
+	c.Handle(Handlers{OnDisconnect: func(error) {
+		once.Do(func() { ts.opportunity("BTCUSDT") })
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	if e := next(t, events, "connect"); e.resuming {
+		t.Fatalf("first connection should not resume")
+	}
+	next(t, events, "snapshot")
+	ts.opportunity("TONUSDT")
+	next(t, events, "arbitrage")
+
+	ts.cut()
+	if e := next(t, events, "connect"); !e.resuming {
+		t.Fatalf("reconnect should resume")
+	}
+	if e := next(t, events, "arbitrage"); e.symbol != "BTCUSDT" {
+		t.Fatalf("got %s, want BTCUSDT", e.symbol)
+	}
+}
+
+// TestRejectedCommandIsNotRestored has the server refuse a subscribe and
+// expects the client to keep its state as it was, so the command isn't sent
+// again on the next connection.
+func TestRejectedCommandIsNotRestored(t *testing.T) {
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]string{"type": "error", "op": "subscribe", "error": "not listed"})
+		}
+	}))
+	defer srv.Close()
+
+	c := New("ws" + strings.TrimPrefix(srv.URL, "http"))
+	events := recorder(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+	next(t, events, "connect")
+
+	if err := c.Subscribe(ctx, []string{ChannelArbitrage}, "NOPEUSDT"); err == nil {
+		t.Fatal("Subscribe succeeded, want the server's error")
+	}
+	c.mu.Lock()
+	q := url.Values{}
+	c.cfg.subs.query(q)
+	restore := c.cfg.subs.restore()
+	c.mu.Unlock()
+	if len(q) != 0 || len(restore) != 0 {
+		t.Fatalf("rejected command kept: query %v, restore %+v", q, restore)
+	}
+}
+
+func TestRunStopsWhenUnauthorized(t *testing.T) {
+	auth, err := server.NewAuthenticator("", "secret", "")
+	if err != nil {
+		t.Fatalf("authenticator: %v", err)
+	}
+	ts := newTestServer(t, server.Options{Auth: auth})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := New(ts.URL + "/ws").Run(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Run without a token: got %v, want ErrUnauthorized", err)
+	}
+
+	token, err := server.SignToken([]byte("secret"), server.TokenClaims{Subject: "bot", Scope: "read", Expires: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	c := New(ts.URL+"/ws", WithToken(token))
+	events := recorder(c)
+	go c.Run(ctx)
+	next(t, events, "connect")
+	next(t, events, "snapshot")
+}
@@ -0,0 +1,85 @@
+package client
+
+import "futures-arbitrage-scanner/scanner"
+
+// Channels a client can subscribe to. Each maps to one message type.
+const (
+	ChannelPrices    = "prices"
+	ChannelSpreads   = "spreads"
+	ChannelArbitrage = "arbitrage"
+	ChannelBasis     = "basis"
+	ChannelHealth    = "health"
+)
+
+var allChannels = []string{ChannelPrices, ChannelSpreads, ChannelArbitrage, ChannelBasis, ChannelHealth}
+
+// Snapshot is the state the server sends right after connecting, when a
+// resume fails, and on request. It only covers the client's subscriptions.
+type Snapshot struct {
+	Seq   uint64 `json:"seq"`
+	Epoch int64  `json:"epoch"`
+	// ResumeFailed is set when the client asked to resume but the missed
+	// messages were no longer available; some events were lost.
+	ResumeFailed  bool                            `json:"resume_failed"`
+	Prices        map[string]map[string]float64   `json:"prices"`
+	Spreads       map[string]SymbolSpreads        `json:"spreads"`
+	Opportunities []scanner.ArbitrageOpportunity  `json:"opportunities"`
+	BasisTrades   []scanner.BasisTradeOpportunity `json:"basis_trades"`
+	Sources       []scanner.SourceStatus          `json:"sources"`
+}
+
+// SymbolSpreads is the spread matrix for one symbol in a Snapshot.
+type SymbolSpreads struct {
+	Spreads map[string]map[string]float64 `json:"spreads"`
+	Prices  map[string]float64            `json:"prices"`
+}
+
+// Prices is the latest price per symbol and source.
+type Prices struct {
+	Seq    uint64                        `json:"seq"`
+	Prices map[string]map[string]float64 `json:"prices"`
+}
+
+// Spreads is the spread matrix between the fresh quotes for a symbol.
+type Spreads struct {
+	Seq     uint64                        `json:"seq"`
+	Symbol  string                        `json:"symbol"`
+	Spreads map[string]map[string]float64 `json:"spreads"`
+	Prices  map[string]float64            `json:"prices"`
+}
+
+// Arbitrage is an arbitrage alert.
+type Arbitrage struct {
+	Seq         uint64                       `json:"seq"`
+	Opportunity scanner.ArbitrageOpportunity `json:"opportunity"`
+}
+
+// Basis is a basis trade alert.
+type Basis struct {
+	Seq         uint64                        `json:"seq"`
+	Opportunity scanner.BasisTradeOpportunity `json:"opportunity"`
+}
+
+// SourceHealth is sent when a source becomes degraded and again when it
+// recovers.
+type SourceHealth struct {
+	Seq    uint64               `json:"seq"`
+	Health scanner.SourceHealth `json:"health"`
+}
+
+// envelope is the part every message shares.
+type envelope struct {
+	Type  string `json:"type"`
+	Seq   uint64 `json:"seq"`
+	Op    string `json:"op"`
+	Error string `json:"error"`
+}
+
+// command is a subscription command sent to the server.
+type command struct {
+	Op           string   `json:"op"`
+	Channels     []string `json:"channels,omitempty"`
+	Symbols      []string `json:"symbols,omitempty"`
+	IntervalMs   int64    `json:"interval_ms,omitempty"`
+	MinProfitPct float64  `json:"min_profit_pct,omitempty"`
+}
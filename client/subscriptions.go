@@ -0,0 +1,239 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subscriptions mirrors the filter the server keeps for this client, so it
+// can be restored after a reconnect. It follows the server's rules: nothing
+// explicit means everything, the first subscribe starts from nothing and the
+// first unsubscribe starts from everything.
+type subscriptions struct {
+	explicit     bool
+	channels     map[string]*channelSub
+	throttle     time.Duration
+	minProfitPct float64
+}
+
+type channelSub struct {
+	all     bool
+	symbols map[string]bool
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{channels: make(map[string]*channelSub)}
+}
+
+func (s *subscriptions) clone() *subscriptions {
+	out := &subscriptions{
+		explicit:     s.explicit,
+		channels:     make(map[string]*channelSub, len(s.channels)),
+		throttle:     s.throttle,
+		minProfitPct: s.minProfitPct,
+	}
+	for ch, sub := range s.channels {
+		cp := &channelSub{all: sub.all}
+		if sub.symbols != nil {
+			cp.symbols = make(map[string]bool, len(sub.symbols))
+			for sym := range sub.symbols {
+				cp.symbols[sym] = true
+			}
+		}
+		out.channels[ch] = cp
+	}
+	return out
+}
+
+func validChannel(ch string) bool {
+	for _, c := range allChannels {
+		if c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeSymbols(symbols []string) []string {
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// apply updates the state for cmd, returning the error the server would.
+func (s *subscriptions) apply(cmd command) error {
+	switch cmd.Op {
+	case "subscribe", "unsubscribe":
+		channels := cmd.Channels
+		if len(channels) == 0 {
+			channels = allChannels
+		}
+		for _, ch := range channels {
+			if !validChannel(ch) {
+				return fmt.Errorf("unknown channel %q", ch)
+			}
+		}
+		symbols := normalizeSymbols(cmd.Symbols)
+
+		if cmd.Op == "unsubscribe" {
+			// Check first so a rejected command leaves the state unchanged.
+			for _, ch := range channels {
+				if sub, ok := s.channels[ch]; ok && sub.all && len(symbols) > 0 {
+					return fmt.Errorf("channel %q is subscribed for all symbols; unsubscribe the channel instead", ch)
+				}
+			}
+		}
+
+		if !s.explicit {
+			s.explicit = true
+			if cmd.Op == "unsubscribe" {
+				for _, ch := range allChannels {
+					s.channels[ch] = &channelSub{all: true}
+				}
+				if len(symbols) > 0 {
+					return fmt.Errorf("channel %q is subscribed for all symbols; unsubscribe the channel instead", channels[0])
+				}
+			}
+		}
+
+		for _, ch := range channels {
+			if cmd.Op == "subscribe" {
+				s.subscribe(ch, symbols)
+			} else {
+				s.unsubscribe(ch, symbols)
+			}
+		}
+		return nil
+
+	case "set_throttle":
+		if cmd.IntervalMs < 0 {
+			return fmt.Errorf("interval_ms must not be negative")
+		}
+		s.throttle = time.Duration(cmd.IntervalMs) * time.Millisecond
+		return nil
+
+	case "set_min_profit":
+		if cmd.MinProfitPct < 0 {
+			return fmt.Errorf("min_profit_pct must not be negative")
+		}
+		s.minProfitPct = cmd.MinProfitPct
+		return nil
+
+	default:
+		return fmt.Errorf("unknown op %q", cmd.Op)
+	}
+}
+
+func (s *subscriptions) subscribe(channel string, symbols []string) {
+	sub, ok := s.channels[channel]
+	if !ok {
+		sub = &channelSub{symbols: make(map[string]bool)}
+		s.channels[channel] = sub
+	}
+	if len(symbols) == 0 {
+		sub.all = true
+		return
+	}
+	if sub.symbols == nil {
+		sub.symbols = make(map[string]bool)
+	}
+	for _, sym := range symbols {
+		sub.symbols[sym] = true
+	}
+}
+
+func (s *subscriptions) unsubscribe(channel string, symbols []string) {
+	sub, ok := s.channels[channel]
+	if !ok {
+		return
+	}
+	if len(symbols) == 0 {
+		delete(s.channels, channel)
+		return
+	}
+	for _, sym := range symbols {
+		delete(sub.symbols, sym)
+	}
+	if len(sub.symbols) == 0 {
+		delete(s.channels, channel)
+	}
+}
+
+// union returns the subscribed channels and every symbol any of them is
+// limited to, or no symbols when one of them covers every symbol.
+func (s *subscriptions) union() (channels, symbols []string) {
+	all := false
+	set := make(map[string]bool)
+	for ch, sub := range s.channels {
+		channels = append(channels, ch)
+		all = all || sub.all
+		for sym := range sub.symbols {
+			set[sym] = true
+		}
+	}
+	if !all {
+		for sym := range set {
+			symbols = append(symbols, sym)
+		}
+	}
+	sort.Strings(channels)
+	sort.Strings(symbols)
+	return channels, symbols
+}
+
+// query adds the connection parameters that restore the state. The server
+// takes a single channels × symbols selection up front, so that is the union
+// of the subscriptions; restore returns the commands that narrow it down.
+func (s *subscriptions) query(q url.Values) {
+	if channels, symbols := s.union(); len(channels) > 0 {
+		q.Set("channels", strings.Join(channels, ","))
+		if len(symbols) > 0 {
+			q.Set("symbols", strings.Join(symbols, ","))
+		}
+	}
+	if s.throttle > 0 {
+		q.Set("throttle_ms", strconv.FormatInt(s.throttle.Milliseconds(), 10))
+	}
+	if s.minProfitPct > 0 {
+		q.Set("min_profit", strconv.FormatFloat(s.minProfitPct, 'f', -1, 64))
+	}
+}
+
+// restore returns the commands to send after connecting with query for the
+// server's filter to match the state exactly.
+func (s *subscriptions) restore() []command {
+	if !s.explicit {
+		return nil
+	}
+	channels, symbols := s.union()
+	if len(channels) == 0 {
+		return []command{{Op: "unsubscribe"}}
+	}
+
+	var cmds []command
+	for _, ch := range channels {
+		sub := s.channels[ch]
+		if sub.all || len(sub.symbols) == len(symbols) {
+			continue
+		}
+		own := make([]string, 0, len(sub.symbols))
+		for sym := range sub.symbols {
+			own = append(own, sym)
+		}
+		sort.Strings(own)
+		cmds = append(cmds,
+			command{Op: "unsubscribe", Channels: []string{ch}},
+			command{Op: "subscribe", Channels: []string{ch}, Symbols: own},
+		)
+	}
+	return cmds
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSubscriptionsRestore(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmds    []command
+		query   string
+		restore []command
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:  "one selection",
+			cmds:  []command{{Op: "subscribe", Channels: []string{"arbitrage", "spreads"}, Symbols: []string{"tonusdt"}}},
+			query: "channels=arbitrage%2Cspreads&symbols=TONUSDT",
+		},
+		{
+			name: "per channel symbols",
+			cmds: []command{
+				{Op: "subscribe", Channels: []string{"spreads"}, Symbols: []string{"TONUSDT"}},
+				{Op: "subscribe", Channels: []string{"arbitrage"}},
+			},
+			query: "channels=arbitrage%2Cspreads",
+			restore: []command{
+				{Op: "unsubscribe", Channels: []string{"spreads"}},
+				{Op: "subscribe", Channels: []string{"spreads"}, Symbols: []string{"TONUSDT"}},
+			},
+		},
+		{
+			name:    "unsubscribed from everything",
+			cmds:    []command{{Op: "unsubscribe"}},
+			restore: []command{{Op: "unsubscribe"}},
+		},
+		{
+			name: "unsubscribe from default",
+			cmds: []command{
+				{Op: "unsubscribe", Channels: []string{"prices"}},
+				{Op: "set_min_profit", MinProfitPct: 0.2},
+			},
+			query: "channels=arbitrage%2Cbasis%2Chealth%2Cspreads&min_profit=0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSubscriptions()
+			for _, cmd := range tt.cmds {
+				if err := s.apply(cmd); err != nil {
+					t.Fatalf("apply %+v: %v", cmd, err)
+				}
+			}
+			q := url.Values{}
+			s.query(q)
+			if got := q.Encode(); got != tt.query {
+				t.Errorf("query = %q, want %q", got, tt.query)
+			}
+			if got := s.restore(); !reflect.DeepEqual(got, tt.restore) {
+				t.Errorf("restore = %+v, want %+v", got, tt.restore)
+			}
+		})
+	}
+}
+
+func TestSubscriptionsRejectLikeServer(t *testing.T) {
+	s := newSubscriptions()
+	if err := s.apply(command{Op: "subscribe", Channels: []string{"trades"}}); err == nil {
+		t.Fatalf("expected unknown channel error")
+	}
+	if err := s.apply(command{Op: "subscribe", Channels: []string{"spreads"}}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := s.apply(command{Op: "unsubscribe", Channels: []string{"spreads"}, Symbols: []string{"TONUSDT"}}); err == nil {
+		t.Fatalf("expected error unsubscribing a symbol from an all-symbols channel")
+	}
+	if err := s.apply(command{Op: "set_throttle", IntervalMs: -1}); err == nil {
+		t.Fatalf("expected negative throttle error")
+	}
+}
@@ -0,0 +1,574 @@
+// Package config loads the scanner's settings from a YAML file and the
+// environment and checks them before anything starts.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+	"futures-arbitrage-scanner/simulator"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBroadcastInterval is how often the price table is pushed to
+// clients.
+const DefaultBroadcastInterval = 200 * time.Millisecond
+
+// maxFeePct bounds fee schedules to catch fees given as fractions of 100
+// (0.05 is 0.05%, not 5%) the wrong way round.
+const maxFeePct = 5
+
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// Config is everything that used to be hardcoded. Fields left out of the
+// file keep their defaults.
+type Config struct {
+	// Symbols is the symbol list every venue subscribes to unless it has
+	// its own.
+	Symbols           []string         `yaml:"symbols"`
+	MinProfitPct      float64          `yaml:"min_profit_pct"`
+	AlertCooldown     time.Duration    `yaml:"alert_cooldown"`
+	MaxQuoteAge       time.Duration    `yaml:"max_quote_age"`
+	BroadcastInterval time.Duration    `yaml:"broadcast_interval"`
+	Markets           Markets          `yaml:"markets"`
+	Health            Health           `yaml:"health"`
+	Venues            map[string]Venue `yaml:"venues"`
+	Simulate          Simulate         `yaml:"simulate"`
+}
+
+// Simulate runs the scanner against a made-up market instead of the
+// venues.
+type Simulate struct {
+	Enabled bool `yaml:"enabled"`
+	// Servers serves the venues that have a fake server over websocket, so
+	// their real connectors run against it; the rest are simulated in
+	// process either way.
+	Servers bool             `yaml:"serve_ws"`
+	Market  simulator.Config `yaml:",inline"`
+}
+
+// Health sets when a connected venue is reported degraded: when more than
+// MaxParseErrorRatio of its frames fail to parse in a check window with at
+// least MinFrames, or when it sends no valid update for UpdateTimeout.
+// /health/ready fails until every symbol has fresh quotes from
+// MinFreshVenues venues. Zero turns a check off.
+type Health struct {
+	MaxParseErrorRatio float64       `yaml:"max_parse_error_ratio"`
+	MinFrames          uint64        `yaml:"min_frames"`
+	UpdateTimeout      time.Duration `yaml:"update_timeout"`
+	MinFreshVenues     int           `yaml:"min_fresh_venues"`
+}
+
+// Markets configures market discovery.
+type Markets struct {
+	// RefreshInterval is how often venue metadata is fetched again; 0
+	// fetches it only at startup.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// Cache is the file discovered markets are kept in for startup without
+	// network access; empty disables it.
+	Cache string `yaml:"cache"`
+}
+
+// Venue configures one connector. Venues not listed are enabled with
+// their defaults.
+type Venue struct {
+	Enabled      *bool         `yaml:"enabled"`
+	Symbols      []string      `yaml:"symbols"`
+	WSURL        string        `yaml:"ws_url"`
+	RESTURL      string        `yaml:"rest_url"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Fees         Fees          `yaml:"fees"`
+}
+
+// Fees is a venue's fee schedule in percent of notional.
+type Fees struct {
+	TakerPct float64 `yaml:"taker_pct"`
+	MakerPct float64 `yaml:"maker_pct"`
+}
+
+// Default returns the settings the scanner runs with when nothing is
+// configured.
+func Default() *Config {
+	return &Config{
+		Symbols:           []string{"TONUSDT"},
+		MinProfitPct:      scanner.DefaultMinProfitPct,
+		AlertCooldown:     scanner.DefaultAlertCooldown,
+		MaxQuoteAge:       scanner.DefaultMaxQuoteAge,
+		BroadcastInterval: DefaultBroadcastInterval,
+		Markets:           Markets{RefreshInterval: exchanges.DefaultDiscoveryInterval, Cache: "markets.json"},
+		Venues:            make(map[string]Venue),
+		Health: Health{
+			MaxParseErrorRatio: scanner.DefaultHealthThresholds.MaxParseErrorRatio,
+			MinFrames:          scanner.DefaultHealthThresholds.MinFrames,
+			UpdateTimeout:      scanner.DefaultHealthThresholds.UpdateTimeout,
+			MinFreshVenues:     scanner.DefaultHealthThresholds.MinFreshVenues,
+		},
+	}
+}
+
+// Load reads the file at path over the defaults, applies SCANNER_*
+// environment overrides and validates the result. An empty path skips the
+// file.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		if path != "" {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings from the environment:
+//
+//	SCANNER_SYMBOLS=TONUSDT,BTCUSDT
+//	SCANNER_MIN_PROFIT_PCT, SCANNER_ALERT_COOLDOWN, SCANNER_MAX_QUOTE_AGE,
+//	SCANNER_BROADCAST_INTERVAL
+//	SCANNER_MARKETS_REFRESH_INTERVAL, SCANNER_MARKETS_CACHE
+//	SCANNER_HEALTH_MAX_PARSE_ERROR_RATIO, SCANNER_HEALTH_MIN_FRAMES,
+//	SCANNER_HEALTH_UPDATE_TIMEOUT, SCANNER_HEALTH_MIN_FRESH_VENUES
+//	SCANNER_<VENUE>_ENABLED, _SYMBOLS, _WS_URL, _REST_URL, _POLL_INTERVAL,
+//	_TAKER_FEE_PCT, _MAKER_FEE_PCT
+//
+// where <VENUE> is the upper-cased venue name, e.g. BINANCE_FUTURES.
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var errs []error
+	str := func(key string, dst *string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v := getenv(key); v != "" {
+			*dst = strings.Split(v, ",")
+		}
+	}
+	float := func(key string, dst *float64) {
+		if v := getenv(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid number %q", key, v))
+				return
+			}
+			*dst = f
+		}
+	}
+	integer := func(key string, dst *int) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	unsigned := func(key string, dst *uint64) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid count %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(key string, dst *time.Duration) {
+		if v := getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid duration %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	list("SCANNER_SYMBOLS", &c.Symbols)
+	float("SCANNER_MIN_PROFIT_PCT", &c.MinProfitPct)
+	duration("SCANNER_ALERT_COOLDOWN", &c.AlertCooldown)
+	duration("SCANNER_MAX_QUOTE_AGE", &c.MaxQuoteAge)
+	duration("SCANNER_BROADCAST_INTERVAL", &c.BroadcastInterval)
+	duration("SCANNER_MARKETS_REFRESH_INTERVAL", &c.Markets.RefreshInterval)
+	str("SCANNER_MARKETS_CACHE", &c.Markets.Cache)
+	float("SCANNER_HEALTH_MAX_PARSE_ERROR_RATIO", &c.Health.MaxParseErrorRatio)
+	unsigned("SCANNER_HEALTH_MIN_FRAMES", &c.Health.MinFrames)
+	duration("SCANNER_HEALTH_UPDATE_TIMEOUT", &c.Health.UpdateTimeout)
+	integer("SCANNER_HEALTH_MIN_FRESH_VENUES", &c.Health.MinFreshVenues)
+
+	if c.Venues == nil {
+		c.Venues = make(map[string]Venue)
+	}
+	for _, name := range exchanges.Sources() {
+		key, v := c.venueKey(name)
+		prefix := "SCANNER_" + strings.ToUpper(name) + "_"
+		before := v
+
+		if e := getenv(prefix + "ENABLED"); e != "" {
+			enabled, err := strconv.ParseBool(e)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%sENABLED: invalid boolean %q", prefix, e))
+			} else {
+				v.Enabled = &enabled
+			}
+		}
+		list(prefix+"SYMBOLS", &v.Symbols)
+		str(prefix+"WS_URL", &v.WSURL)
+		str(prefix+"REST_URL", &v.RESTURL)
+		duration(prefix+"POLL_INTERVAL", &v.PollInterval)
+		float(prefix+"TAKER_FEE_PCT", &v.Fees.TakerPct)
+		float(prefix+"MAKER_FEE_PCT", &v.Fees.MakerPct)
+
+		if !v.equal(before) {
+			c.Venues[key] = v
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// venueKey finds the entry for venue, whose key in the file may differ in
+// case from the connector name.
+func (c *Config) venueKey(venue string) (string, Venue) {
+	for key, v := range c.Venues {
+		if strings.EqualFold(key, venue) {
+			return key, v
+		}
+	}
+	return venue, Venue{}
+}
+
+func (v Venue) equal(o Venue) bool {
+	return v.Enabled == o.Enabled && strings.Join(v.Symbols, ",") == strings.Join(o.Symbols, ",") &&
+		v.WSURL == o.WSURL && v.RESTURL == o.RESTURL && v.PollInterval == o.PollInterval && v.Fees == o.Fees
+}
+
+// Validate normalizes symbols and venue names and reports every problem
+// it finds, one per line.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	var err error
+	if c.Symbols, err = normalizeSymbols(c.Symbols); err != nil {
+		fail("symbols: %v", err)
+	} else if len(c.Symbols) == 0 {
+		fail("symbols: at least one symbol is required")
+	}
+	if c.MinProfitPct < 0 {
+		fail("min_profit_pct: must not be negative, got %v", c.MinProfitPct)
+	}
+	if c.AlertCooldown < 0 {
+		fail("alert_cooldown: must not be negative, got %v", c.AlertCooldown)
+	}
+	if c.MaxQuoteAge <= 0 {
+		fail("max_quote_age: must be positive, got %v", c.MaxQuoteAge)
+	}
+	if c.BroadcastInterval < 10*time.Millisecond {
+		fail("broadcast_interval: must be at least 10ms, got %v", c.BroadcastInterval)
+	}
+	if c.Markets.RefreshInterval < 0 || (c.Markets.RefreshInterval > 0 && c.Markets.RefreshInterval < time.Minute) {
+		fail("markets.refresh_interval: must be 0 or at least 1m, got %v", c.Markets.RefreshInterval)
+	}
+	if c.Health.MaxParseErrorRatio < 0 || c.Health.MaxParseErrorRatio > 1 {
+		fail("health.max_parse_error_ratio: must be between 0 and 1, got %v", c.Health.MaxParseErrorRatio)
+	}
+	if c.Health.UpdateTimeout < 0 || (c.Health.UpdateTimeout > 0 && c.Health.UpdateTimeout < scanner.HealthCheckInterval) {
+		fail("health.update_timeout: must be 0 or at least %v, got %v", scanner.HealthCheckInterval, c.Health.UpdateTimeout)
+	}
+	if c.Health.MinFreshVenues < 0 {
+		fail("health.min_fresh_venues: must not be negative, got %d", c.Health.MinFreshVenues)
+	}
+
+	known := exchanges.Sources()
+	c.validateSimulate(known, fail)
+
+	venues := make(map[string]Venue, len(c.Venues))
+	keys := make([]string, 0, len(c.Venues))
+	for key := range c.Venues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v := c.Venues[key]
+		name := canonicalVenue(key, known)
+		if name == "" {
+			fail("venues.%s: unknown venue (known: %s)", key, strings.Join(known, ", "))
+			continue
+		}
+		if _, dup := venues[name]; dup {
+			fail("venues.%s: %s is configured more than once", key, name)
+			continue
+		}
+
+		if v.Symbols, err = normalizeSymbols(v.Symbols); err != nil {
+			fail("venues.%s.symbols: %v", key, err)
+		}
+
+		defaults, _ := exchanges.DefaultEndpoints(name)
+		if v.WSURL != "" {
+			if defaults.WS == "" {
+				fail("venues.%s.ws_url: %s has no websocket endpoint", key, name)
+			} else if err := checkURL(v.WSURL, "ws", "wss"); err != nil {
+				fail("venues.%s.ws_url: %v", key, err)
+			}
+		}
+		if v.RESTURL != "" {
+			if defaults.REST == "" {
+				fail("venues.%s.rest_url: %s has no REST endpoint", key, name)
+			} else if err := checkURL(v.RESTURL, "http", "https"); err != nil {
+				fail("venues.%s.rest_url: %v", key, err)
+			}
+		}
+		if v.PollInterval != 0 {
+			if defaults.PollInterval == 0 {
+				fail("venues.%s.poll_interval: %s streams and does not poll", key, name)
+			} else if v.PollInterval < 100*time.Millisecond {
+				fail("venues.%s.poll_interval: must be at least 100ms, got %v", key, v.PollInterval)
+			}
+		}
+		if v.Fees.TakerPct < 0 || v.Fees.TakerPct > maxFeePct {
+			fail("venues.%s.fees.taker_pct: must be between 0 and %d (percent), got %v", key, maxFeePct, v.Fees.TakerPct)
+		}
+		if v.Fees.MakerPct < -maxFeePct || v.Fees.MakerPct > maxFeePct {
+			fail("venues.%s.fees.maker_pct: must be between -%d and %d (percent), got %v", key, maxFeePct, maxFeePct, v.Fees.MakerPct)
+		}
+		venues[name] = v
+	}
+	c.Venues = venues
+
+	enabled := 0
+	for _, name := range known {
+		if c.VenueEnabled(name) {
+			enabled++
+		}
+	}
+	if enabled == 0 {
+		fail("venues: every venue is disabled")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateSimulate(known []string, fail func(string, ...interface{})) {
+	m := &c.Simulate.Market
+	if m.Tick < 0 {
+		fail("simulate.tick: must not be negative, got %v", m.Tick)
+	}
+	if m.Volatility < 0 {
+		fail("simulate.volatility: must not be negative, got %v", m.Volatility)
+	}
+	if m.Correlation < 0 || m.Correlation > 1 {
+		fail("simulate.correlation: must be between 0 and 1, got %v", m.Correlation)
+	}
+	if m.TradeRate < 0 || m.TradeRate > 1 {
+		fail("simulate.trade_rate: must be between 0 and 1, got %v", m.TradeRate)
+	}
+	if m.Jitter < 0 {
+		fail("simulate.jitter: must not be negative, got %v", m.Jitter)
+	}
+	venue := func(field, name string) string {
+		if v := canonicalVenue(name, known); v != "" {
+			return v
+		}
+		fail("%s: unknown venue %q (known: %s)", field, name, strings.Join(known, ", "))
+		return name
+	}
+	latency := make(map[string]time.Duration, len(m.Latency))
+	for name, d := range m.Latency {
+		if d < 0 {
+			fail("simulate.latency.%s: must not be negative, got %v", name, d)
+		}
+		if name != "*" {
+			name = venue("simulate.latency."+name, name)
+		}
+		latency[name] = d
+	}
+	if m.Latency != nil {
+		m.Latency = latency
+	}
+	for i := range m.Spreads {
+		sp := &m.Spreads[i]
+		field := fmt.Sprintf("simulate.spreads[%d]", i)
+		sp.Venue = venue(field+".venue", sp.Venue)
+		if sp.Symbol != "" {
+			sp.Symbol = exchanges.NormalizeSymbol(strings.ToUpper(sp.Symbol))
+		}
+		if sp.Every < 0 || sp.For < 0 {
+			fail("%s: every and for must not be negative", field)
+		}
+	}
+	for i := range m.Outages {
+		o := &m.Outages[i]
+		field := fmt.Sprintf("simulate.outages[%d]", i)
+		o.Venue = venue(field+".venue", o.Venue)
+		if o.Every < 0 || o.For < 0 {
+			fail("%s: every and for must not be negative", field)
+		}
+	}
+}
+
+// VenueEnabled reports whether the connector for venue should run.
+func (c *Config) VenueEnabled(venue string) bool {
+	v, ok := c.Venues[venue]
+	return !ok || v.Enabled == nil || *v.Enabled
+}
+
+// ScannerOptions returns the scanner options for the configuration: its
+// thresholds, fee schedules and every connector with its symbols. Disabled
+// venues are registered but not started, so they can be enabled later.
+// The connectors default to scanner.DefaultConnectors.
+func (c *Config) ScannerOptions(base ...scanner.Connector) []scanner.Option {
+	if len(base) == 0 {
+		base = scanner.DefaultConnectors()
+	}
+	var connectors []scanner.Connector
+	for _, conn := range base {
+		conn.Disabled = !c.VenueEnabled(conn.Name)
+		conn.Symbols = c.Venues[conn.Name].Symbols
+		connectors = append(connectors, conn)
+	}
+
+	t := c.Thresholds()
+	return []scanner.Option{
+		scanner.WithSymbols(c.Symbols...),
+		scanner.WithMinProfit(t.MinProfitPct),
+		scanner.WithAlertCooldown(t.AlertCooldown),
+		scanner.WithMaxQuoteAge(t.MaxQuoteAge),
+		scanner.WithFees(t.Fees),
+		scanner.WithHealthThresholds(c.HealthThresholds()),
+		scanner.WithConnectors(connectors...),
+	}
+}
+
+// Thresholds returns the scanner's detection settings for the
+// configuration.
+func (c *Config) Thresholds() scanner.Thresholds {
+	fees := make(map[string]scanner.Fees)
+	for name, v := range c.Venues {
+		if v.Fees != (Fees{}) {
+			fees[name] = scanner.Fees{TakerPct: v.Fees.TakerPct, MakerPct: v.Fees.MakerPct}
+		}
+	}
+	return scanner.Thresholds{
+		MinProfitPct:  c.MinProfitPct,
+		AlertCooldown: c.AlertCooldown,
+		MaxQuoteAge:   c.MaxQuoteAge,
+		Fees:          fees,
+	}
+}
+
+// HealthThresholds returns the scanner's degraded source thresholds for
+// the configuration.
+func (c *Config) HealthThresholds() scanner.HealthThresholds {
+	return scanner.HealthThresholds{
+		MaxParseErrorRatio: c.Health.MaxParseErrorRatio,
+		MinFrames:          c.Health.MinFrames,
+		UpdateTimeout:      c.Health.UpdateTimeout,
+		MinFreshVenues:     c.Health.MinFreshVenues,
+	}
+}
+
+// ApplyEndpoints points the exchanges package at the configured URLs and
+// poll intervals. Call it before the connectors start.
+func (c *Config) ApplyEndpoints() {
+	for _, name := range exchanges.Sources() {
+		exchanges.SetEndpoints(name, c.Venues[name].endpoints())
+	}
+}
+
+func (v Venue) endpoints() exchanges.Endpoints {
+	return exchanges.Endpoints{WS: v.WSURL, REST: v.RESTURL, PollInterval: v.PollInterval}
+}
+
+// Clone returns a deep copy of c.
+func (c *Config) Clone() *Config {
+	out := *c
+	out.Symbols = append([]string(nil), c.Symbols...)
+	out.Venues = make(map[string]Venue, len(c.Venues))
+	for name, v := range c.Venues {
+		v.Symbols = append([]string(nil), v.Symbols...)
+		if v.Enabled != nil {
+			enabled := *v.Enabled
+			v.Enabled = &enabled
+		}
+		out.Venues[name] = v
+	}
+	return &out
+}
+
+func canonicalVenue(name string, known []string) string {
+	for _, k := range known {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
+
+func normalizeSymbols(symbols []string) ([]string, error) {
+	out := make([]string, 0, len(symbols))
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		if !symbolPattern.MatchString(s) {
+			return nil, fmt.Errorf("invalid symbol %q: use letters and digits only, e.g. TONUSDT", s)
+		}
+		if s = exchanges.NormalizeSymbol(s); seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", raw, err)
+	}
+	for _, s := range schemes {
+		if u.Scheme == s {
+			if u.Host == "" {
+				return fmt.Errorf("invalid URL %q: missing host", raw)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid URL %q: scheme must be %s", raw, strings.Join(schemes, " or "))
+}
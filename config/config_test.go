@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, `
+symbols: [tonusdt, btcusdt]
+min_profit_pct: 0.1
+alert_cooldown: 30s
+venues:
+  okx_futures:
+    symbols: [TONUSDT]
+    fees: {taker_pct: 0.05}
+  dedust:
+    poll_interval: 5s
+  pyth:
+    enabled: false
+simulate:
+  serve_ws: true
+  tick: 100ms
+  outages: [{venue: Bybit_Futures, every: 1m, for: 5s}]
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if strings.Join(cfg.Symbols, ",") != "TONUSDT,BTCUSDT" {
+		t.Errorf("symbols: got %v", cfg.Symbols)
+	}
+	if cfg.MinProfitPct != 0.1 || cfg.AlertCooldown != 30*time.Second {
+		t.Errorf("thresholds: got %v, %v", cfg.MinProfitPct, cfg.AlertCooldown)
+	}
+	if cfg.MaxQuoteAge != 5*time.Second || cfg.BroadcastInterval != DefaultBroadcastInterval {
+		t.Errorf("defaults not kept: %v, %v", cfg.MaxQuoteAge, cfg.BroadcastInterval)
+	}
+	if cfg.Venues["DeDust"].PollInterval != 5*time.Second {
+		t.Errorf("venue names should be matched case-insensitively: %v", cfg.Venues)
+	}
+	if cfg.VenueEnabled("pyth") || !cfg.VenueEnabled("binance_futures") {
+		t.Errorf("enabled venues wrong")
+	}
+	if got := cfg.Venues["okx_futures"].Fees.TakerPct; got != 0.05 {
+		t.Errorf("okx taker fee: got %v", got)
+	}
+	if sim := cfg.Simulate; sim.Enabled || !sim.Servers || sim.Market.Tick != 100*time.Millisecond || sim.Market.Outages[0].Venue != "bybit_futures" {
+		t.Errorf("simulate: got %+v", sim)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	cfg := Default()
+	cfg.Venues["okx_futures"] = Venue{WSURL: "wss://file.example.com"}
+	env := map[string]string{
+		"SCANNER_SYMBOLS":                    "TONUSDT,ETHUSDT,1000PEPEUSDT,PEPEUSDT",
+		"SCANNER_MIN_PROFIT_PCT":             "0.2",
+		"SCANNER_OKX_FUTURES_WS_URL":         "wss://env.example.com",
+		"SCANNER_BYBIT_FUTURES_ENABLED":      "false",
+		"SCANNER_GATE_FUTURES_TAKER_FEE_PCT": "0.05",
+		"SCANNER_HEALTH_MIN_FRAMES":          "50",
+	}
+	if err := cfg.applyEnv(func(k string) string { return env[k] }); err != nil {
+		t.Fatalf("applyEnv: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	if strings.Join(cfg.Symbols, ",") != "TONUSDT,ETHUSDT,PEPEUSDT" || cfg.MinProfitPct != 0.2 {
+		t.Errorf("globals: got %v, %v", cfg.Symbols, cfg.MinProfitPct)
+	}
+	if cfg.Venues["okx_futures"].WSURL != "wss://env.example.com" {
+		t.Errorf("env should override the file: %+v", cfg.Venues["okx_futures"])
+	}
+	if cfg.VenueEnabled("bybit_futures") {
+		t.Errorf("bybit_futures should be disabled")
+	}
+	if cfg.Venues["gate_futures"].Fees.TakerPct != 0.05 {
+		t.Errorf("gate fee: %+v", cfg.Venues["gate_futures"])
+	}
+	if cfg.Health.MinFrames != 50 {
+		t.Errorf("health min frames: got %d, want 50", cfg.Health.MinFrames)
+	}
+	if _, ok := cfg.Venues["kraken_futures"]; ok {
+		t.Errorf("untouched venues should not get an entry")
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "unknown field",
+			body: "symbol: [TONUSDT]",
+			want: []string{"field symbol not found"},
+		},
+		{
+			name: "bad values",
+			body: `
+symbols: [TON-USDT]
+min_profit_pct: -1
+max_quote_age: 0s
+broadcast_interval: 1ms
+markets: {refresh_interval: 10s}
+health: {max_parse_error_ratio: 1.5, update_timeout: 1s, min_fresh_venues: -1}
+`,
+			want: []string{`invalid symbol "TON-USDT"`, "min_profit_pct: must not be negative", "max_quote_age: must be positive", "broadcast_interval: must be at least 10ms", "markets.refresh_interval: must be 0 or at least 1m",
+				"health.max_parse_error_ratio: must be between 0 and 1", "health.update_timeout: must be 0 or at least 5s",
+				"health.min_fresh_venues: must not be negative"},
+		},
+		{
+			name: "bad venues",
+			body: `
+venues:
+  binanse_futures: {}
+  okx_futures:
+    ws_url: https://ws.okx.com
+    poll_interval: 1s
+    fees: {taker_pct: 5.5}
+  variational_perps:
+    ws_url: wss://example.com
+`,
+			want: []string{
+				"venues.binanse_futures: unknown venue",
+				"venues.okx_futures.ws_url: invalid URL \"https://ws.okx.com\": scheme must be ws or wss",
+				"venues.okx_futures.poll_interval: okx_futures streams and does not poll",
+				"venues.okx_futures.fees.taker_pct: must be between 0 and 5",
+				"venues.variational_perps.ws_url: variational_perps has no websocket endpoint",
+			},
+		},
+		{
+			name: "bad simulate",
+			body: `
+simulate:
+  correlation: 1.5
+  latency: {kraken: 10ms, pyth: -1s}
+  spreads: [{venue: okx_futures, every: -1m}]
+`,
+			want: []string{
+				"simulate.correlation: must be between 0 and 1",
+				`simulate.latency.kraken: unknown venue "kraken"`,
+				"simulate.latency.pyth: must not be negative",
+				"simulate.spreads[0]: every and for must not be negative",
+			},
+		},
+		{
+			name: "bad duration",
+			body: "alert_cooldown: soon",
+			want: []string{"line 1: cannot unmarshal !!str `soon` into time.Duration"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.body))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
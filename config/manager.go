@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+)
+
+// ReloadInterval is how often Watch checks the config file for changes.
+const ReloadInterval = 5 * time.Second
+
+// Manager owns the running configuration and applies changes to it, from
+// the file or from the admin API, to a scanner without restarting it.
+type Manager struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	cfg     *Config
+	scanner *scanner.Scanner
+}
+
+// NewManager returns a Manager for cfg, which was loaded from path and
+// used to build sc.
+func NewManager(path string, cfg *Config, sc *scanner.Scanner) *Manager {
+	m := &Manager{path: path, cfg: cfg.Clone(), scanner: sc}
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			m.modTime = info.ModTime()
+		}
+	}
+	return m
+}
+
+// Current returns a copy of the configuration in force.
+func (m *Manager) Current() *Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg.Clone()
+}
+
+// Update applies fn to a copy of the configuration and, if the result is
+// valid, puts it in force. Changes made this way last until the next
+// reload.
+func (m *Manager) Update(fn func(*Config)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.cfg.Clone()
+	fn(next)
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	m.apply(next)
+	return nil
+}
+
+// Reload loads the file and environment again and puts the result in
+// force. On error the configuration is left as it was.
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var modTime time.Time
+	if m.path != "" {
+		info, err := os.Stat(m.path)
+		if err != nil {
+			return err
+		}
+		modTime = info.ModTime()
+	}
+	next, err := Load(m.path)
+	if err != nil {
+		return err
+	}
+	m.modTime = modTime
+	m.apply(next)
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP and whenever the file changes,
+// until ctx is done. A bad edit is logged and the previous settings stay
+// in force.
+func (m *Manager) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("Config: SIGHUP received, reloading")
+		case <-ticker.C:
+			if !m.fileChanged() {
+				continue
+			}
+		}
+		if err := m.Reload(); err != nil {
+			log.Printf("Config reload failed, keeping previous settings: %v", err)
+			continue
+		}
+		log.Printf("Config reloaded")
+	}
+}
+
+func (m *Manager) fileChanged() bool {
+	if m.path == "" {
+		return false
+	}
+	info, err := os.Stat(m.path)
+	if err != nil {
+		log.Printf("Config file: %v", err)
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !info.ModTime().Equal(m.modTime)
+}
+
+// apply puts next in force, touching only what changed: connectors whose
+// endpoints changed reconnect, the rest follow symbol changes in place
+// where the venue allows it. The caller must hold mu.
+func (m *Manager) apply(next *Config) {
+	prev := m.cfg
+	m.cfg = next.Clone()
+
+	if !thresholdsEqual(prev.Thresholds(), next.Thresholds()) {
+		m.scanner.SetThresholds(next.Thresholds())
+		log.Printf("Config: thresholds updated (min profit %.4f%%, cooldown %v, max quote age %v)",
+			next.MinProfitPct, next.AlertCooldown, next.MaxQuoteAge)
+	}
+	if next.Health != prev.Health {
+		m.scanner.SetHealthThresholds(next.HealthThresholds())
+		log.Printf("Config: health thresholds updated (max parse error ratio %v, min frames %d, update timeout %v, min fresh venues %d)",
+			next.Health.MaxParseErrorRatio, next.Health.MinFrames, next.Health.UpdateTimeout, next.Health.MinFreshVenues)
+	}
+	if next.BroadcastInterval != prev.BroadcastInterval {
+		log.Printf("Config: broadcast_interval change to %v takes effect after a restart", next.BroadcastInterval)
+	}
+	if next.Markets != prev.Markets {
+		log.Printf("Config: markets changes take effect after a restart")
+	}
+	if !reflect.DeepEqual(next.Simulate, prev.Simulate) {
+		log.Printf("Config: simulate changes take effect after a restart")
+	}
+
+	for _, name := range exchanges.Sources() {
+		p, n := prev.Venues[name], next.Venues[name]
+		if p.endpoints() != n.endpoints() {
+			exchanges.SetEndpoints(name, n.endpoints())
+			if err := m.scanner.RestartVenue(name); err == nil {
+				log.Printf("Config: %s endpoints changed, reconnecting", name)
+			}
+		}
+		if prev.VenueEnabled(name) != next.VenueEnabled(name) || strings.Join(p.Symbols, ",") != strings.Join(n.Symbols, ",") {
+			if err := m.scanner.SetVenue(name, next.VenueEnabled(name), n.Symbols); err != nil {
+				log.Printf("Config: %v", err)
+				continue
+			}
+			log.Printf("Config: %s enabled=%v symbols=%v", name, next.VenueEnabled(name), n.Symbols)
+		}
+	}
+
+	if strings.Join(prev.Symbols, ",") != strings.Join(next.Symbols, ",") {
+		m.scanner.SetSymbols(next.Symbols...)
+		log.Printf("Config: symbols set to %v", next.Symbols)
+	}
+}
+
+func thresholdsEqual(a, b scanner.Thresholds) bool {
+	if a.MinProfitPct != b.MinProfitPct || a.AlertCooldown != b.AlertCooldown || a.MaxQuoteAge != b.MaxQuoteAge || len(a.Fees) != len(b.Fees) {
+		return false
+	}
+	for source, f := range a.Fees {
+		if b.Fees[source] != f {
+			return false
+		}
+	}
+	return true
+}
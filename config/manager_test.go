@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/scanner"
+)
+
+func TestManagerReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write("symbols: [TONUSDT]\nmin_profit_pct: 0.1\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	sc := scanner.New(cfg.ScannerOptions()...)
+	m := NewManager(path, cfg, sc)
+
+	write("symbols: [TONUSDT, BTCUSDT]\nmin_profit_pct: 0.3\nvenues:\n  okx_futures:\n    enabled: false\n")
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := strings.Join(sc.Symbols(), ","); got != "TONUSDT,BTCUSDT" {
+		t.Fatalf("scanner symbols = %s", got)
+	}
+	if got := sc.Thresholds().MinProfitPct; got != 0.3 {
+		t.Fatalf("min profit = %v", got)
+	}
+	for _, v := range sc.Venues() {
+		if v.Name == "okx_futures" && v.Enabled {
+			t.Fatalf("okx_futures still enabled")
+		}
+	}
+
+	write("symbols: [ton-usdt]\n")
+	if err := m.Reload(); err == nil {
+		t.Fatalf("expected a validation error")
+	}
+	if got := m.Current().MinProfitPct; got != 0.3 {
+		t.Fatalf("bad reload changed the config: min profit = %v", got)
+	}
+}
+
+func TestManagerUpdate(t *testing.T) {
+	cfg := Default()
+	sc := scanner.New(cfg.ScannerOptions()...)
+	m := NewManager("", cfg, sc)
+
+	err := m.Update(func(c *Config) {
+		c.AlertCooldown = time.Minute
+		c.Venues["gate_futures"] = Venue{Fees: Fees{TakerPct: 0.05}}
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	th := sc.Thresholds()
+	if th.AlertCooldown != time.Minute || th.Fees["gate_futures"].TakerPct != 0.05 {
+		t.Fatalf("thresholds not applied: %+v", th)
+	}
+
+	if err := m.Update(func(c *Config) { c.MaxQuoteAge = 0 }); err == nil {
+		t.Fatalf("expected a validation error")
+	}
+	if sc.Thresholds().MaxQuoteAge != cfg.MaxQuoteAge {
+		t.Fatalf("invalid update reached the scanner")
+	}
+}
@@ -1,6 +1,7 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -32,25 +33,54 @@ type BinanceFuturesBookTicker struct {
 	BestAskQty   string `json:"A"`
 }
 
-func ConnectBinanceFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	streamNames := make([]string, len(symbols)*2)
-	for i, symbol := range symbols {
-		streamNames[i*2] = strings.ToLower(symbol) + "@bookTicker"
-		streamNames[i*2+1] = strings.ToLower(symbol) + "@aggTrade"
-	}
-	streamParam := strings.Join(streamNames, "/")
+func ConnectBinanceFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectBinanceContracts(ctx, "binance_futures", "Binance futures", symbols, orderbookChan, tradeChan)
+}
+
+// ConnectBinanceInverse streams Binance's COIN-M perpetuals (BTCUSD_PERP),
+// whose dstream messages match the USD-M ones.
+func ConnectBinanceInverse(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectBinanceContracts(ctx, "binance_inverse", "Binance COIN-M", symbols, orderbookChan, tradeChan)
+}
 
-	wsURL := fmt.Sprintf("wss://fstream.binance.com/stream?streams=%s", streamParam)
+func connectBinanceContracts(ctx context.Context, venue, label string, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, venue, label, symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectBinanceContractsShard(ctx, venue, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
+// connectBinanceContractsShard streams one shard's symbols, which are
+// named in the connection URL.
+func connectBinanceContractsShard(ctx context.Context, venue, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
+		}
+		streamParam := strings.Join(binanceStreamNames(venue, current), "/")
+		wsURL := fmt.Sprintf("%s?streams=%s", EndpointsFor(venue).WS, streamParam)
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
 		if err != nil {
-			log.Printf("Binance futures connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("Connected to Binance futures WebSocket")
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		// Streams named in the URL are accepted with the handshake.
+		subs := newSubscriptions(stats, label, func(id int64, stream string) error {
+			return conn.WriteJSON(binanceRequest("SUBSCRIBE", []string{stream}, id))
+		})
+		subs.ack(binanceStreamNames(venue, current)...)
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error { return binanceResubscribe(conn, subs, venue, added, removed) })
+		})
 
 		for {
 			var message struct {
@@ -58,43 +88,63 @@ func ConnectBinanceFutures(symbols []string, priceChan chan<- PriceData, orderbo
 				Data   json.RawMessage `json:"data"`
 			}
 
-			err := conn.ReadJSON(&message)
+			frame, receivedAt, err := readFrame(conn, venue)
 			if err != nil {
-				log.Printf("Binance futures read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
+			if err := json.Unmarshal(frame, &message); err != nil {
+				stats.ParseError()
+				continue
+			}
 
 			if strings.Contains(message.Stream, "@bookTicker") {
+				stats.Message("bookTicker")
 				var bookTicker BinanceFuturesBookTicker
 				if err := json.Unmarshal(message.Data, &bookTicker); err != nil {
+					stats.ParseError()
 					continue
 				}
 
 				bidPrice, err1 := strconv.ParseFloat(bookTicker.BestBidPrice, 64)
 				askPrice, err2 := strconv.ParseFloat(bookTicker.BestAskPrice, 64)
 				if err1 != nil || err2 != nil {
+					stats.ParseError()
+					continue
+				}
+
+				symbol, ok := CanonicalSymbol(venue, bookTicker.Symbol)
+				if !ok {
 					continue
 				}
 
 				orderbookData := OrderbookData{
-					Symbol:    bookTicker.Symbol,
-					Source:    "binance_futures",
-					BestBid:   bidPrice,
-					BestAsk:   askPrice,
-					Timestamp: bookTicker.EventTime,
+					Symbol:     symbol,
+					Source:     venue,
+					BestBid:    bidPrice,
+					BestAsk:    askPrice,
+					BidSize:    parseSize(bookTicker.BestBidQty),
+					AskSize:    parseSize(bookTicker.BestAskQty),
+					Timestamp:  bookTicker.EventTime,
+					ReceivedAt: receivedAt,
 				}
 
 				orderbookChan <- orderbookData
 
 			} else if strings.Contains(message.Stream, "@aggTrade") {
+				stats.Message("aggTrade")
 				var trade BinanceFuturesTrade
 				if err := json.Unmarshal(message.Data, &trade); err != nil {
+					stats.ParseError()
 					continue
 				}
 
 				price, err := strconv.ParseFloat(trade.Price, 64)
 				if err != nil {
+					stats.ParseError()
 					continue
 				}
 
@@ -106,20 +156,34 @@ func ConnectBinanceFutures(symbols []string, priceChan chan<- PriceData, orderbo
 					side = "sell"
 				}
 
+				symbol, ok := CanonicalSymbol(venue, trade.Symbol)
+				if !ok {
+					continue
+				}
+
 				tradeData := TradeData{
-					Symbol:    trade.Symbol,
-					Source:    "binance_futures",
-					Price:     price,
-					Quantity:  trade.Quantity,
-					Side:      side,
-					Timestamp: trade.TradeTime,
+					Symbol:     symbol,
+					Source:     venue,
+					Price:      price,
+					Quantity:   parseSize(trade.Quantity),
+					Side:       side,
+					Timestamp:  trade.TradeTime,
+					ReceivedAt: receivedAt,
 				}
 
 				tradeChan <- tradeData
+			} else {
+				binanceReply(subs, frame)
+				stats.Other(hasField(frame, "id"))
 			}
 		}
+		stopFollow()
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
 
@@ -146,25 +210,44 @@ type BinanceSpotBookTicker struct {
 }
 
 // ConnectBinanceSpot connects to Binance spot trading WebSocket API
-func ConnectBinanceSpot(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	streamNames := make([]string, len(symbols)*2)
-	for i, symbol := range symbols {
-		streamNames[i*2] = strings.ToLower(symbol) + "@bookTicker"
-		streamNames[i*2+1] = strings.ToLower(symbol) + "@aggTrade"
-	}
-	streamParam := strings.Join(streamNames, "/")
-
-	wsURL := fmt.Sprintf("wss://stream.binance.com:9443/stream?streams=%s", streamParam)
+func ConnectBinanceSpot(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, "binance_spot", "Binance spot", symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectBinanceSpotShard(ctx, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
+// connectBinanceSpotShard streams one shard's symbols, which are named in
+// the connection URL.
+func connectBinanceSpotShard(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
+		}
+		streamParam := strings.Join(binanceStreamNames("binance_spot", current), "/")
+		wsURL := fmt.Sprintf("%s?streams=%s", EndpointsFor("binance_spot").WS, streamParam)
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
 		if err != nil {
-			log.Printf("Binance spot connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("Connected to Binance spot WebSocket")
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		// Streams named in the URL are accepted with the handshake.
+		subs := newSubscriptions(stats, label, func(id int64, stream string) error {
+			return conn.WriteJSON(binanceRequest("SUBSCRIBE", []string{stream}, id))
+		})
+		subs.ack(binanceStreamNames("binance_spot", current)...)
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error { return binanceResubscribe(conn, subs, "binance_spot", added, removed) })
+		})
 
 		for {
 			var message struct {
@@ -172,43 +255,63 @@ func ConnectBinanceSpot(symbols []string, priceChan chan<- PriceData, orderbookC
 				Data   json.RawMessage `json:"data"`
 			}
 
-			err := conn.ReadJSON(&message)
+			frame, receivedAt, err := readFrame(conn, "binance_spot")
 			if err != nil {
-				log.Printf("Binance spot read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
+			if err := json.Unmarshal(frame, &message); err != nil {
+				stats.ParseError()
+				continue
+			}
 
 			if strings.Contains(message.Stream, "@bookTicker") {
+				stats.Message("bookTicker")
 				var bookTicker BinanceSpotBookTicker
 				if err := json.Unmarshal(message.Data, &bookTicker); err != nil {
+					stats.ParseError()
 					continue
 				}
 
 				bidPrice, err1 := strconv.ParseFloat(bookTicker.BestBidPrice, 64)
 				askPrice, err2 := strconv.ParseFloat(bookTicker.BestAskPrice, 64)
 				if err1 != nil || err2 != nil {
+					stats.ParseError()
+					continue
+				}
+
+				symbol, ok := CanonicalSymbol("binance_spot", bookTicker.Symbol)
+				if !ok {
 					continue
 				}
 
 				orderbookData := OrderbookData{
-					Symbol:    bookTicker.Symbol,
-					Source:    "binance_spot",
-					BestBid:   bidPrice,
-					BestAsk:   askPrice,
-					Timestamp: bookTicker.EventTime,
+					Symbol:     symbol,
+					Source:     "binance_spot",
+					BestBid:    bidPrice,
+					BestAsk:    askPrice,
+					BidSize:    parseSize(bookTicker.BestBidQty),
+					AskSize:    parseSize(bookTicker.BestAskQty),
+					Timestamp:  bookTicker.EventTime,
+					ReceivedAt: receivedAt,
 				}
 
 				orderbookChan <- orderbookData
 
 			} else if strings.Contains(message.Stream, "@aggTrade") {
+				stats.Message("aggTrade")
 				var trade BinanceSpotTrade
 				if err := json.Unmarshal(message.Data, &trade); err != nil {
+					stats.ParseError()
 					continue
 				}
 
 				price, err := strconv.ParseFloat(trade.Price, 64)
 				if err != nil {
+					stats.ParseError()
 					continue
 				}
 
@@ -220,19 +323,150 @@ func ConnectBinanceSpot(symbols []string, priceChan chan<- PriceData, orderbookC
 					side = "sell"
 				}
 
+				symbol, ok := CanonicalSymbol("binance_spot", trade.Symbol)
+				if !ok {
+					continue
+				}
+
 				tradeData := TradeData{
-					Symbol:    trade.Symbol,
-					Source:    "binance_spot",
-					Price:     price,
-					Quantity:  trade.Quantity,
-					Side:      side,
-					Timestamp: trade.TradeTime,
+					Symbol:     symbol,
+					Source:     "binance_spot",
+					Price:      price,
+					Quantity:   parseSize(trade.Quantity),
+					Side:       side,
+					Timestamp:  trade.TradeTime,
+					ReceivedAt: receivedAt,
 				}
 
 				tradeChan <- tradeData
+			} else {
+				binanceReply(subs, frame)
+				stats.Other(hasField(frame, "id"))
 			}
 		}
+		stopFollow()
+		subs.close()
+		stopClose()
+
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
+	}
+}
+
+func binanceStreamNames(venue string, symbols []string) []string {
+	streamNames := make([]string, 0, len(symbols)*2)
+	for _, symbol := range symbols {
+		native, ok := NativeSymbol(venue, symbol)
+		if !ok {
+			continue
+		}
+		streamNames = append(streamNames, strings.ToLower(native)+"@bookTicker", strings.ToLower(native)+"@aggTrade")
+	}
+	return streamNames
+}
+
+// binanceResubscribe changes the streams of a combined stream connection in
+// place.
+func binanceResubscribe(conn *websocket.Conn, subs *subscriptions, venue string, added, removed []string) error {
+	if len(removed) > 0 {
+		streams := binanceStreamNames(venue, removed)
+		subs.unsubscribed(streams...)
+		if err := conn.WriteJSON(binanceRequest("UNSUBSCRIBE", streams, time.Now().UnixNano())); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		streams := binanceStreamNames(venue, added)
+		if err := conn.WriteJSON(binanceRequest("SUBSCRIBE", streams, subs.request(streams...))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func binanceRequest(method string, streams []string, id int64) map[string]interface{} {
+	return map[string]interface{}{"method": method, "params": streams, "id": id}
+}
 
-		time.Sleep(2 * time.Second)
+// binanceReply applies the answer to a SUBSCRIBE request, {"result":null,
+// "id":1} or {"error":{"code":2,"msg":"Invalid request"},"id":1}, to subs.
+// Vest's ws-api answers the same way.
+func binanceReply(subs *subscriptions, frame []byte) {
+	var reply struct {
+		ID    int64 `json:"id"`
+		Error *struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(frame, &reply); err != nil || reply.ID == 0 {
+		return
+	}
+	if reply.Error != nil {
+		subs.rejectRequest(reply.ID, strconv.Itoa(reply.Error.Code), reply.Error.Msg)
+		return
+	}
+	subs.acked(reply.ID)
+}
+
+// binanceExchangeInfo is the part of /fapi/v1/exchangeInfo,
+// /dapi/v1/exchangeInfo and /api/v3/exchangeInfo market discovery reads.
+type binanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol         string  `json:"symbol"`
+		Status         string  `json:"status"`
+		ContractStatus string  `json:"contractStatus"`
+		ContractType   string  `json:"contractType"`
+		ContractSize   float64 `json:"contractSize"`
+		BaseAsset      string  `json:"baseAsset"`
+		QuoteAsset     string  `json:"quoteAsset"`
+		MarginAsset    string  `json:"marginAsset"`
+		Filters        []struct {
+			FilterType string `json:"filterType"`
+			TickSize   string `json:"tickSize"`
+			StepSize   string `json:"stepSize"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// parseBinanceExchangeInfo reads spot pairs and perpetuals; dated futures
+// are skipped. COIN-M perpetuals, margined in their base asset, are
+// Inverse with contracts of contractSize USD.
+func parseBinanceExchangeInfo(body []byte) ([]Market, error) {
+	var info binanceExchangeInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+
+	markets := make([]Market, 0, len(info.Symbols))
+	for _, s := range info.Symbols {
+		if s.ContractType != "" && s.ContractType != "PERPETUAL" {
+			continue
+		}
+		status := s.Status
+		if status == "" {
+			status = s.ContractStatus
+		}
+		m := Market{
+			Native: s.Symbol,
+			Base:   s.BaseAsset,
+			Quote:  s.QuoteAsset,
+			Status: marketStatus(status == "TRADING", status),
+		}
+		if s.MarginAsset != "" && s.MarginAsset == s.BaseAsset {
+			m.Kind = Inverse
+			m.Multiplier = s.ContractSize
+		}
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				m.TickSize = parseSize(f.TickSize)
+			case "LOT_SIZE":
+				m.LotSize = parseSize(f.StepSize)
+			}
+		}
+		markets = append(markets, m)
 	}
+	return markets, nil
 }
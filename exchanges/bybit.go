@@ -1,9 +1,12 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -26,6 +29,7 @@ type BybitFuturesTrade struct {
 type BybitFuturesOrderbook struct {
 	Topic string `json:"topic"`
 	Type  string `json:"type"`
+	TS    int64  `json:"ts"`
 	Data  struct {
 		Symbol   string     `json:"s"`
 		Bids     [][]string `json:"b"`
@@ -35,42 +39,79 @@ type BybitFuturesOrderbook struct {
 	} `json:"data"`
 }
 
-func ConnectBybitFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://stream.bybit.com/v5/public/linear"
+func ConnectBybitFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectBybitContracts(ctx, "bybit_futures", "Bybit futures", symbols, orderbookChan, tradeChan)
+}
 
-	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-		if err != nil {
-			log.Printf("Bybit futures connection error: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
+// ConnectBybitInverse streams Bybit's inverse perpetuals (BTCUSD).
+func ConnectBybitInverse(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectBybitContracts(ctx, "bybit_inverse", "Bybit inverse", symbols, orderbookChan, tradeChan)
+}
 
-		log.Printf("Connected to Bybit futures WebSocket")
+// connectBybitContracts streams venue's derivatives; Bybit has one public
+// stream per contract category.
+func connectBybitContracts(ctx context.Context, venue, label string, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, venue, label, symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectBybitContractsShard(ctx, venue, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
-		subscribeMsg := map[string]interface{}{
-			"op":   "subscribe",
-			"args": make([]string, len(symbols)*2),
+// connectBybitContractsShard streams one shard's symbols.
+func connectBybitContractsShard(ctx context.Context, venue, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	for {
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
 		}
 
-		for i, symbol := range symbols {
-			subscribeMsg["args"].([]string)[i*2] = fmt.Sprintf("orderbook.1.%s", symbol)
-			subscribeMsg["args"].([]string)[i*2+1] = fmt.Sprintf("publicTrade.%s", symbol)
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor(venue).WS, nil)
+		if err != nil {
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
+			continue
 		}
 
-		err = conn.WriteJSON(subscribeMsg)
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, topic string) error {
+			return conn.WriteJSON(bybitRequest("subscribe", []string{topic}, id))
+		})
+
+		err = bybitSubscribe(conn, subs, venue, "subscribe", current)
 		if err != nil {
-			log.Printf("Bybit futures subscription error: %v", err)
+			log.Printf("%s subscription error: %v", label, err)
+			stats.Error(err)
+			stats.Disconnected()
 			conn.Close()
-			time.Sleep(5 * time.Second)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if len(removed) > 0 {
+					if err := bybitSubscribe(conn, subs, venue, "unsubscribe", removed); err != nil {
+						return err
+					}
+				}
+				if len(added) > 0 {
+					return bybitSubscribe(conn, subs, venue, "subscribe", added)
+				}
+				return nil
+			})
+		})
 
 		for {
-			var message json.RawMessage
-			err := conn.ReadJSON(&message)
+			message, receivedAt, err := readFrame(conn, venue)
 			if err != nil {
-				log.Printf("Bybit futures read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
@@ -79,19 +120,29 @@ func ConnectBybitFutures(symbols []string, priceChan chan<- PriceData, orderbook
 			var orderbookMsg BybitFuturesOrderbook
 			if err := json.Unmarshal(message, &orderbookMsg); err == nil &&
 				len(orderbookMsg.Data.Asks) > 0 && len(orderbookMsg.Data.Bids) > 0 {
+				stats.Message("orderbook")
 
 				bidPrice, err1 := strconv.ParseFloat(orderbookMsg.Data.Bids[0][0], 64)
 				askPrice, err2 := strconv.ParseFloat(orderbookMsg.Data.Asks[0][0], 64)
 				if err1 != nil || err2 != nil {
+					stats.ParseError()
+					continue
+				}
+
+				symbol, ok := CanonicalSymbol(venue, orderbookMsg.Data.Symbol)
+				if !ok {
 					continue
 				}
 
 				orderbookData := OrderbookData{
-					Symbol:    orderbookMsg.Data.Symbol,
-					Source:    "bybit_futures",
-					BestBid:   bidPrice,
-					BestAsk:   askPrice,
-					Timestamp: time.Now().UnixMilli(),
+					Symbol:     symbol,
+					Source:     venue,
+					BestBid:    bidPrice,
+					BestAsk:    askPrice,
+					BidSize:    levelSize(orderbookMsg.Data.Bids[0]),
+					AskSize:    levelSize(orderbookMsg.Data.Asks[0]),
+					Timestamp:  orderbookMsg.TS,
+					ReceivedAt: receivedAt,
 				}
 
 				orderbookChan <- orderbookData
@@ -102,10 +153,12 @@ func ConnectBybitFutures(symbols []string, priceChan chan<- PriceData, orderbook
 			var tradeMsg BybitFuturesTrade
 			if err := json.Unmarshal(message, &tradeMsg); err == nil &&
 				(tradeMsg.Type == "snapshot" || tradeMsg.Type == "delta") {
+				stats.Message("publicTrade")
 
 				for _, trade := range tradeMsg.Data {
 					price, err := strconv.ParseFloat(trade.Price, 64)
 					if err != nil {
+						stats.ParseError()
 						continue
 					}
 
@@ -117,21 +170,36 @@ func ConnectBybitFutures(symbols []string, priceChan chan<- PriceData, orderbook
 						side = "sell"
 					}
 
+					symbol, ok := CanonicalSymbol(venue, trade.Symbol)
+					if !ok {
+						continue
+					}
+
 					tradeData := TradeData{
-						Symbol:    trade.Symbol,
-						Source:    "bybit_futures",
-						Price:     price,
-						Quantity:  trade.Size,
-						Side:      side,
-						Timestamp: trade.Timestamp,
+						Symbol:     symbol,
+						Source:     venue,
+						Price:      price,
+						Quantity:   parseSize(trade.Size),
+						Side:       side,
+						Timestamp:  trade.Timestamp,
+						ReceivedAt: receivedAt,
 					}
 
 					tradeChan <- tradeData
 				}
+				continue
 			}
+
+			bybitReply(subs, message)
+			stats.Other(hasField(message, "op", "success"))
 		}
+		stopFollow()
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
 
@@ -152,6 +220,7 @@ type BybitSpotTrade struct {
 type BybitSpotOrderbook struct {
 	Topic string `json:"topic"`
 	Type  string `json:"type"`
+	TS    int64  `json:"ts"`
 	Data  struct {
 		Symbol   string     `json:"s"`
 		Bids     [][]string `json:"b"`
@@ -162,42 +231,68 @@ type BybitSpotOrderbook struct {
 }
 
 // ConnectBybitSpot connects to Bybit spot trading WebSocket API
-func ConnectBybitSpot(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://stream.bybit.com/v5/public/spot"
+func ConnectBybitSpot(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, "bybit_spot", "Bybit spot", symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectBybitSpotShard(ctx, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
+// connectBybitSpotShard streams one shard's symbols.
+func connectBybitSpotShard(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
+		}
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor("bybit_spot").WS, nil)
 		if err != nil {
-			log.Printf("Bybit spot connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("Connected to Bybit spot WebSocket")
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, topic string) error {
+			return conn.WriteJSON(bybitRequest("subscribe", []string{topic}, id))
+		})
 
-		subscribeMsg := map[string]interface{}{
-			"op":   "subscribe",
-			"args": make([]string, len(symbols)*2),
-		}
-
-		for i, symbol := range symbols {
-			subscribeMsg["args"].([]string)[i*2] = fmt.Sprintf("orderbook.1.%s", symbol)
-			subscribeMsg["args"].([]string)[i*2+1] = fmt.Sprintf("publicTrade.%s", symbol)
-		}
-
-		err = conn.WriteJSON(subscribeMsg)
+		err = bybitSubscribe(conn, subs, "bybit_spot", "subscribe", current)
 		if err != nil {
-			log.Printf("Bybit spot subscription error: %v", err)
+			log.Printf("%s subscription error: %v", label, err)
+			stats.Error(err)
+			stats.Disconnected()
 			conn.Close()
-			time.Sleep(5 * time.Second)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if len(removed) > 0 {
+					if err := bybitSubscribe(conn, subs, "bybit_spot", "unsubscribe", removed); err != nil {
+						return err
+					}
+				}
+				if len(added) > 0 {
+					return bybitSubscribe(conn, subs, "bybit_spot", "subscribe", added)
+				}
+				return nil
+			})
+		})
 
 		for {
-			var message json.RawMessage
-			err := conn.ReadJSON(&message)
+			message, receivedAt, err := readFrame(conn, "bybit_spot")
 			if err != nil {
-				log.Printf("Bybit spot read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
@@ -206,19 +301,29 @@ func ConnectBybitSpot(symbols []string, priceChan chan<- PriceData, orderbookCha
 			var orderbookMsg BybitSpotOrderbook
 			if err := json.Unmarshal(message, &orderbookMsg); err == nil &&
 				len(orderbookMsg.Data.Asks) > 0 && len(orderbookMsg.Data.Bids) > 0 {
+				stats.Message("orderbook")
 
 				bidPrice, err1 := strconv.ParseFloat(orderbookMsg.Data.Bids[0][0], 64)
 				askPrice, err2 := strconv.ParseFloat(orderbookMsg.Data.Asks[0][0], 64)
 				if err1 != nil || err2 != nil {
+					stats.ParseError()
+					continue
+				}
+
+				symbol, ok := CanonicalSymbol("bybit_spot", orderbookMsg.Data.Symbol)
+				if !ok {
 					continue
 				}
 
 				orderbookData := OrderbookData{
-					Symbol:    orderbookMsg.Data.Symbol,
-					Source:    "bybit_spot",
-					BestBid:   bidPrice,
-					BestAsk:   askPrice,
-					Timestamp: time.Now().UnixMilli(),
+					Symbol:     symbol,
+					Source:     "bybit_spot",
+					BestBid:    bidPrice,
+					BestAsk:    askPrice,
+					BidSize:    levelSize(orderbookMsg.Data.Bids[0]),
+					AskSize:    levelSize(orderbookMsg.Data.Asks[0]),
+					Timestamp:  orderbookMsg.TS,
+					ReceivedAt: receivedAt,
 				}
 
 				orderbookChan <- orderbookData
@@ -229,10 +334,12 @@ func ConnectBybitSpot(symbols []string, priceChan chan<- PriceData, orderbookCha
 			var tradeMsg BybitSpotTrade
 			if err := json.Unmarshal(message, &tradeMsg); err == nil &&
 				(tradeMsg.Type == "snapshot" || tradeMsg.Type == "delta") {
+				stats.Message("publicTrade")
 
 				for _, trade := range tradeMsg.Data {
 					price, err := strconv.ParseFloat(trade.Price, 64)
 					if err != nil {
+						stats.ParseError()
 						continue
 					}
 
@@ -244,20 +351,179 @@ func ConnectBybitSpot(symbols []string, priceChan chan<- PriceData, orderbookCha
 						side = "sell"
 					}
 
+					symbol, ok := CanonicalSymbol("bybit_spot", trade.Symbol)
+					if !ok {
+						continue
+					}
+
 					tradeData := TradeData{
-						Symbol:    trade.Symbol,
-						Source:    "bybit_spot",
-						Price:     price,
-						Quantity:  trade.Size,
-						Side:      side,
-						Timestamp: trade.Timestamp,
+						Symbol:     symbol,
+						Source:     "bybit_spot",
+						Price:      price,
+						Quantity:   parseSize(trade.Size),
+						Side:       side,
+						Timestamp:  trade.Timestamp,
+						ReceivedAt: receivedAt,
 					}
 
 					tradeChan <- tradeData
 				}
+				continue
 			}
+
+			bybitReply(subs, message)
+			stats.Other(hasField(message, "op", "success"))
+		}
+		stopFollow()
+		subs.close()
+		stopClose()
+
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
+	}
+}
+
+// bybitSubscribe sends op ("subscribe" or "unsubscribe") for the top of book
+// and trade topics of symbols on venue, a request per symbol so that one
+// the venue rejects doesn't take the others with it.
+func bybitSubscribe(conn *websocket.Conn, subs *subscriptions, venue, op string, symbols []string) error {
+	for _, symbol := range symbols {
+		native, ok := NativeSymbol(venue, symbol)
+		if !ok {
+			continue
+		}
+		topics := []string{fmt.Sprintf("orderbook.1.%s", native), fmt.Sprintf("publicTrade.%s", native)}
+		var id int64
+		if op == "subscribe" {
+			id = subs.request(topics...)
+		} else {
+			subs.unsubscribed(topics...)
+		}
+		if err := conn.WriteJSON(bybitRequest(op, topics, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bybitRequest(op string, topics []string, id int64) map[string]interface{} {
+	msg := map[string]interface{}{"op": op, "args": topics}
+	if id != 0 {
+		msg["req_id"] = strconv.FormatInt(id, 10)
+	}
+	return msg
+}
+
+// bybitReply applies the answer to a subscribe request, {"success":false,
+// "ret_msg":"Invalid symbol :[orderbook.1.FOOUSDT]","op":"subscribe",
+// "req_id":"3"}, to subs. Bybit gives no error code.
+func bybitReply(subs *subscriptions, frame []byte) {
+	var reply struct {
+		Success bool   `json:"success"`
+		RetMsg  string `json:"ret_msg"`
+		Op      string `json:"op"`
+		ReqID   string `json:"req_id"`
+	}
+	if err := json.Unmarshal(frame, &reply); err != nil || reply.Op != "subscribe" {
+		return
+	}
+	id, err := strconv.ParseInt(reply.ReqID, 10, 64)
+	if err != nil {
+		return
+	}
+	if reply.Success {
+		subs.acked(id)
+	} else {
+		subs.rejectRequest(id, "", reply.RetMsg)
+	}
+}
+
+// bybitInstrumentsInfo is a page of /v5/market/instruments-info.
+type bybitInstrumentsInfo struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []struct {
+			Symbol       string `json:"symbol"`
+			ContractType string `json:"contractType"`
+			Status       string `json:"status"`
+			BaseCoin     string `json:"baseCoin"`
+			QuoteCoin    string `json:"quoteCoin"`
+			PriceFilter  struct {
+				TickSize string `json:"tickSize"`
+			} `json:"priceFilter"`
+			LotSizeFilter struct {
+				QtyStep       string `json:"qtyStep"`
+				BasePrecision string `json:"basePrecision"`
+			} `json:"lotSizeFilter"`
+		} `json:"list"`
+		NextPageCursor string `json:"nextPageCursor"`
+	} `json:"result"`
+}
+
+// parseBybitInstruments reads one page of spot pairs, linear or inverse
+// perpetuals and the cursor of the next page, empty on the last. Inverse
+// contracts are worth 1 USD each.
+func parseBybitInstruments(body []byte) ([]Market, string, error) {
+	var info bybitInstrumentsInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, "", err
+	}
+	if info.RetCode != 0 {
+		return nil, "", fmt.Errorf("bybit error %d: %s", info.RetCode, info.RetMsg)
+	}
+
+	markets := make([]Market, 0, len(info.Result.List))
+	for _, s := range info.Result.List {
+		var kind Kind
+		switch s.ContractType {
+		case "", "LinearPerpetual":
+		case "InversePerpetual":
+			kind = Inverse
+		default:
+			continue
+		}
+		lot := s.LotSizeFilter.QtyStep
+		if lot == "" {
+			lot = s.LotSizeFilter.BasePrecision
 		}
+		markets = append(markets, Market{
+			Native:   s.Symbol,
+			Base:     s.BaseCoin,
+			Quote:    s.QuoteCoin,
+			TickSize: parseSize(s.PriceFilter.TickSize),
+			LotSize:  parseSize(lot),
+			Kind:     kind,
+			Status:   marketStatus(s.Status == "Trading", s.Status),
+		})
+	}
+	return markets, info.Result.NextPageCursor, nil
+}
 
-		time.Sleep(2 * time.Second)
+// fetchBybitInstruments pages through the instruments of category.
+func fetchBybitInstruments(category string) marketFetcher {
+	return func(ctx context.Context, base string) ([]Market, error) {
+		var markets []Market
+		cursor := ""
+		for {
+			u := base + "/v5/market/instruments-info?limit=1000&category=" + category
+			if cursor != "" {
+				u += "&cursor=" + url.QueryEscape(cursor)
+			}
+			body, err := fetchMetadata(ctx, http.MethodGet, u, nil)
+			if err != nil {
+				return nil, err
+			}
+			page, next, err := parseBybitInstruments(body)
+			if err != nil {
+				return nil, err
+			}
+			markets = append(markets, page...)
+			if next == "" || next == cursor {
+				return markets, nil
+			}
+			cursor = next
+		}
 	}
 }
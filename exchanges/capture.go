@@ -0,0 +1,136 @@
+package exchanges
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Frame is one inbound message as a connector received it: a websocket
+// frame, an event stream's data line or a polled response body.
+type Frame struct {
+	Venue      string `json:"venue"`
+	ReceivedAt int64  `json:"received_at"`
+	Data       string `json:"data"`
+}
+
+// capture writes every inbound frame to <dir>/<venue>.jsonl, one Frame per
+// line, while it is on.
+var capture struct {
+	on    atomic.Bool
+	mu    sync.Mutex
+	dir   string
+	files map[string]*os.File
+}
+
+// SetCaptureDir turns raw frame capture on, appending to a file per venue
+// in dir, or off when dir is empty. It takes effect at once, for
+// connectors already running too.
+func SetCaptureDir(dir string) error {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("capture: %w", err)
+		}
+	}
+	for venue, f := range capture.files {
+		f.Close()
+		delete(capture.files, venue)
+	}
+	capture.dir = dir
+	capture.files = make(map[string]*os.File)
+	capture.on.Store(dir != "")
+	return nil
+}
+
+// CapturePath returns the file venue's frames are captured to in dir.
+func CapturePath(dir, venue string) string {
+	return filepath.Join(dir, venue+".jsonl")
+}
+
+// received counts an inbound frame for venue and captures it. Connectors
+// call it for every frame they read, before parsing it.
+func received(venue string, data []byte, receivedAt int64) {
+	Stats(venue).Received()
+	captureFrame(venue, data, receivedAt)
+}
+
+// captureFrame records data if capture is on. A venue whose file can't be
+// written is logged once and skipped until capture is set again.
+func captureFrame(venue string, data []byte, receivedAt int64) {
+	if !capture.on.Load() {
+		return
+	}
+	line, err := json.Marshal(Frame{Venue: venue, ReceivedAt: receivedAt, Data: string(data)})
+	if err != nil {
+		return
+	}
+
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	if capture.dir == "" {
+		return
+	}
+	f, ok := capture.files[venue]
+	if !ok {
+		f, err = os.OpenFile(CapturePath(capture.dir, venue), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Printf("Capture %s: %v", venue, err)
+		}
+		capture.files[venue] = f
+	}
+	if f == nil {
+		return
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("Capture %s: %v", venue, err)
+		f.Close()
+		capture.files[venue] = nil
+	}
+}
+
+// ReadCapture reads the frames captured in path, in the order they came.
+func ReadCapture(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var frames []Frame
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 64<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var fr Frame
+		if err := json.Unmarshal(sc.Bytes(), &fr); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		frames = append(frames, fr)
+	}
+	return frames, sc.Err()
+}
+
+// readFrame reads the next message from conn, counts and captures it, and
+// returns it with the time it arrived.
+func readFrame(conn *websocket.Conn, venue string) ([]byte, int64, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, 0, err
+	}
+	receivedAt := time.Now().UnixMilli()
+	received(venue, data, receivedAt)
+	return data, receivedAt, nil
+}
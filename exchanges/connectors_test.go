@@ -0,0 +1,401 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges/mockvenue"
+)
+
+type connectFunc func(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData)
+
+// serveMock starts a fake server speaking protocol and points venue at it
+// until the test ends.
+func serveMock(t *testing.T, venue, protocol string) *mockvenue.Server {
+	t.Helper()
+	srv, err := mockvenue.New(protocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		srv.Close()
+		SetEndpoints(venue, Endpoints{})
+	})
+
+	e := Endpoints{WS: srv.URL(), PollInterval: 20 * time.Millisecond}
+	if defaults, _ := DefaultEndpoints(venue); defaults.WS == "" || protocol == "pyth" {
+		e = Endpoints{REST: srv.URL(), PollInterval: 20 * time.Millisecond}
+	}
+	SetEndpoints(venue, e)
+	return srv
+}
+
+type feeds struct {
+	prices chan PriceData
+	books  chan OrderbookData
+	trades chan TradeData
+}
+
+func runConnector(t *testing.T, connect connectFunc, symbols ...string) feeds {
+	t.Helper()
+	return runConnectorSet(t, connect, NewSymbolSet(symbols))
+}
+
+// runConnectorSet runs connect on set until the test ends, so the test can
+// change the symbols while it runs.
+func runConnectorSet(t *testing.T, connect connectFunc, set *SymbolSet) feeds {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	f := feeds{make(chan PriceData, 1000), make(chan OrderbookData, 1000), make(chan TradeData, 1000)}
+	go func() {
+		defer close(done)
+		connect(ctx, set, f.prices, f.books, f.trades)
+	}()
+	return f
+}
+
+// publishUntil publishes b, and t unless it is nil, until the connector
+// reports something, and returns what it reported first.
+func publishUntil(t *testing.T, srv *mockvenue.Server, b mockvenue.Book, tr *mockvenue.Trade, f feeds) interface{} {
+	t.Helper()
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case p := <-f.prices:
+			return p
+		case ob := <-f.books:
+			return ob
+		case <-deadline:
+			t.Fatalf("nothing received from the connector (subscriptions %v)", srv.Subscriptions())
+		case <-tick.C:
+			srv.PublishBook(b)
+			if tr != nil {
+				srv.PublishTrade(*tr)
+			}
+		}
+	}
+}
+
+func TestConnectorsAgainstMockVenues(t *testing.T) {
+	SetMarkets("lighter_futures", []Market{{Native: "TON", ID: "7", Base: "TON", Quote: "USD", Status: MarketTrading}})
+	defer SetMarkets("lighter_futures", nil)
+
+	pythBTC, _ := NativeSymbol("pyth", "BTCUSDT")
+	book := func(native string, bid, ask, size float64) mockvenue.Book {
+		return mockvenue.Book{
+			Symbol: native,
+			Bids:   []mockvenue.Level{{Price: bid, Size: size}, {Price: bid - 1, Size: 2 * size}},
+			Asks:   []mockvenue.Level{{Price: ask, Size: size}, {Price: ask + 1, Size: 2 * size}},
+			Time:   time.UnixMilli(1700000000000),
+		}
+	}
+
+	tests := []struct {
+		venue    string
+		protocol string
+		connect  connectFunc
+		symbol   string
+		native   string
+		trades   bool
+		// price is set for venues that report a price, not a book.
+		price float64
+		// sizes is false for venues that don't report them.
+		sizes bool
+	}{
+		{"binance_futures", "binance", ConnectBinanceFutures, "BTCUSDT", "BTCUSDT", true, 0, true},
+		{"binance_spot", "binance", ConnectBinanceSpot, "BTCUSDT", "BTCUSDT", true, 0, true},
+		{"binance_inverse", "binance", ConnectBinanceInverse, "BTCUSDT", "BTCUSD_PERP", true, 0, true},
+		{"bybit_futures", "bybit", ConnectBybitFutures, "BTCUSDT", "BTCUSDT", true, 0, true},
+		{"bybit_spot", "bybit", ConnectBybitSpot, "BTCUSDT", "BTCUSDT", true, 0, true},
+		{"bybit_inverse", "bybit", ConnectBybitInverse, "BTCUSDT", "BTCUSD", true, 0, true},
+		{"okx_futures", "okx", ConnectOKXFutures, "BTCUSDT", "BTC-USDT-SWAP", true, 0, true},
+		{"okx_inverse", "okx", ConnectOKXInverse, "BTCUSDT", "BTC-USD-SWAP", true, 0, true},
+		{"gate_futures", "gate", ConnectGateFutures, "BTCUSDT", "BTC_USDT", false, 0, true},
+		{"hyperliquid_futures", "hyperliquid", ConnectHyperliquidFutures, "BTCUSDT", "BTC", true, 0, true},
+		{"kraken_futures", "kraken", ConnectKrakenFutures, "BTCUSDT", "PF_XBTUSD", false, 0, true},
+		{"kraken_inverse", "kraken", ConnectKrakenInverse, "BTCUSDT", "PI_XBTUSD", false, 0, true},
+		{"paradex_futures", "paradex", ConnectParadexFutures, "BTCUSDT", "BTC-USD-PERP", false, 0, false},
+		{"lighter_futures", "lighter", ConnectLighterFutures, "TONUSDT", "7", false, 0, false},
+		{"vest_futures", "vest", ConnectVestFutures, "TONUSDT", "TON-PERP", false, 0, false},
+		{"variational_perps", "variational", ConnectVariationalFutures, "TONUSDT", "TON", false, 0, false},
+		{"pyth", "pyth", ConnectPythPrices, "BTCUSDT", pythBTC, false, 60000.5, false},
+		{"DeDust", "dedust", func(ctx context.Context, _ *SymbolSet, prices chan<- PriceData, _ chan<- OrderbookData, _ chan<- TradeData) {
+			ConnectDeDust(ctx, prices)
+		}, "TONUSDT", "TON/USDT", false, 60000.5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.venue, func(t *testing.T) {
+			srv := serveMock(t, tt.venue, tt.protocol)
+			f := runConnector(t, tt.connect, tt.symbol)
+
+			var tr *mockvenue.Trade
+			if tt.trades {
+				tr = &mockvenue.Trade{Symbol: tt.native, ID: 42, Price: 60001, Size: 3, Buy: true, Time: time.UnixMilli(1700000000001)}
+			}
+			got := publishUntil(t, srv, book(tt.native, 60000, 60001, 5), tr, f)
+
+			if tt.price != 0 {
+				p, ok := got.(PriceData)
+				if !ok {
+					t.Fatalf("got %T %+v, want a price", got, got)
+				}
+				if p.Symbol != tt.symbol || p.Source != tt.venue || math.Abs(p.Price-tt.price) > 1e-6 || p.ReceivedAt == 0 {
+					t.Errorf("price = %+v, want %s %s at %v", p, tt.symbol, tt.venue, tt.price)
+				}
+				return
+			}
+
+			ob, ok := got.(OrderbookData)
+			if !ok {
+				t.Fatalf("got %T %+v, want a book", got, got)
+			}
+			if ob.Symbol != tt.symbol || ob.Source != tt.venue || ob.BestBid != 60000 || ob.BestAsk != 60001 || ob.ReceivedAt == 0 {
+				t.Errorf("book = %+v, want %s %s 60000/60001", ob, tt.symbol, tt.venue)
+			}
+			if tt.sizes && (ob.BidSize != 5 || ob.AskSize != 5) {
+				t.Errorf("sizes = %v/%v, want 5/5", ob.BidSize, ob.AskSize)
+			}
+
+			if tt.trades {
+				select {
+				case trade := <-f.trades:
+					if trade.Symbol != tt.symbol || trade.Price != 60001 || trade.Quantity != 3 || trade.Side != "buy" {
+						t.Errorf("trade = %+v, want a buy of 3 %s at 60001", trade, tt.symbol)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("no trade")
+				}
+			}
+		})
+	}
+}
+
+// TestKrakenBookDeltas checks the book kept from a snapshot and the level
+// updates that follow it.
+func TestKrakenBookDeltas(t *testing.T) {
+	srv := serveMock(t, "kraken_futures", "kraken")
+	f := runConnector(t, ConnectKrakenFutures, "BTCUSDT")
+
+	first := mockvenue.Book{
+		Symbol: "PF_XBTUSD",
+		Bids:   []mockvenue.Level{{Price: 100, Size: 1}, {Price: 99, Size: 2}},
+		Asks:   []mockvenue.Level{{Price: 101, Size: 1}, {Price: 102, Size: 2}},
+	}
+	publishUntil(t, srv, first, nil, f)
+	for len(f.books) > 0 {
+		<-f.books
+	}
+
+	// The best bid goes, the ask is resized: the connector must apply both
+	// deltas to what it has.
+	srv.PublishBook(mockvenue.Book{
+		Symbol: "PF_XBTUSD",
+		Bids:   []mockvenue.Level{{Price: 99, Size: 2}},
+		Asks:   []mockvenue.Level{{Price: 101, Size: 4}, {Price: 102, Size: 2}},
+	})
+	var last OrderbookData
+	timeout := time.After(5 * time.Second)
+	for last.BestBid != 99 || last.AskSize != 4 {
+		select {
+		case last = <-f.books:
+		case <-timeout:
+			t.Fatalf("book = %+v, want bid 99 and ask 101 x 4", last)
+		}
+	}
+}
+
+// TestConnectorsReconnect drops the connection and expects the connector
+// to come back and subscribe again.
+func TestConnectorsReconnect(t *testing.T) {
+	tests := []struct {
+		venue    string
+		protocol string
+		connect  connectFunc
+		native   string
+	}{
+		{"binance_futures", "binance", ConnectBinanceFutures, "BTCUSDT"},
+		{"okx_futures", "okx", ConnectOKXFutures, "BTC-USDT-SWAP"},
+		{"hyperliquid_futures", "hyperliquid", ConnectHyperliquidFutures, "BTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.venue, func(t *testing.T) {
+			t.Parallel()
+			srv := serveMock(t, tt.venue, tt.protocol)
+			f := runConnector(t, tt.connect, "BTCUSDT")
+			b := mockvenue.Book{
+				Symbol: tt.native,
+				Bids:   []mockvenue.Level{{Price: 100, Size: 1}},
+				Asks:   []mockvenue.Level{{Price: 101, Size: 1}},
+			}
+			publishUntil(t, srv, b, nil, f)
+
+			reconnects := Stats(tt.venue).Snapshot().Reconnects
+			srv.DropClients()
+			deadline := time.Now().Add(10 * time.Second)
+			for srv.Clients() == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("connector did not reconnect")
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			for len(f.books) > 0 {
+				<-f.books
+			}
+			publishUntil(t, srv, b, nil, f)
+			if got := Stats(tt.venue).Snapshot().Reconnects; got <= reconnects {
+				t.Errorf("reconnects = %d, want more than %d", got, reconnects)
+			}
+		})
+	}
+}
+
+// TestRejectedSubscriptions has the venue refuse one of two symbols and
+// expects the connector to keep the other, retry the refused channel until
+// it disables it with the venue's error code, and subscribe to it again on
+// a new connection once the venue lists it.
+func TestRejectedSubscriptions(t *testing.T) {
+	delay := subscribeRetryDelay
+	subscribeRetryDelay = 20 * time.Millisecond
+	SetMarkets("lighter_futures", []Market{
+		{Native: "TON", ID: "7", Base: "TON", Quote: "USD", Status: MarketTrading},
+		{Native: "ETH", ID: "8", Base: "ETH", Quote: "USD", Status: MarketTrading},
+	})
+	SetMarkets("vest_futures", []Market{
+		{Native: "TON-PERP", Base: "TON", Quote: "USD", Status: MarketTrading},
+		{Native: "ETH-PERP", Base: "ETH", Quote: "USD", Status: MarketTrading},
+	})
+	// Cleanups run once the parallel subtests are done.
+	t.Cleanup(func() {
+		subscribeRetryDelay = delay
+		SetMarkets("lighter_futures", nil)
+		SetMarkets("vest_futures", nil)
+	})
+
+	tests := []struct {
+		venue    string
+		protocol string
+		connect  connectFunc
+		symbols  []string
+		unlisted string
+		listed   string // a channel the venue accepts
+		refused  string // the channel it refuses
+		code     string
+	}{
+		{"bybit_futures", "bybit", ConnectBybitFutures, []string{"BTCUSDT", "ETHUSDT"}, "ETHUSDT", "orderbook.1.BTCUSDT", "orderbook.1.ETHUSDT", ""},
+		{"okx_futures", "okx", ConnectOKXFutures, []string{"BTCUSDT", "ETHUSDT"}, "ETH-USDT-SWAP", "books5:BTC-USDT-SWAP", "books5:ETH-USDT-SWAP", "60018"},
+		{"gate_futures", "gate", ConnectGateFutures, []string{"BTCUSDT", "ETHUSDT"}, "ETH_USDT", "futures.book_ticker:BTC_USDT", "futures.book_ticker:ETH_USDT", "2"},
+		{"hyperliquid_futures", "hyperliquid", ConnectHyperliquidFutures, []string{"BTCUSDT", "ETHUSDT"}, "ETH", "l2Book:BTC", "l2Book:ETH", ""},
+		{"kraken_futures", "kraken", ConnectKrakenFutures, []string{"BTCUSDT", "ETHUSDT"}, "PF_ETHUSD", "book:PF_XBTUSD", "book:PF_ETHUSD", ""},
+		{"lighter_futures", "lighter", ConnectLighterFutures, []string{"TONUSDT", "ETHUSDT"}, "8", "order_book/7", "order_book/8", "30005"},
+		{"vest_futures", "vest", ConnectVestFutures, []string{"TONUSDT", "ETHUSDT"}, "ETH-PERP", "TON-PERP@depth", "ETH-PERP@depth", "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.venue, func(t *testing.T) {
+			t.Parallel()
+			srv := serveMock(t, tt.venue, tt.protocol)
+			srv.Unlist(tt.unlisted)
+			runConnector(t, tt.connect, tt.symbols...)
+
+			waitSubscription(t, tt.venue, tt.refused, SubscriptionDisabled)
+			if sub := subscription(tt.venue, tt.refused); sub.Code != tt.code || sub.Error == "" {
+				t.Errorf("%s = %+v, want the venue's error with code %q", tt.refused, sub, tt.code)
+			}
+			waitSubscription(t, tt.venue, tt.listed, SubscriptionActive)
+
+			srv.List(tt.unlisted)
+			srv.DropClients()
+			waitSubscription(t, tt.venue, tt.refused, SubscriptionActive)
+		})
+	}
+}
+
+func subscription(venue, channel string) Subscription {
+	for _, sub := range Stats(venue).Snapshot().Subscriptions {
+		if sub.Channel == channel {
+			return sub
+		}
+	}
+	return Subscription{}
+}
+
+func waitSubscription(t *testing.T, venue, channel, state string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for subscription(venue, channel).State != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is %+v, want %s (all: %+v)", channel, subscription(venue, channel), state, Stats(venue).Snapshot().Subscriptions)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestShardedConnector spreads three symbols over three connections, keeps
+// the one left after a change on its connection while closing the others,
+// and opens a connection under a freed shard number for a new symbol.
+func TestShardedConnector(t *testing.T) {
+	size := shardSizes["bybit_futures"]
+	shardSizes["bybit_futures"] = 1
+	defer func() { shardSizes["bybit_futures"] = size }()
+
+	srv := serveMock(t, "bybit_futures", "bybit")
+	set := NewSymbolSet([]string{"BTCUSDT", "ETHUSDT", "SOLUSDT"})
+	f := runConnectorSet(t, ConnectBybitFutures, set)
+
+	waitShards := func(want ...string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			var got []string
+			connected := true
+			for _, sh := range Stats("bybit_futures").Snapshot().Shards {
+				got = append(got, fmt.Sprintf("%d:%s", sh.Shard, strings.Join(sh.Symbols, ",")))
+				connected = connected && sh.Connected
+			}
+			if connected && strings.Join(got, " ") == strings.Join(want, " ") && srv.Clients() == len(want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("shards %v with %d clients, want %v all connected", got, srv.Clients(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	sol := mockvenue.Book{
+		Symbol: "SOLUSDT",
+		Bids:   []mockvenue.Level{{Price: 100, Size: 1}},
+		Asks:   []mockvenue.Level{{Price: 101, Size: 1}},
+	}
+
+	waitShards("1:BTCUSDT", "2:ETHUSDT", "3:SOLUSDT")
+	publishUntil(t, srv, sol, nil, f)
+
+	set.Set([]string{"SOLUSDT"})
+	waitShards("3:SOLUSDT")
+
+	set.Set([]string{"SOLUSDT", "XRPUSDT"})
+	waitShards("1:XRPUSDT", "3:SOLUSDT")
+	for len(f.books) > 0 {
+		<-f.books
+	}
+	xrp := sol
+	xrp.Symbol = "XRPUSDT"
+	if ob, ok := publishUntil(t, srv, xrp, nil, f).(OrderbookData); !ok || ob.Symbol != "XRPUSDT" {
+		t.Errorf("got %+v from the new shard, want an XRPUSDT book", ob)
+	}
+}
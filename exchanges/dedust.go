@@ -1,7 +1,9 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -23,32 +25,58 @@ type DeDustPool struct {
 	Reserves []string      `json:"reserves"`
 }
 
-func ConnectDeDust(priceChan chan<- PriceData) {
+// ConnectDeDust polls DeDust pools for the TON/USDT price until ctx is done.
+func ConnectDeDust(ctx context.Context, priceChan chan<- PriceData) {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
 	usdtAddr := "EQCxE6mUtQJKFnGfaROTKOt1lZbDiiX1kCixRv7Nw2Id_sDs"
 
-	ticker := time.NewTicker(2 * time.Second) // Poll frequently (2s)
+	stats := Stats("DeDust")
+
+	endpoints := EndpointsFor("DeDust")
+	ticker := time.NewTicker(endpoints.PollInterval)
 	defer ticker.Stop()
 
     log.Println("DeDust: Connected and polling for deepest liquidity pool...")
 
-	for range ticker.C {
-		resp, err := client.Get(DeDustPoolsURL)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoints.REST, nil)
+		if err != nil {
+			log.Printf("DeDust: Error building request: %v", err)
+			return
+		}
+		resp, err := client.Do(req)
 		if err != nil {
 			log.Printf("DeDust: Error fetching pools: %v", err)
+			stats.Disconnected()
 			continue
 		}
 
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("DeDust: Error reading pools: %v", err)
+			stats.Disconnected()
+			continue
+		}
+		received("DeDust", body, time.Now().UnixMilli())
+
 		var pools []DeDustPool
-		if err := json.NewDecoder(resp.Body).Decode(&pools); err != nil {
+		if err := json.Unmarshal(body, &pools); err != nil {
 			log.Printf("DeDust: Error decoding pools: %v", err)
-			resp.Body.Close()
+			stats.ParseError()
 			continue
 		}
-		resp.Body.Close()
+		stats.Connected()
+		stats.Message("pools")
 
         var bestPrice float64
         var maxLiquidity float64
@@ -92,10 +120,10 @@ func ConnectDeDust(priceChan chan<- PriceData) {
 
         if found {
             priceChan <- PriceData{
-                Symbol:    "TONUSDT",
-                Source:    "DeDust",
-                Price:     bestPrice,
-                Timestamp: time.Now().UnixMilli(),
+                Symbol:     "TONUSDT",
+                Source:     "DeDust",
+                Price:      bestPrice,
+                ReceivedAt: time.Now().UnixMilli(),
             }
             // Optional: verbose log to prove updates
             // log.Printf("DeDust: Deepest Pool Price: %.4f (TVL: %.2f USDT)", bestPrice, maxLiquidity)
@@ -0,0 +1,270 @@
+package exchanges
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDiscoveryInterval is how often market metadata is fetched again.
+const DefaultDiscoveryInterval = time.Hour
+
+// marketFetcher downloads and parses one venue's market list from its REST
+// base URL.
+type marketFetcher func(ctx context.Context, base string) ([]Market, error)
+
+var marketFetchers = map[string]marketFetcher{
+	"binance_futures":     getMarkets("/fapi/v1/exchangeInfo", parseBinanceExchangeInfo),
+	"binance_spot":        getMarkets("/api/v3/exchangeInfo", parseBinanceExchangeInfo),
+	"binance_inverse":     getMarkets("/dapi/v1/exchangeInfo", parseBinanceExchangeInfo),
+	"bybit_futures":       fetchBybitInstruments("linear"),
+	"bybit_spot":          fetchBybitInstruments("spot"),
+	"bybit_inverse":       fetchBybitInstruments("inverse"),
+	"okx_futures":         getMarkets("/api/v5/public/instruments?instType=SWAP", parseOKXInstruments),
+	"okx_inverse":         getMarkets("/api/v5/public/instruments?instType=SWAP", parseOKXInverseInstruments),
+	"gate_futures":        getMarkets("/futures/usdt/contracts", parseGateContracts),
+	"hyperliquid_futures": postMarkets("/info", `{"type":"meta"}`, parseHyperliquidMeta),
+	"kraken_futures":      getMarkets("/derivatives/api/v3/instruments", parseKrakenInstruments),
+	"kraken_inverse":      getMarkets("/derivatives/api/v3/instruments", parseKrakenInverseInstruments),
+	"paradex_futures":     getMarkets("/markets", parseParadexMarkets),
+	"lighter_futures":     getMarkets("/api/v1/orderBooks", parseLighterOrderBooks),
+	"extended_futures":    getMarkets("/info/markets", parseExtendedMarkets),
+}
+
+var discoveryClient = &http.Client{Timeout: 15 * time.Second}
+
+func getMarkets(path string, parse func([]byte) ([]Market, error)) marketFetcher {
+	return func(ctx context.Context, base string) ([]Market, error) {
+		body, err := fetchMetadata(ctx, http.MethodGet, base+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		return parse(body)
+	}
+}
+
+func postMarkets(path, request string, parse func([]byte) ([]Market, error)) marketFetcher {
+	return func(ctx context.Context, base string) ([]Market, error) {
+		body, err := fetchMetadata(ctx, http.MethodPost, base+path, []byte(request))
+		if err != nil {
+			return nil, err
+		}
+		return parse(body)
+	}
+}
+
+func fetchMetadata(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "crypto-futures-arbitrage-scanner/1.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := discoveryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+}
+
+// decimalStep is the step of a size or price with places decimals.
+func decimalStep(places int) float64 {
+	return math.Pow10(-places)
+}
+
+// marketStatus is MarketTrading when trading, otherwise the venue's own
+// word for the state.
+func marketStatus(trading bool, raw string) string {
+	if trading {
+		return MarketTrading
+	}
+	if raw == "" {
+		return "halted"
+	}
+	return strings.ToLower(raw)
+}
+
+// DiscoveryVenues returns the venues whose markets can be discovered,
+// sorted.
+func DiscoveryVenues() []string {
+	out := make([]string, 0, len(marketFetchers))
+	for venue := range marketFetchers {
+		out = append(out, venue)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RefreshMarkets fetches venue's market list from its REST endpoint and
+// puts it in the instrument registry. On error the registry keeps what it
+// had.
+func RefreshMarkets(ctx context.Context, venue string) error {
+	fetch, ok := marketFetchers[venue]
+	if !ok {
+		return fmt.Errorf("%s: market discovery not supported", venue)
+	}
+	base := strings.TrimRight(EndpointsFor(venue).REST, "/")
+	if base == "" {
+		return fmt.Errorf("%s: no REST endpoint", venue)
+	}
+
+	markets, err := fetch(ctx, base)
+	if err != nil {
+		return fmt.Errorf("%s: %w", venue, err)
+	}
+	if len(markets) == 0 {
+		return fmt.Errorf("%s: no markets in response", venue)
+	}
+	SetMarkets(venue, markets)
+	return nil
+}
+
+// Discovery keeps the instrument registry filled from the venues' market
+// metadata. What it finds is cached in a file, so a restart without
+// network access still knows what every venue trades.
+type Discovery struct {
+	// CachePath is the cache file; empty disables the cache.
+	CachePath string
+	// Interval is how often Run refreshes; zero refreshes once.
+	Interval time.Duration
+	// Venues limits discovery to these venues; nil means all of them.
+	Venues []string
+}
+
+// NewDiscovery returns a Discovery for every venue that supports it.
+func NewDiscovery(cachePath string, interval time.Duration) *Discovery {
+	return &Discovery{CachePath: cachePath, Interval: interval}
+}
+
+type marketCache struct {
+	Saved   time.Time           `json:"saved"`
+	Markets map[string][]Market `json:"markets"`
+}
+
+func (d *Discovery) venues() []string {
+	if d.Venues != nil {
+		return d.Venues
+	}
+	return DiscoveryVenues()
+}
+
+// LoadCache fills the registry from the cache file for venues whose
+// markets aren't known yet. A missing file is not an error.
+func (d *Discovery) LoadCache() error {
+	if d.CachePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.CachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var cache marketCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return fmt.Errorf("%s: %v", d.CachePath, err)
+	}
+	for _, venue := range d.venues() {
+		if markets := cache.Markets[venue]; len(markets) > 0 && Markets(venue) == nil {
+			SetMarkets(venue, markets)
+		}
+	}
+	log.Printf("Markets: loaded cache from %s (saved %s)", d.CachePath, cache.Saved.Format(time.RFC3339))
+	return nil
+}
+
+// Refresh fetches every venue's markets at once and saves the cache.
+// Venues that fail keep what they had and their errors are returned
+// together.
+func (d *Discovery) Refresh(ctx context.Context) error {
+	venues := d.venues()
+	errs := make([]error, len(venues))
+
+	var wg sync.WaitGroup
+	for i, venue := range venues {
+		wg.Add(1)
+		go func(i int, venue string) {
+			defer wg.Done()
+			if errs[i] = RefreshMarkets(ctx, venue); errs[i] == nil {
+				log.Printf("Markets: %s lists %d instruments", venue, len(Markets(venue)))
+			}
+		}(i, venue)
+	}
+	wg.Wait()
+
+	if err := d.saveCache(); err != nil {
+		errs = append(errs, fmt.Errorf("markets cache: %v", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Run refreshes every Interval until ctx is done, logging failures.
+func (d *Discovery) Run(ctx context.Context) {
+	if d.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				log.Printf("Market discovery: %v", err)
+			}
+		}
+	}
+}
+
+func (d *Discovery) saveCache() error {
+	if d.CachePath == "" {
+		return nil
+	}
+	cache := marketCache{Saved: time.Now().UTC(), Markets: make(map[string][]Market)}
+	for _, venue := range d.venues() {
+		if markets := Markets(venue); len(markets) > 0 {
+			cache.Markets[venue] = markets
+		}
+	}
+	if len(cache.Markets) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(d.CachePath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := d.CachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.CachePath)
+}
@@ -0,0 +1,249 @@
+package exchanges
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarketMetadata(t *testing.T) {
+	bybit := func(body []byte) ([]Market, error) {
+		markets, _, err := parseBybitInstruments(body)
+		return markets, err
+	}
+
+	cases := []struct {
+		name    string
+		parse   func([]byte) ([]Market, error)
+		payload string
+		want    []Market
+	}{
+		{
+			name:  "binance",
+			parse: parseBinanceExchangeInfo,
+			payload: `{"symbols":[
+				{"symbol":"BTCUSDT","status":"TRADING","contractType":"PERPETUAL","baseAsset":"BTC","quoteAsset":"USDT",
+				 "filters":[{"filterType":"PRICE_FILTER","tickSize":"0.10"},{"filterType":"LOT_SIZE","stepSize":"0.001"}]},
+				{"symbol":"BTCUSDT_250926","status":"TRADING","contractType":"CURRENT_QUARTER","baseAsset":"BTC","quoteAsset":"USDT"},
+				{"symbol":"ETHUSDT","status":"SETTLING","contractType":"PERPETUAL","baseAsset":"ETH","quoteAsset":"USDT"}]}`,
+			want: []Market{
+				{Native: "BTCUSDT", Base: "BTC", Quote: "USDT", TickSize: 0.1, LotSize: 0.001, Status: MarketTrading},
+				{Native: "ETHUSDT", Base: "ETH", Quote: "USDT", Status: "settling"},
+			},
+		},
+		{
+			name:  "binance coin-m",
+			parse: parseBinanceExchangeInfo,
+			payload: `{"symbols":[
+				{"symbol":"BTCUSD_PERP","contractStatus":"TRADING","contractType":"PERPETUAL","contractSize":100,"baseAsset":"BTC","quoteAsset":"USD","marginAsset":"BTC"},
+				{"symbol":"BTCUSD_250926","contractStatus":"TRADING","contractType":"CURRENT_QUARTER","contractSize":100,"baseAsset":"BTC","quoteAsset":"USD","marginAsset":"BTC"}]}`,
+			want: []Market{
+				{Native: "BTCUSD_PERP", Base: "BTC", Quote: "USD", Kind: Inverse, Multiplier: 100, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "bybit",
+			parse: bybit,
+			payload: `{"retCode":0,"result":{"list":[
+				{"symbol":"1000PEPEUSDT","contractType":"LinearPerpetual","status":"Trading","baseCoin":"1000PEPE","quoteCoin":"USDT",
+				 "priceFilter":{"tickSize":"0.0000001"},"lotSizeFilter":{"qtyStep":"100"}},
+				{"symbol":"BTC-26SEP25","contractType":"LinearFutures","status":"Trading","baseCoin":"BTC","quoteCoin":"USDC"}],
+				"nextPageCursor":""}}`,
+			want: []Market{
+				{Native: "1000PEPEUSDT", Base: "1000PEPE", Quote: "USDT", TickSize: 0.0000001, LotSize: 100, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "bybit inverse",
+			parse: bybit,
+			payload: `{"retCode":0,"result":{"list":[
+				{"symbol":"BTCUSD","contractType":"InversePerpetual","status":"Trading","baseCoin":"BTC","quoteCoin":"USD","priceFilter":{"tickSize":"0.5"},"lotSizeFilter":{"qtyStep":"1"}},
+				{"symbol":"BTCUSDZ25","contractType":"InverseFutures","status":"Trading","baseCoin":"BTC","quoteCoin":"USD"}],
+				"nextPageCursor":""}}`,
+			want: []Market{
+				{Native: "BTCUSD", Base: "BTC", Quote: "USD", Kind: Inverse, TickSize: 0.5, LotSize: 1, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "okx inverse",
+			parse: parseOKXInverseInstruments,
+			payload: `{"code":"0","data":[
+				{"instId":"BTC-USDT-SWAP","ctType":"linear","ctVal":"0.01","uly":"BTC-USDT","tickSz":"0.1","lotSz":"0.01","state":"live"},
+				{"instId":"BTC-USD-SWAP","ctType":"inverse","ctVal":"100","uly":"BTC-USD","tickSz":"0.1","lotSz":"1","state":"live"}]}`,
+			want: []Market{
+				{Native: "BTC-USD-SWAP", Base: "BTC", Quote: "USD", TickSize: 0.1, LotSize: 1, Multiplier: 100, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "okx",
+			parse: parseOKXInstruments,
+			payload: `{"code":"0","data":[
+				{"instId":"BTC-USDT-SWAP","ctType":"linear","ctVal":"0.01","uly":"BTC-USDT","tickSz":"0.1","lotSz":"0.01","state":"live"},
+				{"instId":"BTC-USD-SWAP","ctType":"inverse","ctVal":"100","uly":"BTC-USD","tickSz":"0.1","lotSz":"1","state":"live"}]}`,
+			want: []Market{
+				{Native: "BTC-USDT-SWAP", Base: "BTC", Quote: "USDT", TickSize: 0.1, LotSize: 0.01, Multiplier: 0.01, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "gate",
+			parse: parseGateContracts,
+			payload: `[{"name":"BTC_USDT","quanto_multiplier":"0.0001","order_price_round":"0.1","order_size_min":1,"in_delisting":false},
+				{"name":"LUNA_USDT","quanto_multiplier":"1","order_price_round":"0.0001","order_size_min":1,"in_delisting":true}]`,
+			want: []Market{
+				{Native: "BTC_USDT", Base: "BTC", Quote: "USDT", TickSize: 0.1, LotSize: 1, Multiplier: 0.0001, Status: MarketTrading},
+				{Native: "LUNA_USDT", Base: "LUNA", Quote: "USDT", TickSize: 0.0001, LotSize: 1, Multiplier: 1, Status: "delisting"},
+			},
+		},
+		{
+			name:    "hyperliquid",
+			parse:   parseHyperliquidMeta,
+			payload: `{"universe":[{"name":"BTC","szDecimals":5},{"name":"kPEPE","szDecimals":0},{"name":"OLD","szDecimals":1,"isDelisted":true}]}`,
+			want: []Market{
+				{Native: "BTC", Base: "BTC", Quote: "USD", LotSize: 0.00001, Status: MarketTrading},
+				{Native: "kPEPE", Base: "kPEPE", Quote: "USD", LotSize: 1, Status: MarketTrading},
+				{Native: "OLD", Base: "OLD", Quote: "USD", LotSize: 0.1, Status: "delisted"},
+			},
+		},
+		{
+			name:  "kraken",
+			parse: parseKrakenInstruments,
+			payload: `{"result":"success","instruments":[
+				{"symbol":"PF_XBTUSD","type":"flexible_futures","tradeable":true,"tickSize":1,"contractSize":1,"contractValueTradePrecision":4},
+				{"symbol":"PI_XBTUSD","type":"futures_inverse","tradeable":true,"tickSize":0.5,"contractSize":1}]}`,
+			want: []Market{
+				{Native: "PF_XBTUSD", Base: "XBT", Quote: "USD", TickSize: 1, LotSize: 0.0001, Multiplier: 1, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "kraken inverse",
+			parse: parseKrakenInverseInstruments,
+			payload: `{"result":"success","instruments":[
+				{"symbol":"PF_XBTUSD","type":"flexible_futures","tradeable":true,"tickSize":1,"contractSize":1,"contractValueTradePrecision":4},
+				{"symbol":"PI_XBTUSD","type":"futures_inverse","tradeable":true,"tickSize":0.5,"contractSize":1},
+				{"symbol":"FI_XBTUSD_251226","type":"futures_inverse","tradeable":true,"tickSize":0.5,"contractSize":1}]}`,
+			want: []Market{
+				{Native: "PI_XBTUSD", Base: "XBT", Quote: "USD", TickSize: 0.5, LotSize: 1, Multiplier: 1, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "paradex",
+			parse: parseParadexMarkets,
+			payload: `{"results":[
+				{"symbol":"ETH-USD-PERP","base_currency":"ETH","quote_currency":"USD","asset_kind":"PERP","price_tick_size":"0.01","order_size_increment":"0.001"},
+				{"symbol":"ETH-USD-3000-C","base_currency":"ETH","quote_currency":"USD","asset_kind":"PERP_OPTION"}]}`,
+			want: []Market{
+				{Native: "ETH-USD-PERP", Base: "ETH", Quote: "USD", TickSize: 0.01, LotSize: 0.001, Status: MarketTrading},
+			},
+		},
+		{
+			name:  "lighter",
+			parse: parseLighterOrderBooks,
+			payload: `{"code":200,"order_books":[
+				{"symbol":"ETH","market_id":0,"market_type":"perp","status":"active","supported_size_decimals":4,"supported_price_decimals":2},
+				{"symbol":"TON","market_id":41,"market_type":"perp","status":"inactive","supported_size_decimals":1,"supported_price_decimals":4},
+				{"symbol":"ETH/USDC","market_id":2048,"market_type":"spot","status":"active"}]}`,
+			want: []Market{
+				{Native: "ETH", ID: "0", Base: "ETH", TickSize: 0.01, LotSize: 0.0001, Status: MarketTrading},
+				{Native: "TON", ID: "41", Base: "TON", TickSize: 0.0001, LotSize: 0.1, Status: "inactive"},
+			},
+		},
+		{
+			name:  "extended",
+			parse: parseExtendedMarkets,
+			payload: `{"status":"OK","data":[
+				{"name":"TON-USD","assetName":"TON","collateralAssetName":"USD","status":"ACTIVE","tradingConfig":{"minPriceChange":"0.0001","minOrderSizeChange":"0.1"}},
+				{"name":"TON-USDT","assetName":"TON","collateralAssetName":"USDT","status":"DELISTED"}]}`,
+			want: []Market{
+				{Native: "TON-USDT", Base: "TON", Quote: "USDT", Status: "delisted"},
+				{Native: "TON-USD", Base: "TON", Quote: "USD", TickSize: 0.0001, LotSize: 0.1, Status: MarketTrading},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.parse([]byte(tc.payload))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetMarketsReplacesRule(t *testing.T) {
+	defer SetMarkets("lighter_futures", nil)
+
+	SetMarkets("lighter_futures", []Market{
+		{Native: "ETH", ID: "0", Base: "ETH", Status: MarketTrading},
+		{Native: "TON", ID: "41", Base: "TON", Status: "inactive"},
+	})
+
+	if got, ok := NativeSymbol("lighter_futures", "ETHUSDT"); !ok || got != "ETH" {
+		t.Fatalf("NativeSymbol(ETHUSDT): got %q, %v", got, ok)
+	}
+	if got, ok := NativeSymbol("lighter_futures", "TONUSDT"); ok {
+		t.Fatalf("NativeSymbol(TONUSDT): inactive market resolved to %q", got)
+	}
+	if got, ok := CanonicalSymbol("lighter_futures", "TON"); !ok || got != "TONUSDT" {
+		t.Fatalf("CanonicalSymbol(TON): got %q, %v", got, ok)
+	}
+	if m, ok := MarketFor("lighter_futures", "ETH-USD"); !ok || m.ID != "0" || m.Multiplier != 1 || m.Kind != Perpetual {
+		t.Fatalf("MarketFor(ETH-USD): got %+v, %v", m, ok)
+	}
+
+	SetMarkets("lighter_futures", nil)
+	if got, ok := NativeSymbol("lighter_futures", "TONUSDT"); !ok || got != "TON" {
+		t.Fatalf("after reset: got %q, %v", got, ok)
+	}
+	if _, ok := NativeSymbol("lighter_futures", "ETHUSDT"); ok {
+		t.Fatalf("after reset: ETHUSDT should not be listed")
+	}
+}
+
+func TestDiscoveryRefreshAndCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/futures/usdt/contracts" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"name":"BTC_USDT","quanto_multiplier":"0.0001","order_price_round":"0.1","order_size_min":1},
+			{"name":"SOL_USDT","quanto_multiplier":"1","order_price_round":"0.01","order_size_min":1,"in_delisting":true}]`))
+	}))
+	defer srv.Close()
+
+	SetEndpoints("gate_futures", Endpoints{REST: srv.URL})
+	defer SetEndpoints("gate_futures", Endpoints{})
+	defer SetMarkets("gate_futures", nil)
+
+	d := NewDiscovery(filepath.Join(t.TempDir(), "cache", "markets.json"), 0)
+	d.Venues = []string{"gate_futures"}
+	if err := d.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := SupportedSymbols("gate_futures", []string{"BTCUSDT", "SOLUSDT", "ETHUSDT"}); !reflect.DeepEqual(got, []string{"BTCUSDT"}) {
+		t.Fatalf("after refresh: got %v", got)
+	}
+
+	// Offline: the cache brings the markets back.
+	SetMarkets("gate_futures", nil)
+	SetEndpoints("gate_futures", Endpoints{REST: "http://127.0.0.1:1"})
+	if err := d.Refresh(context.Background()); err == nil {
+		t.Fatalf("Refresh against a dead endpoint succeeded")
+	}
+	if err := d.LoadCache(); err != nil {
+		t.Fatalf("LoadCache: %v", err)
+	}
+	m, ok := MarketFor("gate_futures", "BTCUSDT")
+	if !ok || m.Native != "BTC_USDT" || m.Multiplier != 0.0001 || m.TickSize != 0.1 {
+		t.Fatalf("from cache: got %+v, %v", m, ok)
+	}
+	if _, ok := NativeSymbol("gate_futures", "ETHUSDT"); ok {
+		t.Fatalf("from cache: ETHUSDT should not be listed")
+	}
+}
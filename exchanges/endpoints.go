@@ -0,0 +1,100 @@
+package exchanges
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Endpoints are the URLs a connector talks to. WS is its streaming endpoint
+// and REST its HTTP base URL, which market discovery reads too (or, for
+// polling venues, the URL it polls); PollInterval is only used by venues
+// without a stream. Connectors look theirs up with EndpointsFor when they
+// start.
+type Endpoints struct {
+	WS           string
+	REST         string
+	PollInterval time.Duration
+}
+
+var defaultEndpoints = map[string]Endpoints{
+	"binance_futures":     {WS: "wss://fstream.binance.com/stream", REST: "https://fapi.binance.com"},
+	"binance_spot":        {WS: "wss://stream.binance.com:9443/stream", REST: "https://api.binance.com"},
+	"binance_inverse":     {WS: "wss://dstream.binance.com/stream", REST: "https://dapi.binance.com"},
+	"bybit_futures":       {WS: "wss://stream.bybit.com/v5/public/linear", REST: "https://api.bybit.com"},
+	"bybit_spot":          {WS: "wss://stream.bybit.com/v5/public/spot", REST: "https://api.bybit.com"},
+	"bybit_inverse":       {WS: "wss://stream.bybit.com/v5/public/inverse", REST: "https://api.bybit.com"},
+	"gate_futures":        {WS: "wss://fx-ws.gateio.ws/v4/ws/usdt", REST: "https://api.gateio.ws/api/v4"},
+	"hyperliquid_futures": {WS: "wss://api.hyperliquid.xyz/ws", REST: "https://api.hyperliquid.xyz"},
+	"kraken_futures":      {WS: "wss://futures.kraken.com/ws/v1", REST: "https://futures.kraken.com"},
+	"kraken_inverse":      {WS: "wss://futures.kraken.com/ws/v1", REST: "https://futures.kraken.com"},
+	"okx_futures":         {WS: "wss://ws.okx.com:8443/ws/v5/public", REST: "https://www.okx.com"},
+	"okx_inverse":         {WS: "wss://ws.okx.com:8443/ws/v5/public", REST: "https://www.okx.com"},
+	"paradex_futures":     {WS: "wss://ws.api.prod.paradex.trade/v1", REST: "https://api.prod.paradex.trade/v1"},
+	"vest_futures":        {WS: "wss://ws-prod.hz.vestmarkets.com/ws-api?version=1.0"},
+	"extended_futures": {
+		WS:   "wss://api.starknet.extended.exchange/stream.extended.exchange/v1",
+		REST: "https://api.starknet.extended.exchange/api/v1",
+	},
+	"lighter_futures": {
+		WS:   "wss://mainnet.zklighter.elliot.ai/stream",
+		REST: "https://mainnet.zklighter.elliot.ai",
+	},
+	"variational_perps": {REST: "https://omni-client-api.prod.ap-northeast-1.variational.io/metadata/stats", PollInterval: 2 * time.Second},
+	"pyth":              {REST: "https://hermes.pyth.network/v2/updates/price/stream"},
+	"DeDust":            {REST: DeDustPoolsURL, PollInterval: 2 * time.Second},
+}
+
+var (
+	endpointsMutex sync.RWMutex
+	endpointsBySrc = make(map[string]Endpoints)
+)
+
+// EndpointsFor returns the endpoints for source: the defaults with any
+// fields set through SetEndpoints replacing them.
+func EndpointsFor(source string) Endpoints {
+	e := defaultEndpoints[source]
+
+	endpointsMutex.RLock()
+	override, ok := endpointsBySrc[source]
+	endpointsMutex.RUnlock()
+	if !ok {
+		return e
+	}
+
+	if override.WS != "" {
+		e.WS = override.WS
+	}
+	if override.REST != "" {
+		e.REST = override.REST
+	}
+	if override.PollInterval > 0 {
+		e.PollInterval = override.PollInterval
+	}
+	return e
+}
+
+// SetEndpoints overrides the non-zero fields of e for source. It takes
+// effect the next time the source's connector starts.
+func SetEndpoints(source string, e Endpoints) {
+	endpointsMutex.Lock()
+	endpointsBySrc[source] = e
+	endpointsMutex.Unlock()
+}
+
+// DefaultEndpoints returns the built-in endpoints for source and whether
+// the source is known.
+func DefaultEndpoints(source string) (Endpoints, bool) {
+	e, ok := defaultEndpoints[source]
+	return e, ok
+}
+
+// Sources returns the name of every built-in connector, sorted.
+func Sources() []string {
+	out := make([]string, 0, len(defaultEndpoints))
+	for source := range defaultEndpoints {
+		out = append(out, source)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -1,13 +1,16 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -40,31 +43,17 @@ type extendedMarketsResponse struct {
 		TradingStatus   string          `json:"status"`
 		UIName          string          `json:"uiName"`
 		MarketStats     json.RawMessage `json:"marketStats"`
-		TradingConfig   json.RawMessage `json:"tradingConfig"`
+		TradingConfig   struct {
+			MinPriceChange     string `json:"minPriceChange"`
+			MinOrderSizeChange string `json:"minOrderSizeChange"`
+		} `json:"tradingConfig"`
 	} `json:"data"`
 	Error json.RawMessage `json:"error"`
 }
 
-func convertToExtendedMarket(symbol string) string {
-	if _, ok := normalizeTONSymbol(symbol); ok {
-		// Fallback market identifier if discovery fails.
-		return "TON-USD"
-	}
-	return ""
-}
-
-func convertFromExtendedMarket(market string) string {
-	canon, ok := normalizeTONSymbol(market)
-	if !ok {
-		return ""
-	}
-	return canon
-}
-
 func parseExtendedOrderbookMessage(payload []byte) (symbol string, bestBid float64, bestAsk float64, ts int64, ok bool) {
 	var env extendedOrderbookEnvelope
 	if err := json.Unmarshal(payload, &env); err != nil {
-		log.Printf("Extended parse error: %v", err)
 		return "", 0, 0, 0, false
 	}
 
@@ -73,9 +62,8 @@ func parseExtendedOrderbookMessage(payload []byte) (symbol string, bestBid float
 		market = env.Data.MarketLong
 	}
 
-	stdSymbol := convertFromExtendedMarket(market)
-	if stdSymbol == "" {
-		log.Printf("Extended unknown market: %s or %s", env.Data.Market, env.Data.MarketLong)
+	stdSymbol, ok := CanonicalSymbol("extended_futures", market)
+	if !ok {
 		return "", 0, 0, 0, false
 	}
 
@@ -105,77 +93,43 @@ func parseExtendedOrderbookMessage(payload []byte) (symbol string, bestBid float
 		return "", 0, 0, 0, false
 	}
 
-	if env.TS > 0 {
-		ts = env.TS
-	} else {
-		ts = time.Now().UnixMilli()
-	}
-
-	return stdSymbol, bestBid, bestAsk, ts, true
+	return stdSymbol, bestBid, bestAsk, env.TS, true
 }
 
 func extendedUserAgent() string {
 	return "crypto-futures-arbitrage-scanner/1.0"
 }
 
-func fetchExtendedMarkets(restBaseURL string) (map[string]string, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	url := strings.TrimRight(restBaseURL, "/") + "/info/markets"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", extendedUserAgent())
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
+// parseExtendedMarkets reads /info/markets. A base can trade against more
+// than one collateral; USDT is preferred over USDC over USD, so those come
+// first.
+func parseExtendedMarkets(body []byte) ([]Market, error) {
 	var decoded extendedMarketsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+	if err := json.Unmarshal(body, &decoded); err != nil {
 		return nil, err
 	}
 
-	m := make(map[string]string)
-	bestMarket := ""
-	bestRank := -1
+	rank := map[string]int{"USDT": 0, "USDC": 1, "USD": 2}
+	markets := make([]Market, 0, len(decoded.Data))
 	for _, row := range decoded.Data {
-		asset := strings.ToUpper(row.AssetName)
 		collateral := strings.ToUpper(row.CollateralAsset)
-		if asset != "TON" {
-			continue
-		}
-
-		rank := -1
-		switch collateral {
-		case "USDT":
-			rank = 30
-		case "USDC":
-			rank = 20
-		case "USD":
-			rank = 10
-		}
-		if rank < 0 {
+		if _, ok := rank[collateral]; !ok {
 			continue
 		}
-
-		if rank > bestRank {
-			bestRank = rank
-			bestMarket = row.Name
-		}
-	}
-
-	if bestMarket != "" {
-		m[tonCanonicalSymbol] = bestMarket
-	}
-
-	return m, nil
+		markets = append(markets, Market{
+			Native:   row.Name,
+			Base:     row.AssetName,
+			Quote:    collateral,
+			TickSize: parseSize(row.TradingConfig.MinPriceChange),
+			LotSize:  parseSize(row.TradingConfig.MinOrderSizeChange),
+			Status:   marketStatus(strings.ToUpper(row.TradingStatus) == "ACTIVE", row.TradingStatus),
+		})
+	}
+	sort.SliceStable(markets, func(i, j int) bool { return rank[markets[i].Quote] < rank[markets[j].Quote] })
+	return markets, nil
 }
 
-func connectExtendedMarketOrderbook(stdSymbol, market, wsBaseURL string, orderbookChan chan<- OrderbookData) {
+func connectExtendedMarketOrderbook(ctx context.Context, stdSymbol, market, wsBaseURL string, orderbookChan chan<- OrderbookData) {
 	headers := http.Header{}
 	headers.Set("User-Agent", extendedUserAgent())
 
@@ -183,12 +137,16 @@ func connectExtendedMarketOrderbook(stdSymbol, market, wsBaseURL string, orderbo
 
 	backoff := 2 * time.Second
 	maxBackoff := 60 * time.Second
+	stats := Stats("extended_futures")
 
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, headers)
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, headers)
 		if err != nil {
 			log.Printf("Extended connection error (%s/%s): %v (retrying in %s)", stdSymbol, market, err, backoff)
-			time.Sleep(backoff)
+			stats.Error(err)
+			if !stats.Backoff(ctx, backoff) {
+				return
+			}
 			if backoff < maxBackoff {
 				backoff *= 2
 				if backoff > maxBackoff {
@@ -200,18 +158,27 @@ func connectExtendedMarketOrderbook(stdSymbol, market, wsBaseURL string, orderbo
 
 		backoff = 2 * time.Second
 		log.Printf("Connected to Extended orderbook stream (%s/%s)", stdSymbol, market)
+		stats.Connected()
+		// The market's stream is named in the URL and accepted with the
+		// handshake.
+		stats.Subscribed("orderbooks/" + market)
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
 
 		for {
-			_, msg, err := conn.ReadMessage()
+			msg, receivedAt, err := readFrame(conn, "extended_futures")
 			if err != nil {
 				log.Printf("Extended read error (%s/%s): %v", stdSymbol, market, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
 			// log.Printf("Extended received: %s", string(msg))
 
+			stats.Message("orderbook")
 			parsedSymbol, bestBid, bestAsk, ts, ok := parseExtendedOrderbookMessage(msg)
 			if !ok {
+				stats.ParseError()
 				continue
 			}
 			if parsedSymbol == "" {
@@ -219,45 +186,47 @@ func connectExtendedMarketOrderbook(stdSymbol, market, wsBaseURL string, orderbo
 			}
 
 			orderbookChan <- OrderbookData{
-				Symbol:    parsedSymbol,
-				Source:    "extended_futures",
-				BestBid:   bestBid,
-				BestAsk:   bestAsk,
-				Timestamp: ts,
+				Symbol:     parsedSymbol,
+				Source:     "extended_futures",
+				BestBid:    bestBid,
+				BestAsk:    bestAsk,
+				Timestamp:  ts,
+				ReceivedAt: receivedAt,
 			}
 		}
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
 
-func ConnectExtendedFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	restBaseURL := "https://api.starknet.extended.exchange/api/v1"
-	wsBaseURL := "wss://api.starknet.extended.exchange/stream.extended.exchange/v1"
+func ConnectExtendedFutures(ctx context.Context, set *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	wsBaseURL := EndpointsFor("extended_futures").WS
 
-	tonSyms := filterTONSymbols(symbols)
-	if len(tonSyms) == 0 {
-		log.Printf("Extended skipped: no TON symbol provided (symbols=%v)", symbols)
-		return
-	}
-
-	marketMap, err := fetchExtendedMarkets(restBaseURL)
-	if err != nil {
-		log.Printf("Extended market discovery error: %v", err)
-		marketMap = make(map[string]string)
+	if Markets("extended_futures") == nil {
+		if err := RefreshMarkets(ctx, "extended_futures"); err != nil {
+			log.Printf("Extended market discovery error: %v", err)
+		}
 	}
 
-	market := marketMap[tonCanonicalSymbol]
-	if market == "" {
-		market = convertToExtendedMarket(tonCanonicalSymbol)
-	}
-	if market == "" {
-		log.Printf("Extended: unable to resolve TON market; sleeping")
-		time.Sleep(30 * time.Second)
+	symbols := set.Symbols()
+	supported := SupportedSymbols("extended_futures", symbols)
+	if len(supported) == 0 {
+		log.Printf("Extended skipped: no listed market for %v", symbols)
 		return
 	}
 
-	go connectExtendedMarketOrderbook(tonCanonicalSymbol, market, wsBaseURL, orderbookChan)
-
-	select {}
+	// Each market has its own stream.
+	var wg sync.WaitGroup
+	for _, symbol := range supported {
+		market, _ := NativeSymbol("extended_futures", symbol)
+		wg.Add(1)
+		go func(symbol, market string) {
+			defer wg.Done()
+			connectExtendedMarketOrderbook(ctx, symbol, market, wsBaseURL, orderbookChan)
+		}(symbol, market)
+	}
+	wg.Wait()
 }
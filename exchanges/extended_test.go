@@ -2,14 +2,14 @@ package exchanges
 
 import "testing"
 
-func TestConvertExtendedMarket(t *testing.T) {
-	if got := convertToExtendedMarket("TONUSDT"); got == "" {
+func TestExtendedMarket(t *testing.T) {
+	if got, _ := NativeSymbol("extended_futures", "TONUSDT"); got == "" {
 		t.Fatalf("expected non-empty market")
 	}
-	if got := convertToExtendedMarket("TON-USD"); got == "" {
+	if got, _ := NativeSymbol("extended_futures", "TON-USD"); got == "" {
 		t.Fatalf("expected non-empty market")
 	}
-	if got := convertToExtendedMarket("BTCUSDT"); got != "" {
+	if got, _ := NativeSymbol("extended_futures", "BTCUSDT"); got != "" {
 		t.Fatalf("expected empty market, got %q", got)
 	}
 
@@ -24,8 +24,8 @@ func TestConvertExtendedMarket(t *testing.T) {
 		{in: "BTC-USD", want: ""},
 	}
 	for _, tc := range fromCases {
-		if got := convertFromExtendedMarket(tc.in); got != tc.want {
-			t.Fatalf("convertFromExtendedMarket(%q): got %q want %q", tc.in, got, tc.want)
+		if got, _ := CanonicalSymbol("extended_futures", tc.in); got != tc.want {
+			t.Fatalf("CanonicalSymbol(extended_futures, %q): got %q want %q", tc.in, got, tc.want)
 		}
 	}
 }
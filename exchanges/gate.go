@@ -1,8 +1,8 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"strconv"
 	"strings"
@@ -23,6 +23,7 @@ type GateFuturesTrade struct {
 
 type GateWebSocketMessage struct {
 	Time    int64  `json:"time"`
+	ID      int64  `json:"id"`
 	Channel string `json:"channel"`
 	Event   string `json:"event"`
 	Result  struct {
@@ -75,55 +76,75 @@ type GateOrderbookMessage struct {
 
 type GateSubscribeMessage struct {
 	Time    int64    `json:"time"`
+	ID      int64    `json:"id,omitempty"`
 	Channel string   `json:"channel"`
 	Event   string   `json:"event"`
 	Payload []string `json:"payload"`
 }
 
-func ConnectGateFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://fx-ws.gateio.ws/v4/ws/usdt"
+func ConnectGateFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, "gate_futures", "Gate.io futures", symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectGateShard(ctx, label, stats, symbols, orderbookChan)
+	})
+}
 
+// connectGateShard streams one shard's book tickers.
+func connectGateShard(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
+		}
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor("gate_futures").WS, nil)
 		if err != nil {
-			log.Printf("Gate.io connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("Connected to Gate.io futures WebSocket")
-
-		// Convert symbols to Gate.io format
-		gateSymbols := make([]string, len(symbols))
-		for i, symbol := range symbols {
-			gateSymbols[i] = convertToGateSymbol(symbol)
-		}
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, channel string) error {
+			return conn.WriteJSON(gateBookTickerMessage("subscribe", []string{strings.TrimPrefix(channel, gateBookTickerChannel+":")}, id))
+		})
 
 		// Subscribe to book ticker for all symbols - this provides best bid/ask
-		bookTickerSubscribeMsg := GateSubscribeMessage{
-			Time:    time.Now().Unix(),
-			Channel: "futures.book_ticker",
-			Event:   "subscribe",
-			Payload: gateSymbols,
-		}
-
-		err = conn.WriteJSON(bookTickerSubscribeMsg)
+		err = gateSubscribe(conn, subs, "subscribe", current)
 		if err != nil {
-			log.Printf("Gate.io book ticker subscription error: %v", err)
+			log.Printf("%s book ticker subscription error: %v", label, err)
+			stats.Error(err)
+			stats.Disconnected()
 			conn.Close()
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		if err != nil {
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if len(removed) > 0 {
+					if err := gateSubscribe(conn, subs, "unsubscribe", removed); err != nil {
+						return err
+					}
+				}
+				if len(added) > 0 {
+					return gateSubscribe(conn, subs, "subscribe", added)
+				}
+				return nil
+			})
+		})
 
 		for {
-			var message json.RawMessage
-			err := conn.ReadJSON(&message)
+			message, receivedAt, err := readFrame(conn, "gate_futures")
 			if err != nil {
-				log.Printf("Gate.io read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
@@ -132,12 +153,18 @@ func ConnectGateFutures(symbols []string, priceChan chan<- PriceData, orderbookC
 			var wsMsg GateWebSocketMessage
 			if err := json.Unmarshal(message, &wsMsg); err == nil {
 				if wsMsg.Error != nil {
-					log.Printf("Gate.io WebSocket error: %d - %s", wsMsg.Error.Code, wsMsg.Error.Message)
+					stats.Message("error")
+					if wsMsg.Event != "subscribe" || !subs.rejectRequest(wsMsg.ID, strconv.Itoa(wsMsg.Error.Code), wsMsg.Error.Message) {
+						log.Printf("Gate.io WebSocket error: %d - %s", wsMsg.Error.Code, wsMsg.Error.Message)
+					}
 					continue
 				}
 
-				// Skip subscription confirmation messages
-				if wsMsg.Event == "subscribe" {
+				if wsMsg.Event == "subscribe" || wsMsg.Event == "unsubscribe" {
+					stats.Message("subscribe")
+					if wsMsg.Event == "subscribe" {
+						subs.acked(wsMsg.ID)
+					}
 					continue
 				}
 			}
@@ -145,105 +172,133 @@ func ConnectGateFutures(symbols []string, priceChan chan<- PriceData, orderbookC
 			// Try to parse as book ticker message
 			var bookTickerMsg GateBookTickerMessage
 			if err := json.Unmarshal(message, &bookTickerMsg); err == nil &&
-				bookTickerMsg.Channel == "futures.book_ticker" &&
+				bookTickerMsg.Channel == gateBookTickerChannel &&
 				bookTickerMsg.Event == "update" {
+				stats.Message("book_ticker")
 
 				// Parse best bid and ask
 				bestBid, err1 := strconv.ParseFloat(bookTickerMsg.Result.BestBid, 64)
 				bestAsk, err2 := strconv.ParseFloat(bookTickerMsg.Result.BestAsk, 64)
 				if err1 != nil || err2 != nil {
 					log.Printf("Gate.io: Error parsing prices - bid: %v, ask: %v", err1, err2)
+					stats.ParseError()
 					continue
 				}
 
 				// Convert Gate.io symbol back to standard format
-				standardSymbol := convertFromGateSymbol(bookTickerMsg.Result.Symbol)
-
-				// Use timestamp from message
-				var timestamp int64
-				if bookTickerMsg.Result.Timestamp > 0 {
-					timestamp = bookTickerMsg.Result.Timestamp
-				} else {
-					timestamp = time.Now().UnixMilli()
+				standardSymbol, ok := CanonicalSymbol("gate_futures", bookTickerMsg.Result.Symbol)
+				if !ok {
+					continue
 				}
 
 				orderbookData := OrderbookData{
-					Symbol:    standardSymbol,
-					Source:    "gate_futures",
-					BestBid:   bestBid,
-					BestAsk:   bestAsk,
-					Timestamp: timestamp,
+					Symbol:     standardSymbol,
+					Source:     "gate_futures",
+					BestBid:    bestBid,
+					BestAsk:    bestAsk,
+					BidSize:    float64(bookTickerMsg.Result.BestBidSize),
+					AskSize:    float64(bookTickerMsg.Result.BestAskSize),
+					Timestamp:  bookTickerMsg.Result.Timestamp,
+					ReceivedAt: receivedAt,
 				}
 
 				orderbookChan <- orderbookData
 				continue
 			}
 
-			// Silently ignore unhandled message types
+			stats.Other(wsMsg.Channel == "futures.pong")
 		}
+		stopFollow()
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
 
-// convertToGateSymbol converts standard symbol format to Gate.io format
-// BTCUSDT -> BTC_USDT (for USDT perpetual futures)
-func convertToGateSymbol(symbol string) string {
-	// Handle common symbols for USDT perpetual futures
-	switch symbol {
-	case "BTCUSDT":
-		return "BTC_USDT"
-	case "ETHUSDT":
-		return "ETH_USDT"
-	case "ADAUSDT":
-		return "ADA_USDT"
-	case "SOLUSDT":
-		return "SOL_USDT"
-	case "DOTUSDT":
-		return "DOT_USDT"
-	case "LINKUSDT":
-		return "LINK_USDT"
-	case "AVAXUSDT":
-		return "AVAX_USDT"
-	case "MATICUSDT":
-		return "MATIC_USDT"
-	case "UNIUSDT":
-		return "UNI_USDT"
-	case "LTCUSDT":
-		return "LTC_USDT"
-	case "BCHUSDT":
-		return "BCH_USDT"
-	case "XRPUSDT":
-		return "XRP_USDT"
-	default:
-		// Generic conversion for USDT perpetual futures
-		if strings.HasSuffix(symbol, "USDT") {
-			base := strings.TrimSuffix(symbol, "USDT")
-			return fmt.Sprintf("%s_USDT", base)
+// gateBookTickerChannel carries best bid and ask for the contracts in its
+// payload.
+const gateBookTickerChannel = "futures.book_ticker"
+
+// gateSubscribe sends event ("subscribe" or "unsubscribe") for the book
+// ticker of symbols, a request per contract so that one the venue rejects
+// doesn't take the others with it. Channels are tracked as
+// "futures.book_ticker:BTC_USDT".
+func gateSubscribe(conn *websocket.Conn, subs *subscriptions, event string, symbols []string) error {
+	for _, symbol := range symbols {
+		// Convert symbols to Gate.io format
+		contract, ok := NativeSymbol("gate_futures", symbol)
+		if !ok {
+			continue
 		}
-		// For other quote currencies, insert underscore before the last part
-		if len(symbol) >= 6 {
-			// Assume last 3-4 characters are quote currency
-			if strings.HasSuffix(symbol, "USDC") {
-				base := strings.TrimSuffix(symbol, "USDC")
-				return fmt.Sprintf("%s_USDC", base)
-			}
-			if strings.HasSuffix(symbol, "USD") {
-				base := strings.TrimSuffix(symbol, "USD")
-				return fmt.Sprintf("%s_USD", base)
-			}
+		channel := gateBookTickerChannel + ":" + contract
+		var id int64
+		if event == "subscribe" {
+			id = subs.request(channel)
+		} else {
+			subs.unsubscribed(channel)
 		}
-		return symbol
+		if err := conn.WriteJSON(gateBookTickerMessage(event, []string{contract}, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gateBookTickerMessage builds event for the book ticker of contracts.
+func gateBookTickerMessage(event string, contracts []string, id int64) GateSubscribeMessage {
+	return GateSubscribeMessage{
+		Time:    time.Now().Unix(),
+		ID:      id,
+		Channel: gateBookTickerChannel,
+		Event:   event,
+		Payload: contracts,
 	}
 }
 
-// convertFromGateSymbol converts Gate.io symbol format back to standard format
-// BTC_USDT -> BTCUSDT
-func convertFromGateSymbol(gateSymbol string) string {
-	// Remove underscore and convert to standard format
-	parts := strings.Split(gateSymbol, "_")
-	if len(parts) == 2 {
-		return parts[0] + parts[1]
+// gateContract is an entry of /futures/usdt/contracts.
+type gateContract struct {
+	Name             string `json:"name"`
+	QuantoMultiplier string `json:"quanto_multiplier"`
+	OrderPriceRound  string `json:"order_price_round"`
+	OrderSizeMin     int64  `json:"order_size_min"`
+	InDelisting      bool   `json:"in_delisting"`
+	Status           string `json:"status"`
+}
+
+// parseGateContracts reads USDT perpetuals. Sizes are in contracts of
+// quanto_multiplier base units each.
+func parseGateContracts(body []byte) ([]Market, error) {
+	var contracts []gateContract
+	if err := json.Unmarshal(body, &contracts); err != nil {
+		return nil, err
+	}
+
+	markets := make([]Market, 0, len(contracts))
+	for _, c := range contracts {
+		base, quote, ok := strings.Cut(c.Name, "_")
+		if !ok {
+			continue
+		}
+		status := c.Status
+		if c.InDelisting {
+			status = "delisting"
+		}
+		lot := float64(c.OrderSizeMin)
+		if lot == 0 {
+			lot = 1
+		}
+		markets = append(markets, Market{
+			Native:     c.Name,
+			Base:       base,
+			Quote:      quote,
+			TickSize:   parseSize(c.OrderPriceRound),
+			LotSize:    lot,
+			Multiplier: parseSize(c.QuantoMultiplier),
+			Status:     marketStatus(status == "" || status == "trading", status),
+		})
 	}
-	return gateSymbol
+	return markets, nil
 }
@@ -1,9 +1,11 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -39,60 +41,63 @@ type HyperliquidL2BookData struct {
 	Time   int64                `json:"time"`
 }
 
-func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://api.hyperliquid.xyz/ws"
+func ConnectHyperliquidFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, "hyperliquid_futures", "Hyperliquid futures", symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectHyperliquidShard(ctx, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
+// connectHyperliquidShard streams one shard's coins.
+func connectHyperliquidShard(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
+		}
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor("hyperliquid_futures").WS, nil)
 		if err != nil {
-			log.Printf("Hyperliquid connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("Connected to Hyperliquid futures WebSocket")
+		log.Printf("Connected to %s WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, channel string) error {
+			feed, coin, _ := strings.Cut(channel, ":")
+			return conn.WriteJSON(hyperliquidRequest("subscribe", feed, coin))
+		})
 
 		// Subscribe to trades and l2Book for each symbol
-		for _, symbol := range symbols {
-			// Convert BTCUSDT to BTC for Hyperliquid
-			coin := symbol[:3] // Extract first 3 characters (BTC from BTCUSDT)
-
-			// Subscribe to trades
-			tradeSubscribeMsg := map[string]interface{}{
-				"method": "subscribe",
-				"subscription": map[string]interface{}{
-					"type": "trades",
-					"coin": coin,
-				},
-			}
-
-			err = conn.WriteJSON(tradeSubscribeMsg)
-			if err != nil {
-				log.Printf("Hyperliquid trade subscription error for %s: %v", coin, err)
-				continue
-			}
-
-			// Subscribe to l2Book (orderbook)
-			l2BookSubscribeMsg := map[string]interface{}{
-				"method": "subscribe",
-				"subscription": map[string]interface{}{
-					"type": "l2Book",
-					"coin": coin,
-				},
-			}
-
-			err = conn.WriteJSON(l2BookSubscribeMsg)
-			if err != nil {
-				log.Printf("Hyperliquid l2Book subscription error for %s: %v", coin, err)
-				continue
+		if err := hyperliquidSubscribe(conn, subs, "subscribe", current); err != nil {
+			stats.Error(err)
+			stats.Disconnected()
+			conn.Close()
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
 			}
+			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if err := hyperliquidSubscribe(conn, subs, "unsubscribe", removed); err != nil {
+					return err
+				}
+				return hyperliquidSubscribe(conn, subs, "subscribe", added)
+			})
+		})
 
 		for {
-			var message json.RawMessage
-			err := conn.ReadJSON(&message)
+			message, receivedAt, err := readFrame(conn, "hyperliquid_futures")
 			if err != nil {
-				log.Printf("Hyperliquid read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
@@ -100,6 +105,7 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 			// Try to parse as trade message first
 			var tradeMessage HyperliquidTrade
 			if err := json.Unmarshal(message, &tradeMessage); err == nil && tradeMessage.Channel == "trades" && len(tradeMessage.Data) > 0 {
+				stats.Message("trades")
 				// Handle both array and single object formats
 				var trades []HyperliquidTradeData
 
@@ -109,6 +115,7 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 					var singleTrade HyperliquidTradeData
 					if err := json.Unmarshal(tradeMessage.Data, &singleTrade); err != nil {
 						log.Printf("Hyperliquid trade data parse error: %v", err)
+						stats.ParseError()
 						continue
 					}
 					trades = []HyperliquidTradeData{singleTrade}
@@ -118,11 +125,15 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 					// Parse price from string
 					price, err := strconv.ParseFloat(trade.Price, 64)
 					if err != nil {
+						stats.ParseError()
 						continue
 					}
 
 					// Convert coin back to symbol format (BTC -> BTCUSDT)
-					symbol := trade.Coin + "USDT"
+					symbol, ok := CanonicalSymbol("hyperliquid_futures", trade.Coin)
+					if !ok {
+						continue
+					}
 
 					// Normalize trade side (Hyperliquid uses "A" for ask/sell, "B" for bid/buy)
 					var side string
@@ -133,12 +144,13 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 					}
 
 					tradeData := TradeData{
-						Symbol:    symbol,
-						Source:    "hyperliquid_futures",
-						Price:     price,
-						Quantity:  trade.Size,
-						Side:      side,
-						Timestamp: trade.Timestamp,
+						Symbol:     symbol,
+						Source:     "hyperliquid_futures",
+						Price:      price,
+						Quantity:   parseSize(trade.Size),
+						Side:       side,
+						Timestamp:  trade.Timestamp,
+						ReceivedAt: receivedAt,
 					}
 
 					tradeChan <- tradeData
@@ -149,9 +161,11 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 			// Try to parse as l2Book message
 			var l2BookMessage HyperliquidL2Book
 			if err := json.Unmarshal(message, &l2BookMessage); err == nil && l2BookMessage.Channel == "l2Book" && len(l2BookMessage.Data) > 0 {
+				stats.Message("l2Book")
 				var l2BookData HyperliquidL2BookData
 				if err := json.Unmarshal(l2BookMessage.Data, &l2BookData); err != nil {
 					log.Printf("Hyperliquid l2Book data parse error: %v", err)
+					stats.ParseError()
 					continue
 				}
 
@@ -161,25 +175,149 @@ func ConnectHyperliquidFutures(symbols []string, priceChan chan<- PriceData, ord
 					bestBid, err1 := strconv.ParseFloat(l2BookData.Levels[0][0].Price, 64)
 					bestAsk, err2 := strconv.ParseFloat(l2BookData.Levels[1][0].Price, 64)
 					if err1 != nil || err2 != nil {
+						stats.ParseError()
 						continue
 					}
 
 					// Convert coin back to symbol format (BTC -> BTCUSDT)
-					symbol := l2BookData.Coin + "USDT"
+					symbol, ok := CanonicalSymbol("hyperliquid_futures", l2BookData.Coin)
+					if !ok {
+						continue
+					}
 
 					orderbookData := OrderbookData{
-						Symbol:    symbol,
-						Source:    "hyperliquid_futures",
-						BestBid:   bestBid,
-						BestAsk:   bestAsk,
-						Timestamp: l2BookData.Time,
+						Symbol:     symbol,
+						Source:     "hyperliquid_futures",
+						BestBid:    bestBid,
+						BestAsk:    bestAsk,
+						BidSize:    parseSize(l2BookData.Levels[0][0].Size),
+						AskSize:    parseSize(l2BookData.Levels[1][0].Size),
+						Timestamp:  l2BookData.Time,
+						ReceivedAt: receivedAt,
 					}
 
 					orderbookChan <- orderbookData
 				}
+				continue
+			}
+
+			var envelope struct {
+				Channel string          `json:"channel"`
+				Data    json.RawMessage `json:"data"`
+			}
+			json.Unmarshal(message, &envelope)
+			hyperliquidReply(subs, envelope.Channel, envelope.Data)
+			stats.Other(envelope.Channel == "subscriptionResponse" || envelope.Channel == "pong")
+		}
+		stopFollow()
+		subs.close()
+		stopClose()
+
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
+	}
+}
+
+// hyperliquidSubscribe sends method ("subscribe" or "unsubscribe") for the
+// trades and l2Book feeds of symbols. Feeds are tracked as "l2Book:BTC".
+func hyperliquidSubscribe(conn *websocket.Conn, subs *subscriptions, method string, symbols []string) error {
+	for _, symbol := range symbols {
+		// Convert BTCUSDT to BTC for Hyperliquid
+		coin, ok := NativeSymbol("hyperliquid_futures", symbol)
+		if !ok {
+			continue
+		}
+
+		for _, feed := range []string{"trades", "l2Book"} {
+			if method == "subscribe" {
+				subs.request(feed + ":" + coin)
+			} else {
+				subs.unsubscribed(feed + ":" + coin)
 			}
+			if err := conn.WriteJSON(hyperliquidRequest(method, feed, coin)); err != nil {
+				log.Printf("Hyperliquid %s %s error for %s: %v", feed, method, coin, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hyperliquidRequest(method, feed, coin string) map[string]interface{} {
+	return map[string]interface{}{
+		"method": method,
+		"subscription": map[string]interface{}{
+			"type": feed,
+			"coin": coin,
+		},
+	}
+}
+
+// hyperliquidSubscription is the subscription a response or error is
+// about.
+type hyperliquidSubscription struct {
+	Type string `json:"type"`
+	Coin string `json:"coin"`
+}
+
+// hyperliquidReply applies the answer to a subscription to subs: a
+// subscriptionResponse naming it, or an error whose text ends with it,
+// e.g. "Invalid subscription {\"type\":\"l2Book\",\"coin\":\"FOO\"}".
+// Hyperliquid gives no error code.
+func hyperliquidReply(subs *subscriptions, channel string, data json.RawMessage) {
+	switch channel {
+	case "subscriptionResponse":
+		var resp struct {
+			Method       string                  `json:"method"`
+			Subscription hyperliquidSubscription `json:"subscription"`
+		}
+		if json.Unmarshal(data, &resp) == nil && resp.Method == "subscribe" {
+			subs.ack(resp.Subscription.Type + ":" + resp.Subscription.Coin)
+		}
+	case "error":
+		var text string
+		if json.Unmarshal(data, &text) != nil {
+			return
+		}
+		var sub hyperliquidSubscription
+		if i := strings.Index(text, "{"); i < 0 || json.Unmarshal([]byte(text[i:]), &sub) != nil || sub.Type == "" {
+			subs.rejectNext("", text)
+		} else if strings.HasPrefix(text, "Already subscribed") {
+			subs.ack(sub.Type + ":" + sub.Coin)
+		} else {
+			subs.reject(sub.Type+":"+sub.Coin, "", text)
 		}
+	}
+}
+
+// hyperliquidMeta is the response to an info request of type meta.
+type hyperliquidMeta struct {
+	Universe []struct {
+		Name       string `json:"name"`
+		SzDecimals int    `json:"szDecimals"`
+		IsDelisted bool   `json:"isDelisted"`
+	} `json:"universe"`
+}
+
+// parseHyperliquidMeta reads the perpetuals, all quoted in USD. Prices
+// have no fixed tick, only a limit on significant figures, so TickSize is
+// left out.
+func parseHyperliquidMeta(body []byte) ([]Market, error) {
+	var meta hyperliquidMeta
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return nil, err
+	}
 
-		time.Sleep(2 * time.Second)
+	markets := make([]Market, 0, len(meta.Universe))
+	for _, a := range meta.Universe {
+		markets = append(markets, Market{
+			Native:  a.Name,
+			Base:    a.Name,
+			Quote:   "USD",
+			LotSize: decimalStep(a.SzDecimals),
+			Status:  marketStatus(!a.IsDelisted, "delisted"),
+		})
 	}
+	return markets, nil
 }
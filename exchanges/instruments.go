@@ -0,0 +1,555 @@
+package exchanges
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kind tells spot pairs from perpetual swaps, and linear swaps from
+// inverse ones.
+type Kind string
+
+const (
+	Spot      Kind = "spot"
+	Perpetual Kind = "perp"
+	// Inverse is a coin-margined perpetual: contracts are worth a fixed
+	// amount of quote currency and pay out in the base coin.
+	Inverse Kind = "inverse"
+)
+
+// Instrument is a market in canonical terms, e.g. the BTC/USDT perpetual.
+// Its Symbol is what the scanner and its clients see: BTCUSDT.
+type Instrument struct {
+	Base  string
+	Quote string
+	Kind  Kind
+}
+
+// Symbol returns the canonical symbol, base then quote.
+func (i Instrument) Symbol() string {
+	return i.Base + i.Quote
+}
+
+// MarketTrading is the Status of a market open for trading; other statuses
+// are the venue's own word for it, lowercased.
+const MarketTrading = "trading"
+
+// Market is what a venue's metadata says about one of its instruments.
+// TickSize and LotSize are in the venue's own units: price per tick and the
+// smallest size step, in contracts where the venue trades contracts.
+// Multiplier is how many of the venue's base units one contract is, or
+// for Inverse markets how much quote currency, and Scale how much base
+// asset one of those base units is: 1000 for 1000PEPEUSDT or kPEPE, whose
+// prices are per 1000 PEPE.
+type Market struct {
+	Venue  string `json:"venue"`
+	Symbol string `json:"symbol"`
+	Native string `json:"native"`
+	// ID is the venue's market id, for venues whose streams use one.
+	ID         string  `json:"id,omitempty"`
+	Base       string  `json:"base"`
+	Quote      string  `json:"quote"`
+	Kind       Kind    `json:"kind"`
+	TickSize   float64 `json:"tick_size,omitempty"`
+	LotSize    float64 `json:"lot_size,omitempty"`
+	Multiplier float64 `json:"multiplier"`
+	Scale      float64 `json:"scale"`
+	Status     string  `json:"status"`
+}
+
+// Trading reports whether the market is open.
+func (m Market) Trading() bool {
+	return m.Status == MarketTrading
+}
+
+// knownQuotes are split off the end of an unseparated symbol, longest
+// first so USDT wins over USD.
+var knownQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD"}
+
+// dollarQuotes are the quotes venues that settle in dollars report for
+// what the scanner treats as USDT.
+var dollarQuotes = map[string]bool{"USD": true, "USDT": true, "USDC": true}
+
+// venueRule is how one venue spells instruments.
+type venueRule struct {
+	kind Kind
+	// quotes maps the canonical quotes the venue trades to its spelling.
+	quotes map[string]string
+	// foldDollar makes every dollar quote, or none, mean USDT: the venue
+	// has one dollar market per base.
+	foldDollar bool
+	// bases maps canonical bases to the venue's where they differ.
+	bases  map[string]string
+	format func(base, quote string) string
+	parse  func(native string) (base, quote string, ok bool)
+	// listed maps canonical symbols to native ones that don't follow
+	// format. With only set, nothing else trades.
+	listed map[string]string
+	only   bool
+}
+
+// tonCanonicalSymbol is the one market the TON venues are checked for.
+const tonCanonicalSymbol = "TONUSDT"
+
+var (
+	instrumentsMutex sync.RWMutex
+	venueRules       = map[string]*venueRule{
+		"binance_futures": {kind: Perpetual, quotes: sameQuotes("USDT", "USDC"), format: concatSymbol},
+		"binance_spot":    {kind: Spot, quotes: sameQuotes("USDT", "USDC", "FDUSD"), format: concatSymbol},
+		"binance_inverse": {kind: Inverse, quotes: map[string]string{"USDT": "USD"}, foldDollar: true, format: func(base, quote string) string {
+			return base + quote + "_PERP"
+		}},
+		"bybit_futures": {kind: Perpetual, quotes: sameQuotes("USDT", "USDC"), format: concatSymbol},
+		"bybit_spot":    {kind: Spot, quotes: sameQuotes("USDT", "USDC"), format: concatSymbol},
+		"bybit_inverse": {kind: Inverse, quotes: map[string]string{"USDT": "USD"}, foldDollar: true, format: concatSymbol},
+		"okx_futures":   {kind: Perpetual, quotes: sameQuotes("USDT", "USDC"), format: okxSwap},
+		"okx_inverse":   {kind: Inverse, quotes: map[string]string{"USDT": "USD"}, foldDollar: true, format: okxSwap},
+		"gate_futures": {kind: Perpetual, quotes: sameQuotes("USDT"), format: func(base, quote string) string {
+			return base + "_" + quote
+		}},
+		"kraken_futures": {
+			kind: Perpetual, quotes: map[string]string{"USDT": "USD"}, foldDollar: true,
+			bases: map[string]string{"BTC": "XBT"},
+			format: func(base, quote string) string {
+				return "PF_" + base + quote
+			},
+			parse: krakenProductParser("PF_"),
+		},
+		"kraken_inverse": {
+			kind: Inverse, quotes: map[string]string{"USDT": "USD"}, foldDollar: true,
+			bases: map[string]string{"BTC": "XBT"},
+			format: func(base, quote string) string {
+				return "PI_" + base + quote
+			},
+			parse: krakenProductParser("PI_"),
+		},
+		"paradex_futures": {kind: Perpetual, quotes: map[string]string{"USDT": "USD"}, foldDollar: true, format: func(base, quote string) string {
+			return base + "-" + quote + "-PERP"
+		}},
+		"hyperliquid_futures": {kind: Perpetual, quotes: map[string]string{"USDT": "USD"}, foldDollar: true, format: baseOnly, parse: parseCoin},
+
+		// These venues have only been checked for TON; market discovery
+		// will replace the lists.
+		"vest_futures": {kind: Perpetual, foldDollar: true, only: true, listed: map[string]string{tonCanonicalSymbol: "TON-PERP"}},
+		// Extended's TON market is discovered at start; TON-USD is the
+		// fallback.
+		"extended_futures":  {kind: Perpetual, foldDollar: true, only: true, listed: map[string]string{tonCanonicalSymbol: "TON-USD"}},
+		"lighter_futures":   {kind: Perpetual, foldDollar: true, only: true, listed: map[string]string{tonCanonicalSymbol: "TON"}},
+		"variational_perps": {kind: Perpetual, foldDollar: true, only: true, listed: map[string]string{tonCanonicalSymbol: "TON"}},
+		"DeDust":            {kind: Spot, foldDollar: true, only: true, listed: map[string]string{tonCanonicalSymbol: "TON/USDT"}},
+		"pyth": {kind: Spot, foldDollar: true, only: true, listed: map[string]string{
+			"BTCUSDT": "e62df6c8b4a85fe1a67db44dc12de5db330f7ac66b72dc658afedf0f4a415b43", // BTC/USD price feed ID
+		}},
+	}
+
+	// venueMarkets holds what discovery found, per venue. Once a venue has
+	// markets they are the list of what it trades, replacing its rule's
+	// guesses.
+	venueMarkets = make(map[string]*marketSet)
+)
+
+type marketSet struct {
+	bySymbol map[string]Market
+	byNative map[string]string
+}
+
+func sameQuotes(quotes ...string) map[string]string {
+	m := make(map[string]string, len(quotes))
+	for _, q := range quotes {
+		m[q] = q
+	}
+	return m
+}
+
+func concatSymbol(base, quote string) string { return base + quote }
+
+func baseOnly(base, quote string) string { return base }
+
+func okxSwap(base, quote string) string { return base + "-" + quote + "-SWAP" }
+
+// parseCoin reads a bare coin name as given; case matters on Hyperliquid,
+// where kPEPE is 1000 PEPE.
+func parseCoin(native string) (string, string, bool) {
+	return native, "", native != ""
+}
+
+// splitScale strips a size prefix from a base: 1000PEPE and kPEPE are
+// PEPE at a scale of 1000, 1000000MOG is MOG at a million.
+func splitScale(base string) (string, float64) {
+	if len(base) > 1 && base[0] == 'k' && base[1] >= 'A' && base[1] <= 'Z' {
+		return base[1:], 1000
+	}
+	zeros := 0
+	if strings.HasPrefix(base, "1") {
+		for zeros+1 < len(base) && base[zeros+1] == '0' {
+			zeros++
+		}
+	}
+	rest := base[min(zeros+1, len(base)):]
+	if zeros < 3 || rest == "" || rest[0] < 'A' || rest[0] > 'Z' {
+		return base, 1
+	}
+	return rest, math.Pow10(zeros)
+}
+
+// NormalizeSymbol strips a size prefix from a symbol: 1000PEPEUSDT is
+// reported as PEPEUSDT, with prices per PEPE, on every venue. Other
+// symbols are returned as given.
+func NormalizeSymbol(symbol string) string {
+	base, quote, ok := ParseSymbol(symbol)
+	if !ok || quote == "" {
+		return symbol
+	}
+	if b, scale := splitScale(base); scale != 1 {
+		return b + quote
+	}
+	return symbol
+}
+
+// krakenProductParser reads product ids with prefix, e.g. PF_XBTUSD as XBT
+// and USD; Kraken's linear perpetuals are PF_ and its inverse ones PI_.
+func krakenProductParser(prefix string) func(string) (string, string, bool) {
+	return func(native string) (string, string, bool) {
+		rest, ok := strings.CutPrefix(strings.ToUpper(native), prefix)
+		if !ok {
+			return "", "", false
+		}
+		base, ok := strings.CutSuffix(rest, "USD")
+		if !ok || base == "" {
+			return "", "", false
+		}
+		return base, "USD", true
+	}
+}
+
+// ParseSymbol reads a symbol written any of the ways venues and users
+// write them: TONUSDT, ton-usdt, TON/USD, TON_USDC, TON-PERP, TON-USD-PERP,
+// BTC-USDT-SWAP or a bare base. quote is empty when the symbol has none.
+func ParseSymbol(symbol string) (base, quote string, ok bool) {
+	u := strings.ToUpper(strings.TrimSpace(symbol))
+	u = strings.NewReplacer("/", "-", "_", "-", ":", "-").Replace(u)
+
+	var parts []string
+	for i, p := range strings.Split(u, "-") {
+		if i > 0 {
+			p = strings.TrimPrefix(p, "PERP")
+		}
+		if p == "" || p == "PERP" || p == "SWAP" {
+			continue
+		}
+		parts = append(parts, p)
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", "", false
+	case 1:
+		base = strings.TrimSuffix(parts[0], "PERP")
+		for _, q := range knownQuotes {
+			if b, found := strings.CutSuffix(base, q); found && b != "" {
+				base, quote = b, q
+				break
+			}
+		}
+	default:
+		base, quote = parts[0], parts[1]
+	}
+
+	if !alphanumeric(base) || (quote != "" && !alphanumeric(quote)) {
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+func alphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalQuote resolves a quote as a venue or user wrote it to the
+// canonical one, or reports that the venue doesn't trade it. No quote
+// means USDT.
+func (r *venueRule) canonicalQuote(quote string) (string, bool) {
+	if quote == "" || (r.foldDollar && dollarQuotes[quote]) {
+		return "USDT", true
+	}
+	for canonical, native := range r.quotes {
+		if native == quote {
+			return canonical, true
+		}
+	}
+	return "", false
+}
+
+// resolve turns a base and quote as venue writes them into the canonical
+// instrument and the scale of the venue's base.
+func (r *venueRule) resolve(base, quote string) (Instrument, float64, bool) {
+	base, scale := splitScale(base)
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	for canonical, alias := range r.bases {
+		if alias == base {
+			base = canonical
+		}
+	}
+	quote, ok := r.canonicalQuote(quote)
+	if !ok || !alphanumeric(base) {
+		return Instrument{}, 0, false
+	}
+	return Instrument{Base: base, Quote: quote, Kind: r.kind}, scale, true
+}
+
+// resolveUnscaled is resolve for a venue without discovered markets. Only
+// discovery tells the normalizers a market's scale, so a size prefix stays
+// part of the base, written in digits: 1000PEPEUSDT and kPEPE are both
+// 1000PEPEUSDT. Their per-1000 prices are then never compared with per
+// PEPE ones.
+func (r *venueRule) resolveUnscaled(base, quote string) (Instrument, bool) {
+	inst, scale, ok := r.resolve(base, quote)
+	if ok && scale != 1 {
+		inst.Base = strconv.FormatFloat(scale, 'f', -1, 64) + inst.Base
+	}
+	return inst, ok
+}
+
+func rule(venue string) (*venueRule, bool) {
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	r, ok := venueRules[venue]
+	return r, ok
+}
+
+// LookupInstrument resolves a canonical or loosely written symbol to the
+// instrument venue trades for it and the venue's own symbol. Without
+// discovered markets a size prefix is kept as written, see resolveUnscaled,
+// so PEPEUSDT is looked up as PEPEUSDT even where the venue lists only
+// 1000PEPEUSDT.
+func LookupInstrument(venue, symbol string) (Instrument, string, bool) {
+	r, ok := rule(venue)
+	if !ok {
+		return Instrument{}, "", false
+	}
+	base, quote, ok := ParseSymbol(symbol)
+	if !ok {
+		return Instrument{}, "", false
+	}
+
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	if ms := venueMarkets[venue]; ms != nil {
+		inst, _, ok := r.resolve(base, quote)
+		if !ok {
+			return Instrument{}, "", false
+		}
+		m, ok := ms.bySymbol[inst.Symbol()]
+		if !ok || !m.Trading() {
+			return Instrument{}, "", false
+		}
+		return inst, m.Native, true
+	}
+	inst, ok := r.resolveUnscaled(base, quote)
+	if !ok {
+		return Instrument{}, "", false
+	}
+	if native, listed := r.listed[inst.Symbol()]; listed {
+		return inst, native, true
+	}
+	if r.only || r.format == nil {
+		return Instrument{}, "", false
+	}
+	nativeQuote, ok := r.quotes[inst.Quote]
+	if !ok {
+		return Instrument{}, "", false
+	}
+	base = inst.Base
+	if alias, ok := r.bases[base]; ok {
+		base = alias
+	}
+	return inst, r.format(base, nativeQuote), true
+}
+
+// NativeSymbol returns venue's symbol for a canonical or loosely written
+// one, and false if the venue doesn't trade it.
+func NativeSymbol(venue, symbol string) (string, bool) {
+	_, native, ok := LookupInstrument(venue, symbol)
+	return native, ok
+}
+
+// CanonicalSymbol returns the canonical symbol for one of venue's own, and
+// false if it isn't an instrument the venue is known to trade.
+func CanonicalSymbol(venue, native string) (string, bool) {
+	r, ok := rule(venue)
+	if !ok {
+		return "", false
+	}
+
+	instrumentsMutex.RLock()
+	if ms := venueMarkets[venue]; ms != nil {
+		symbol, ok := ms.byNative[native]
+		if !ok {
+			symbol, ok = ms.byNative[strings.ToUpper(native)]
+		}
+		instrumentsMutex.RUnlock()
+		return symbol, ok
+	}
+	for canonical, n := range r.listed {
+		if n == native {
+			instrumentsMutex.RUnlock()
+			return canonical, true
+		}
+	}
+	instrumentsMutex.RUnlock()
+
+	parse := r.parse
+	if parse == nil {
+		parse = ParseSymbol
+	}
+	base, quote, ok := parse(native)
+	if !ok {
+		return "", false
+	}
+	inst, ok := r.resolveUnscaled(base, quote)
+	if !ok {
+		return "", false
+	}
+
+	instrumentsMutex.RLock()
+	_, listed := r.listed[inst.Symbol()]
+	instrumentsMutex.RUnlock()
+	if r.only && !listed {
+		return "", false
+	}
+	return inst.Symbol(), true
+}
+
+// SupportedSymbols returns the canonical form of each of symbols venue
+// trades, without duplicates, in the order given.
+func SupportedSymbols(venue string, symbols []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		inst, _, ok := LookupInstrument(venue, s)
+		if !ok || seen[inst.Symbol()] {
+			continue
+		}
+		seen[inst.Symbol()] = true
+		out = append(out, inst.Symbol())
+	}
+	return out
+}
+
+// VenueKind returns the kind of instrument venue trades, or "" for a venue
+// without symbol rules.
+func VenueKind(venue string) Kind {
+	r, ok := rule(venue)
+	if !ok {
+		return ""
+	}
+	return r.kind
+}
+
+// InstrumentVenues returns every venue with symbol rules, sorted.
+func InstrumentVenues() []string {
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	out := make([]string, 0, len(venueRules))
+	for venue := range venueRules {
+		out = append(out, venue)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SetMarkets replaces what venue is known to trade with markets, e.g. after
+// discovery. Each market's Native must be set; Symbol, Base, Quote and Kind
+// are filled in from the venue's rule where empty, and markets the rule
+// can't place are dropped. If several markets map to one symbol the first
+// trading one wins. No markets puts the venue back on its rule alone.
+func SetMarkets(venue string, markets []Market) {
+	r, ok := rule(venue)
+	if !ok {
+		return
+	}
+
+	var ms *marketSet
+	if len(markets) > 0 {
+		ms = &marketSet{bySymbol: make(map[string]Market), byNative: make(map[string]string)}
+		for _, m := range markets {
+			if m.Native == "" {
+				continue
+			}
+			if m.Symbol == "" {
+				inst, scale, ok := r.resolve(m.Base, m.Quote)
+				if !ok {
+					continue
+				}
+				m.Symbol, m.Base, m.Quote, m.Scale = inst.Symbol(), inst.Base, inst.Quote, scale
+			}
+			m.Venue = venue
+			if m.Kind == "" {
+				m.Kind = r.kind
+			}
+			if m.Multiplier == 0 {
+				m.Multiplier = 1
+			}
+			if m.Scale == 0 {
+				m.Scale = 1
+			}
+			if prev, ok := ms.bySymbol[m.Symbol]; !ok || (!prev.Trading() && m.Trading()) {
+				ms.bySymbol[m.Symbol] = m
+			}
+			ms.byNative[m.Native] = m.Symbol
+		}
+	}
+
+	instrumentsMutex.Lock()
+	if ms == nil {
+		delete(venueMarkets, venue)
+	} else {
+		venueMarkets[venue] = ms
+	}
+	instrumentsMutex.Unlock()
+}
+
+// Markets returns what discovery found for venue, sorted by symbol, or nil
+// if the venue's markets aren't known.
+func Markets(venue string) []Market {
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	ms := venueMarkets[venue]
+	if ms == nil {
+		return nil
+	}
+	out := make([]Market, 0, len(ms.bySymbol))
+	for _, m := range ms.bySymbol {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// MarketFor returns the market venue trades for a canonical symbol, if its
+// markets are known.
+func MarketFor(venue, symbol string) (Market, bool) {
+	inst, _, ok := LookupInstrument(venue, symbol)
+	if !ok {
+		return Market{}, false
+	}
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	ms := venueMarkets[venue]
+	if ms == nil {
+		return Market{}, false
+	}
+	m, ok := ms.bySymbol[inst.Symbol()]
+	return m, ok
+}
@@ -0,0 +1,190 @@
+package exchanges
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	cases := []struct {
+		in        string
+		wantBase  string
+		wantQuote string
+		wantOK    bool
+	}{
+		{in: "BTCUSDT", wantBase: "BTC", wantQuote: "USDT", wantOK: true},
+		{in: "btc-usdt", wantBase: "BTC", wantQuote: "USDT", wantOK: true},
+		{in: "ETH/USDC", wantBase: "ETH", wantQuote: "USDC", wantOK: true},
+		{in: "SOL_USDT", wantBase: "SOL", wantQuote: "USDT", wantOK: true},
+		{in: "BTCFDUSD", wantBase: "BTC", wantQuote: "FDUSD", wantOK: true},
+		{in: "BTC-USDT-SWAP", wantBase: "BTC", wantQuote: "USDT", wantOK: true},
+		{in: "ETH-USD-PERP", wantBase: "ETH", wantQuote: "USD", wantOK: true},
+		{in: "1000PEPEUSDT", wantBase: "1000PEPE", wantQuote: "USDT", wantOK: true},
+		{in: "TON-PERP", wantBase: "TON", wantOK: true},
+		{in: "DOGE", wantBase: "DOGE", wantOK: true},
+		{in: "USDT", wantBase: "USDT", wantOK: true},
+		{in: "", wantOK: false},
+		{in: "BTC$USDT", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		base, quote, ok := ParseSymbol(tc.in)
+		if ok != tc.wantOK {
+			t.Fatalf("ParseSymbol(%q): ok=%v want %v", tc.in, ok, tc.wantOK)
+		}
+		if base != tc.wantBase || quote != tc.wantQuote {
+			t.Fatalf("ParseSymbol(%q): got %q/%q want %q/%q", tc.in, base, quote, tc.wantBase, tc.wantQuote)
+		}
+	}
+}
+
+func TestTONAliases(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{in: "TONUSDT", want: tonCanonicalSymbol, wantOK: true},
+		{in: "tonusdt", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON-USDT", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON/USDT", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON_USDT", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON-USD", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON/USDC", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON-PERP", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TONPERP", want: tonCanonicalSymbol, wantOK: true},
+		{in: "TON-USD-PERP", want: tonCanonicalSymbol, wantOK: true},
+		{in: "BTCUSDT", want: "", wantOK: false},
+		{in: "", want: "", wantOK: false},
+		{in: "   ", want: "", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		inst, _, ok := LookupInstrument("vest_futures", tc.in)
+		if ok != tc.wantOK {
+			t.Fatalf("LookupInstrument(vest_futures, %q): ok=%v want %v", tc.in, ok, tc.wantOK)
+		}
+		if ok && inst.Symbol() != tc.want {
+			t.Fatalf("LookupInstrument(vest_futures, %q): got %q want %q", tc.in, inst.Symbol(), tc.want)
+		}
+	}
+}
+
+func TestSupportedSymbols(t *testing.T) {
+	cases := []struct {
+		name  string
+		venue string
+		in    []string
+		want  []string
+	}{
+		{name: "no-ton", venue: "vest_futures", in: []string{"BTCUSDT", "ETHUSDT"}, want: nil},
+		{name: "canonical-present", venue: "vest_futures", in: []string{"BTCUSDT", "TONUSDT", "ETHUSDT"}, want: []string{tonCanonicalSymbol}},
+		{name: "alias-present", venue: "vest_futures", in: []string{"BTCUSDT", "TON-USD", "ETHUSDT"}, want: []string{tonCanonicalSymbol}},
+		{name: "many-aliases-dedupe", venue: "vest_futures", in: []string{"TON-USD", "TON/USDT", "TONUSDT"}, want: []string{tonCanonicalSymbol}},
+		{name: "handles-extra-aliases", venue: "vest_futures", in: []string{"TON-USDC", "TON-USD-PERP"}, want: []string{tonCanonicalSymbol}},
+		{name: "gate-usdt-only", venue: "gate_futures", in: []string{"BTCUSDT", "BTCUSDC", "ETH-USDT"}, want: []string{"BTCUSDT", "ETHUSDT"}},
+		{name: "kraken-folds-dollars", venue: "kraken_futures", in: []string{"BTCUSDT", "BTCUSDC", "ETHUSD"}, want: []string{"BTCUSDT", "ETHUSDT"}},
+		{name: "unknown-venue", venue: "nowhere", in: []string{"BTCUSDT"}, want: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SupportedSymbols(tc.venue, tc.in)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("SupportedSymbols(%s, %v): got %v want %v", tc.venue, tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNativeSymbol(t *testing.T) {
+	cases := []struct {
+		venue string
+		in    string
+		want  string
+	}{
+		{venue: "binance_futures", in: "BTCUSDT", want: "BTCUSDT"},
+		{venue: "binance_futures", in: "ETH-USDC", want: "ETHUSDC"},
+		{venue: "binance_futures", in: "BTCFDUSD", want: ""},
+		{venue: "binance_spot", in: "BTCFDUSD", want: "BTCFDUSD"},
+		{venue: "bybit_futures", in: "SOLUSDT", want: "SOLUSDT"},
+		{venue: "bybit_spot", in: "sol/usdt", want: "SOLUSDT"},
+		{venue: "okx_futures", in: "BTCUSDT", want: "BTC-USDT-SWAP"},
+		{venue: "okx_futures", in: "ETHUSDC", want: "ETH-USDC-SWAP"},
+		{venue: "gate_futures", in: "BTCUSDT", want: "BTC_USDT"},
+		{venue: "gate_futures", in: "BTCUSDC", want: ""},
+		{venue: "kraken_futures", in: "BTCUSDT", want: "PF_XBTUSD"},
+		{venue: "kraken_futures", in: "ETHUSDT", want: "PF_ETHUSD"},
+		{venue: "paradex_futures", in: "BTCUSDT", want: "BTC-USD-PERP"},
+		{venue: "binance_inverse", in: "BTCUSDT", want: "BTCUSD_PERP"},
+		{venue: "bybit_inverse", in: "ETHUSDT", want: "ETHUSD"},
+		{venue: "okx_inverse", in: "BTCUSDT", want: "BTC-USD-SWAP"},
+		{venue: "kraken_inverse", in: "BTCUSDT", want: "PI_XBTUSD"},
+		{venue: "hyperliquid_futures", in: "BTCUSDT", want: "BTC"},
+		{venue: "hyperliquid_futures", in: "DOGEUSDT", want: "DOGE"},
+		{venue: "vest_futures", in: "TONUSDT", want: "TON-PERP"},
+		{venue: "extended_futures", in: "TONUSDT", want: "TON-USD"},
+		{venue: "lighter_futures", in: "TONUSDT", want: "TON"},
+		{venue: "variational_perps", in: "TONUSDT", want: "TON"},
+		{venue: "DeDust", in: "TONUSDT", want: "TON/USDT"},
+		{venue: "pyth", in: "BTCUSDT", want: "e62df6c8b4a85fe1a67db44dc12de5db330f7ac66b72dc658afedf0f4a415b43"},
+		{venue: "pyth", in: "ETHUSDT", want: ""},
+		{venue: "nowhere", in: "BTCUSDT", want: ""},
+	}
+
+	for _, tc := range cases {
+		if got, _ := NativeSymbol(tc.venue, tc.in); got != tc.want {
+			t.Fatalf("NativeSymbol(%s, %q): got %q want %q", tc.venue, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCanonicalSymbol(t *testing.T) {
+	cases := []struct {
+		venue  string
+		native string
+		want   string
+	}{
+		{venue: "binance_futures", native: "BTCUSDT", want: "BTCUSDT"},
+		{venue: "binance_spot", native: "ETHFDUSD", want: "ETHFDUSD"},
+		{venue: "bybit_futures", native: "1000PEPEUSDT", want: "1000PEPEUSDT"},
+		{venue: "hyperliquid_futures", native: "kPEPE", want: "1000PEPEUSDT"},
+		{venue: "okx_futures", native: "BTC-USDT-SWAP", want: "BTCUSDT"},
+		{venue: "gate_futures", native: "ETH_USDT", want: "ETHUSDT"},
+		{venue: "kraken_futures", native: "PF_XBTUSD", want: "BTCUSDT"},
+		{venue: "kraken_futures", native: "PF_SOLUSD", want: "SOLUSDT"},
+		{venue: "kraken_futures", native: "PI_XBTUSD", want: ""},
+		{venue: "kraken_inverse", native: "PI_XBTUSD", want: "BTCUSDT"},
+		{venue: "kraken_inverse", native: "PF_XBTUSD", want: ""},
+		{venue: "binance_inverse", native: "ETHUSD_PERP", want: "ETHUSDT"},
+		{venue: "okx_inverse", native: "BTC-USD-SWAP", want: "BTCUSDT"},
+		{venue: "paradex_futures", native: "ETH-USD-PERP", want: "ETHUSDT"},
+		{venue: "hyperliquid_futures", native: "BTC", want: "BTCUSDT"},
+		{venue: "hyperliquid_futures", native: "DOGE", want: "DOGEUSDT"},
+		{venue: "lighter_futures", native: "TON", want: "TONUSDT"},
+		{venue: "DeDust", native: "TON/USDT", want: "TONUSDT"},
+		{venue: "pyth", native: "e62df6c8b4a85fe1a67db44dc12de5db330f7ac66b72dc658afedf0f4a415b43", want: "BTCUSDT"},
+		{venue: "pyth", native: "deadbeef", want: ""},
+	}
+
+	for _, tc := range cases {
+		if got, _ := CanonicalSymbol(tc.venue, tc.native); got != tc.want {
+			t.Fatalf("CanonicalSymbol(%s, %q): got %q want %q", tc.venue, tc.native, got, tc.want)
+		}
+	}
+}
+
+func TestNativeSymbolRoundTrip(t *testing.T) {
+	for _, venue := range InstrumentVenues() {
+		for _, symbol := range []string{"BTCUSDT", "ETHUSDT", tonCanonicalSymbol} {
+			native, ok := NativeSymbol(venue, symbol)
+			if !ok {
+				continue
+			}
+			if got, ok := CanonicalSymbol(venue, native); !ok || got != symbol {
+				t.Fatalf("%s: %s -> %q -> %q, %v", venue, symbol, native, got, ok)
+			}
+		}
+	}
+}
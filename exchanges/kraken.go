@@ -1,7 +1,9 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -31,13 +33,16 @@ type KrakenOrderBook struct {
 	Asks []KrakenOrderBookEntry
 }
 
-func processKrakenOrderbook(productID string, orderBook *KrakenOrderBook, orderbookChan chan<- OrderbookData) {
+func processKrakenOrderbook(venue, productID string, orderBook *KrakenOrderBook, timestamp, receivedAt int64, orderbookChan chan<- OrderbookData) {
 	if len(orderBook.Bids) == 0 || len(orderBook.Asks) == 0 {
 		return
 	}
 
 	// Convert symbol back to standard format (PF_XBTUSD -> BTCUSDT)
-	symbol := convertFromKrakenSymbol(productID)
+	symbol, ok := CanonicalSymbol(venue, productID)
+	if !ok {
+		return
+	}
 
 	// Get best bid (highest price in bids)
 	bestBid := orderBook.Bids[0].Price
@@ -46,85 +51,129 @@ func processKrakenOrderbook(productID string, orderBook *KrakenOrderBook, orderb
 	bestAsk := orderBook.Asks[0].Price
 
 	orderbookData := OrderbookData{
-		Symbol:    symbol,
-		Source:    "kraken_futures",
-		BestBid:   bestBid,
-		BestAsk:   bestAsk,
-		Timestamp: time.Now().UnixMilli(),
+		Symbol:     symbol,
+		Source:     venue,
+		BestBid:    bestBid,
+		BestAsk:    bestAsk,
+		BidSize:    orderBook.Bids[0].Qty,
+		AskSize:    orderBook.Asks[0].Qty,
+		Timestamp:  timestamp,
+		ReceivedAt: receivedAt,
 	}
 
 	orderbookChan <- orderbookData
 }
 
-func ConnectKrakenFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://futures.kraken.com/ws/v1"
+func ConnectKrakenFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectKraken(ctx, "kraken_futures", "Kraken", symbols, orderbookChan)
+}
 
-	var productIDs []string
-	for _, sym := range symbols {
-		id := convertToKrakenSymbol(sym)
-		if id != "" {
-			productIDs = append(productIDs, id)
-		}
-	}
-	if len(productIDs) == 0 {
-		log.Printf("Kraken: no supported markets for requested symbols (%v); disabling", symbols)
-		return
-	}
+// ConnectKrakenInverse streams Kraken's inverse perpetuals (PI_).
+func ConnectKrakenInverse(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectKraken(ctx, "kraken_inverse", "Kraken inverse", symbols, orderbookChan)
+}
 
-	// Maintain orderbooks for each symbol
-	orderbooks := make(map[string]*KrakenOrderBook)
+// connectKraken streams the books of venue, whose product ids share
+// Kraken's futures feed.
+func connectKraken(ctx context.Context, venue, label string, symbols *SymbolSet, orderbookChan chan<- OrderbookData) {
+	connectSharded(ctx, venue, label, symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectKrakenShard(ctx, venue, label, stats, symbols, orderbookChan)
+	})
+}
 
+// connectKrakenShard streams one shard's books.
+func connectKrakenShard(ctx context.Context, venue, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData) {
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-		if err != nil {
-			log.Printf("Kraken connection error: %v", err)
-			time.Sleep(5 * time.Second)
+		current, changed := symbols.Watch()
+		if len(krakenProductIDs(venue, current)) == 0 {
+			log.Printf("%s: no supported markets for requested symbols (%v); waiting for a symbol change", label, current)
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
 			continue
 		}
 
-		log.Printf("Connected to Kraken futures WebSocket")
+		// Maintain orderbooks for each symbol
+		orderbooks := make(map[string]*KrakenOrderBook)
 
-		for _, krakenSymbol := range productIDs {
-			subscribeMsg := map[string]interface{}{
-				"event":       "subscribe",
-				"feed":        "book",
-				"product_ids": []string{krakenSymbol},
-			}
-
-			err = conn.WriteJSON(subscribeMsg)
-			if err != nil {
-				log.Printf("Kraken subscription error for %s: %v", krakenSymbol, err)
-				continue
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor(venue).WS, nil)
+		if err != nil {
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
 			}
+			continue
+		}
 
-			orderbooks[krakenSymbol] = &KrakenOrderBook{
-				Bids: make([]KrakenOrderBookEntry, 0),
-				Asks: make([]KrakenOrderBookEntry, 0),
+		log.Printf("Connected to %s futures WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, channel string) error {
+			return conn.WriteJSON(krakenRequest("subscribe", strings.TrimPrefix(channel, "book:")))
+		})
+
+		if err := krakenSubscribe(conn, subs, venue, "subscribe", current); err != nil {
+			stats.Error(err)
+			stats.Disconnected()
+			conn.Close()
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
 			}
+			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if err := krakenSubscribe(conn, subs, venue, "unsubscribe", removed); err != nil {
+					return err
+				}
+				return krakenSubscribe(conn, subs, venue, "subscribe", added)
+			})
+		})
 
 		for {
-			var rawMessage map[string]interface{}
-			err := conn.ReadJSON(&rawMessage)
+			frame, receivedAt, err := readFrame(conn, venue)
 			if err != nil {
-				log.Printf("Kraken read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
+			var rawMessage map[string]interface{}
+			if err := json.Unmarshal(frame, &rawMessage); err != nil {
+				stats.ParseError()
+				continue
+			}
+
+			// Subscription acks carry the feed too, so events go first.
+			if event, ok := rawMessage["event"].(string); ok {
+				stats.Message(event)
+				krakenReply(subs, event, frame)
+				continue
+			}
 
 			// Check if it's a book_snapshot or book update
 			if feed, ok := rawMessage["feed"].(string); ok {
+				stats.Message(feed)
 				var data KrakenOrderBookData
 				messageBytes, _ := json.Marshal(rawMessage)
 				err = json.Unmarshal(messageBytes, &data)
 				if err != nil {
-					log.Printf("Kraken orderbook unmarshal error: %v", err)
+					log.Printf("%s orderbook unmarshal error: %v", label, err)
+					stats.ParseError()
 					continue
 				}
 
 				orderbook, exists := orderbooks[data.ProductID]
 				if !exists {
-					continue
+					if feed != "book_snapshot" {
+						continue
+					}
+					orderbook = &KrakenOrderBook{}
+					orderbooks[data.ProductID] = orderbook
 				}
 
 				if feed == "book_snapshot" {
@@ -137,11 +186,83 @@ func ConnectKrakenFutures(symbols []string, priceChan chan<- PriceData, orderboo
 				}
 
 				// Send updated orderbook
-				processKrakenOrderbook(data.ProductID, orderbook, orderbookChan)
+				processKrakenOrderbook(venue, data.ProductID, orderbook, int64(data.Timestamp), receivedAt, orderbookChan)
+				continue
 			}
+
+			stats.Unknown()
+		}
+		stopFollow()
+		subs.close()
+		stopClose()
+
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
 		}
+	}
+}
 
-		time.Sleep(2 * time.Second)
+func krakenProductIDs(venue string, symbols []string) []string {
+	var productIDs []string
+	for _, sym := range symbols {
+		if id, ok := NativeSymbol(venue, sym); ok {
+			productIDs = append(productIDs, id)
+		}
+	}
+	return productIDs
+}
+
+// krakenSubscribe sends event ("subscribe" or "unsubscribe") for the book
+// feed of each of symbols venue trades. Feeds are tracked as
+// "book:PF_XBTUSD".
+func krakenSubscribe(conn *websocket.Conn, subs *subscriptions, venue, event string, symbols []string) error {
+	for _, krakenSymbol := range krakenProductIDs(venue, symbols) {
+		if event == "subscribe" {
+			subs.request("book:" + krakenSymbol)
+		} else {
+			subs.unsubscribed("book:" + krakenSymbol)
+		}
+		if err := conn.WriteJSON(krakenRequest(event, krakenSymbol)); err != nil {
+			log.Printf("Kraken %s error for %s: %v", event, krakenSymbol, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func krakenRequest(event, productID string) map[string]interface{} {
+	return map[string]interface{}{
+		"event":       event,
+		"feed":        "book",
+		"product_ids": []string{productID},
+	}
+}
+
+// krakenReply applies the answer to a subscription to subs. Acks name their
+// products and so do most refusals; an {"event":"error","message":
+// "Invalid product id"} names nothing, but answers come in the order
+// requests were sent.
+func krakenReply(subs *subscriptions, event string, frame []byte) {
+	var reply struct {
+		Feed       string   `json:"feed"`
+		ProductIDs []string `json:"product_ids"`
+		Message    string   `json:"message"`
+	}
+	if err := json.Unmarshal(frame, &reply); err != nil {
+		return
+	}
+	switch event {
+	case "subscribed":
+		for _, id := range reply.ProductIDs {
+			subs.ack(reply.Feed + ":" + id)
+		}
+	case "subscribed_failed", "error":
+		if len(reply.ProductIDs) == 0 {
+			subs.rejectNext("", reply.Message)
+		}
+		for _, id := range reply.ProductIDs {
+			subs.reject("book:"+id, "", reply.Message)
+		}
 	}
 }
 
@@ -213,33 +334,58 @@ func upsertPriceLevel(orderbook *KrakenOrderBook, side string, price, qty float6
 	}
 }
 
-func convertToKrakenSymbol(symbol string) string {
-	switch strings.ToUpper(symbol) {
-	case "BTCUSDT":
-		return "PF_XBTUSD"
-	case "ETHUSDT":
-		return "PF_ETHUSD"
-	case "XRPUSDT":
-		return "PF_XRPUSD"
-	case "SOLUSDT":
-		return "PF_SOLUSD"
-	default:
-		return ""
-	}
-}
-
-func convertFromKrakenSymbol(symbol string) string {
-	// Convert PF_XBTUSD to BTCUSDT (standard format)
-	switch symbol {
-	case "PF_XBTUSD":
-		return "BTCUSDT"
-	case "PF_ETHUSD":
-		return "ETHUSDT"
-	case "PF_XRPUSD":
-		return "XRPUSDT"
-	case "PF_SOLUSD":
-		return "SOLUSDT"
-	default:
-		return symbol
+// krakenInstruments is the response of /derivatives/api/v3/instruments.
+type krakenInstruments struct {
+	Result      string `json:"result"`
+	Instruments []struct {
+		Symbol                      string  `json:"symbol"`
+		Type                        string  `json:"type"`
+		Tradeable                   bool    `json:"tradeable"`
+		TickSize                    float64 `json:"tickSize"`
+		ContractSize                float64 `json:"contractSize"`
+		ContractValueTradePrecision int     `json:"contractValueTradePrecision"`
+	} `json:"instruments"`
+}
+
+// parseKrakenInstruments reads the multi-collateral perpetuals (PF_).
+func parseKrakenInstruments(body []byte) ([]Market, error) {
+	return parseKrakenInstrumentsOf(body, "flexible_futures", "PF_")
+}
+
+// parseKrakenInverseInstruments reads the inverse perpetuals (PI_), whose
+// contracts are worth contractSize USD each.
+func parseKrakenInverseInstruments(body []byte) ([]Market, error) {
+	return parseKrakenInstrumentsOf(body, "futures_inverse", "PI_")
+}
+
+func parseKrakenInstrumentsOf(body []byte, typ, prefix string) ([]Market, error) {
+	var info krakenInstruments
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+	if info.Result != "" && info.Result != "success" {
+		return nil, fmt.Errorf("kraken result %q", info.Result)
+	}
+
+	parse := krakenProductParser(prefix)
+	markets := make([]Market, 0, len(info.Instruments))
+	for _, s := range info.Instruments {
+		if s.Type != typ {
+			continue
+		}
+		base, quote, ok := parse(s.Symbol)
+		if !ok {
+			continue
+		}
+		markets = append(markets, Market{
+			Native:     s.Symbol,
+			Base:       base,
+			Quote:      quote,
+			TickSize:   s.TickSize,
+			LotSize:    decimalStep(s.ContractValueTradePrecision),
+			Multiplier: s.ContractSize,
+			Status:     marketStatus(s.Tradeable, "untradeable"),
+		})
 	}
+	return markets, nil
 }
@@ -1,6 +1,7 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -22,6 +23,9 @@ type lighterOrderBooksResponse struct {
 		MarketID   int    `json:"market_id"`
 		MarketType string `json:"market_type"`
 		Status     string `json:"status"`
+		// Decimals of sizes and prices the market accepts.
+		SizeDecimals  int `json:"supported_size_decimals"`
+		PriceDecimals int `json:"supported_price_decimals"`
 	} `json:"order_books"`
 }
 
@@ -47,13 +51,6 @@ type lighterOrderBookUpdate struct {
 	} `json:"order_book"`
 }
 
-func convertToLighterSymbol(symbol string) string {
-	if _, ok := normalizeTONSymbol(symbol); ok {
-		return "TON"
-	}
-	return ""
-}
-
 func parseLighterMarketID(channel string) int {
 	channel = strings.TrimSpace(channel)
 	if channel == "" {
@@ -79,7 +76,6 @@ func parseLighterMarketID(channel string) int {
 func parseLighterOrderBookMessage(payload []byte) (marketID int, bestBid float64, bestAsk float64, ts int64, ok bool) {
 	var msg lighterOrderBookUpdate
 	if err := json.Unmarshal(payload, &msg); err != nil {
-		log.Printf("Lighter parse error: %v", err)
 		return 0, 0, 0, 0, false
 	}
 	if msg.Type != "update/order_book" {
@@ -117,62 +113,38 @@ func parseLighterOrderBookMessage(payload []byte) (marketID int, bestBid float64
 		return 0, 0, 0, 0, false
 	}
 
-	if msg.Timestamp > 0 {
-		ts = msg.Timestamp
-	} else {
-		ts = time.Now().UnixMilli()
-	}
-
-	return marketID, bestBid, bestAsk, ts, true
+	return marketID, bestBid, bestAsk, msg.Timestamp, true
 }
 
-func fetchLighterMarketMap(baseURL string) (map[string]int, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	url := strings.TrimRight(baseURL, "/") + "/api/v1/orderBooks"
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
+// parseLighterOrderBooks reads the perpetuals of /api/v1/orderBooks. Their
+// streams are addressed by market id.
+func parseLighterOrderBooks(body []byte) ([]Market, error) {
 	var decoded lighterOrderBooksResponse
-	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+	if err := json.Unmarshal(body, &decoded); err != nil {
 		return nil, err
 	}
 
-	symbolToID := make(map[string]int)
+	markets := make([]Market, 0, len(decoded.OrderBooks))
 	for _, ob := range decoded.OrderBooks {
 		if strings.ToLower(ob.MarketType) != "perp" {
 			continue
 		}
-		if strings.ToLower(ob.Status) != "active" {
-			continue
-		}
 		s := strings.ToUpper(strings.TrimSpace(ob.Symbol))
-		if s == "" {
-			continue
-		}
-
-		base := s
-		if parts := strings.SplitN(base, "-", 2); len(parts) > 0 {
-			base = parts[0]
-		}
-		if parts := strings.SplitN(base, "/", 2); len(parts) > 0 {
-			base = parts[0]
-		}
+		base, quote, _ := strings.Cut(strings.ReplaceAll(s, "/", "-"), "-")
 		if base == "" {
 			continue
 		}
-
-		if base != "TON" {
-			continue
-		}
-
-		symbolToID[base] = ob.MarketID
-		symbolToID[s] = ob.MarketID
+		markets = append(markets, Market{
+			Native:   ob.Symbol,
+			ID:       strconv.Itoa(ob.MarketID),
+			Base:     base,
+			Quote:    quote,
+			TickSize: decimalStep(ob.PriceDecimals),
+			LotSize:  decimalStep(ob.SizeDecimals),
+			Status:   marketStatus(strings.ToLower(ob.Status) == "active", ob.Status),
+		})
 	}
-
-	return symbolToID, nil
+	return markets, nil
 }
 
 func lighterAuthToken() string {
@@ -185,45 +157,53 @@ func lighterAuthToken() string {
 	return ""
 }
 
-func ConnectLighterFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	restBaseURL := "https://mainnet.zklighter.elliot.ai"
-	wsURL := "wss://mainnet.zklighter.elliot.ai/stream"
+func ConnectLighterFutures(ctx context.Context, set *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	wsURL := EndpointsFor("lighter_futures").WS
 
-	tonSyms := filterTONSymbols(symbols)
-	if len(tonSyms) == 0 {
-		log.Printf("Lighter skipped: no TON symbol provided (symbols=%v)", symbols)
-		return
+	// Streams need market ids, which only discovery knows.
+	if Markets("lighter_futures") == nil {
+		if err := RefreshMarkets(ctx, "lighter_futures"); err != nil {
+			log.Printf("Lighter market discovery error: %v", err)
+		}
 	}
 
-	symbolToID, err := fetchLighterMarketMap(restBaseURL)
-	if err != nil {
-		log.Printf("Lighter market map fetch error: %v", err)
-		symbolToID = map[string]int{}
+	symbols := set.Symbols()
+	supported := SupportedSymbols("lighter_futures", symbols)
+	if len(supported) == 0 {
+		log.Printf("Lighter skipped: no listed market for %v", symbols)
+		return
 	}
 
 	selectedIDs := make(map[int]string)
-	id, ok := symbolToID["TON"]
-	if !ok {
-		id, ok = symbolToID["TON-USDT"]
-	}
-	if ok {
-		selectedIDs[id] = tonCanonicalSymbol
+	for _, symbol := range supported {
+		market, ok := MarketFor("lighter_futures", symbol)
+		if !ok {
+			continue
+		}
+		if id, err := strconv.Atoi(market.ID); err == nil {
+			selectedIDs[id] = symbol
+		}
 	}
 	if len(selectedIDs) == 0 {
-		log.Printf("Lighter: unable to resolve TON market id; sleeping")
-		time.Sleep(30 * time.Second)
+		log.Printf("Lighter: unable to resolve market ids for %v; sleeping", supported)
+		sleepCtx(ctx, 30*time.Second)
 		return
 	}
 
 	auth := lighterAuthToken()
 
+	stats := Stats("lighter_futures")
+
 	for {
 		headers := http.Header{}
 		headers.Set("User-Agent", "crypto-futures-arbitrage-scanner/1.0")
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, headers)
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, headers)
 		if err != nil {
 			log.Printf("Lighter connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
@@ -237,39 +217,90 @@ func ConnectLighterFutures(symbols []string, priceChan chan<- PriceData, orderbo
 		})
 
 		log.Printf("Connected to Lighter WebSocket")
+		stats.Connected()
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		subs := newSubscriptions(stats, "Lighter", func(_ int64, channel string) error {
+			return conn.WriteJSON(lighterSubscribeMessage{Type: "subscribe", Channel: channel, Auth: auth})
+		})
 
 		for id := range selectedIDs {
 			sub := lighterSubscribeMessage{Type: "subscribe", Channel: fmt.Sprintf("order_book/%d", id)}
 			if auth != "" {
 				sub.Auth = auth
 			}
+			subs.request(sub.Channel)
 			if err := conn.WriteJSON(sub); err != nil {
 				log.Printf("Lighter subscribe error (%d): %v", id, err)
+				stats.Error(err)
 			}
 		}
 
 		for {
-			_, msg, err := conn.ReadMessage()
+			msg, receivedAt, err := readFrame(conn, "lighter_futures")
 			if err != nil {
 				log.Printf("Lighter read error: %v", err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
 
+			var envelope struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(msg, &envelope); err != nil {
+				stats.ParseError()
+				continue
+			}
+			if envelope.Type != "update/order_book" {
+				lighterReply(subs, envelope.Type, msg)
+				stats.Other(envelope.Type == "connected" || envelope.Type == "ping" || envelope.Type == "pong" ||
+					envelope.Type == "error" || strings.HasPrefix(envelope.Type, "subscribed/") || strings.HasPrefix(envelope.Type, "unsubscribed/"))
+				continue
+			}
 
 			marketID, bestBid, bestAsk, ts, ok := parseLighterOrderBookMessage(msg)
 			if !ok {
+				stats.ParseError()
 				continue
 			}
+			stats.Message("order_book")
 
 			stdSymbol := selectedIDs[marketID]
 			if stdSymbol == "" {
 				continue
 			}
 
-			orderbookChan <- OrderbookData{Symbol: stdSymbol, Source: "lighter_futures", BestBid: bestBid, BestAsk: bestAsk, Timestamp: ts}
+			orderbookChan <- OrderbookData{Symbol: stdSymbol, Source: "lighter_futures", BestBid: bestBid, BestAsk: bestAsk, Timestamp: ts, ReceivedAt: receivedAt}
 		}
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
+	}
+}
+
+// lighterReply applies the answer to a subscription to subs: a
+// "subscribed/order_book" naming its channel as "order_book:7", or an
+// {"type":"error","error":{"code":..,"message":..}} that names nothing but
+// answers the oldest request.
+func lighterReply(subs *subscriptions, msgType string, frame []byte) {
+	var reply struct {
+		Channel string `json:"channel"`
+		Error   struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(frame, &reply); err != nil {
+		return
+	}
+	switch {
+	case strings.HasPrefix(msgType, "subscribed/"):
+		subs.ack(strings.Replace(reply.Channel, ":", "/", 1))
+	case msgType == "error":
+		subs.rejectNext(strconv.Itoa(reply.Error.Code), reply.Error.Message)
 	}
 }
@@ -2,7 +2,7 @@ package exchanges
 
 import "testing"
 
-func TestConvertToLighterSymbol(t *testing.T) {
+func TestLighterSymbol(t *testing.T) {
 	cases := []struct {
 		in   string
 		want string
@@ -17,8 +17,8 @@ func TestConvertToLighterSymbol(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		if got := convertToLighterSymbol(tc.in); got != tc.want {
-			t.Fatalf("convertToLighterSymbol(%q): got %q want %q", tc.in, got, tc.want)
+		if got, _ := NativeSymbol("lighter_futures", tc.in); got != tc.want {
+			t.Fatalf("NativeSymbol(lighter_futures, %q): got %q want %q", tc.in, got, tc.want)
 		}
 	}
 }
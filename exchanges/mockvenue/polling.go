@@ -0,0 +1,82 @@
+package mockvenue
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// pythProtocol is Hermes' price stream: GET ?ids[]=<feed id>..., answered
+// with server-sent events. A book is published as its mid, in units of
+// 1e-8.
+type pythProtocol struct{}
+
+const pythExpo = -8
+
+func (pythProtocol) sse() {}
+
+func (pythProtocol) connect(r *http.Request) []string {
+	return r.URL.Query()["ids[]"]
+}
+
+func (pythProtocol) handle([]byte) (sub, unsub []string, replies [][]byte) { return nil, nil, nil }
+
+func (pythProtocol) book(b Book) [][]byte {
+	price := strconv.FormatInt(int64(math.Round(mid(b)*math.Pow10(-pythExpo))), 10)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"parsed": []map[string]interface{}{{
+			"id":    b.Symbol,
+			"price": map[string]interface{}{"price": price, "conf": "0", "expo": pythExpo, "publish_time": b.Time.Unix()},
+		}},
+	})}
+}
+
+func (pythProtocol) trade(Trade) [][]byte { return nil }
+
+// dedustUSDT is the USDT jetton the DeDust connector prices TON in.
+const dedustUSDT = "EQCxE6mUtQJKFnGfaROTKOt1lZbDiiX1kCixRv7Nw2Id_sDs"
+
+// dedustProtocol is DeDust's pool list. Every book becomes a TON/USDT pool
+// priced at its mid, holding its bid side's size in TON; the connector
+// takes the deepest.
+type dedustProtocol struct{}
+
+func (dedustProtocol) poll(books []Book) []byte {
+	pools := make([]map[string]interface{}, 0, len(books))
+	for _, b := range books {
+		ton := top(b.Bids).Size
+		pools = append(pools, map[string]interface{}{
+			"assets": []map[string]interface{}{
+				{"type": "native", "metadata": map[string]int{"decimals": 9}},
+				{"type": "jetton", "address": dedustUSDT, "metadata": map[string]int{"decimals": 6}},
+			},
+			"reserves": []string{
+				strconv.FormatFloat(math.Round(ton*1e9), 'f', 0, 64),
+				strconv.FormatFloat(math.Round(ton*mid(b)*1e6), 'f', 0, 64),
+			},
+		})
+	}
+	return mustJSON(pools)
+}
+
+// variationalProtocol is Variational's metadata/stats: every listing with
+// its mark price and the quote for a 1k size.
+type variationalProtocol struct{}
+
+func (variationalProtocol) poll(books []Book) []byte {
+	listings := make([]map[string]interface{}, 0, len(books))
+	for _, b := range books {
+		listings = append(listings, map[string]interface{}{
+			"ticker":     b.Symbol,
+			"mark_price": decimal(mid(b)),
+			"quotes": map[string]interface{}{
+				"size_1k": map[string]string{"bid": decimal(top(b.Bids).Price), "ask": decimal(top(b.Asks).Price)},
+			},
+		})
+	}
+	return mustJSON(map[string]interface{}{"listings": listings})
+}
+
+func mid(b Book) float64 {
+	return (top(b.Bids).Price + top(b.Asks).Price) / 2
+}
@@ -0,0 +1,620 @@
+package mockvenue
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func top(ls []Level) Level {
+	if len(ls) == 0 {
+		return Level{}
+	}
+	return ls[0]
+}
+
+func firstN(ls []Level, n int) []Level {
+	if len(ls) > n {
+		return ls[:n]
+	}
+	return ls
+}
+
+// binanceProtocol is Binance's combined stream endpoint: streams named in
+// ?streams=, SUBSCRIBE/UNSUBSCRIBE to change them, bookTicker and aggTrade
+// payloads wrapped in {"stream","data"}.
+type binanceProtocol struct{}
+
+func binanceSymbols(streams []string) []string {
+	var out []string
+	for _, stream := range streams {
+		symbol, kind, ok := strings.Cut(stream, "@")
+		if ok && kind == "bookTicker" {
+			out = append(out, strings.ToUpper(symbol))
+		}
+	}
+	return out
+}
+
+func (binanceProtocol) connect(r *http.Request) []string {
+	return binanceSymbols(strings.Split(r.URL.Query().Get("streams"), "/"))
+}
+
+func (binanceProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Method string   `json:"method"`
+		Params []string `json:"params"`
+		ID     int64    `json:"id"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	switch req.Method {
+	case "SUBSCRIBE":
+		sub = binanceSymbols(req.Params)
+	case "UNSUBSCRIBE":
+		unsub = binanceSymbols(req.Params)
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{"result": nil, "id": req.ID})}
+}
+
+// refuse answers like Binance does to a stream it doesn't know.
+func (binanceProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]interface{}{"error": map[string]interface{}{"code": 2, "msg": "Invalid request: unknown stream"}, "id": req.ID})}
+}
+
+func (binanceProtocol) book(b Book) [][]byte {
+	bid, ask := top(b.Bids), top(b.Asks)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"stream": strings.ToLower(b.Symbol) + "@bookTicker",
+		"data": map[string]interface{}{
+			"e": "bookTicker", "E": b.Time.UnixMilli(), "T": b.Time.UnixMilli(), "s": b.Symbol,
+			"b": decimal(bid.Price), "B": decimal(bid.Size), "a": decimal(ask.Price), "A": decimal(ask.Size),
+		},
+	})}
+}
+
+func (binanceProtocol) trade(t Trade) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"stream": strings.ToLower(t.Symbol) + "@aggTrade",
+		"data": map[string]interface{}{
+			"e": "aggTrade", "E": t.Time.UnixMilli(), "s": t.Symbol, "a": t.ID,
+			"p": decimal(t.Price), "q": decimal(t.Size), "T": t.Time.UnixMilli(), "m": !t.Buy,
+		},
+	})}
+}
+
+// bybitProtocol is Bybit's v5 public stream: {"op":"subscribe","args":[
+// "orderbook.1.BTCUSDT","publicTrade.BTCUSDT"]}, answered with
+// {"success":true,"op":...}.
+type bybitProtocol struct{}
+
+func (bybitProtocol) connect(*http.Request) []string { return nil }
+
+func (bybitProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Op    string   `json:"op"`
+		Args  []string `json:"args"`
+		ReqID string   `json:"req_id"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	var symbols []string
+	for _, arg := range req.Args {
+		if symbol, ok := strings.CutPrefix(arg, "orderbook.1."); ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	switch req.Op {
+	case "subscribe":
+		sub = symbols
+	case "unsubscribe":
+		unsub = symbols
+	case "ping":
+		return nil, nil, [][]byte{mustJSON(map[string]interface{}{"success": true, "ret_msg": "pong", "op": "ping"})}
+	default:
+		return nil, nil, nil
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{"success": true, "ret_msg": "", "op": req.Op, "req_id": req.ReqID})}
+}
+
+func (bybitProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		Args  []string `json:"args"`
+		ReqID string   `json:"req_id"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"success": false, "ret_msg": "Invalid symbol :[" + strings.Join(req.Args, ",") + "]", "op": "subscribe", "req_id": req.ReqID,
+	})}
+}
+
+func (bybitProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"topic": "orderbook.1." + b.Symbol,
+		"type":  "snapshot",
+		"ts":    b.Time.UnixMilli(),
+		"data": map[string]interface{}{
+			"s": b.Symbol, "b": levels(firstN(b.Bids, 1)), "a": levels(firstN(b.Asks, 1)), "u": b.Time.UnixMilli(), "seq": b.Time.UnixMilli(),
+		},
+	})}
+}
+
+func (bybitProtocol) trade(t Trade) [][]byte {
+	side := "Sell"
+	if t.Buy {
+		side = "Buy"
+	}
+	return [][]byte{mustJSON(map[string]interface{}{
+		"topic": "publicTrade." + t.Symbol,
+		"type":  "snapshot",
+		"ts":    t.Time.UnixMilli(),
+		"data": []map[string]interface{}{{
+			"T": t.Time.UnixMilli(), "s": t.Symbol, "S": side, "v": decimal(t.Size), "p": decimal(t.Price), "i": decimal(float64(t.ID)),
+		}},
+	})}
+}
+
+// okxProtocol is OKX's v5 public endpoint: {"op":"subscribe","args":[
+// {"channel":"books5","instId":...}]}, answered per arg with
+// {"event":"subscribe","arg":...}.
+type okxProtocol struct{}
+
+func (okxProtocol) connect(*http.Request) []string { return nil }
+
+func (okxProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	if string(frame) == "ping" {
+		return nil, nil, [][]byte{[]byte("pong")}
+	}
+	var req struct {
+		Op   string `json:"op"`
+		Args []struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		} `json:"args"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil || (req.Op != "subscribe" && req.Op != "unsubscribe") {
+		return nil, nil, nil
+	}
+	for _, arg := range req.Args {
+		if arg.Channel == "books5" {
+			if req.Op == "subscribe" {
+				sub = append(sub, arg.InstID)
+			} else {
+				unsub = append(unsub, arg.InstID)
+			}
+		}
+		replies = append(replies, mustJSON(map[string]interface{}{"event": req.Op, "arg": arg, "connId": "mock"}))
+	}
+	return sub, unsub, replies
+}
+
+func (okxProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		ID   string `json:"id"`
+		Args []struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		} `json:"args"`
+	}
+	json.Unmarshal(frame, &req)
+	msg := "Wrong URL or channel"
+	if len(req.Args) > 0 {
+		msg += ":" + req.Args[0].Channel + ",instId:" + req.Args[0].InstID + " doesn't exist."
+	}
+	return [][]byte{mustJSON(map[string]interface{}{"event": "error", "code": "60018", "msg": msg, "id": req.ID, "connId": "mock"})}
+}
+
+func (okxProtocol) book(b Book) [][]byte {
+	ts := decimal(float64(b.Time.UnixMilli()))
+	return [][]byte{mustJSON(map[string]interface{}{
+		"arg": map[string]string{"channel": "books5", "instId": b.Symbol},
+		"data": []map[string]interface{}{{
+			"instId": b.Symbol, "ts": ts,
+			"bids": levels(firstN(b.Bids, 5), "0", "1"), "asks": levels(firstN(b.Asks, 5), "0", "1"),
+		}},
+	})}
+}
+
+func (okxProtocol) trade(t Trade) [][]byte {
+	side := "sell"
+	if t.Buy {
+		side = "buy"
+	}
+	return [][]byte{mustJSON(map[string]interface{}{
+		"arg": map[string]string{"channel": "trades", "instId": t.Symbol},
+		"data": []map[string]interface{}{{
+			"instId": t.Symbol, "tradeId": decimal(float64(t.ID)), "px": decimal(t.Price), "sz": decimal(t.Size),
+			"side": side, "ts": decimal(float64(t.Time.UnixMilli())),
+		}},
+	})}
+}
+
+// gateProtocol is Gate's USDT futures endpoint, futures.book_ticker only;
+// sizes are whole contracts.
+type gateProtocol struct{}
+
+func (gateProtocol) connect(*http.Request) []string { return nil }
+
+func (gateProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Time    int64    `json:"time"`
+		ID      int64    `json:"id"`
+		Channel string   `json:"channel"`
+		Event   string   `json:"event"`
+		Payload []string `json:"payload"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	if req.Channel == "futures.ping" {
+		return nil, nil, [][]byte{mustJSON(map[string]interface{}{"time": req.Time, "channel": "futures.pong", "event": ""})}
+	}
+	if req.Channel != "futures.book_ticker" {
+		return nil, nil, nil
+	}
+	switch req.Event {
+	case "subscribe":
+		sub = req.Payload
+	case "unsubscribe":
+		unsub = req.Payload
+	default:
+		return nil, nil, nil
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{
+		"time": req.Time, "id": req.ID, "channel": req.Channel, "event": req.Event, "result": map[string]string{"status": "success"},
+	})}
+}
+
+func (gateProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		Time    int64    `json:"time"`
+		ID      int64    `json:"id"`
+		Channel string   `json:"channel"`
+		Payload []string `json:"payload"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"time": req.Time, "id": req.ID, "channel": req.Channel, "event": "subscribe",
+		"error": map[string]interface{}{"code": 2, "message": "unknown contract " + strings.Join(req.Payload, ",")},
+	})}
+}
+
+func (gateProtocol) book(b Book) [][]byte {
+	bid, ask := top(b.Bids), top(b.Asks)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"time": b.Time.Unix(), "time_ms": b.Time.UnixMilli(), "channel": "futures.book_ticker", "event": "update",
+		"result": map[string]interface{}{
+			"t": b.Time.UnixMilli(), "u": b.Time.UnixMilli(), "s": b.Symbol,
+			"b": decimal(bid.Price), "B": int64(bid.Size), "a": decimal(ask.Price), "A": int64(ask.Size),
+		},
+	})}
+}
+
+func (gateProtocol) trade(Trade) [][]byte { return nil }
+
+// hyperliquidProtocol is Hyperliquid's /ws: {"method":"subscribe",
+// "subscription":{"type":"l2Book","coin":"BTC"}}, with l2Book and trades
+// channels. Coins are case-sensitive (kPEPE).
+type hyperliquidProtocol struct{}
+
+func (hyperliquidProtocol) connect(*http.Request) []string { return nil }
+
+func (hyperliquidProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Method       string `json:"method"`
+		Subscription struct {
+			Type string `json:"type"`
+			Coin string `json:"coin"`
+		} `json:"subscription"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	if req.Method == "ping" {
+		return nil, nil, [][]byte{mustJSON(map[string]string{"channel": "pong"})}
+	}
+	if req.Subscription.Type == "l2Book" {
+		switch req.Method {
+		case "subscribe":
+			sub = []string{req.Subscription.Coin}
+		case "unsubscribe":
+			unsub = []string{req.Subscription.Coin}
+		}
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{
+		"channel": "subscriptionResponse",
+		"data":    map[string]interface{}{"method": req.Method, "subscription": req.Subscription},
+	})}
+}
+
+func hyperliquidLevels(ls []Level) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, map[string]interface{}{"px": decimal(l.Price), "sz": decimal(l.Size), "n": 1})
+	}
+	return out
+}
+
+func (hyperliquidProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		Subscription json.RawMessage `json:"subscription"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]string{"channel": "error", "data": "Invalid subscription " + string(req.Subscription)})}
+}
+
+func (hyperliquidProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"channel": "l2Book",
+		"data": map[string]interface{}{
+			"coin": b.Symbol, "time": b.Time.UnixMilli(),
+			"levels": [][]map[string]interface{}{hyperliquidLevels(b.Bids), hyperliquidLevels(b.Asks)},
+		},
+	})}
+}
+
+func (hyperliquidProtocol) trade(t Trade) [][]byte {
+	side := "A"
+	if t.Buy {
+		side = "B"
+	}
+	return [][]byte{mustJSON(map[string]interface{}{
+		"channel": "trades",
+		"data": []map[string]interface{}{{
+			"coin": t.Symbol, "side": side, "px": decimal(t.Price), "sz": decimal(t.Size), "time": t.Time.UnixMilli(), "tid": t.ID,
+		}},
+	})}
+}
+
+// krakenProtocol is Kraken Futures' v1 feed: {"event":"subscribe",
+// "feed":"book","product_ids":[...]}, a book_snapshot and then one book
+// message per changed level, quantity 0 removing it.
+type krakenProtocol struct{}
+
+func (krakenProtocol) connect(*http.Request) []string { return nil }
+
+func (krakenProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Event      string   `json:"event"`
+		Feed       string   `json:"feed"`
+		ProductIDs []string `json:"product_ids"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil || req.Feed != "book" {
+		return nil, nil, nil
+	}
+	switch req.Event {
+	case "subscribe":
+		sub = req.ProductIDs
+	case "unsubscribe":
+		unsub = req.ProductIDs
+	default:
+		return nil, nil, nil
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{"event": req.Event + "d", "feed": req.Feed, "product_ids": req.ProductIDs})}
+}
+
+func krakenLevels(ls []Level) []map[string]float64 {
+	out := make([]map[string]float64, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, map[string]float64{"price": l.Price, "qty": l.Size})
+	}
+	return out
+}
+
+func (krakenProtocol) refuse([]byte) [][]byte {
+	return [][]byte{mustJSON(map[string]string{"event": "error", "message": "Invalid product id"})}
+}
+
+func (krakenProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"feed": "book_snapshot", "product_id": b.Symbol, "timestamp": b.Time.UnixMilli(), "seq": b.Time.UnixMilli(),
+		"bids": krakenLevels(b.Bids), "asks": krakenLevels(b.Asks),
+	})}
+}
+
+func (krakenProtocol) delta(prev, b Book) [][]byte {
+	var frames [][]byte
+	update := func(side string, l Level) {
+		frames = append(frames, mustJSON(map[string]interface{}{
+			"feed": "book", "product_id": b.Symbol, "side": side, "seq": b.Time.UnixMilli(),
+			"price": l.Price, "qty": l.Size, "timestamp": b.Time.UnixMilli(),
+		}))
+	}
+	diff := func(side string, prev, next []Level) {
+		was := make(map[float64]float64, len(prev))
+		for _, l := range prev {
+			was[l.Price] = l.Size
+		}
+		is := make(map[float64]bool, len(next))
+		for _, l := range next {
+			is[l.Price] = true
+			if size, ok := was[l.Price]; !ok || size != l.Size {
+				update(side, l)
+			}
+		}
+		for _, l := range prev {
+			if !is[l.Price] {
+				update(side, Level{Price: l.Price})
+			}
+		}
+	}
+	diff("buy", prev.Bids, b.Bids)
+	diff("sell", prev.Asks, b.Asks)
+	return frames
+}
+
+func (krakenProtocol) trade(Trade) [][]byte { return nil }
+
+// paradexProtocol is Paradex's JSON-RPC feed. Only markets_summary is
+// served, and it covers every market.
+type paradexProtocol struct{}
+
+func (paradexProtocol) connect(*http.Request) []string { return nil }
+
+func (paradexProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		ID     int64  `json:"id"`
+		Method string `json:"method"`
+		Params struct {
+			Channel string `json:"channel"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	if req.Params.Channel != "markets_summary" {
+		return nil, nil, [][]byte{mustJSON(map[string]interface{}{
+			"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32602, "message": "unknown channel"},
+		})}
+	}
+	switch req.Method {
+	case "subscribe":
+		sub = []string{All}
+	case "unsubscribe":
+		unsub = []string{All}
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": req.ID, "result": map[string]string{"channel": req.Params.Channel},
+	})}
+}
+
+func (paradexProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "method": "subscription",
+		"params": map[string]interface{}{
+			"channel": "markets_summary",
+			"data": map[string]interface{}{
+				"symbol": b.Symbol, "bid": decimal(top(b.Bids).Price), "ask": decimal(top(b.Asks).Price), "created_at": b.Time.UnixMilli(),
+			},
+		},
+	})}
+}
+
+func (paradexProtocol) trade(Trade) [][]byte { return nil }
+
+// lighterProtocol is Lighter's /stream: {"type":"subscribe",
+// "channel":"order_book/0"}. Its symbols are market ids.
+type lighterProtocol struct{}
+
+func (lighterProtocol) connect(*http.Request) []string { return nil }
+
+func (lighterProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Type    string `json:"type"`
+		Channel string `json:"channel"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	id, ok := strings.CutPrefix(req.Channel, "order_book/")
+	if !ok {
+		return nil, nil, nil
+	}
+	switch req.Type {
+	case "subscribe":
+		sub = []string{id}
+	case "unsubscribe":
+		unsub = []string{id}
+	default:
+		return nil, nil, nil
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]string{"type": req.Type + "d/order_book", "channel": "order_book:" + id})}
+}
+
+func lighterLevels(ls []Level) []map[string]string {
+	out := make([]map[string]string, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, map[string]string{"price": decimal(l.Price), "size": decimal(l.Size)})
+	}
+	return out
+}
+
+func (lighterProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		Channel string `json:"channel"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]interface{}{
+		"type": "error", "error": map[string]interface{}{"code": 30005, "message": "Invalid channel: " + req.Channel},
+	})}
+}
+
+func (lighterProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"channel": "order_book:" + b.Symbol, "type": "update/order_book", "timestamp": b.Time.UnixMilli(),
+		"order_book": map[string]interface{}{"asks": lighterLevels(b.Asks), "bids": lighterLevels(b.Bids)},
+	})}
+}
+
+func (lighterProtocol) trade(Trade) [][]byte { return nil }
+
+// vestProtocol is Vest's ws-api: {"method":"SUBSCRIBE","params":
+// ["TON-PERP@depth"]} and {"method":"PING"}.
+type vestProtocol struct{}
+
+func (vestProtocol) connect(*http.Request) []string { return nil }
+
+func vestSymbols(params []string) []string {
+	var out []string
+	for _, p := range params {
+		if symbol, ok := strings.CutSuffix(p, "@depth"); ok {
+			out = append(out, symbol)
+		}
+	}
+	return out
+}
+
+func (vestProtocol) handle(frame []byte) (sub, unsub []string, replies [][]byte) {
+	var req struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		ID     int64           `json:"id"`
+	}
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, nil, nil
+	}
+	var params []string
+	json.Unmarshal(req.Params, &params)
+	switch req.Method {
+	case "PING":
+		return nil, nil, [][]byte{mustJSON(map[string]interface{}{"data": "PONG"})}
+	case "SUBSCRIBE":
+		sub = vestSymbols(params)
+	case "UNSUBSCRIBE":
+		unsub = vestSymbols(params)
+	default:
+		return nil, nil, nil
+	}
+	return sub, unsub, [][]byte{mustJSON(map[string]interface{}{"result": nil, "id": req.ID})}
+}
+
+func (vestProtocol) refuse(frame []byte) [][]byte {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	json.Unmarshal(frame, &req)
+	return [][]byte{mustJSON(map[string]interface{}{"error": map[string]interface{}{"code": -1, "msg": "invalid symbol"}, "id": req.ID})}
+}
+
+func (vestProtocol) book(b Book) [][]byte {
+	return [][]byte{mustJSON(map[string]interface{}{
+		"channel": b.Symbol + "@depth",
+		"data":    map[string]interface{}{"bids": levels(b.Bids), "asks": levels(b.Asks)},
+	})}
+}
+
+func (vestProtocol) trade(Trade) [][]byte { return nil }
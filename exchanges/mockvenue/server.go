@@ -0,0 +1,504 @@
+// Package mockvenue serves fake exchange endpoints that speak a venue's
+// public market data protocol, so the connectors in package exchanges can
+// run without the venue: in tests and in simulate mode.
+//
+// A Server knows nothing about canonical symbols. It is fed books and
+// trades in the venue's own symbols and sends each connected client the
+// ones it subscribed to, framed the way the venue frames them. Venues
+// that are polled over HTTP answer with the latest book of every symbol.
+package mockvenue
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// writeWait is the deadline for a single write to a client.
+const writeWait = 5 * time.Second
+
+// Level is one price level of a book.
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// Book is a venue's book for one of its symbols, best levels first. Sizes
+// are in the venue's own units.
+type Book struct {
+	Symbol string
+	Bids   []Level
+	Asks   []Level
+	Time   time.Time
+}
+
+// Trade is a public trade on one of the venue's symbols.
+type Trade struct {
+	Symbol string
+	ID     int64
+	Price  float64
+	Size   float64
+	Buy    bool
+	Time   time.Time
+}
+
+// protocol frames a venue's messages. Symbols are the venue's own,
+// upper-cased where the venue doesn't care about case.
+type protocol interface {
+	// connect returns what a client subscribed to in its request URL.
+	connect(r *http.Request) []string
+	// handle reads a frame from a client and returns the symbols it
+	// subscribed to and unsubscribed from and the frames to answer with.
+	handle(frame []byte) (sub, unsub []string, replies [][]byte)
+	book(b Book) [][]byte
+	trade(t Trade) [][]byte
+}
+
+// deltaProtocol is a protocol that sends a snapshot first and then only
+// the levels that changed since the book a client last got.
+type deltaProtocol interface {
+	protocol
+	delta(prev, b Book) [][]byte
+}
+
+// pollProtocol is served over plain HTTP: every request is answered with
+// the latest book of every symbol.
+type pollProtocol interface {
+	poll(books []Book) []byte
+}
+
+// refusingProtocol answers a request that subscribes to an unlisted
+// symbol with the venue's error, refusing the whole request.
+type refusingProtocol interface {
+	refuse(frame []byte) [][]byte
+}
+
+// sseProtocol streams its frames as server-sent events rather than over a
+// websocket.
+type sseProtocol interface {
+	protocol
+	sse()
+}
+
+// All in a client's subscriptions stands for every symbol, for venues
+// whose channels cover the whole market.
+const All = "*"
+
+var protocols = map[string]func() interface{}{
+	"binance":     func() interface{} { return binanceProtocol{} },
+	"bybit":       func() interface{} { return bybitProtocol{} },
+	"okx":         func() interface{} { return okxProtocol{} },
+	"gate":        func() interface{} { return gateProtocol{} },
+	"hyperliquid": func() interface{} { return hyperliquidProtocol{} },
+	"kraken":      func() interface{} { return krakenProtocol{} },
+	"paradex":     func() interface{} { return paradexProtocol{} },
+	"lighter":     func() interface{} { return lighterProtocol{} },
+	"vest":        func() interface{} { return vestProtocol{} },
+	"pyth":        func() interface{} { return pythProtocol{} },
+	"dedust":      func() interface{} { return dedustProtocol{} },
+	"variational": func() interface{} { return variationalProtocol{} },
+}
+
+// Protocols returns the protocols New accepts, sorted.
+func Protocols() []string {
+	out := make([]string, 0, len(protocols))
+	for name := range protocols {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Server is a fake venue endpoint.
+type Server struct {
+	name     string
+	proto    interface{}
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	clients  map[*client]bool
+	books    map[string]Book
+	unlisted map[string]bool
+	down     bool
+	listener net.Listener
+	http     *http.Server
+}
+
+// client is a streaming connection, over a websocket or as server-sent
+// events.
+type client struct {
+	mu    sync.Mutex // serializes writes
+	send  func(frame []byte) error
+	close func()
+
+	// guarded by Server.mu
+	subs map[string]bool
+	sent map[string]Book
+}
+
+// New returns a server speaking protocol, one of Protocols.
+func New(protocol string) (*Server, error) {
+	mk, ok := protocols[protocol]
+	if !ok {
+		return nil, fmt.Errorf("mockvenue: unknown protocol %q (known: %s)", protocol, strings.Join(Protocols(), ", "))
+	}
+	return &Server{
+		name:     protocol,
+		proto:    mk(),
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		clients:  make(map[*client]bool),
+		books:    make(map[string]Book),
+		unlisted: make(map[string]bool),
+	}, nil
+}
+
+// Start listens on addr, e.g. "127.0.0.1:0", and serves until Close.
+func (s *Server) Start(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = lis
+	s.http = &http.Server{Handler: s}
+	s.mu.Unlock()
+
+	go func() {
+		if err := s.http.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Mock %s server: %v", s.name, err)
+		}
+	}()
+	return nil
+}
+
+// URL returns the base URL of a started server: ws:// for websocket
+// protocols, http:// for the rest.
+func (s *Server) URL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	scheme := "ws://"
+	if s.overHTTP() {
+		scheme = "http://"
+	}
+	return scheme + s.listener.Addr().String()
+}
+
+func (s *Server) overHTTP() bool {
+	switch s.proto.(type) {
+	case pollProtocol, sseProtocol:
+		return true
+	}
+	return false
+}
+
+// Close stops the server and drops every client.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	srv := s.http
+	s.mu.Unlock()
+	s.dropClients()
+	if srv == nil {
+		return nil
+	}
+	return srv.Close()
+}
+
+// SetDown takes the venue down, dropping its clients and refusing new
+// ones, or brings it back up.
+func (s *Server) SetDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+	if down {
+		s.dropClients()
+	}
+}
+
+// Unlist makes the venue refuse subscriptions to symbols from now on, as
+// for a market it doesn't list, where its protocol has an error to refuse
+// them with. Existing subscriptions are kept.
+func (s *Server) Unlist(symbols ...string) {
+	s.mu.Lock()
+	for _, symbol := range symbols {
+		s.unlisted[symbol] = true
+	}
+	s.mu.Unlock()
+}
+
+// List takes back Unlist for symbols.
+func (s *Server) List(symbols ...string) {
+	s.mu.Lock()
+	for _, symbol := range symbols {
+		delete(s.unlisted, symbol)
+	}
+	s.mu.Unlock()
+}
+
+// DropClients closes every client connection, as a venue does when it
+// restarts; clients may connect again at once.
+func (s *Server) DropClients() {
+	s.dropClients()
+}
+
+func (s *Server) dropClients() {
+	s.mu.Lock()
+	clients := s.clients
+	s.clients = make(map[*client]bool)
+	s.mu.Unlock()
+	for c := range clients {
+		c.close()
+	}
+}
+
+// Clients returns how many clients are connected.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Subscriptions returns every symbol some client is subscribed to, sorted.
+// All means a client takes every symbol.
+func (s *Server) Subscriptions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	for c := range s.clients {
+		for symbol := range c.subs {
+			seen[symbol] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for symbol := range seen {
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// PublishBook sends b to the clients subscribed to its symbol. Polled
+// venues answer with the latest book of each symbol.
+func (s *Server) PublishBook(b Book) {
+	if b.Time.IsZero() {
+		b.Time = time.Now()
+	}
+	s.mu.Lock()
+	s.books[b.Symbol] = b
+	s.mu.Unlock()
+
+	stream, ok := s.proto.(protocol)
+	if !ok {
+		return
+	}
+	delta, _ := s.proto.(deltaProtocol)
+	for _, c := range s.subscribers(b.Symbol) {
+		s.mu.Lock()
+		prev, sent := c.sent[b.Symbol]
+		c.sent[b.Symbol] = b
+		s.mu.Unlock()
+
+		frames := stream.book(b)
+		if delta != nil && sent {
+			frames = delta.delta(prev, b)
+		}
+		if err := c.write(frames...); err != nil {
+			s.remove(c)
+		}
+	}
+}
+
+// PublishTrade sends t to the clients subscribed to its symbol.
+func (s *Server) PublishTrade(t Trade) {
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+	stream, ok := s.proto.(protocol)
+	if !ok {
+		return
+	}
+	frames := stream.trade(t)
+	if len(frames) == 0 {
+		return
+	}
+	for _, c := range s.subscribers(t.Symbol) {
+		if err := c.write(frames...); err != nil {
+			s.remove(c)
+		}
+	}
+}
+
+func (s *Server) subscribers(symbol string) []*client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*client
+	for c := range s.clients {
+		if c.subs[symbol] || c.subs[All] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ServeHTTP serves a request the way the venue would: a websocket, an
+// event stream or a poll.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	down := s.down
+	s.mu.Unlock()
+	if down {
+		http.Error(w, "venue down", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch p := s.proto.(type) {
+	case pollProtocol:
+		s.servePoll(w, p)
+	case sseProtocol:
+		s.serveEvents(w, r, p)
+	case protocol:
+		s.serveWebSocket(w, r, p)
+	}
+}
+
+func (s *Server) servePoll(w http.ResponseWriter, p pollProtocol) {
+	s.mu.Lock()
+	books := make([]Book, 0, len(s.books))
+	for _, b := range s.books {
+		books = append(books, b)
+	}
+	s.mu.Unlock()
+	sort.Slice(books, func(i, j int) bool { return books[i].Symbol < books[j].Symbol })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(p.poll(books))
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request, p sseProtocol) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	done := make(chan struct{})
+	var once sync.Once
+	c := s.add(p.connect(r), func(frame []byte) error {
+		select {
+		case <-done:
+			return net.ErrClosed
+		default:
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", frame); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}, func() { once.Do(func() { close(done) }) })
+
+	select {
+	case <-done:
+	case <-r.Context().Done():
+	}
+	s.remove(c)
+	// w must not be written once we return; wait out a write in progress.
+	c.mu.Lock()
+	c.mu.Unlock()
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, p protocol) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := s.add(p.connect(r), func(frame []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return conn.WriteMessage(websocket.TextMessage, frame)
+	}, func() { conn.Close() })
+	defer s.remove(c)
+
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		sub, unsub, replies := p.handle(frame)
+		s.mu.Lock()
+		if r, ok := p.(refusingProtocol); ok {
+			for _, symbol := range sub {
+				if s.unlisted[symbol] {
+					sub, replies = nil, r.refuse(frame)
+					break
+				}
+			}
+		}
+		for _, symbol := range sub {
+			c.subs[symbol] = true
+		}
+		for _, symbol := range unsub {
+			delete(c.subs, symbol)
+			delete(c.sent, symbol)
+		}
+		s.mu.Unlock()
+		if err := c.write(replies...); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) add(subs []string, send func([]byte) error, close func()) *client {
+	c := &client{send: send, close: close, subs: make(map[string]bool), sent: make(map[string]Book)}
+	for _, symbol := range subs {
+		c.subs[symbol] = true
+	}
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	return c
+}
+
+func (s *Server) remove(c *client) {
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+	c.close()
+}
+
+func (c *client) write(frames ...[]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range frames {
+		if err := c.send(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decimal formats a price or size the way venues send them, as a string.
+func decimal(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// levels formats book levels as [["price","size"], ...].
+func levels(ls []Level, extra ...string) [][]string {
+	out := make([][]string, 0, len(ls))
+	for _, l := range ls {
+		out = append(out, append([]string{decimal(l.Price), decimal(l.Size)}, extra...))
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package exchanges
+
+import (
+	"strconv"
+	"strings"
+)
+
+// marketUnits returns the scale of venue's base and its contract
+// multiplier for a canonical symbol, both 1 unless discovery says
+// otherwise, and whether the venue's contracts are inverse.
+func marketUnits(venue, symbol string) (scale, multiplier float64, inverse bool) {
+	scale, multiplier = 1, 1
+
+	instrumentsMutex.RLock()
+	defer instrumentsMutex.RUnlock()
+	if r, ok := venueRules[venue]; ok {
+		inverse = r.kind == Inverse
+	}
+	ms := venueMarkets[venue]
+	if ms == nil {
+		return scale, multiplier, inverse
+	}
+	if m, ok := ms.bySymbol[symbol]; ok {
+		if m.Scale > 0 {
+			scale = m.Scale
+		}
+		if m.Multiplier > 0 {
+			multiplier = m.Multiplier
+		}
+		inverse = m.Kind == Inverse
+	}
+	return scale, multiplier, inverse
+}
+
+// baseSize turns a size in contracts at price into base asset. Linear
+// contracts are multiplier base units each; inverse ones are worth
+// multiplier in quote currency, so the base they stand for depends on
+// the price.
+func baseSize(size, price, scale, multiplier float64, inverse bool) float64 {
+	if !inverse {
+		return size * multiplier * scale
+	}
+	if price <= 0 {
+		return 0
+	}
+	return size * multiplier / price
+}
+
+// NormalizePrice turns a venue's price into the price of one unit of base
+// asset.
+func NormalizePrice(p PriceData) PriceData {
+	scale, _, _ := marketUnits(p.Source, p.Symbol)
+	p.Price /= scale
+	return p
+}
+
+// NormalizeOrderbook turns a venue's top of book into prices per unit of
+// base asset and sizes in base asset.
+func NormalizeOrderbook(o OrderbookData) OrderbookData {
+	scale, multiplier, inverse := marketUnits(o.Source, o.Symbol)
+	o.BestBid /= scale
+	o.BestAsk /= scale
+	o.BidSize = baseSize(o.BidSize, o.BestBid, scale, multiplier, inverse)
+	o.AskSize = baseSize(o.AskSize, o.BestAsk, scale, multiplier, inverse)
+	return o
+}
+
+// NormalizeTrade turns a venue's trade into a price per unit of base asset
+// and a quantity in base asset.
+func NormalizeTrade(t TradeData) TradeData {
+	scale, multiplier, inverse := marketUnits(t.Source, t.Symbol)
+	t.Price /= scale
+	t.Quantity = baseSize(t.Quantity, t.Price, scale, multiplier, inverse)
+	return t
+}
+
+// parseSize reads a size sent as a string. Sizes are informational, so one
+// that doesn't parse is reported as unknown, 0.
+func parseSize(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// levelSize is the size of a [price, size, ...] book level.
+func levelSize(level []string) float64 {
+	if len(level) < 2 {
+		return 0
+	}
+	return parseSize(level[1])
+}
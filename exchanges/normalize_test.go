@@ -0,0 +1,82 @@
+package exchanges
+
+import "testing"
+
+func TestNormalizeSymbol(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "1000PEPEUSDT", want: "PEPEUSDT"},
+		{in: "1000000MOGUSDT", want: "MOGUSDT"},
+		{in: "1INCHUSDT", want: "1INCHUSDT"},
+		{in: "100XUSDT", want: "100XUSDT"},
+		{in: "BTCUSDT", want: "BTCUSDT"},
+		{in: "TON", want: "TON"},
+	}
+
+	for _, tc := range cases {
+		if got := NormalizeSymbol(tc.in); got != tc.want {
+			t.Fatalf("NormalizeSymbol(%q): got %q want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeUnits(t *testing.T) {
+	defer SetMarkets("okx_futures", nil)
+	defer SetMarkets("hyperliquid_futures", nil)
+
+	SetMarkets("okx_futures", []Market{
+		{Native: "BTC-USDT-SWAP", Base: "BTC", Quote: "USDT", Multiplier: 0.01, Status: MarketTrading},
+	})
+	SetMarkets("hyperliquid_futures", []Market{
+		{Native: "kPEPE", Base: "kPEPE", Quote: "USD", Status: MarketTrading},
+	})
+
+	ob := NormalizeOrderbook(OrderbookData{Symbol: "BTCUSDT", Source: "okx_futures", BestBid: 60000, BestAsk: 60001, BidSize: 250, AskSize: 3})
+	if ob.BestBid != 60000 || ob.BidSize != 2.5 || ob.AskSize != 0.03 {
+		t.Fatalf("okx contracts: got %+v", ob)
+	}
+
+	tr := NormalizeTrade(TradeData{Symbol: "PEPEUSDT", Source: "hyperliquid_futures", Price: 0.012, Quantity: 50})
+	if tr.Price != 0.000012 || tr.Quantity != 50000 {
+		t.Fatalf("hyperliquid kPEPE: got %+v", tr)
+	}
+	if p := NormalizePrice(PriceData{Symbol: "PEPEUSDT", Source: "hyperliquid_futures", Price: 0.012}); p.Price != 0.000012 {
+		t.Fatalf("hyperliquid kPEPE price: got %v", p.Price)
+	}
+
+	// OKX's inverse swaps are 100 USD a contract: 30 of them at 60000 are
+	// 0.05 BTC.
+	defer SetMarkets("okx_inverse", nil)
+	SetMarkets("okx_inverse", []Market{
+		{Native: "BTC-USD-SWAP", Base: "BTC", Quote: "USD", Multiplier: 100, Status: MarketTrading},
+	})
+	if tr := NormalizeTrade(TradeData{Symbol: "BTCUSDT", Source: "okx_inverse", Price: 60000, Quantity: 30}); tr.Price != 60000 || tr.Quantity != 0.05 {
+		t.Fatalf("okx inverse: got %+v", tr)
+	}
+
+	// Without market metadata the venue's units are taken as they are.
+	if tr := NormalizeTrade(TradeData{Symbol: "BTCUSDT", Source: "gate_futures", Price: 60000, Quantity: 7}); tr.Price != 60000 || tr.Quantity != 7 {
+		t.Fatalf("gate without metadata: got %+v", tr)
+	}
+}
+
+// TestScaledSymbolWithoutDiscovery expects a size prefix to stay in the
+// symbol when no markets say what it scales, so a per-1000 quote is neither
+// divided by a guess nor reported as the per-coin symbol.
+func TestScaledSymbolWithoutDiscovery(t *testing.T) {
+	SetMarkets("binance_futures", nil)
+
+	symbol, ok := CanonicalSymbol("binance_futures", "1000PEPEUSDT")
+	if !ok || symbol != "1000PEPEUSDT" {
+		t.Fatalf("CanonicalSymbol: got %q, %v want 1000PEPEUSDT", symbol, ok)
+	}
+	if native, _ := NativeSymbol("binance_futures", symbol); native != "1000PEPEUSDT" {
+		t.Fatalf("NativeSymbol(%q): got %q want 1000PEPEUSDT", symbol, native)
+	}
+	ob := NormalizeOrderbook(OrderbookData{Symbol: symbol, Source: "binance_futures", BestBid: 0.0123, BestAsk: 0.0124})
+	if ob.Symbol != "1000PEPEUSDT" || ob.BestBid != 0.0123 {
+		t.Fatalf("orderbook: got %+v, want the per-1000 quote under 1000PEPEUSDT", ob)
+	}
+}
@@ -1,6 +1,7 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -40,6 +41,7 @@ type OKXFuturesOrderbook struct {
 }
 
 type OKXSubscribeMessage struct {
+	ID   string `json:"id,omitempty"`
 	Op   string `json:"op"`
 	Args []struct {
 		Channel string `json:"channel"`
@@ -47,66 +49,79 @@ type OKXSubscribeMessage struct {
 	} `json:"args"`
 }
 
-func ConnectOKXFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://ws.okx.com:8443/ws/v5/public"
+func ConnectOKXFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectOKXSwaps(ctx, "okx_futures", "OKX", symbols, orderbookChan, tradeChan)
+}
 
-	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
-		if err != nil {
-			log.Printf("OKX connection error: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
+// ConnectOKXInverse streams OKX's coin-margined swaps (BTC-USD-SWAP).
+func ConnectOKXInverse(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectOKXSwaps(ctx, "okx_inverse", "OKX inverse", symbols, orderbookChan, tradeChan)
+}
 
-		log.Printf("Connected to OKX futures WebSocket")
+// connectOKXSwaps streams the swaps of venue from OKX's public channels.
+func connectOKXSwaps(ctx context.Context, venue, label string, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	connectSharded(ctx, venue, label, symbols, func(ctx context.Context, label string, stats *SourceStats, symbols *SymbolSet) {
+		connectOKXSwapsShard(ctx, venue, label, stats, symbols, orderbookChan, tradeChan)
+	})
+}
 
-		// Subscribe to both trades and orderbooks for all symbols
-		var subscribeArgs []struct {
-			Channel string `json:"channel"`
-			InstID  string `json:"instId"`
+// connectOKXSwapsShard streams one shard's swaps.
+func connectOKXSwapsShard(ctx context.Context, venue, label string, stats *SourceStats, symbols *SymbolSet, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	for {
+		current := waitForSymbols(ctx, symbols)
+		if current == nil {
+			return
 		}
 
-		for _, symbol := range symbols {
-			// Convert symbol format (BTCUSDT -> BTC-USDT-SWAP for perpetual futures)
-			okxSymbol := convertToOKXSymbol(symbol)
-
-			// Subscribe to trades
-			subscribeArgs = append(subscribeArgs, struct {
-				Channel string `json:"channel"`
-				InstID  string `json:"instId"`
-			}{
-				Channel: "trades",
-				InstID:  okxSymbol,
-			})
-
-			// Subscribe to orderbooks (books5 for top 5 levels)
-			subscribeArgs = append(subscribeArgs, struct {
-				Channel string `json:"channel"`
-				InstID  string `json:"instId"`
-			}{
-				Channel: "books5",
-				InstID:  okxSymbol,
-			})
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor(venue).WS, nil)
+		if err != nil {
+			log.Printf("%s connection error: %v", label, err)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
+			continue
 		}
 
-		subscribeMsg := OKXSubscribeMessage{
-			Op:   "subscribe",
-			Args: subscribeArgs,
-		}
+		log.Printf("Connected to %s futures WebSocket", label)
+		stats.Connected()
+		subs := newSubscriptions(stats, label, func(id int64, channel string) error {
+			return conn.WriteJSON(okxSubscribeMessage("subscribe", []string{channel}, id))
+		})
 
-		err = conn.WriteJSON(subscribeMsg)
+		// Subscribe to both trades and orderbooks for all symbols
+		err = okxSubscribe(conn, subs, venue, "subscribe", current)
 		if err != nil {
-			log.Printf("OKX subscription error: %v", err)
+			log.Printf("%s subscription error: %v", label, err)
+			stats.Error(err)
+			stats.Disconnected()
 			conn.Close()
-			time.Sleep(5 * time.Second)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+		stopFollow := followSymbols(ctx, symbols, current, conn, func(added, removed []string) error {
+			return subs.write(func() error {
+				if len(removed) > 0 {
+					if err := okxSubscribe(conn, subs, venue, "unsubscribe", removed); err != nil {
+						return err
+					}
+				}
+				if len(added) > 0 {
+					return okxSubscribe(conn, subs, venue, "subscribe", added)
+				}
+				return nil
+			})
+		})
 
 		for {
-			var message json.RawMessage
-			err := conn.ReadJSON(&message)
+			message, receivedAt, err := readFrame(conn, venue)
 			if err != nil {
-				log.Printf("OKX read error: %v", err)
+				log.Printf("%s read error: %v", label, err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
@@ -114,28 +129,34 @@ func ConnectOKXFutures(symbols []string, priceChan chan<- PriceData, orderbookCh
 			// Check if it's a trade message
 			var tradeMsg OKXFuturesTrade
 			if err := json.Unmarshal(message, &tradeMsg); err == nil && tradeMsg.Arg.Channel == "trades" && len(tradeMsg.Data) > 0 {
+				stats.Message("trades")
 				for _, trade := range tradeMsg.Data {
 					price, err := strconv.ParseFloat(trade.Price, 64)
 					if err != nil {
+						stats.ParseError()
 						continue
 					}
 
 					// Convert timestamp from string to int64
 					timestamp, err := strconv.ParseInt(trade.Timestamp, 10, 64)
 					if err != nil {
-						timestamp = time.Now().UnixMilli()
+						timestamp = 0
 					}
 
 					// Convert OKX symbol back to standard format
-					standardSymbol := convertFromOKXSymbol(trade.InstID)
+					standardSymbol, ok := CanonicalSymbol(venue, trade.InstID)
+					if !ok {
+						continue
+					}
 
 					tradeData := TradeData{
-						Symbol:    standardSymbol,
-						Source:    "okx_futures",
-						Price:     price,
-						Quantity:  trade.Size,
-						Side:      trade.Side, // OKX already provides "buy" or "sell"
-						Timestamp: timestamp,
+						Symbol:     standardSymbol,
+						Source:     venue,
+						Price:      price,
+						Quantity:   parseSize(trade.Size),
+						Side:       trade.Side, // OKX already provides "buy" or "sell"
+						Timestamp:  timestamp,
+						ReceivedAt: receivedAt,
 					}
 
 					tradeChan <- tradeData
@@ -146,6 +167,7 @@ func ConnectOKXFutures(symbols []string, priceChan chan<- PriceData, orderbookCh
 			// Check if it's an orderbook message
 			var orderbookMsg OKXFuturesOrderbook
 			if err := json.Unmarshal(message, &orderbookMsg); err == nil && orderbookMsg.Arg.Channel == "books5" && len(orderbookMsg.Data) > 0 {
+				stats.Message("books5")
 				for _, book := range orderbookMsg.Data {
 					if len(book.Bids) == 0 || len(book.Asks) == 0 {
 						continue
@@ -155,85 +177,175 @@ func ConnectOKXFutures(symbols []string, priceChan chan<- PriceData, orderbookCh
 					bestBid, err1 := strconv.ParseFloat(book.Bids[0][0], 64)
 					bestAsk, err2 := strconv.ParseFloat(book.Asks[0][0], 64)
 					if err1 != nil || err2 != nil {
+						stats.ParseError()
 						continue
 					}
 
 					// Convert timestamp from string to int64
 					timestamp, err := strconv.ParseInt(book.Timestamp, 10, 64)
 					if err != nil {
-						timestamp = time.Now().UnixMilli()
+						timestamp = 0
 					}
 
 					// Convert OKX symbol back to standard format
-					standardSymbol := convertFromOKXSymbol(book.InstID)
+					standardSymbol, ok := CanonicalSymbol(venue, book.InstID)
+					if !ok {
+						continue
+					}
 
 					orderbookData := OrderbookData{
-						Symbol:    standardSymbol,
-						Source:    "okx_futures",
-						BestBid:   bestBid,
-						BestAsk:   bestAsk,
-						Timestamp: timestamp,
+						Symbol:     standardSymbol,
+						Source:     venue,
+						BestBid:    bestBid,
+						BestAsk:    bestAsk,
+						BidSize:    levelSize(book.Bids[0]),
+						AskSize:    levelSize(book.Asks[0]),
+						Timestamp:  timestamp,
+						ReceivedAt: receivedAt,
 					}
 
 					orderbookChan <- orderbookData
 				}
 				continue
 			}
+
+			okxReply(subs, message)
+			stats.Other(string(message) == "pong" || hasField(message, "event"))
 		}
+		stopFollow()
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
 
-// convertToOKXSymbol converts standard symbol format to OKX format
-// BTCUSDT -> BTC-USDT-SWAP (for perpetual futures)
-func convertToOKXSymbol(symbol string) string {
-	// Handle common symbols
-	switch symbol {
-	case "BTCUSDT":
-		return "BTC-USDT-SWAP"
-	case "ETHUSDT":
-		return "ETH-USDT-SWAP"
-	case "ADAUSDT":
-		return "ADA-USDT-SWAP"
-	case "SOLUSDT":
-		return "SOL-USDT-SWAP"
-	case "DOTUSDT":
-		return "DOT-USDT-SWAP"
-	case "LINKUSDT":
-		return "LINK-USDT-SWAP"
-	case "AVAXUSDT":
-		return "AVAX-USDT-SWAP"
-	case "MATICUSDT":
-		return "MATIC-USDT-SWAP"
-	case "UNIUSDT":
-		return "UNI-USDT-SWAP"
-	case "LTCUSDT":
-		return "LTC-USDT-SWAP"
-	case "BCHUSDT":
-		return "BCH-USDT-SWAP"
-	case "XRPUSDT":
-		return "XRP-USDT-SWAP"
-	default:
-		// Generic conversion for other symbols
-		if strings.HasSuffix(symbol, "USDT") {
-			base := strings.TrimSuffix(symbol, "USDT")
-			return fmt.Sprintf("%s-USDT-SWAP", base)
+// okxSubscribe sends op ("subscribe" or "unsubscribe") for the trades and
+// books5 channels of the symbols venue trades, a request per symbol so that
+// one the venue rejects doesn't take the others with it. Channels are
+// tracked as "books5:BTC-USDT-SWAP".
+func okxSubscribe(conn *websocket.Conn, subs *subscriptions, venue, op string, symbols []string) error {
+	for _, symbol := range symbols {
+		// Convert symbol format (BTCUSDT -> BTC-USDT-SWAP for perpetual futures)
+		okxSymbol, ok := NativeSymbol(venue, symbol)
+		if !ok {
+			continue
+		}
+		channels := []string{"trades:" + okxSymbol, "books5:" + okxSymbol}
+		var id int64
+		if op == "subscribe" {
+			id = subs.request(channels...)
+		} else {
+			subs.unsubscribed(channels...)
+		}
+		if err := conn.WriteJSON(okxSubscribeMessage(op, channels, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// okxSubscribeMessage builds op for channels named "channel:instId".
+func okxSubscribeMessage(op string, channels []string, id int64) OKXSubscribeMessage {
+	msg := OKXSubscribeMessage{Op: op}
+	if id != 0 {
+		msg.ID = strconv.FormatInt(id, 10)
+	}
+	for _, ch := range channels {
+		channel, instID, _ := strings.Cut(ch, ":")
+		msg.Args = append(msg.Args, struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		}{Channel: channel, InstID: instID})
+	}
+	return msg
+}
+
+// okxReply applies an answer to a subscribe request to subs: an
+// {"event":"subscribe","arg":{...}} per channel, or {"event":"error",
+// "code":"60018","msg":...,"id":"3"} for the whole request.
+func okxReply(subs *subscriptions, frame []byte) {
+	var reply struct {
+		Event string `json:"event"`
+		ID    string `json:"id"`
+		Code  string `json:"code"`
+		Msg   string `json:"msg"`
+		Arg   struct {
+			Channel string `json:"channel"`
+			InstID  string `json:"instId"`
+		} `json:"arg"`
+	}
+	if err := json.Unmarshal(frame, &reply); err != nil {
+		return
+	}
+	switch reply.Event {
+	case "subscribe":
+		subs.ack(reply.Arg.Channel + ":" + reply.Arg.InstID)
+	case "error":
+		if id, err := strconv.ParseInt(reply.ID, 10, 64); err == nil {
+			subs.rejectRequest(id, reply.Code, reply.Msg)
+		} else {
+			subs.rejectNext(reply.Code, reply.Msg)
 		}
-		return symbol + "-SWAP"
 	}
 }
 
-// convertFromOKXSymbol converts OKX symbol format back to standard format
-// BTC-USDT-SWAP -> BTCUSDT
-func convertFromOKXSymbol(okxSymbol string) string {
-	// Remove -SWAP suffix and convert to standard format
-	if strings.HasSuffix(okxSymbol, "-SWAP") {
-		withoutSwap := strings.TrimSuffix(okxSymbol, "-SWAP")
-		parts := strings.Split(withoutSwap, "-")
-		if len(parts) == 2 {
-			return parts[0] + parts[1]
+// okxInstruments is the response of /api/v5/public/instruments.
+type okxInstruments struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstID string `json:"instId"`
+		CtType string `json:"ctType"`
+		CtVal  string `json:"ctVal"`
+		Uly    string `json:"uly"`
+		TickSz string `json:"tickSz"`
+		LotSz  string `json:"lotSz"`
+		State  string `json:"state"`
+	} `json:"data"`
+}
+
+// parseOKXInstruments reads linear swaps. Sizes are in contracts of ctVal
+// base units each.
+func parseOKXInstruments(body []byte) ([]Market, error) {
+	return parseOKXSwaps(body, "linear")
+}
+
+// parseOKXInverseInstruments reads inverse swaps, whose contracts are worth
+// ctVal USD each.
+func parseOKXInverseInstruments(body []byte) ([]Market, error) {
+	return parseOKXSwaps(body, "inverse")
+}
+
+func parseOKXSwaps(body []byte, ctType string) ([]Market, error) {
+	var info okxInstruments
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+	if info.Code != "" && info.Code != "0" {
+		return nil, fmt.Errorf("okx error %s: %s", info.Code, info.Msg)
+	}
+
+	markets := make([]Market, 0, len(info.Data))
+	for _, s := range info.Data {
+		if s.CtType != ctType {
+			continue
+		}
+		base, quote, ok := strings.Cut(s.Uly, "-")
+		if !ok {
+			continue
 		}
+		markets = append(markets, Market{
+			Native:     s.InstID,
+			Base:       base,
+			Quote:      quote,
+			TickSize:   parseSize(s.TickSz),
+			LotSize:    parseSize(s.LotSz),
+			Multiplier: parseSize(s.CtVal),
+			Status:     marketStatus(s.State == "live", s.State),
+		})
 	}
-	return okxSymbol
+	return markets, nil
 }
@@ -1,10 +1,10 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -24,6 +24,10 @@ type ParadexWSResponse struct {
 		Channel string `json:"channel"`
 		Status  string `json:"status"`
 	} `json:"result,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
 }
 
 type ParadexTradeEvent struct {
@@ -49,63 +53,77 @@ type ParadexMarketSummaryEvent struct {
 	Params  struct {
 		Channel string `json:"channel"`
 		Data    struct {
-			Symbol string `json:"symbol"`
-			Bid    string `json:"bid"`
-			Ask    string `json:"ask"`
+			Symbol    string `json:"symbol"`
+			Bid       string `json:"bid"`
+			Ask       string `json:"ask"`
+			CreatedAt int64  `json:"created_at"`
 		} `json:"data"`
 	} `json:"params"`
 }
 
-func ConnectParadexFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	supported := false
-	for _, sym := range symbols {
-		if convertToParadexSymbol(sym) != "" {
-			supported = true
-			break
-		}
-	}
-	if !supported {
-		log.Printf("Paradex: no supported markets for requested symbols (%v); disabling", symbols)
-		return
-	}
-
-	wsURL := "wss://ws.api.prod.paradex.trade/v1"
+func ConnectParadexFutures(ctx context.Context, symbols *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	stats := Stats("paradex_futures")
 
 	for {
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		current, changed := symbols.Watch()
+		if !paradexSupports(current) {
+			log.Printf("Paradex: no supported markets for requested symbols (%v); waiting for a symbol change", current)
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			continue
+		}
+
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, EndpointsFor("paradex_futures").WS, nil)
 		if err != nil {
 			log.Printf("Paradex connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
 		log.Printf("Connected to Paradex futures WebSocket")
+		stats.Connected()
+		subs := newSubscriptions(stats, "Paradex", func(id int64, channel string) error {
+			return conn.WriteJSON(paradexSubscribeRequest(channel, id))
+		})
 
 		// Subscribe to markets_summary channel (provides bid/ask for all markets)
-
-		subscribeReq := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"method":  "subscribe",
-			"params": map[string]interface{}{
-				"channel": "markets_summary",
-			},
-			"id": 1,
-		}
-
-		if err := conn.WriteJSON(subscribeReq); err != nil {
+		if err := conn.WriteJSON(paradexSubscribeRequest("markets_summary", subs.request("markets_summary"))); err != nil {
+			log.Printf("Paradex subscription error: %v", err)
+			stats.Error(err)
+			stats.Disconnected()
+			conn.Close()
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
+			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
 
 		// Read messages
 		for {
-			_, message, err := conn.ReadMessage()
+			message, receivedAt, err := readFrame(conn, "paradex_futures")
 			if err != nil {
 				log.Printf("Paradex read error: %v", err)
+				stats.Error(err)
+				stats.Disconnected()
 				break
 			}
 
 			// Try to parse as subscription response first
 			var subResponse ParadexWSResponse
 			if err := json.Unmarshal(message, &subResponse); err == nil && subResponse.Result.Channel == "markets_summary" {
+				stats.Message("subscribe")
+				subs.acked(subResponse.ID)
+				continue
+			}
+			if subResponse.Error != nil && subs.rejectRequest(subResponse.ID, strconv.Itoa(subResponse.Error.Code), subResponse.Error.Message) {
+				stats.Message("subscribe")
 				continue
 			}
 
@@ -113,72 +131,109 @@ func ConnectParadexFutures(symbols []string, priceChan chan<- PriceData, orderbo
 			var marketEvent ParadexMarketSummaryEvent
 			if err := json.Unmarshal(message, &marketEvent); err == nil &&
 				marketEvent.Method == "subscription" && marketEvent.Params.Channel == "markets_summary" {
+				stats.Message("markets_summary")
 
-				symbol := convertFromParadexSymbol(marketEvent.Params.Data.Symbol)
-				if symbol == "" {
+				symbol, ok := CanonicalSymbol("paradex_futures", marketEvent.Params.Data.Symbol)
+				if !ok {
 					continue // Skip unsupported symbols
 				}
 
+				// markets_summary covers every market, so a symbol change
+				// only changes what is passed on.
+				select {
+				case <-changed:
+					current, changed = symbols.Watch()
+				default:
+				}
+				if !containsSymbol(current, symbol) {
+					continue
+				}
+
 				// Parse bid and ask prices
 				bidPrice, err1 := strconv.ParseFloat(marketEvent.Params.Data.Bid, 64)
 				askPrice, err2 := strconv.ParseFloat(marketEvent.Params.Data.Ask, 64)
 
 				if err1 != nil || err2 != nil {
+					stats.ParseError()
 					continue
 				}
 
 				// Send orderbook data
 				orderbookChan <- OrderbookData{
-					Symbol:    symbol,
-					Source:    "paradex_futures",
-					BestBid:   bidPrice,
-					BestAsk:   askPrice,
-					Timestamp: time.Now().UnixMilli(),
+					Symbol:     symbol,
+					Source:     "paradex_futures",
+					BestBid:    bidPrice,
+					BestAsk:    askPrice,
+					Timestamp:  marketEvent.Params.Data.CreatedAt,
+					ReceivedAt: receivedAt,
 				}
+				continue
 			}
+
+			stats.Other(hasField(message, "id"))
 		}
 
+		subs.close()
+		stopClose()
 		conn.Close()
 		log.Printf("Paradex connection closed, reconnecting in 5 seconds...")
-		time.Sleep(5 * time.Second)
+		if !stats.Backoff(ctx, 5*time.Second) {
+			return
+		}
 	}
 }
 
-// Convert standard symbol format to Paradex format
-// BTCUSDT -> BTC-USD-PERP
-// ETHUSDT -> ETH-USD-PERP
-func convertToParadexSymbol(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-
-	// Map of supported symbols
-	symbolMap := map[string]string{
-		"BTCUSDT": "BTC-USD-PERP",
-		"ETHUSDT": "ETH-USD-PERP",
-		"XRPUSDT": "XRP-USD-PERP",
-		"SOLUSDT": "SOL-USD-PERP",
+func paradexSubscribeRequest(channel string, id int64) ParadexWSRequest {
+	return ParadexWSRequest{
+		ID:      id,
+		JSONRPC: "2.0",
+		Method:  "subscribe",
+		Params:  map[string]interface{}{"channel": channel},
 	}
+}
 
-	if paradexSymbol, exists := symbolMap[symbol]; exists {
-		return paradexSymbol
+func paradexSupports(symbols []string) bool {
+	for _, sym := range symbols {
+		if _, ok := NativeSymbol("paradex_futures", sym); ok {
+			return true
+		}
 	}
+	return false
+}
 
-	return ""
+// paradexMarkets is the response of /v1/markets.
+type paradexMarkets struct {
+	Results []struct {
+		Symbol             string `json:"symbol"`
+		BaseCurrency       string `json:"base_currency"`
+		QuoteCurrency      string `json:"quote_currency"`
+		AssetKind          string `json:"asset_kind"`
+		PriceTickSize      string `json:"price_tick_size"`
+		OrderSizeIncrement string `json:"order_size_increment"`
+	} `json:"results"`
 }
 
-// Convert Paradex symbol format back to standard format
-// BTC-USD-PERP -> BTCUSDT
-func convertFromParadexSymbol(paradexSymbol string) string {
-	// Map from Paradex format back to standard
-	symbolMap := map[string]string{
-		"BTC-USD-PERP": "BTCUSDT",
-		"ETH-USD-PERP": "ETHUSDT",
-		"XRP-USD-PERP": "XRPUSDT",
-		"SOL-USD-PERP": "SOLUSDT",
+// parseParadexMarkets reads the perpetuals; options are skipped. Paradex
+// lists only markets that trade.
+func parseParadexMarkets(body []byte) ([]Market, error) {
+	var info paradexMarkets
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
 	}
 
-	if symbol, exists := symbolMap[paradexSymbol]; exists {
-		return symbol
+	markets := make([]Market, 0, len(info.Results))
+	for _, s := range info.Results {
+		if s.AssetKind != "PERP" {
+			continue
+		}
+		markets = append(markets, Market{
+			Native:   s.Symbol,
+			Base:     s.BaseCurrency,
+			Quote:    s.QuoteCurrency,
+			TickSize: parseSize(s.PriceTickSize),
+			LotSize:  parseSize(s.OrderSizeIncrement),
+			Status:   MarketTrading,
+		})
 	}
-
-	return ""
+	return markets, nil
 }
@@ -2,6 +2,7 @@ package exchanges
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -32,11 +33,6 @@ type PythSSEResponse struct {
 	Parsed []PythParsedFeed `json:"parsed"`
 }
 
-// Pyth price feed IDs for different symbols
-var pythPriceFeedIDs = map[string]string{
-	"BTCUSDT": "e62df6c8b4a85fe1a67db44dc12de5db330f7ac66b72dc658afedf0f4a415b43", // BTC/USD price feed ID
-}
-
 // ParsePythPrice converts Pyth price string and exponent to float64
 func ParsePythPrice(priceStr string, expo int) (float64, error) {
 	priceInt, err := strconv.ParseInt(priceStr, 10, 64)
@@ -47,14 +43,17 @@ func ParsePythPrice(priceStr string, expo int) (float64, error) {
 	return realPrice, nil
 }
 
-// ConnectPythPrices connects to Pyth Network SSE endpoint for price feeds
-func ConnectPythPrices(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+// ConnectPythPrices connects to Pyth Network SSE endpoint for price feeds.
+// The feed ids are part of the stream URL, so symbol changes need a restart.
+func ConnectPythPrices(ctx context.Context, set *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	symbols := set.Symbols()
+
 	// Filter symbols to only those we have price feed IDs for
 	var validSymbols []string
 	var priceFeedIDs []string
 
-	for _, symbol := range symbols {
-		if feedID, exists := pythPriceFeedIDs[symbol]; exists {
+	for _, symbol := range SupportedSymbols("pyth", symbols) {
+		if feedID, exists := NativeSymbol("pyth", symbol); exists {
 			validSymbols = append(validSymbols, symbol)
 			priceFeedIDs = append(priceFeedIDs, feedID)
 		}
@@ -71,17 +70,28 @@ func ConnectPythPrices(symbols []string, priceChan chan<- PriceData, orderbookCh
 		idParams = append(idParams, fmt.Sprintf("ids[]=%s", id))
 	}
 	idsParam := strings.Join(idParams, "&")
-	sseURL := fmt.Sprintf("https://hermes.pyth.network/v2/updates/price/stream?%s", idsParam)
+	sseURL := fmt.Sprintf("%s?%s", EndpointsFor("pyth").REST, idsParam)
+
+	stats := Stats("pyth")
 
 	for {
-		resp, err := http.Get(sseURL)
+		req, err := http.NewRequestWithContext(ctx, "GET", sseURL, nil)
+		if err != nil {
+			log.Printf("Pyth SSE request error: %v", err)
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			log.Printf("Pyth SSE connection error: %v", err)
-			time.Sleep(5 * time.Second)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
 		log.Printf("Connected to Pyth SSE")
+		stats.Connected()
 
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
@@ -91,30 +101,28 @@ func ConnectPythPrices(symbols []string, priceChan chan<- PriceData, orderbookCh
 			if strings.HasPrefix(line, "data:") {
 				data := strings.TrimPrefix(line, "data:")
 				data = strings.TrimSpace(data)
+				receivedAt := time.Now().UnixMilli()
+				received("pyth", []byte(data), receivedAt)
 
 				// Skip empty data lines or heartbeat messages
 				if data == "" || data == "heartbeat" {
+					stats.Message("heartbeat")
 					continue
 				}
+				stats.Message("price_update")
 
 				var response PythSSEResponse
 				if err := json.Unmarshal([]byte(data), &response); err != nil {
 					log.Printf("Pyth JSON unmarshal error: %v", err)
+					stats.ParseError()
 					continue
 				}
 
 				// Process each parsed price feed
 				for _, feed := range response.Parsed {
 					// Find the symbol for this price feed ID
-					var symbol string
-					for sym, feedID := range pythPriceFeedIDs {
-						if feedID == feed.ID {
-							symbol = sym
-							break
-						}
-					}
-
-					if symbol == "" {
+					symbol, ok := CanonicalSymbol("pyth", feed.ID)
+					if !ok {
 						continue
 					}
 
@@ -122,15 +130,17 @@ func ConnectPythPrices(symbols []string, priceChan chan<- PriceData, orderbookCh
 					price, err := ParsePythPrice(feed.Price.Price, feed.Price.Expo)
 					if err != nil {
 						log.Printf("Pyth price parsing error for %s: %v", symbol, err)
+						stats.ParseError()
 						continue
 					}
 
 					// Create price data
 					priceData := PriceData{
-						Symbol:    symbol,
-						Source:    "pyth",
-						Price:     price,
-						Timestamp: feed.Price.PublishTime * 1000, // Convert to milliseconds
+						Symbol:     symbol,
+						Source:     "pyth",
+						Price:      price,
+						Timestamp:  feed.Price.PublishTime * 1000, // Convert to milliseconds
+						ReceivedAt: receivedAt,
 					}
 
 					priceChan <- priceData
@@ -140,10 +150,17 @@ func ConnectPythPrices(symbols []string, priceChan chan<- PriceData, orderbookCh
 
 		if err := scanner.Err(); err != nil {
 			log.Printf("Pyth SSE scanner error: %v", err)
+			stats.Error(err)
 		}
 
 		resp.Body.Close()
+		stats.Disconnected()
+		if ctx.Err() != nil {
+			return
+		}
 		log.Printf("Pyth SSE connection closed, reconnecting in 5 seconds...")
-		time.Sleep(5 * time.Second)
+		if !stats.Backoff(ctx, 5*time.Second) {
+			return
+		}
 	}
 }
@@ -0,0 +1,202 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// shardSizes is the most symbols one connection to a venue carries, kept
+// well inside what the venue allows per connection: Binance's 1024
+// streams and URL length, Bybit's topics per connection, OKX and Gate's
+// channels per connection, Hyperliquid's subscriptions per connection and
+// Kraken's products per feed. Venues missing here use one connection.
+var shardSizes = map[string]int{
+	"binance_futures":     100,
+	"binance_inverse":     100,
+	"binance_spot":        100,
+	"bybit_futures":       100,
+	"bybit_inverse":       100,
+	"bybit_spot":          50,
+	"okx_futures":         100,
+	"okx_inverse":         100,
+	"gate_futures":        100,
+	"hyperliquid_futures": 50,
+	"kraken_futures":      100,
+	"kraken_inverse":      100,
+}
+
+// shardFunc runs one connection for the symbols in set, reporting on stats
+// and logging as label, until ctx is done.
+type shardFunc func(ctx context.Context, label string, stats *SourceStats, set *SymbolSet)
+
+// shard is one of a sharded connector's connections.
+type shard struct {
+	n       int
+	symbols []string
+	set     *SymbolSet
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// connectSharded spreads symbols over connections of at most
+// shardSizes[venue] symbols each, running connect for every one with its
+// own symbol set and shard of the venue's stats, until ctx is done.
+//
+// On a change, symbols keep their connection, so only added and removed
+// ones are subscribed or unsubscribed in place. Added symbols fill the
+// lowest numbered shards first, and once the symbols fit in fewer shards,
+// the highest numbered ones hand theirs over and close.
+func connectSharded(ctx context.Context, venue, label string, symbols *SymbolSet, connect shardFunc) {
+	size := shardSizes[venue]
+	stats := Stats(venue)
+	var shards []*shard
+
+	start := func(n int, syms []string) *shard {
+		runCtx, cancel := context.WithCancel(ctx)
+		sh := &shard{n: n, symbols: syms, set: NewSymbolSet(syms), cancel: cancel, done: make(chan struct{})}
+		shardStats := stats.Shard(n)
+		shardStats.setSymbols(syms)
+		shardLabel := label
+		if n > 1 {
+			shardLabel = fmt.Sprintf("%s (shard %d)", label, n)
+		}
+		go func() {
+			defer close(sh.done)
+			connect(runCtx, shardLabel, shardStats, sh.set)
+		}()
+		return sh
+	}
+	stop := func(sh *shard) {
+		sh.cancel()
+		<-sh.done
+		stats.dropShard(sh.n)
+	}
+
+	for {
+		next, changed := symbols.Watch()
+		assigned := assignShards(shards, next, size)
+
+		var kept []*shard
+		for i, syms := range assigned {
+			switch {
+			case i >= len(shards):
+				kept = append(kept, start(freeShard(shards, kept), syms))
+			case len(syms) == 0:
+				stop(shards[i])
+			default:
+				sh := shards[i]
+				if added, removed := diffSymbols(sh.symbols, syms); len(added) > 0 || len(removed) > 0 {
+					sh.symbols = syms
+					sh.set.Set(syms)
+					stats.Shard(sh.n).setSymbols(syms)
+				}
+				kept = append(kept, sh)
+			}
+		}
+		shards = kept
+
+		select {
+		case <-ctx.Done():
+			var wg sync.WaitGroup
+			for _, sh := range shards {
+				wg.Add(1)
+				go func(sh *shard) {
+					defer wg.Done()
+					stop(sh)
+				}(sh)
+			}
+			wg.Wait()
+			return
+		case <-changed:
+		}
+	}
+}
+
+// freeShard returns the lowest shard number neither old nor kept shards use.
+func freeShard(old, kept []*shard) int {
+	used := make(map[int]bool)
+	for _, sh := range append(append([]*shard(nil), old...), kept...) {
+		used[sh.n] = true
+	}
+	n := 1
+	for used[n] {
+		n++
+	}
+	return n
+}
+
+// assignShards returns the symbols each shard carries for next, by shard
+// position: those it already had, then the new ones filling the shards
+// that carry fewer than size symbols in order, then new shards. Once the
+// symbols fit in fewer shards, the highest numbered ones are emptied into
+// the others; an empty entry means the shard closes. A size of zero puts
+// every symbol on one shard.
+func assignShards(shards []*shard, next []string, size int) [][]string {
+	if size <= 0 {
+		size = len(next)
+	}
+	wanted := make(map[string]bool, len(next))
+	for _, s := range next {
+		wanted[s] = true
+	}
+
+	assigned := make([][]string, len(shards))
+	placed := make(map[string]bool, len(next))
+	for i, sh := range shards {
+		for _, s := range sh.symbols {
+			if wanted[s] && !placed[s] {
+				assigned[i] = append(assigned[i], s)
+				placed[s] = true
+			}
+		}
+	}
+
+	need := 0
+	if len(next) > 0 {
+		need = (len(next) + size - 1) / size
+	}
+	carrying := 0
+	for _, syms := range assigned {
+		if len(syms) > 0 {
+			carrying++
+		}
+	}
+	var moving []string
+	for i := len(assigned) - 1; i >= 0 && carrying > need; i-- {
+		if len(assigned[i]) > 0 {
+			moving = append(moving, assigned[i]...)
+			assigned[i] = nil
+			carrying--
+		}
+	}
+	for _, s := range next {
+		if !placed[s] {
+			moving = append(moving, s)
+			placed[s] = true
+		}
+	}
+
+	fill := func(i int) {
+		room := size - len(assigned[i])
+		if room < 0 {
+			room = 0
+		} else if room > len(moving) {
+			room = len(moving)
+		}
+		assigned[i] = append(assigned[i], moving[:room]...)
+		moving = moving[room:]
+	}
+	for i := range assigned {
+		if len(assigned[i]) > 0 {
+			fill(i)
+		}
+	}
+	for i := 0; len(moving) > 0; i++ {
+		if i == len(assigned) {
+			assigned = append(assigned, nil)
+		}
+		fill(i)
+	}
+	return assigned
+}
@@ -0,0 +1,39 @@
+package exchanges
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssignShards(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards []string // current symbols per shard, comma separated
+		next   []string
+		want   []string
+	}{
+		{"first start", nil, []string{"A", "B", "C"}, []string{"A,B", "C"}},
+		{"added fill the first shard with room", []string{"A,B", "C"}, []string{"A", "B", "C", "D", "E"}, []string{"A,B", "C,D", "E"}},
+		{"removed leave the rest in place", []string{"A,B", "C,D"}, []string{"A", "C", "D"}, []string{"A", "C,D"}},
+		{"added fill the gaps", []string{"A", "C,D"}, []string{"A", "C", "D", "E"}, []string{"A,E", "C,D"}},
+		{"spare shards hand over and close", []string{"A,B", "C,D", "E"}, []string{"A", "E"}, []string{"A,E", "", ""}},
+		{"emptied shard closes", []string{"A,B", "C,D"}, []string{"C", "D"}, []string{"", "C,D"}},
+		{"everything removed", []string{"A,B", "C"}, nil, []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shards []*shard
+			for i, syms := range tt.shards {
+				shards = append(shards, &shard{n: i + 1, symbols: strings.Split(syms, ",")})
+			}
+			var got []string
+			for _, syms := range assignShards(shards, tt.next, 2) {
+				got = append(got, strings.Join(syms, ","))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxErrorHistory is how many recent errors each source keeps.
+const maxErrorHistory = 20
+
+// Subscription states. A disabled subscription was rejected too often to
+// be requested again before the next connection.
+const (
+	SubscriptionPending  = "pending"
+	SubscriptionActive   = "active"
+	SubscriptionRejected = "rejected"
+	SubscriptionDisabled = "disabled"
+)
+
+// SourceError is a connection, read or subscription error.
+type SourceError struct {
+	Time  time.Time
+	Error string
+}
+
+// Subscription is the venue's answer to a subscription request: pending
+// until it acknowledges it, then active or rejected with its error code
+// and message.
+type Subscription struct {
+	Channel string
+	State   string
+	Code    string
+	Error   string
+	Since   time.Time
+}
+
+// SourceStats holds ingestion counters for a single connector. Connectors
+// obtain theirs via Stats and update it from their read loops. A connector
+// that spreads its symbols over several connections reports each one on a
+// shard of its stats, whose counters and errors also count for the source.
+type SourceStats struct {
+	mu          sync.Mutex
+	source      string
+	parent      *SourceStats
+	shard       int
+	symbols     []string
+	shards      map[int]*SourceStats
+	received    uint64
+	messages    map[string]uint64
+	parseErrors uint64
+	unknown     uint64
+	updates     uint64
+	connects    uint64
+	reconnects  uint64
+	connected   bool
+	connectedAt time.Time
+	lastUpdate  time.Time
+	lastFrame   time.Time
+	backoff     time.Duration
+	retryAt     time.Time
+	errors      []SourceError
+	subs        map[string]Subscription
+}
+
+// SourceStatsSnapshot is a point-in-time copy of a connector's counters.
+// Received counts raw frames; each is then a message of a known kind, a
+// parse error (rejected) or of an unknown kind. Updates counts the prices,
+// books and trades that reached the scanner.
+type SourceStatsSnapshot struct {
+	Source      string
+	Received    uint64
+	Messages    map[string]uint64
+	ParseErrors uint64
+	Unknown     uint64
+	Updates     uint64
+	// Connects counts every connection, the first one included.
+	Connects   uint64
+	Reconnects uint64
+	Connected  bool
+	// ConnectedAt is when the current connection was made, LastFrame when
+	// the last frame arrived and LastUpdate the last update; zero if never.
+	ConnectedAt time.Time
+	LastFrame   time.Time
+	LastUpdate  time.Time
+	// Backoff is the wait before the next connection attempt, until
+	// RetryAt; zero unless the connector is waiting to reconnect.
+	Backoff time.Duration
+	RetryAt time.Time
+	// Errors are the most recent errors, oldest first.
+	Errors        []SourceError
+	Subscriptions []Subscription
+	// Shard numbers one connection of a sharded source from 1, and Symbols
+	// are the symbols it carries; both are empty for the source itself.
+	Shard   int
+	Symbols []string
+	// Shards are a sharded source's connections, by number. The source is
+	// connected while any of them is, since the earliest, and its
+	// subscriptions are theirs.
+	Shards []SourceStatsSnapshot
+}
+
+var (
+	statsMutex sync.Mutex
+	statsBySrc = make(map[string]*SourceStats)
+)
+
+// Stats returns the counters for source, creating them on first use.
+func Stats(source string) *SourceStats {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+
+	st, ok := statsBySrc[source]
+	if !ok {
+		st = &SourceStats{source: source, messages: make(map[string]uint64)}
+		statsBySrc[source] = st
+	}
+	return st
+}
+
+// Shard returns the stats of connection n of a sharded source, creating
+// them on first use.
+func (s *SourceStats) Shard(n int) *SourceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sh, ok := s.shards[n]
+	if !ok {
+		if s.shards == nil {
+			s.shards = make(map[int]*SourceStats)
+		}
+		sh = &SourceStats{source: s.source, parent: s, shard: n, messages: make(map[string]uint64)}
+		s.shards[n] = sh
+	}
+	return sh
+}
+
+// dropShard forgets connection n once the source no longer needs it.
+func (s *SourceStats) dropShard(n int) {
+	s.mu.Lock()
+	delete(s.shards, n)
+	s.mu.Unlock()
+}
+
+// setSymbols records the symbols a shard carries.
+func (s *SourceStats) setSymbols(symbols []string) {
+	s.mu.Lock()
+	s.symbols = append([]string(nil), symbols...)
+	s.mu.Unlock()
+}
+
+// Connected records a successful connection. Polling connectors may call it
+// on every successful poll; only transitions from disconnected count, and
+// every connection after the first one counts as a reconnect. A new
+// connection starts without subscriptions.
+func (s *SourceStats) Connected() {
+	s.mu.Lock()
+	connecting := !s.connected
+	reconnect := connecting && s.connects > 0
+	if connecting {
+		s.connects++
+		if reconnect {
+			s.reconnects++
+		}
+		s.connectedAt = time.Now()
+		s.subs = nil
+	}
+	s.connected = true
+	s.mu.Unlock()
+
+	if connecting && s.parent != nil {
+		s.parent.shardConnected(reconnect)
+	}
+}
+
+// shardConnected counts a connection made by one of the source's shards.
+func (s *SourceStats) shardConnected(reconnect bool) {
+	s.mu.Lock()
+	s.connects++
+	if reconnect {
+		s.reconnects++
+	}
+	s.mu.Unlock()
+}
+
+// Disconnected records that the connection was lost.
+func (s *SourceStats) Disconnected() {
+	s.mu.Lock()
+	s.connected = false
+	s.mu.Unlock()
+}
+
+// Received counts a raw inbound frame, before it is parsed.
+func (s *SourceStats) Received() {
+	s.mu.Lock()
+	s.received++
+	s.lastFrame = time.Now()
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.Received()
+	}
+}
+
+// Error records a connection, read or subscription error in the source's
+// recent history.
+func (s *SourceStats) Error(err error) {
+	s.mu.Lock()
+	s.errors = append(s.errors, SourceError{Time: time.Now(), Error: err.Error()})
+	if len(s.errors) > maxErrorHistory {
+		s.errors = s.errors[len(s.errors)-maxErrorHistory:]
+	}
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.Error(fmt.Errorf("shard %d: %w", s.shard, err))
+	}
+}
+
+// Backoff waits d before the connector tries to connect again, reporting
+// the wait meanwhile. It returns false early if ctx is done.
+func (s *SourceStats) Backoff(ctx context.Context, d time.Duration) bool {
+	s.mu.Lock()
+	s.backoff = d
+	s.retryAt = time.Now().Add(d)
+	s.mu.Unlock()
+
+	ok := sleepCtx(ctx, d)
+
+	s.mu.Lock()
+	s.backoff = 0
+	s.retryAt = time.Time{}
+	s.mu.Unlock()
+	return ok
+}
+
+// Subscribing records subscription requests sent for channels.
+func (s *SourceStats) Subscribing(channels ...string) {
+	s.setSubscriptions(SubscriptionPending, "", "", channels)
+}
+
+// Subscribed records that the venue acknowledged channels.
+func (s *SourceStats) Subscribed(channels ...string) {
+	s.setSubscriptions(SubscriptionActive, "", "", channels)
+}
+
+// Rejected records that the venue refused channel, with its error code
+// and message.
+func (s *SourceStats) Rejected(channel, code, message string) {
+	s.setSubscriptions(SubscriptionRejected, code, message, []string{channel})
+}
+
+// Disabled records that channel, last rejected, is no longer requested.
+func (s *SourceStats) Disabled(channel string) {
+	s.mu.Lock()
+	if sub, ok := s.subs[channel]; ok {
+		sub.State = SubscriptionDisabled
+		sub.Since = time.Now()
+		s.subs[channel] = sub
+	}
+	s.mu.Unlock()
+}
+
+// Unsubscribed forgets channels.
+func (s *SourceStats) Unsubscribed(channels ...string) {
+	s.mu.Lock()
+	for _, ch := range channels {
+		delete(s.subs, ch)
+	}
+	s.mu.Unlock()
+}
+
+func (s *SourceStats) setSubscriptions(state, code, message string, channels []string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs == nil {
+		s.subs = make(map[string]Subscription)
+	}
+	for _, ch := range channels {
+		sub := Subscription{Channel: ch, State: state, Code: code, Error: message, Since: now}
+		if old, ok := s.subs[ch]; ok && old.State == state && old.Code == code && old.Error == message {
+			sub.Since = old.Since
+		}
+		s.subs[ch] = sub
+	}
+}
+
+// Message counts an inbound message of the given type.
+func (s *SourceStats) Message(kind string) {
+	s.mu.Lock()
+	s.messages[kind]++
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.Message(kind)
+	}
+}
+
+// ParseError counts a message that could not be decoded.
+func (s *SourceStats) ParseError() {
+	s.mu.Lock()
+	s.parseErrors++
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.ParseError()
+	}
+}
+
+// Unknown counts a message of a type the connector doesn't handle. Venues
+// announce new message types this way before changing the ones we parse.
+func (s *SourceStats) Unknown() {
+	s.mu.Lock()
+	s.unknown++
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.Unknown()
+	}
+}
+
+// Other counts a message the connector has no handler for: a control
+// message, such as a subscription ack, a pong or an error, or else one of
+// an unknown type.
+func (s *SourceStats) Other(control bool) {
+	if control {
+		s.Message("control")
+		return
+	}
+	s.Unknown()
+}
+
+// hasField reports whether frame is a JSON object with any of fields at
+// its top level.
+func hasField(frame []byte, fields ...string) bool {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(frame, &obj) != nil {
+		return false
+	}
+	for _, f := range fields {
+		if _, ok := obj[f]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Update records a valid price, book or trade from the connector.
+func (s *SourceStats) Update() {
+	s.mu.Lock()
+	s.updates++
+	s.lastUpdate = time.Now()
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.Update()
+	}
+}
+
+// Snapshot returns a copy of the current counters, with a sharded
+// source's connections combined from its shards.
+func (s *SourceStats) Snapshot() SourceStatsSnapshot {
+	s.mu.Lock()
+	messages := make(map[string]uint64, len(s.messages))
+	for kind, n := range s.messages {
+		messages[kind] = n
+	}
+
+	subs := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+
+	shards := make([]*SourceStats, 0, len(s.shards))
+	for _, sh := range s.shards {
+		shards = append(shards, sh)
+	}
+
+	snap := SourceStatsSnapshot{
+		Source:      s.source,
+		Received:    s.received,
+		Messages:    messages,
+		ParseErrors: s.parseErrors,
+		Unknown:     s.unknown,
+		Updates:     s.updates,
+		Connects:    s.connects,
+		Reconnects:  s.reconnects,
+		Connected:   s.connected,
+		ConnectedAt: s.connectedAt,
+		LastFrame:   s.lastFrame,
+		LastUpdate:  s.lastUpdate,
+		Backoff:     s.backoff,
+		RetryAt:     s.retryAt,
+		Errors:      append([]SourceError(nil), s.errors...),
+		Shard:       s.shard,
+		Symbols:     append([]string(nil), s.symbols...),
+
+		Subscriptions: subs,
+	}
+	s.mu.Unlock()
+
+	for _, sh := range shards {
+		st := sh.Snapshot()
+		snap.Shards = append(snap.Shards, st)
+		if st.Connected {
+			if !snap.Connected || st.ConnectedAt.Before(snap.ConnectedAt) {
+				snap.ConnectedAt = st.ConnectedAt
+			}
+			snap.Connected = true
+		}
+		if st.Backoff > 0 && (snap.Backoff == 0 || st.RetryAt.Before(snap.RetryAt)) {
+			snap.Backoff, snap.RetryAt = st.Backoff, st.RetryAt
+		}
+		snap.Subscriptions = append(snap.Subscriptions, st.Subscriptions...)
+	}
+	sort.Slice(snap.Shards, func(i, j int) bool { return snap.Shards[i].Shard < snap.Shards[j].Shard })
+	sort.Slice(snap.Subscriptions, func(i, j int) bool { return snap.Subscriptions[i].Channel < snap.Subscriptions[j].Channel })
+	return snap
+}
+
+// AllStats returns snapshots for every connector that has reported, sorted
+// by source name.
+func AllStats() []SourceStatsSnapshot {
+	statsMutex.Lock()
+	sources := make([]*SourceStats, 0, len(statsBySrc))
+	for _, st := range statsBySrc {
+		sources = append(sources, st)
+	}
+	statsMutex.Unlock()
+
+	out := make([]SourceStatsSnapshot, 0, len(sources))
+	for _, st := range sources {
+		out = append(out, st.Snapshot())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
@@ -0,0 +1,92 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStatsSubscriptionsAndErrors(t *testing.T) {
+	stats := Stats("stats_test_venue")
+	stats.Connected()
+	stats.Subscribing("book", "trades")
+	stats.Subscribed("book")
+	stats.Rejected("trades", "10001", "unknown channel")
+
+	subs := stats.Snapshot().Subscriptions
+	if len(subs) != 2 || subs[0].Channel != "book" || subs[0].State != SubscriptionActive {
+		t.Fatalf("got %+v, want book active first", subs)
+	}
+	if subs[1].State != SubscriptionRejected || subs[1].Code != "10001" || subs[1].Error != "unknown channel" {
+		t.Fatalf("got %+v, want trades rejected with the venue's code", subs[1])
+	}
+
+	for i := 0; i < maxErrorHistory+5; i++ {
+		stats.Error(fmt.Errorf("error %d", i))
+	}
+	errs := stats.Snapshot().Errors
+	if len(errs) != maxErrorHistory || errs[len(errs)-1].Error != fmt.Sprintf("error %d", maxErrorHistory+4) {
+		t.Fatalf("kept %d errors ending in %+v, want the last %d", len(errs), errs[len(errs)-1], maxErrorHistory)
+	}
+
+	stats.Disconnected()
+	stats.Connected()
+	if subs := stats.Snapshot().Subscriptions; len(subs) != 0 {
+		t.Fatalf("subscriptions %+v survived a reconnect", subs)
+	}
+}
+
+func TestStatsBackoff(t *testing.T) {
+	stats := Stats("stats_test_backoff")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() { done <- stats.Backoff(ctx, time.Minute) }()
+
+	deadline := time.Now().Add(time.Second)
+	for stats.Snapshot().Backoff != time.Minute {
+		if time.Now().After(deadline) {
+			t.Fatal("backoff not reported while waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if <-done {
+		t.Fatal("Backoff returned true after cancel")
+	}
+	if st := stats.Snapshot(); st.Backoff != 0 || !st.RetryAt.IsZero() {
+		t.Fatalf("backoff %v until %v left after waiting", st.Backoff, st.RetryAt)
+	}
+}
+
+func TestStatsShards(t *testing.T) {
+	stats := Stats("stats_test_shards")
+	one, two := stats.Shard(1), stats.Shard(2)
+	one.setSymbols([]string{"BTCUSDT"})
+	one.Connected()
+	one.Subscribed("book:BTC")
+	one.Received()
+	two.Connected()
+	two.Received()
+	two.Error(fmt.Errorf("read error"))
+	two.Disconnected()
+	two.Connected()
+	two.Subscribed("book:ETH")
+
+	st := stats.Snapshot()
+	if !st.Connected || st.Received != 2 || st.Connects != 3 || st.Reconnects != 1 {
+		t.Fatalf("got connected %v, %d received, %d connects, %d reconnects; want the shards' sums", st.Connected, st.Received, st.Connects, st.Reconnects)
+	}
+	if len(st.Subscriptions) != 2 || len(st.Errors) != 1 || st.Errors[0].Error != "shard 2: read error" {
+		t.Fatalf("got subscriptions %+v and errors %+v, want both shards'", st.Subscriptions, st.Errors)
+	}
+	if len(st.Shards) != 2 || st.Shards[0].Shard != 1 || st.Shards[0].Symbols[0] != "BTCUSDT" || st.Shards[1].Reconnects != 1 {
+		t.Fatalf("shards %+v", st.Shards)
+	}
+
+	one.Disconnected()
+	stats.dropShard(2)
+	if st := stats.Snapshot(); st.Connected || len(st.Shards) != 1 || len(st.Subscriptions) != 1 {
+		t.Fatalf("got %+v after shard 1 disconnected and shard 2 closed", st)
+	}
+}
@@ -0,0 +1,222 @@
+package exchanges
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// maxSubscribeAttempts is how many times a channel is requested before a
+// rejection disables it until the next connection.
+const maxSubscribeAttempts = 3
+
+// subscribeRetryDelay is how long a rejected channel waits before it is
+// requested again on its own.
+var subscribeRetryDelay = 5 * time.Second
+
+// subscriptions follows what one connection asked its venue for. Requests
+// get an id and are reported pending in the source's stats, acks make their
+// channels active, and a rejected channel is requested again on its own
+// after subscribeRetryDelay, up to maxSubscribeAttempts times, before it is
+// disabled. Retries and symbol changes write from their own goroutines, so
+// every write to the connection goes through write.
+type subscriptions struct {
+	label  string
+	stats  *SourceStats
+	resend func(id int64, channel string) error
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	nextID   int64
+	pending  map[int64][]string // channels not acked yet, by request id
+	attempts map[string]int
+	retries  map[string]*time.Timer
+	closed   bool
+}
+
+// newSubscriptions tracks a connection's subscriptions in stats. resend
+// requests one rejected channel again under id.
+func newSubscriptions(stats *SourceStats, label string, resend func(id int64, channel string) error) *subscriptions {
+	return &subscriptions{
+		label:    label,
+		stats:    stats,
+		resend:   resend,
+		pending:  make(map[int64][]string),
+		attempts: make(map[string]int),
+		retries:  make(map[string]*time.Timer),
+	}
+}
+
+// write runs fn, which writes to the connection, alone.
+func (s *subscriptions) write(fn func() error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return fn()
+}
+
+// request records a subscription request for channels and returns the id
+// to send it with.
+func (s *subscriptions) request(channels ...string) int64 {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = append([]string(nil), channels...)
+	for _, ch := range channels {
+		s.attempts[ch]++
+	}
+	s.mu.Unlock()
+
+	s.stats.Subscribing(channels...)
+	return id
+}
+
+// ack records that the venue accepted channels.
+func (s *subscriptions) ack(channels ...string) {
+	s.mu.Lock()
+	for _, ch := range channels {
+		s.forget(ch)
+		delete(s.attempts, ch)
+	}
+	s.mu.Unlock()
+
+	s.stats.Subscribed(channels...)
+}
+
+// acked records that the venue accepted request id, reporting whether the
+// id was one of the connection's pending requests.
+func (s *subscriptions) acked(id int64) bool {
+	s.mu.Lock()
+	channels, ok := s.pending[id]
+	s.mu.Unlock()
+	if ok {
+		s.ack(channels...)
+	}
+	return ok
+}
+
+// rejectRequest rejects every channel of request id, reporting whether the
+// id was one of the connection's pending requests.
+func (s *subscriptions) rejectRequest(id int64, code, message string) bool {
+	s.mu.Lock()
+	channels, ok := s.pending[id]
+	s.mu.Unlock()
+	for _, ch := range channels {
+		s.reject(ch, code, message)
+	}
+	return ok
+}
+
+// rejectNext rejects the oldest pending request, for venues whose errors
+// don't say which request failed but answer requests in order.
+func (s *subscriptions) rejectNext(code, message string) bool {
+	s.mu.Lock()
+	var oldest int64
+	for id := range s.pending {
+		if oldest == 0 || id < oldest {
+			oldest = id
+		}
+	}
+	s.mu.Unlock()
+	if oldest == 0 {
+		return false
+	}
+	return s.rejectRequest(oldest, code, message)
+}
+
+// reject records that the venue refused channel with code and message and
+// requests it again later, or disables it once it has been requested
+// maxSubscribeAttempts times.
+func (s *subscriptions) reject(channel, code, message string) {
+	s.mu.Lock()
+	s.forget(channel)
+	attempts := s.attempts[channel]
+	retry := !s.closed && attempts < maxSubscribeAttempts
+	if retry {
+		if t := s.retries[channel]; t != nil {
+			t.Stop()
+		}
+		s.retries[channel] = time.AfterFunc(subscribeRetryDelay, func() { s.retry(channel) })
+	}
+	s.mu.Unlock()
+
+	reason := message
+	if code != "" {
+		reason = code + " " + message
+	}
+	s.stats.Rejected(channel, code, message)
+	if retry {
+		log.Printf("%s subscription to %s rejected (%s), retrying in %v", s.label, channel, reason, subscribeRetryDelay)
+		return
+	}
+	log.Printf("%s subscription to %s rejected (%s) %d times, disabled until reconnect", s.label, channel, reason, attempts)
+	s.stats.Disabled(channel)
+}
+
+func (s *subscriptions) retry(channel string) {
+	s.mu.Lock()
+	_, wanted := s.retries[channel]
+	delete(s.retries, channel)
+	s.mu.Unlock()
+	if !wanted {
+		return
+	}
+
+	err := s.write(func() error {
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return nil
+		}
+		return s.resend(s.request(channel), channel)
+	})
+	if err != nil {
+		log.Printf("%s resubscribe error for %s: %v", s.label, channel, err)
+		s.stats.Error(err)
+	}
+}
+
+// unsubscribed stops tracking channels the connector no longer wants.
+func (s *subscriptions) unsubscribed(channels ...string) {
+	s.mu.Lock()
+	for _, ch := range channels {
+		s.forget(ch)
+		delete(s.attempts, ch)
+		if t := s.retries[ch]; t != nil {
+			t.Stop()
+			delete(s.retries, ch)
+		}
+	}
+	s.mu.Unlock()
+
+	s.stats.Unsubscribed(channels...)
+}
+
+// close stops pending retries once the connection is gone.
+func (s *subscriptions) close() {
+	s.mu.Lock()
+	s.closed = true
+	for ch, t := range s.retries {
+		t.Stop()
+		delete(s.retries, ch)
+	}
+	s.mu.Unlock()
+}
+
+// forget drops channel from the pending requests. s.mu must be held.
+func (s *subscriptions) forget(channel string) {
+	for id, channels := range s.pending {
+		var kept []string
+		for _, ch := range channels {
+			if ch != channel {
+				kept = append(kept, ch)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.pending, id)
+		} else {
+			s.pending[id] = kept
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package exchanges
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionsRetryOnlyWantedChannels(t *testing.T) {
+	defer func(d time.Duration) { subscribeRetryDelay = d }(subscribeRetryDelay)
+	subscribeRetryDelay = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	var resent []string
+	stats := Stats("subscriptions_test_venue")
+	stats.Connected()
+	subs := newSubscriptions(stats, "Test", func(_ int64, channel string) error {
+		mu.Lock()
+		resent = append(resent, channel)
+		mu.Unlock()
+		return nil
+	})
+
+	id := subs.request("a", "b", "c")
+	subs.ack("a")
+	if !subs.rejectRequest(id, "1", "no such market") {
+		t.Fatal("request not found")
+	}
+	subs.unsubscribed("b")
+	if subs.acked(id) {
+		t.Error("request still pending after every channel was answered")
+	}
+
+	time.Sleep(10 * subscribeRetryDelay)
+	mu.Lock()
+	got := append([]string(nil), resent...)
+	mu.Unlock()
+	if len(got) != 1 || got[0] != "c" {
+		t.Fatalf("resent %v, want only c", got)
+	}
+
+	subs.reject("c", "1", "no such market")
+	subs.close()
+	time.Sleep(10 * subscribeRetryDelay)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(resent) != 1 {
+		t.Errorf("resent %v after close", resent)
+	}
+	if sub := stats.Snapshot().Subscriptions; len(sub) != 2 || sub[0].State != SubscriptionActive || sub[1].Code != "1" {
+		t.Errorf("subscriptions %+v, want a active and c rejected", sub)
+	}
+}
@@ -0,0 +1,130 @@
+package exchanges
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// SymbolSet is a connector's symbol list. It can change while the connector
+// runs: connectors that can subscribe and unsubscribe in place follow it
+// with followSymbols, the rest read it when they start and are restarted by
+// their owner on a change.
+type SymbolSet struct {
+	mu      sync.Mutex
+	symbols []string
+	changed chan struct{}
+}
+
+// NewSymbolSet returns a set holding symbols.
+func NewSymbolSet(symbols []string) *SymbolSet {
+	return &SymbolSet{symbols: append([]string(nil), symbols...), changed: make(chan struct{})}
+}
+
+// Symbols returns the current symbols.
+func (s *SymbolSet) Symbols() []string {
+	symbols, _ := s.Watch()
+	return symbols
+}
+
+// Watch returns the current symbols and a channel that is closed when they
+// next change.
+func (s *SymbolSet) Watch() ([]string, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.symbols...), s.changed
+}
+
+// Set replaces the symbols and wakes everyone watching.
+func (s *SymbolSet) Set(symbols []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = append([]string(nil), symbols...)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func containsSymbol(symbols []string, symbol string) bool {
+	for _, s := range symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSymbols returns the symbols in next but not prev, and in prev but not
+// next.
+func diffSymbols(prev, next []string) (added, removed []string) {
+	for _, s := range next {
+		if !containsSymbol(prev, s) {
+			added = append(added, s)
+		}
+	}
+	for _, s := range prev {
+		if !containsSymbol(next, s) {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// followSymbols calls update with the symbols added to and removed from set
+// since current, every time it changes, until stop is called or ctx is done.
+// An update error closes conn so the connector reconnects with the full
+// list.
+func followSymbols(ctx context.Context, set *SymbolSet, current []string, conn io.Closer, update func(added, removed []string) error) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		for {
+			next, changed := set.Watch()
+			if added, removed := diffSymbols(current, next); len(added) > 0 || len(removed) > 0 {
+				if err := update(added, removed); err != nil {
+					conn.Close()
+					return
+				}
+				current = next
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-changed:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// waitForSymbols returns the set's symbols, waiting while it is empty. It
+// returns nil once ctx is done.
+func waitForSymbols(ctx context.Context, set *SymbolSet) []string {
+	for {
+		symbols, changed := set.Watch()
+		if len(symbols) > 0 {
+			return symbols
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// sleepCtx waits for d, returning false early if ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,32 @@
+package exchanges
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffSymbols(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev, next  []string
+		added, gone string
+	}{
+		{"unchanged", []string{"TONUSDT"}, []string{"TONUSDT"}, "", ""},
+		{"added", []string{"TONUSDT"}, []string{"TONUSDT", "BTCUSDT"}, "BTCUSDT", ""},
+		{"removed", []string{"TONUSDT", "BTCUSDT"}, []string{"BTCUSDT"}, "", "TONUSDT"},
+		{"replaced", []string{"TONUSDT"}, []string{"ETHUSDT"}, "ETHUSDT", "TONUSDT"},
+		{"from empty", nil, []string{"TONUSDT"}, "TONUSDT", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffSymbols(tt.prev, tt.next)
+			if got := strings.Join(added, ","); got != tt.added {
+				t.Errorf("added = %q, want %q", got, tt.added)
+			}
+			if got := strings.Join(removed, ","); got != tt.gone {
+				t.Errorf("removed = %q, want %q", got, tt.gone)
+			}
+		})
+	}
+}
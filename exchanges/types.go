@@ -1,25 +1,40 @@
 package exchanges
 
+// Timestamp fields carry the venue's own event time in Unix milliseconds and
+// are zero when the venue does not provide one. ReceivedAt is always the local
+// arrival time of the frame, so the two can be compared to measure feed
+// latency and clock skew.
+//
+// Connectors send prices and sizes in the venue's own units: a 1000PEPEUSDT
+// price is per 1000 PEPE and a Gate or OKX size counts contracts. The
+// scanner turns them into per-unit base asset terms with the Normalize
+// functions as they arrive. Sizes are zero when the venue doesn't send one.
+
 type PriceData struct {
-	Symbol    string
-	Source    string
-	Price     float64
-	Timestamp int64
+	Symbol     string
+	Source     string
+	Price      float64
+	Timestamp  int64
+	ReceivedAt int64
 }
 
 type OrderbookData struct {
-	Symbol    string
-	Source    string
-	BestBid   float64
-	BestAsk   float64
-	Timestamp int64
+	Symbol     string
+	Source     string
+	BestBid    float64
+	BestAsk    float64
+	BidSize    float64
+	AskSize    float64
+	Timestamp  int64
+	ReceivedAt int64
 }
 
 type TradeData struct {
-	Symbol    string
-	Source    string
-	Price     float64
-	Quantity  string
-	Side      string // "buy" or "sell" (normalized)
-	Timestamp int64
+	Symbol     string
+	Source     string
+	Price      float64
+	Quantity   float64
+	Side       string // "buy" or "sell" (normalized)
+	Timestamp  int64
+	ReceivedAt int64
 }
@@ -1,7 +1,10 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -22,16 +25,9 @@ type variationalMetadataResponse struct {
 	} `json:"listings"`
 }
 
-func convertToVariationalTicker(symbol string) string {
-	if _, ok := normalizeTONSymbol(symbol); ok {
-		return "TON"
-	}
-	return ""
-}
-
 func parseVariationalTopOfBook(meta variationalMetadataResponse, symbol string) (bestBid float64, bestAsk float64, ok bool) {
-	ticker := convertToVariationalTicker(symbol)
-	if ticker == "" {
+	ticker, ok := NativeSymbol("variational_perps", symbol)
+	if !ok {
 		return 0, 0, false
 	}
 
@@ -52,8 +48,8 @@ func parseVariationalTopOfBook(meta variationalMetadataResponse, symbol string)
 }
 
 func parseVariationalMark(meta variationalMetadataResponse, symbol string) (mark float64, ok bool) {
-	ticker := convertToVariationalTicker(symbol)
-	if ticker == "" {
+	ticker, ok := NativeSymbol("variational_perps", symbol)
+	if !ok {
 		return 0, false
 	}
 
@@ -71,27 +67,30 @@ func parseVariationalMark(meta variationalMetadataResponse, symbol string) (mark
 	return 0, false
 }
 
-func ConnectVariationalFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	url := "https://omni-client-api.prod.ap-northeast-1.variational.io/metadata/stats"
+func ConnectVariationalFutures(ctx context.Context, set *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	endpoints := EndpointsFor("variational_perps")
+	url := endpoints.REST
 
-	tonSyms := filterTONSymbols(symbols)
-	if len(tonSyms) == 0 {
-		log.Printf("Variational skipped: no TON symbol provided (symbols=%v)", symbols)
+	symbols := set.Symbols()
+	supportedSymbols := SupportedSymbols("variational_perps", symbols)
+	if len(supportedSymbols) == 0 {
+		log.Printf("Variational skipped: no listed market for %v", symbols)
 		return
 	}
 
-	supportedSymbols := []string{tonCanonicalSymbol}
-
 	client := &http.Client{Timeout: 10 * time.Second}
 
 	backoff := 2 * time.Second
 	maxBackoff := 60 * time.Second
+	stats := Stats("variational_perps")
 
 	for {
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			log.Printf("Variational request build error: %v", err)
-			time.Sleep(backoff)
+			if !stats.Backoff(ctx, backoff) {
+				return
+			}
 			if backoff < maxBackoff {
 				backoff *= 2
 				if backoff > maxBackoff {
@@ -103,8 +102,12 @@ func ConnectVariationalFutures(symbols []string, priceChan chan<- PriceData, ord
 
 		resp, err := client.Do(req)
 		if err != nil {
+			stats.Disconnected()
 			log.Printf("Variational request error: %v (retrying in %s)", err, backoff)
-			time.Sleep(backoff)
+			stats.Error(err)
+			if !stats.Backoff(ctx, backoff) {
+				return
+			}
 			if backoff < maxBackoff {
 				backoff *= 2
 				if backoff > maxBackoff {
@@ -116,8 +119,12 @@ func ConnectVariationalFutures(symbols []string, priceChan chan<- PriceData, ord
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 			resp.Body.Close()
+			stats.Disconnected()
 			log.Printf("Variational request error: unexpected status %s (retrying in %s)", resp.Status, backoff)
-			time.Sleep(backoff)
+			stats.Error(errors.New("unexpected status " + resp.Status))
+			if !stats.Backoff(ctx, backoff) {
+				return
+			}
 			if backoff < maxBackoff {
 				backoff *= 2
 				if backoff > maxBackoff {
@@ -127,12 +134,19 @@ func ConnectVariationalFutures(symbols []string, priceChan chan<- PriceData, ord
 			continue
 		}
 
-		var meta variationalMetadataResponse
-		err = json.NewDecoder(resp.Body).Decode(&meta)
+		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		var meta variationalMetadataResponse
+		if err == nil {
+			received("variational_perps", body, time.Now().UnixMilli())
+			err = json.Unmarshal(body, &meta)
+		}
 		if err != nil {
 			log.Printf("Variational decode error: %v (retrying in %s)", err, backoff)
-			time.Sleep(backoff)
+			stats.ParseError()
+			if !stats.Backoff(ctx, backoff) {
+				return
+			}
 			if backoff < maxBackoff {
 				backoff *= 2
 				if backoff > maxBackoff {
@@ -143,21 +157,25 @@ func ConnectVariationalFutures(symbols []string, priceChan chan<- PriceData, ord
 		}
 
 		backoff = 2 * time.Second
+		stats.Connected()
+		stats.Message("metadata")
 
 		now := time.Now().UnixMilli()
 		for _, sym := range supportedSymbols {
 			bestBid, bestAsk, ok := parseVariationalTopOfBook(meta, sym)
 			if ok {
-				orderbookChan <- OrderbookData{Symbol: sym, Source: "variational_perps", BestBid: bestBid, BestAsk: bestAsk, Timestamp: now}
+				orderbookChan <- OrderbookData{Symbol: sym, Source: "variational_perps", BestBid: bestBid, BestAsk: bestAsk, ReceivedAt: now}
 				continue
 			}
 
 			mark, ok := parseVariationalMark(meta, sym)
 			if ok {
-				priceChan <- PriceData{Symbol: sym, Source: "variational_perps", Price: mark, Timestamp: now}
+				priceChan <- PriceData{Symbol: sym, Source: "variational_perps", Price: mark, ReceivedAt: now}
 			}
 		}
 
-		time.Sleep(2 * time.Second)
+		if !sleepCtx(ctx, endpoints.PollInterval) {
+			return
+		}
 	}
 }
@@ -5,7 +5,7 @@ import (
 	"testing"
 )
 
-func TestConvertVariationalTicker(t *testing.T) {
+func TestVariationalTicker(t *testing.T) {
 	cases := []struct {
 		in   string
 		want string
@@ -19,8 +19,8 @@ func TestConvertVariationalTicker(t *testing.T) {
 		{in: "", want: ""},
 	}
 	for _, tc := range cases {
-		if got := convertToVariationalTicker(tc.in); got != tc.want {
-			t.Fatalf("convertToVariationalTicker(%q): got %q want %q", tc.in, got, tc.want)
+		if got, _ := NativeSymbol("variational_perps", tc.in); got != tc.want {
+			t.Fatalf("NativeSymbol(variational_perps, %q): got %q want %q", tc.in, got, tc.want)
 		}
 	}
 }
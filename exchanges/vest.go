@@ -1,6 +1,7 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"math"
@@ -32,21 +33,6 @@ type vestDepthMessage struct {
 	} `json:"data"`
 }
 
-func convertToVestSymbol(symbol string) string {
-	if _, ok := normalizeTONSymbol(symbol); !ok {
-		return ""
-	}
-	return "TON-PERP"
-}
-
-func convertFromVestSymbol(vestSymbol string) string {
-	canon, ok := normalizeTONSymbol(vestSymbol)
-	if !ok {
-		return ""
-	}
-	return canon
-}
-
 func parseVestDepthMessage(payload []byte) (symbol string, bestBid float64, bestAsk float64, ts int64, ok bool) {
 	var msg vestDepthMessage
 	if err := json.Unmarshal(payload, &msg); err != nil {
@@ -58,8 +44,8 @@ func parseVestDepthMessage(payload []byte) (symbol string, bestBid float64, best
 		channelSym = channelSym[:idx]
 	}
 
-	symbol = convertFromVestSymbol(channelSym)
-	if symbol == "" {
+	symbol, ok = CanonicalSymbol("vest_futures", channelSym)
+	if !ok {
 		return "", 0, 0, 0, false
 	}
 
@@ -95,53 +81,62 @@ func parseVestDepthMessage(payload []byte) (symbol string, bestBid float64, best
 		return "", 0, 0, 0, false
 	}
 
-	ts = time.Now().UnixMilli()
-	return symbol, bestBid, bestAsk, ts, true
+	// Vest depth frames carry no event time.
+	return symbol, bestBid, bestAsk, 0, true
 }
 
-func ConnectVestFutures(symbols []string, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
-	wsURL := "wss://ws-prod.hz.vestmarkets.com/ws-api?version=1.0"
-
-	tonSyms := filterTONSymbols(symbols)
-	if len(tonSyms) == 0 {
-		log.Printf("Vest skipped: no TON symbol provided (symbols=%v)", symbols)
-		return
-	}
+func ConnectVestFutures(ctx context.Context, set *SymbolSet, priceChan chan<- PriceData, orderbookChan chan<- OrderbookData, tradeChan chan<- TradeData) {
+	wsURL := EndpointsFor("vest_futures").WS
 
+	symbols := set.Symbols()
 	var params []string
-	vestSym := convertToVestSymbol(tonCanonicalSymbol)
-	if vestSym != "" {
-		params = append(params, vestSym+"@depth")
+	for _, symbol := range symbols {
+		if vestSym, ok := NativeSymbol("vest_futures", symbol); ok {
+			params = append(params, vestSym+"@depth")
+		}
 	}
 	if len(params) == 0 {
-		log.Printf("Vest: unable to map TON to Vest market; sleeping")
-		time.Sleep(30 * time.Second)
+		log.Printf("Vest skipped: no listed market for %v", symbols)
 		return
 	}
 
+	stats := Stats("vest_futures")
+
 	for {
 		headers := http.Header{}
 		headers.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, headers)
+		conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, headers)
 		if err != nil {
 			if resp != nil {
 				log.Printf("Vest connection error: %v, Status: %s", err, resp.Status)
 			} else {
 				log.Printf("Vest connection error: %v", err)
 			}
-			time.Sleep(5 * time.Second)
+			stats.Error(err)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
 
 		log.Printf("Connected to Vest WebSocket")
+		stats.Connected()
+		subs := newSubscriptions(stats, "Vest", func(id int64, channel string) error {
+			return conn.WriteJSON(vestSubscribeRequest{Method: "SUBSCRIBE", Params: []string{channel}, ID: int(id)})
+		})
 
-		subReq := vestSubscribeRequest{Method: "SUBSCRIBE", Params: params, ID: 1}
+		subReq := vestSubscribeRequest{Method: "SUBSCRIBE", Params: params, ID: int(subs.request(params...))}
 		if err := conn.WriteJSON(subReq); err != nil {
 			log.Printf("Vest subscription error: %v", err)
+			stats.Error(err)
+			stats.Disconnected()
 			conn.Close()
-			time.Sleep(5 * time.Second)
+			if !stats.Backoff(ctx, 5*time.Second) {
+				return
+			}
 			continue
 		}
+		stopClose := context.AfterFunc(ctx, func() { conn.Close() })
 
 
 
@@ -153,7 +148,7 @@ func ConnectVestFutures(symbols []string, priceChan chan<- PriceData, orderbookC
 				select {
 				case <-ticker.C:
 					pingReq := vestPingRequest{Method: "PING", Params: []interface{}{}, ID: 0}
-					if err := c.WriteJSON(pingReq); err != nil {
+					if err := subs.write(func() error { return c.WriteJSON(pingReq) }); err != nil {
 						return
 					}
 				}
@@ -161,27 +156,49 @@ func ConnectVestFutures(symbols []string, priceChan chan<- PriceData, orderbookC
 		}(conn)
 
 		for {
-			_, msg, err := conn.ReadMessage()
+			msg, receivedAt, err := readFrame(conn, "vest_futures")
 			if err != nil {
 				log.Printf("Vest read error: %v", err)
+				stats.Error(err)
+				stats.Disconnected()
 				conn.Close()
 				break
 			}
 
+			var envelope struct {
+				Channel string `json:"channel"`
+			}
+			if err := json.Unmarshal(msg, &envelope); err != nil {
+				stats.ParseError()
+				continue
+			}
+			if !strings.HasSuffix(envelope.Channel, "@depth") {
+				binanceReply(subs, msg)
+				stats.Other(hasField(msg, "id", "data"))
+				continue
+			}
+
 			stdSymbol, bestBid, bestAsk, ts, ok := parseVestDepthMessage(msg)
 			if !ok {
+				stats.ParseError()
 				continue
 			}
+			stats.Message("depth")
 
 			orderbookChan <- OrderbookData{
-				Symbol:    stdSymbol,
-				Source:    "vest_futures",
-				BestBid:   bestBid,
-				BestAsk:   bestAsk,
-				Timestamp: ts,
+				Symbol:     stdSymbol,
+				Source:     "vest_futures",
+				BestBid:    bestBid,
+				BestAsk:    bestAsk,
+				Timestamp:  ts,
+				ReceivedAt: receivedAt,
 			}
 		}
+		subs.close()
+		stopClose()
 
-		time.Sleep(2 * time.Second)
+		if !stats.Backoff(ctx, 2*time.Second) {
+			return
+		}
 	}
 }
@@ -2,7 +2,7 @@ package exchanges
 
 import "testing"
 
-func TestConvertVestSymbol(t *testing.T) {
+func TestVestSymbols(t *testing.T) {
 	cases := []struct {
 		in   string
 		want string
@@ -18,8 +18,8 @@ func TestConvertVestSymbol(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		if got := convertToVestSymbol(tc.in); got != tc.want {
-			t.Fatalf("convertToVestSymbol(%q): got %q want %q", tc.in, got, tc.want)
+		if got, _ := NativeSymbol("vest_futures", tc.in); got != tc.want {
+			t.Fatalf("NativeSymbol(vest_futures, %q): got %q want %q", tc.in, got, tc.want)
 		}
 	}
 
@@ -35,8 +35,8 @@ func TestConvertVestSymbol(t *testing.T) {
 	}
 
 	for _, tc := range fromCases {
-		if got := convertFromVestSymbol(tc.in); got != tc.want {
-			t.Fatalf("convertFromVestSymbol(%q): got %q want %q", tc.in, got, tc.want)
+		if got, _ := CanonicalSymbol("vest_futures", tc.in); got != tc.want {
+			t.Fatalf("CanonicalSymbol(vest_futures, %q): got %q want %q", tc.in, got, tc.want)
 		}
 	}
 }
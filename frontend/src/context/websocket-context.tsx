@@ -14,7 +14,15 @@ export const WebSocketProvider: React.FC<{ children: React.ReactNode }> = ({ chi
     // In dev, usage with Vite proxy: ws://localhost:5173/ws -> http://localhost:8082/ws
     // The proxy configuration in vite.config.ts handles the /ws path
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const socketUrl = `${protocol}//${window.location.host}/ws`;
+    // Forward an api_key or token from the page URL when auth is enabled
+    const pageParams = new URLSearchParams(window.location.search);
+    const authParams = new URLSearchParams();
+    for (const name of ['api_key', 'token']) {
+        const value = pageParams.get(name);
+        if (value) authParams.set(name, value);
+    }
+    const authQuery = authParams.toString() ? `?${authParams.toString()}` : '';
+    const socketUrl = `${protocol}//${window.location.host}/ws${authQuery}`;
     console.log(`[WebSocket] Initializing connection to ${socketUrl} (Secure: ${window.location.protocol === 'https:'})`);
 
     const {
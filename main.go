@@ -1,512 +1,236 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
+	"futures-arbitrage-scanner/config"
 	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/replay"
+	"futures-arbitrage-scanner/scanner"
+	"futures-arbitrage-scanner/server"
+	"futures-arbitrage-scanner/simulator"
 
-	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 )
 
-type ArbitrageOpportunity struct {
-	Symbol     string  `json:"symbol"`
-	BuySource  string  `json:"buy_source"`
-	SellSource string  `json:"sell_source"`
-	BuyPrice   float64 `json:"buy_price"`
-	SellPrice  float64 `json:"sell_price"`
-	ProfitPct  float64 `json:"profit_pct"`
-	Timestamp  int64   `json:"timestamp"`
-}
-
-type BasisTradeOpportunity struct {
-	Symbol      string  `json:"symbol"`
-	DeDustPrice float64 `json:"dedust_price"`
-	ShortSource string  `json:"short_source"`
-	ShortPrice  float64 `json:"short_price"`
-	ProfitPct   float64 `json:"profit_pct"`
-	Timestamp   int64   `json:"timestamp"`
-}
-
-type FuturesScanner struct {
-	prices           map[string]map[string]float64
-	pricesMutex      sync.RWMutex
-	wsClients        map[*websocket.Conn]bool
-	clientsMutex     sync.RWMutex
-	wsWriteMutex     sync.Mutex // Protects WebSocket writes
-	upgrader         websocket.Upgrader
-	priceChan        chan exchanges.PriceData
-	orderbookChan    chan exchanges.OrderbookData
-	tradeChan        chan exchanges.TradeData
-	lastOpportunity  map[string]time.Time // Track last alert per symbol
-	opportunityMutex sync.RWMutex
-}
-
-func NewFuturesScanner() *FuturesScanner {
-	return &FuturesScanner{
-		prices:          make(map[string]map[string]float64),
-		wsClients:       make(map[*websocket.Conn]bool),
-		priceChan:       make(chan exchanges.PriceData, 1000),
-		orderbookChan:   make(chan exchanges.OrderbookData, 1000),
-		tradeChan:       make(chan exchanges.TradeData, 1000),
-		lastOpportunity: make(map[string]time.Time),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
-	}
-}
-
-func (s *FuturesScanner) processPrices() {
-	for priceData := range s.priceChan {
-		s.updatePrice(priceData)
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("No .env file found, using system environment variables")
 	}
-}
 
-func (s *FuturesScanner) processOrderbooks() {
-	for orderbookData := range s.orderbookChan {
-		// Calculate mid price from best bid and best ask
-		midPrice := (orderbookData.BestBid + orderbookData.BestAsk) / 2
-
-		priceData := exchanges.PriceData{
-			Symbol:    orderbookData.Symbol,
-			Source:    orderbookData.Source,
-			Price:     midPrice,
-			Timestamp: orderbookData.Timestamp,
+	if len(os.Args) > 1 && os.Args[1] == "mint-token" {
+		if err := runMintToken(os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
-
-		s.updatePrice(priceData)
-	}
-}
-
-func (s *FuturesScanner) processTrades() {
-	for range s.tradeChan {
-		// Keep trade data for future use but don't use for pricing
-	}
-}
-
-func (s *FuturesScanner) updatePrice(data exchanges.PriceData) {
-	s.pricesMutex.Lock()
-	if s.prices[data.Symbol] == nil {
-		s.prices[data.Symbol] = make(map[string]float64)
-	}
-	s.prices[data.Symbol][data.Source] = data.Price
-	s.pricesMutex.Unlock()
-
-	s.checkArbitrage(data.Symbol)
-	s.checkArbitrage(data.Symbol)
-	s.checkBasisTrade(data.Symbol)
-}
-
-func (s *FuturesScanner) checkBasisTrade(symbol string) {
-	s.pricesMutex.RLock()
-	sourcePrices, exists := s.prices[symbol]
-	if !exists {
-		s.pricesMutex.RUnlock()
-		return
-	}
-
-	dedustPrice, hasDeDust := sourcePrices["DeDust"]
-	if !hasDeDust {
-		s.pricesMutex.RUnlock()
 		return
 	}
-
-	pricesCopy := make(map[string]float64)
-	for source, price := range sourcePrices {
-		if source != "DeDust" {
-			pricesCopy[source] = price
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
-	s.pricesMutex.RUnlock()
 
-	// Find best short opportunity (Highest Price on Futures)
-	var bestShortPrice float64
-	var bestShortSource string
-	first := true
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config file; SCANNER_* variables override it")
+	simulate := flag.Bool("simulate", false, "run against a simulated market instead of the venues")
+	captureDir := flag.String("capture", os.Getenv("CAPTURE_DIR"), "write every inbound frame to <dir>/<venue>.jsonl")
+	flag.Parse()
 
-	for source, price := range pricesCopy {
-		// Strict filter: Short leg MUST be a Futures/Perps exchange
-		// We filter out anything that is "spot" or doesn't have "futures"
-		if strings.Contains(strings.ToLower(source), "spot") {
-			continue
-		}
-		if !strings.Contains(strings.ToLower(source), "futures") && !strings.Contains(strings.ToLower(source), "perp") {
-			continue
-		}
-
-		if first || price > bestShortPrice {
-			bestShortPrice = price
-			bestShortSource = source
-			first = false
+	if *captureDir != "" {
+		if err := exchanges.SetCaptureDir(*captureDir); err != nil {
+			log.Fatal(err)
 		}
+		log.Printf("Capturing raw frames to %s", *captureDir)
 	}
 
-	if bestShortSource == "" {
-		return
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
-
-	// Basis Trade: Buy DeDust (Low), Short Futures (High)
-	if bestShortPrice > dedustPrice {
-		profitPct := ((bestShortPrice - dedustPrice) / dedustPrice) * 100
-		
-		// Threshold for Basis Trade (can be lower or 0 if we want to stream all spreads)
-		// User mentioned "In the table we will see... filter spread"
-		// Let's stream it if there is ANY profit (>0)
-		if profitPct > 0 {
-			opportunity := BasisTradeOpportunity{
-				Symbol:      symbol,
-				DeDustPrice: dedustPrice,
-				ShortSource: bestShortSource,
-				ShortPrice:  bestShortPrice,
-				ProfitPct:   profitPct,
-				Timestamp:   time.Now().UnixMilli(),
-			}
-			s.broadcastBasisTrade(opportunity)
-		}
+	if *simulate {
+		cfg.Simulate.Enabled = true
 	}
-}
+	cfg.ApplyEndpoints()
 
-func (s *FuturesScanner) broadcastBasisTrade(opportunity BasisTradeOpportunity) {
-	s.clientsMutex.RLock()
-	clients := make([]*websocket.Conn, 0, len(s.wsClients))
-	for client := range s.wsClients {
-		clients = append(clients, client)
+	auth, err := server.NewAuthenticator(os.Getenv("AUTH_KEYS_FILE"), os.Getenv("AUTH_TOKEN_SECRET"), os.Getenv("ALLOWED_ORIGINS"))
+	if err != nil {
+		log.Fatalf("Auth setup failed: %v", err)
 	}
-	s.clientsMutex.RUnlock()
-
-	message := map[string]interface{}{
-		"type":        "basis_trade",
-		"opportunity": opportunity,
+	if !auth.Enabled() {
+		log.Println("Authentication disabled: set AUTH_KEYS_FILE or AUTH_TOKEN_SECRET to require credentials")
 	}
+	go auth.Watch()
 
-	s.wsWriteMutex.Lock()
-	defer s.wsWriteMutex.Unlock()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	var toRemove []*websocket.Conn
-	for _, client := range clients {
-		err := client.WriteJSON(message)
-		if err != nil {
-			// log.Printf("WebSocket write error: %v", err)
-			client.Close()
-			toRemove = append(toRemove, client)
+	connectors := scanner.DefaultConnectors()
+	if cfg.Simulate.Enabled {
+		connectors = simulated(ctx, cfg, connectors)
+	} else {
+		// Markets are known before connectors subscribe: from the cache,
+		// then from the venues if they answer in time.
+		discovery := exchanges.NewDiscovery(cfg.Markets.Cache, cfg.Markets.RefreshInterval)
+		if err := discovery.LoadCache(); err != nil {
+			log.Printf("Markets cache: %v", err)
 		}
-	}
-
-	if len(toRemove) > 0 {
-		s.clientsMutex.Lock()
-		for _, client := range toRemove {
-			delete(s.wsClients, client)
+		discoverCtx, cancelDiscover := context.WithTimeout(ctx, 20*time.Second)
+		if err := discovery.Refresh(discoverCtx); err != nil {
+			log.Printf("Market discovery: %v", err)
 		}
-		s.clientsMutex.Unlock()
+		cancelDiscover()
+		go discovery.Run(ctx)
 	}
-}
 
-func (s *FuturesScanner) checkArbitrage(symbol string) {
-	s.pricesMutex.RLock()
-	sourcePrices, exists := s.prices[symbol]
-	if !exists || len(sourcePrices) < 2 {
-		s.pricesMutex.RUnlock()
-		return
-	}
+	sc := scanner.New(cfg.ScannerOptions(connectors...)...)
+	manager := config.NewManager(*configPath, cfg, sc)
+	go manager.Watch(ctx)
 
-	// Create a copy of the prices map to avoid race conditions
-	pricesCopy := make(map[string]float64)
-	for source, price := range sourcePrices {
-		pricesCopy[source] = price
-	}
-	s.pricesMutex.RUnlock()
+	srv := server.New(sc, server.Options{
+		Auth:           auth,
+		OverflowPolicy: os.Getenv("WS_OVERFLOW_POLICY"),
+		PricesInterval: cfg.BroadcastInterval,
+		Config:         manager,
+	})
 
-	var minPrice, maxPrice float64
-	var minSource, maxSource string
-	first := true
+	go sc.Run(ctx)
+	go srv.Run(ctx)
 
-	for source, price := range pricesCopy {
-		if first {
-			minPrice = price
-			maxPrice = price
-			minSource = source
-			maxSource = source
-			first = false
-			continue
-		}
+	srv.RegisterRoutes(http.DefaultServeMux)
+	http.Handle("/", http.FileServer(http.Dir("./static/")))
 
-		if price < minPrice {
-			minPrice = price
-			minSource = source
-		}
-		if price > maxPrice {
-			maxPrice = price
-			maxSource = source
-		}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8082"
 	}
 
-	profitPct := ((maxPrice - minPrice) / minPrice) * 100
-
-	// Only alert if profit is significant (>0.05%) and we haven't alerted recently
-	if profitPct > 0.05 {
-		opportunityKey := fmt.Sprintf("%s_%s_%s", symbol, minSource, maxSource)
-
-		s.opportunityMutex.RLock()
-		lastAlert, exists := s.lastOpportunity[opportunityKey]
-		s.opportunityMutex.RUnlock()
-
-		now := time.Now()
-		// Only send alert if it's been more than 10 seconds since last alert for this pair
-		// This prevents spam while still allowing frequent updates for crypto markets
-		if !exists || now.Sub(lastAlert) > 10*time.Second {
-			s.opportunityMutex.Lock()
-			s.lastOpportunity[opportunityKey] = now
-			s.opportunityMutex.Unlock()
-
-			opportunity := ArbitrageOpportunity{
-				Symbol:     symbol,
-				BuySource:  minSource,
-				SellSource: maxSource,
-				BuyPrice:   minPrice,
-				SellPrice:  maxPrice,
-				ProfitPct:  profitPct,
-				Timestamp:  now.UnixMilli(),
-			}
-
-			s.broadcastOpportunity(opportunity)
-		}
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
 	}
+	go func() {
+		lis, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Printf("gRPC listen error on :%s: %v", grpcPort, err)
+			return
+		}
+		log.Printf("gRPC server starting on :%s", grpcPort)
+		if err := srv.GRPCServer().Serve(lis); err != nil {
+			log.Printf("gRPC server error: %v", err)
+		}
+	}()
 
-	// Always broadcast current spreads for the spread matrix using the copy
-	s.broadcastSpreads(symbol, pricesCopy)
-}
-
-func (s *FuturesScanner) broadcastOpportunity(opportunity ArbitrageOpportunity) {
-	s.clientsMutex.RLock()
-	clients := make([]*websocket.Conn, 0, len(s.wsClients))
-	for client := range s.wsClients {
-		clients = append(clients, client)
-	}
-	s.clientsMutex.RUnlock()
+	httpServer := &http.Server{Addr: ":" + port}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
 
-	message := map[string]interface{}{
-		"type":        "arbitrage",
-		"opportunity": opportunity,
+	log.Printf("Server starting on http://localhost:%s", port)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
 	}
+}
 
-	s.wsWriteMutex.Lock()
-	defer s.wsWriteMutex.Unlock()
+// simulated swaps connectors for a simulated market. With serve_ws, venues
+// that have a fake server keep their real connector, pointed at it.
+func simulated(ctx context.Context, cfg *config.Config, connectors []scanner.Connector) []scanner.Connector {
+	sim := simulator.New(cfg.Simulate.Market)
+	log.Printf("Simulate mode: no venue is contacted (seed %d)", sim.Config().Seed)
 
-	var toRemove []*websocket.Conn
-	for _, client := range clients {
-		err := client.WriteJSON(message)
-		if err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			client.Close()
-			toRemove = append(toRemove, client)
+	var served []string
+	if cfg.Simulate.Servers {
+		var venues []string
+		for _, c := range connectors {
+			venues = append(venues, c.Name)
 		}
-	}
-
-	// Remove failed clients
-	if len(toRemove) > 0 {
-		s.clientsMutex.Lock()
-		for _, client := range toRemove {
-			delete(s.wsClients, client)
+		var err error
+		if served, err = sim.Serve(ctx, venues); err != nil {
+			log.Fatalf("Simulate mode: %v", err)
 		}
-		s.clientsMutex.Unlock()
 	}
+	return sim.Connectors(connectors, served...)
 }
 
-func (s *FuturesScanner) broadcastSpreads(symbol string, sourcePrices map[string]float64) {
-	s.clientsMutex.RLock()
-	clients := make([]*websocket.Conn, 0, len(s.wsClients))
-	for client := range s.wsClients {
-		clients = append(clients, client)
+// runMintToken implements "mint-token": it prints a token signed with
+// AUTH_TOKEN_SECRET, and its id on stderr.
+func runMintToken(args []string) error {
+	fs := flag.NewFlagSet("mint-token", flag.ContinueOnError)
+	sub := fs.String("sub", "", "token subject, used to revoke it")
+	scopeName := fs.String("scope", "read", "read or admin")
+	ttl := fs.Duration("ttl", 30*24*time.Hour, "lifetime")
+	maxConns := fs.Int("max-conns", 0, "streaming connection limit, 0 for none")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	s.clientsMutex.RUnlock()
 
-	// Calculate all pairwise spreads
-	spreads := make(map[string]map[string]float64)
-
-	for buySource, buyPrice := range sourcePrices {
-		spreads[buySource] = make(map[string]float64)
-		for sellSource, sellPrice := range sourcePrices {
-			if buySource != sellSource {
-				spreadPct := ((sellPrice - buyPrice) / buyPrice) * 100
-				spreads[buySource][sellSource] = spreadPct
-			}
-		}
+	secret := os.Getenv("AUTH_TOKEN_SECRET")
+	if secret == "" {
+		return errors.New("AUTH_TOKEN_SECRET is not set")
 	}
-
-	message := map[string]interface{}{
-		"type":    "spreads",
-		"symbol":  symbol,
-		"spreads": spreads,
-		"prices":  sourcePrices,
+	if *sub == "" {
+		return errors.New("-sub is required")
 	}
-
-	s.wsWriteMutex.Lock()
-	defer s.wsWriteMutex.Unlock()
-
-	var toRemove []*websocket.Conn
-	for _, client := range clients {
-		err := client.WriteJSON(message)
-		if err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			client.Close()
-			toRemove = append(toRemove, client)
-		}
+	if *ttl <= 0 {
+		return errors.New("-ttl must be positive")
 	}
 
-	// Remove failed clients
-	if len(toRemove) > 0 {
-		s.clientsMutex.Lock()
-		for _, client := range toRemove {
-			delete(s.wsClients, client)
-		}
-		s.clientsMutex.Unlock()
+	claims := server.TokenClaims{
+		ID:       server.NewTokenID(),
+		Subject:  *sub,
+		Scope:    *scopeName,
+		Expires:  time.Now().Add(*ttl).Unix(),
+		MaxConns: *maxConns,
 	}
-}
-
-func (s *FuturesScanner) broadcastPrices() {
-	ticker := time.NewTicker(200 * time.Millisecond)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		s.pricesMutex.RLock()
-		pricesCopy := make(map[string]map[string]float64)
-		for symbol, prices := range s.prices {
-			pricesCopy[symbol] = make(map[string]float64)
-			for exchange, price := range prices {
-				pricesCopy[symbol][exchange] = price
-			}
-		}
-		s.pricesMutex.RUnlock()
-
-		if len(pricesCopy) > 0 {
-			message := map[string]interface{}{
-				"type":   "prices",
-				"prices": pricesCopy,
-			}
-
-			s.clientsMutex.RLock()
-			clients := make([]*websocket.Conn, 0, len(s.wsClients))
-			for client := range s.wsClients {
-				clients = append(clients, client)
-			}
-			s.clientsMutex.RUnlock()
-
-			s.wsWriteMutex.Lock()
-			var toRemove []*websocket.Conn
-			for _, client := range clients {
-				err := client.WriteJSON(message)
-				if err != nil {
-					log.Printf("WebSocket write error: %v", err)
-					client.Close()
-					toRemove = append(toRemove, client)
-				}
-			}
-			s.wsWriteMutex.Unlock()
-
-			// Remove failed clients
-			if len(toRemove) > 0 {
-				s.clientsMutex.Lock()
-				for _, client := range toRemove {
-					delete(s.wsClients, client)
-				}
-				s.clientsMutex.Unlock()
-			}
-		}
+	token, err := server.SignToken([]byte(secret), claims)
+	if err != nil {
+		return err
 	}
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "token id %s\n", claims.ID)
+	return nil
 }
 
-func (s *FuturesScanner) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	log.Printf("WebSocket connection attempt from %s", r.RemoteAddr)
-
-	conn, err := s.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WebSocket upgrade error from %s: %v", r.RemoteAddr, err)
-		return
+// runReplay implements "replay": it plays a capture file to its venue's
+// connector and prints what the connector emitted as JSON.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	venue := fs.String("venue", "", "connector to replay to, by default the venue the frames were captured from")
+	symbols := fs.String("symbols", "TONUSDT", "comma-separated symbols the connector subscribes to")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	defer conn.Close()
-
-	s.clientsMutex.Lock()
-	s.wsClients[conn] = true
-	clientCount := len(s.wsClients)
-	s.clientsMutex.Unlock()
-
-	log.Printf("WebSocket client connected from %s. Total clients: %d", r.RemoteAddr, clientCount)
-
-	defer func() {
-		s.clientsMutex.Lock()
-		delete(s.wsClients, conn)
-		log.Printf("WebSocket client disconnected. Total clients: %d", len(s.wsClients))
-		s.clientsMutex.Unlock()
-	}()
-
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
-		}
+	if fs.NArg() != 1 {
+		return errors.New("usage: replay [-venue name] [-symbols list] capture.jsonl")
 	}
-}
 
-func main() {
-	err := godotenv.Load()
+	frames, err := exchanges.ReadCapture(fs.Arg(0))
 	if err != nil {
-		log.Println("No .env file found, using system environment variables")
+		return err
 	}
-
-	scanner := NewFuturesScanner()
-
-	symbols := []string{"TONUSDT"}
-
-	// Start processing goroutines
-	go scanner.processPrices()
-	go scanner.processOrderbooks()
-	go scanner.processTrades()
-
-	// Start exchange connections with orderbook feeds
-	go exchanges.ConnectBinanceFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectBybitFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectHyperliquidFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectKrakenFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectOKXFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectGateFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectParadexFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectVestFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectExtendedFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectVariationalFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectLighterFutures(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-
-	// Start spot exchange connections with orderbook feeds
-	go exchanges.ConnectBinanceSpot(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-	go exchanges.ConnectBybitSpot(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-
-
-    // Start DeDust connection
-    go exchanges.ConnectDeDust(scanner.priceChan)
-	// Start Pyth price feed connection
-	go exchanges.ConnectPythPrices(symbols, scanner.priceChan, scanner.orderbookChan, scanner.tradeChan)
-
-	go scanner.broadcastPrices()
-
-	http.HandleFunc("/ws", scanner.handleWebSocket)
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"status":"ok","version":"v2-debug"}`))
-	})
-	http.Handle("/", http.FileServer(http.Dir("./static/")))
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8082"
+	if *venue == "" && len(frames) > 0 {
+		*venue = frames[0].Venue
 	}
-
-	log.Printf("Server starting on http://localhost:%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	res, err := replay.Run(context.Background(), *venue, strings.Split(*symbols, ","), frames)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
 }
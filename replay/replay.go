@@ -0,0 +1,259 @@
+// Package replay plays frames captured with exchanges.SetCaptureDir back to
+// a venue's connector and reports what it made of them, so a schema change
+// can be reproduced from the frames that broke it and captures can serve
+// as golden test fixtures.
+//
+// The connector runs unchanged: a local server stands in for the venue,
+// answers its connection the way the venue's transport does (a websocket,
+// an event stream or polls) and sends the captured frames verbatim.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+
+	"github.com/gorilla/websocket"
+)
+
+// subscribeWait is how long the websocket server waits for a client's
+// first frame, its subscription, before sending; some venues subscribe in
+// the URL and send nothing.
+const subscribeWait = 200 * time.Millisecond
+
+// Timeout bounds a replay.
+const Timeout = 30 * time.Second
+
+// polled venues are read a response at a time; eventStream venues over
+// server-sent events. The rest stream over a websocket.
+var (
+	polled      = map[string]bool{"DeDust": true, "variational_perps": true}
+	eventStream = map[string]bool{"pyth": true}
+)
+
+// Result is what a connector emitted for a capture, in order, with its
+// counters over the replay. ReceivedAt is zeroed: it is the replay's
+// clock, not the capture's.
+type Result struct {
+	Venue       string                    `json:"venue"`
+	Frames      int                       `json:"frames"`
+	Prices      []exchanges.PriceData     `json:"prices"`
+	Books       []exchanges.OrderbookData `json:"books"`
+	Trades      []exchanges.TradeData     `json:"trades"`
+	Messages    map[string]uint64         `json:"messages"`
+	ParseErrors uint64                    `json:"parse_errors"`
+	Unknown     uint64                    `json:"unknown"`
+}
+
+// Run plays frames to venue's connector, subscribed to symbols, and
+// returns what it emitted. The connector's endpoints point at the replay
+// server while it runs, so run one replay per venue at a time.
+func Run(ctx context.Context, venue string, symbols []string, frames []exchanges.Frame) (Result, error) {
+	connector, ok := connectorFor(venue)
+	if !ok {
+		return Result{}, fmt.Errorf("replay: unknown venue %q", venue)
+	}
+
+	srv := newServer(venue, frames)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Result{}, err
+	}
+	httpServer := &http.Server{Handler: srv}
+	go httpServer.Serve(lis)
+	defer httpServer.Close()
+
+	base := lis.Addr().String()
+	e := exchanges.Endpoints{WS: "ws://" + base, PollInterval: 10 * time.Millisecond}
+	if polled[venue] || eventStream[venue] {
+		e = exchanges.Endpoints{REST: "http://" + base, PollInterval: 10 * time.Millisecond}
+	}
+	exchanges.SetEndpoints(venue, e)
+	defer exchanges.SetEndpoints(venue, exchanges.Endpoints{})
+
+	stats := exchanges.Stats(venue)
+	before := stats.Snapshot()
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	prices := make(chan exchanges.PriceData)
+	books := make(chan exchanges.OrderbookData)
+	trades := make(chan exchanges.TradeData)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		connector.Run(runCtx, exchanges.NewSymbolSet(symbols), scanner.Feeds{Prices: prices, Orderbooks: books, Trades: trades})
+	}()
+
+	res := Result{Venue: venue, Frames: len(frames)}
+	done := srv.done
+	for {
+		select {
+		case p := <-prices:
+			p.ReceivedAt = 0
+			res.Prices = append(res.Prices, p)
+		case b := <-books:
+			b.ReceivedAt = 0
+			res.Books = append(res.Books, b)
+		case t := <-trades:
+			t.ReceivedAt = 0
+			res.Trades = append(res.Trades, t)
+		case <-done:
+			// Every frame is out; once the connector has read them all
+			// it reports the connection lost, or polls again.
+			done = nil
+			go func() {
+				if !polled[venue] {
+					for runCtx.Err() == nil {
+						if st := stats.Snapshot(); st.Connects > before.Connects && !st.Connected {
+							break
+						}
+						time.Sleep(5 * time.Millisecond)
+					}
+				}
+				stop()
+			}()
+		case <-finished:
+			after := stats.Snapshot()
+			res.Messages = make(map[string]uint64)
+			for kind, n := range after.Messages {
+				if d := n - before.Messages[kind]; d > 0 {
+					res.Messages[kind] = d
+				}
+			}
+			res.ParseErrors = after.ParseErrors - before.ParseErrors
+			res.Unknown = after.Unknown - before.Unknown
+			if ctx.Err() != nil {
+				return res, errors.New("replay: timed out before the connector read every frame")
+			}
+			return res, nil
+		case <-ctx.Done():
+			stop()
+		}
+	}
+}
+
+func connectorFor(venue string) (scanner.Connector, bool) {
+	for _, c := range scanner.DefaultConnectors() {
+		if c.Name == venue {
+			return c, true
+		}
+	}
+	return scanner.Connector{}, false
+}
+
+// server sends the frames to the first client that connects and turns the
+// rest away, so a connector's reconnect doesn't see them twice.
+type server struct {
+	venue    string
+	frames   []exchanges.Frame
+	upgrader websocket.Upgrader
+
+	mu     sync.Mutex
+	served bool
+	polls  int
+	done   chan struct{}
+}
+
+func newServer(venue string, frames []exchanges.Frame) *server {
+	return &server{venue: venue, frames: frames, done: make(chan struct{})}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if polled[s.venue] {
+		s.servePoll(w)
+		return
+	}
+
+	s.mu.Lock()
+	first := !s.served
+	s.served = true
+	s.mu.Unlock()
+	if !first {
+		http.Error(w, "replay finished", http.StatusServiceUnavailable)
+		return
+	}
+	defer close(s.done)
+
+	if eventStream[s.venue] {
+		s.serveEvents(w)
+		return
+	}
+	s.serveWebSocket(w, r)
+}
+
+// servePoll answers each poll with the next frame. The poll after the last
+// means the connector has handled them all.
+func (s *server) servePoll(w http.ResponseWriter) {
+	s.mu.Lock()
+	i := s.polls
+	s.polls++
+	s.mu.Unlock()
+
+	if i >= len(s.frames) {
+		if i == len(s.frames) {
+			close(s.done)
+		}
+		http.Error(w, "replay finished", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(s.frames[i].Data))
+}
+
+func (s *server) serveEvents(w http.ResponseWriter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, f := range s.frames {
+		fmt.Fprintf(w, "data: %s\n\n", f.Data)
+	}
+	flusher.Flush()
+}
+
+func (s *server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Replay %s: %v", s.venue, err)
+		return
+	}
+	defer conn.Close()
+
+	subscribed := make(chan struct{}, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			select {
+			case subscribed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	select {
+	case <-subscribed:
+	case <-time.After(subscribeWait):
+	}
+
+	for _, f := range s.frames {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(f.Data)); err != nil {
+			log.Printf("Replay %s: %v", s.venue, err)
+			return
+		}
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "replay finished"), time.Now().Add(time.Second))
+}
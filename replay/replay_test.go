@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the captures")
+
+// TestGolden replays every capture in testdata and compares the result
+// with its .golden.json. To turn a capture into a fixture, copy it to
+// testdata and run go test ./replay -update.
+func TestGolden(t *testing.T) {
+	captures, err := filepath.Glob(filepath.Join("testdata", "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captures) == 0 {
+		t.Fatal("no captures in testdata")
+	}
+
+	for _, path := range captures {
+		venue := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		t.Run(venue, func(t *testing.T) {
+			frames, err := exchanges.ReadCapture(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := Run(context.Background(), venue, []string{"BTCUSDT", "TONUSDT"}, frames)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(path, ".jsonl") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("replay of %s differs from %s:\n%s", path, golden, got)
+			}
+		})
+	}
+}
+
+func TestCaptureRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := exchanges.SetCaptureDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer exchanges.SetCaptureDir("")
+
+	frames, err := exchanges.ReadCapture(filepath.Join("testdata", "binance_futures.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(context.Background(), "binance_futures", []string{"BTCUSDT", "TONUSDT"}, frames); err != nil {
+		t.Fatal(err)
+	}
+	exchanges.SetCaptureDir("")
+
+	// The replayed connector captures what it is sent: the same frames.
+	captured, err := exchanges.ReadCapture(exchanges.CapturePath(dir, "binance_futures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captured) != len(frames) {
+		t.Fatalf("captured %d frames, want %d", len(captured), len(frames))
+	}
+	for i := range frames {
+		if captured[i].Data != frames[i].Data || captured[i].Venue != "binance_futures" || captured[i].ReceivedAt == 0 {
+			t.Errorf("frame %d: got %+v, want data %q", i, captured[i], frames[i].Data)
+		}
+	}
+}
+
+func TestRunUnknownVenue(t *testing.T) {
+	if _, err := Run(context.Background(), "nowhere", nil, nil); err == nil {
+		t.Error("expected an error for an unknown venue")
+	}
+}
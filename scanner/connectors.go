@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"context"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// DefaultConnectors returns every venue connector in the exchanges package.
+// The live ones change subscriptions in place; the rest reconnect when
+// their symbols change.
+func DefaultConnectors() []Connector {
+	return []Connector{
+		FromLiveFeedFunc("binance_futures", exchanges.ConnectBinanceFutures),
+		FromLiveFeedFunc("bybit_futures", exchanges.ConnectBybitFutures),
+		FromLiveFeedFunc("hyperliquid_futures", exchanges.ConnectHyperliquidFutures),
+		FromLiveFeedFunc("kraken_futures", exchanges.ConnectKrakenFutures),
+		FromLiveFeedFunc("okx_futures", exchanges.ConnectOKXFutures),
+		FromLiveFeedFunc("gate_futures", exchanges.ConnectGateFutures),
+		FromLiveFeedFunc("paradex_futures", exchanges.ConnectParadexFutures),
+		FromFeedFunc("vest_futures", exchanges.ConnectVestFutures),
+		FromFeedFunc("extended_futures", exchanges.ConnectExtendedFutures),
+		FromFeedFunc("variational_perps", exchanges.ConnectVariationalFutures),
+		FromFeedFunc("lighter_futures", exchanges.ConnectLighterFutures),
+
+		FromLiveFeedFunc("binance_inverse", exchanges.ConnectBinanceInverse),
+		FromLiveFeedFunc("bybit_inverse", exchanges.ConnectBybitInverse),
+		FromLiveFeedFunc("okx_inverse", exchanges.ConnectOKXInverse),
+		FromLiveFeedFunc("kraken_inverse", exchanges.ConnectKrakenInverse),
+
+		FromLiveFeedFunc("binance_spot", exchanges.ConnectBinanceSpot),
+		FromLiveFeedFunc("bybit_spot", exchanges.ConnectBybitSpot),
+
+		{
+			// DeDust only quotes TON and ignores the symbol list.
+			Name: "DeDust",
+			Live: true,
+			Run: func(ctx context.Context, symbols *exchanges.SymbolSet, feeds Feeds) {
+				exchanges.ConnectDeDust(ctx, feeds.Prices)
+			},
+		},
+		FromFeedFunc("pyth", exchanges.ConnectPythPrices),
+	}
+}
@@ -0,0 +1,212 @@
+package scanner
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// HealthCheckInterval is how often connector counters are checked for
+// degraded sources; parse error ratios are taken over this window.
+const HealthCheckInterval = 5 * time.Second
+
+// Source health states. A degraded source is connected but its messages
+// no longer parse, which usually means the venue changed its API, or some
+// of its shards are disconnected.
+const (
+	HealthOK           = "ok"
+	HealthDegraded     = "degraded"
+	HealthDisconnected = "disconnected"
+)
+
+// HealthThresholds decide when a connected source is degraded: when more
+// than MaxParseErrorRatio of its frames in a check window fail to parse,
+// counted once the window has at least MinFrames, or when no valid update
+// has arrived for UpdateTimeout since it connected or last updated. The
+// scanner is ready once every symbol has fresh quotes from MinFreshVenues
+// sources. A zero field disables its check.
+type HealthThresholds struct {
+	MaxParseErrorRatio float64
+	MinFrames          uint64
+	UpdateTimeout      time.Duration
+	MinFreshVenues     int
+}
+
+// DefaultHealthThresholds are used when WithHealthThresholds isn't given.
+// Two fresh venues are the fewest a spread can be taken between.
+var DefaultHealthThresholds = HealthThresholds{
+	MaxParseErrorRatio: 0.5,
+	MinFrames:          20,
+	UpdateTimeout:      30 * time.Second,
+	MinFreshVenues:     2,
+}
+
+// SourceHealth is a source's health and when it last changed.
+type SourceHealth struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Since  int64  `json:"since"` // Unix ms
+}
+
+// healthMonitor keeps each source's health between checks and the counters
+// its ratios are measured from.
+type healthMonitor struct {
+	mu     sync.Mutex
+	prev   map[string]exchanges.SourceStatsSnapshot
+	status map[string]SourceHealth
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		prev:   make(map[string]exchanges.SourceStatsSnapshot),
+		status: make(map[string]SourceHealth),
+	}
+}
+
+// check updates every source's health from stats and returns the changes
+// into or out of the degraded state.
+func (m *healthMonitor) check(now time.Time, t HealthThresholds, stats []exchanges.SourceStatsSnapshot) []SourceHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var changed []SourceHealth
+	for _, st := range stats {
+		status, reason := evaluateHealth(now, t, m.prev[st.Source], st)
+		m.prev[st.Source] = st
+
+		old, seen := m.status[st.Source]
+		if seen && old.Status == status && old.Reason == reason {
+			continue
+		}
+		h := SourceHealth{Source: st.Source, Status: status, Reason: reason, Since: now.UnixMilli()}
+		if seen && old.Status == status {
+			h.Since = old.Since
+		}
+		m.status[st.Source] = h
+		if status == HealthDegraded || (seen && old.Status == HealthDegraded) {
+			changed = append(changed, h)
+		}
+	}
+	return changed
+}
+
+func evaluateHealth(now time.Time, t HealthThresholds, prev, st exchanges.SourceStatsSnapshot) (status, reason string) {
+	if !st.Connected {
+		return HealthDisconnected, ""
+	}
+
+	var down []string
+	for _, sh := range st.Shards {
+		if !sh.Connected {
+			down = append(down, strconv.Itoa(sh.Shard))
+		}
+	}
+	if len(down) == 1 {
+		return HealthDegraded, fmt.Sprintf("shard %s of %d disconnected", down[0], len(st.Shards))
+	} else if len(down) > 1 {
+		return HealthDegraded, fmt.Sprintf("shards %s of %d disconnected", strings.Join(down, ", "), len(st.Shards))
+	}
+
+	frames := st.Received - prev.Received
+	failed := st.ParseErrors - prev.ParseErrors
+	if t.MaxParseErrorRatio > 0 && frames > 0 && frames >= t.MinFrames && float64(failed)/float64(frames) > t.MaxParseErrorRatio {
+		return HealthDegraded, fmt.Sprintf("%d of %d frames failed to parse", failed, frames)
+	}
+
+	if t.UpdateTimeout > 0 {
+		if st.LastUpdate.Before(st.ConnectedAt) {
+			if now.Sub(st.ConnectedAt) > t.UpdateTimeout {
+				return HealthDegraded, fmt.Sprintf("no valid update in %v since connecting", t.UpdateTimeout)
+			}
+		} else if now.Sub(st.LastUpdate) > t.UpdateTimeout {
+			return HealthDegraded, fmt.Sprintf("no valid update for %v", t.UpdateTimeout)
+		}
+	}
+	return HealthOK, ""
+}
+
+// checkHealth checks every connector's counters, logs sources that become
+// degraded or recover and passes the changes to OnHealth handlers.
+func (s *Scanner) checkHealth(now time.Time) {
+	for _, h := range s.health.check(now, s.HealthThresholds(), exchanges.AllStats()) {
+		if h.Status == HealthDegraded {
+			log.Printf("Source %s degraded: %s", h.Source, h.Reason)
+		} else {
+			log.Printf("Source %s recovered (%s)", h.Source, h.Status)
+		}
+		h := h
+		s.emit(func(handlers Handlers) {
+			if handlers.OnHealth != nil {
+				handlers.OnHealth(h)
+			}
+		})
+	}
+}
+
+// Health returns the health of every source as of the last check, sorted
+// by source.
+func (s *Scanner) Health() []SourceHealth {
+	s.health.mu.Lock()
+	out := make([]SourceHealth, 0, len(s.health.status))
+	for _, h := range s.health.status {
+		out = append(out, h)
+	}
+	s.health.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+// HealthThresholds returns the current degraded source thresholds.
+func (s *Scanner) HealthThresholds() HealthThresholds {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg.health
+}
+
+// SetHealthThresholds replaces the degraded source thresholds from the
+// next check.
+func (s *Scanner) SetHealthThresholds(t HealthThresholds) {
+	s.cfgMu.Lock()
+	s.cfg.health = t
+	s.cfgMu.Unlock()
+}
+
+// SymbolReadiness lists the sources with a fresh quote for a symbol.
+type SymbolReadiness struct {
+	Symbol string   `json:"symbol"`
+	Fresh  []string `json:"fresh"`
+	Ready  bool     `json:"ready"`
+}
+
+// Readiness reports whether every one of the scanner's symbols has fresh
+// quotes from at least MinFreshVenues sources, and the sources each has.
+func (s *Scanner) Readiness() (bool, []SymbolReadiness) {
+	min := s.HealthThresholds().MinFreshVenues
+	fresh := make(map[string][]string)
+	for _, q := range s.Quotes("") {
+		if q.Fresh {
+			fresh[q.Symbol] = append(fresh[q.Symbol], q.Source)
+		}
+	}
+
+	ready := true
+	symbols := s.Symbols()
+	out := make([]SymbolReadiness, 0, len(symbols))
+	for _, symbol := range symbols {
+		r := SymbolReadiness{Symbol: symbol, Fresh: fresh[symbol], Ready: len(fresh[symbol]) >= min}
+		if r.Fresh == nil {
+			r.Fresh = []string{}
+		}
+		ready = ready && r.Ready
+		out = append(out, r)
+	}
+	return ready, out
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+func TestEvaluateHealth(t *testing.T) {
+	now := time.Now()
+	thresholds := HealthThresholds{MaxParseErrorRatio: 0.5, MinFrames: 10, UpdateTimeout: 30 * time.Second}
+	connected := exchanges.SourceStatsSnapshot{Connected: true, ConnectedAt: now.Add(-time.Minute), LastUpdate: now.Add(-time.Second)}
+
+	with := func(fn func(*exchanges.SourceStatsSnapshot)) exchanges.SourceStatsSnapshot {
+		st := connected
+		fn(&st)
+		return st
+	}
+
+	tests := []struct {
+		name       string
+		thresholds HealthThresholds
+		prev, st   exchanges.SourceStatsSnapshot
+		want       string
+	}{
+		{"updating", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 100; st.ParseErrors = 10 }), HealthOK},
+		{"disconnected", thresholds, connected, exchanges.SourceStatsSnapshot{}, HealthDisconnected},
+		{"parse errors", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 100; st.ParseErrors = 60 }), HealthDegraded},
+		{"too few frames to judge", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 5; st.ParseErrors = 5 }), HealthOK},
+		{
+			"ratio over the window only", thresholds,
+			with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 100; st.ParseErrors = 90 }),
+			with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 200; st.ParseErrors = 95 }),
+			HealthOK,
+		},
+		{"ratio check off", HealthThresholds{UpdateTimeout: time.Minute}, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.Received = 100; st.ParseErrors = 100 }), HealthOK},
+		{"no update since connecting", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.LastUpdate = time.Time{} }), HealthDegraded},
+		{"just connected", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) {
+			st.ConnectedAt = now.Add(-time.Second)
+			st.LastUpdate = time.Time{}
+		}), HealthOK},
+		{"updates stopped", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.LastUpdate = now.Add(-40 * time.Second) }), HealthDegraded},
+		{"timeout off", HealthThresholds{}, connected, with(func(st *exchanges.SourceStatsSnapshot) { st.LastUpdate = time.Time{} }), HealthOK},
+		{"all shards connected", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) {
+			st.Shards = []exchanges.SourceStatsSnapshot{{Shard: 1, Connected: true}, {Shard: 2, Connected: true}}
+		}), HealthOK},
+		{"shard disconnected", thresholds, connected, with(func(st *exchanges.SourceStatsSnapshot) {
+			st.Shards = []exchanges.SourceStatsSnapshot{{Shard: 1, Connected: true}, {Shard: 2}}
+		}), HealthDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := evaluateHealth(now, tt.thresholds, tt.prev, tt.st)
+			if got != tt.want {
+				t.Errorf("got %s (%s), want %s", got, reason, tt.want)
+			}
+			if (got == HealthDegraded) != (reason != "") {
+				t.Errorf("status %s with reason %q", got, reason)
+			}
+		})
+	}
+}
+
+func TestHealthMonitorReportsTransitions(t *testing.T) {
+	m := newHealthMonitor()
+	thresholds := HealthThresholds{MaxParseErrorRatio: 0.5, MinFrames: 10}
+	now := time.Now()
+	st := exchanges.SourceStatsSnapshot{Source: "venue", Connected: true, ConnectedAt: now}
+
+	if changed := m.check(now, thresholds, []exchanges.SourceStatsSnapshot{st}); len(changed) != 0 {
+		t.Fatalf("healthy source reported: %+v", changed)
+	}
+
+	st.Received, st.ParseErrors = 20, 20
+	changed := m.check(now.Add(time.Second), thresholds, []exchanges.SourceStatsSnapshot{st})
+	if len(changed) != 1 || changed[0].Status != HealthDegraded || changed[0].Reason != "20 of 20 frames failed to parse" {
+		t.Fatalf("got %+v, want venue degraded", changed)
+	}
+
+	st.Received, st.ParseErrors = 40, 40
+	if changed := m.check(now.Add(2*time.Second), thresholds, []exchanges.SourceStatsSnapshot{st}); len(changed) != 0 {
+		t.Fatalf("unchanged state reported again: %+v", changed)
+	}
+
+	st.Received = 60
+	changed = m.check(now.Add(3*time.Second), thresholds, []exchanges.SourceStatsSnapshot{st})
+	if len(changed) != 1 || changed[0].Status != HealthOK {
+		t.Fatalf("got %+v, want venue recovered", changed)
+	}
+}
+
+func TestCheckHealthNotifiesHandlers(t *testing.T) {
+	source := "health_test_venue"
+	stats := exchanges.Stats(source)
+	stats.Connected()
+
+	s := New(WithHealthThresholds(HealthThresholds{UpdateTimeout: time.Minute}))
+	var got []SourceHealth
+	s.Subscribe(Handlers{OnHealth: func(h SourceHealth) {
+		if h.Source == source {
+			got = append(got, h)
+		}
+	}})
+
+	s.checkHealth(time.Now().Add(2 * time.Minute))
+	if len(got) != 1 || got[0].Source != source || got[0].Status != HealthDegraded {
+		t.Fatalf("got %+v, want %s degraded", got, source)
+	}
+
+	stats.Update()
+	s.checkHealth(time.Now())
+	if len(got) != 2 || got[1].Status != HealthOK {
+		t.Fatalf("got %+v, want %s recovered", got, source)
+	}
+
+	for _, st := range s.Sources() {
+		if st.Source == source && st.Status != HealthOK {
+			t.Errorf("Sources reports %s as %q", source, st.Status)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// ingestKey is one quote stream: a symbol from one source.
+type ingestKey struct {
+	symbol string
+	source string
+}
+
+// conflator holds the latest update per symbol and source until the
+// scanner gets to it. A scanner slowed down by its handlers then skips
+// intermediate quotes instead of blocking the connectors feeding it, whose
+// venues would drop them for reading too slowly. Updates replaced before
+// they were processed are counted per source as conflated.
+type conflator[T any] struct {
+	mu        sync.Mutex
+	pending   map[ingestKey]T
+	order     []ingestKey // pending keys, oldest first
+	conflated map[string]uint64
+	ready     chan struct{}
+}
+
+func newConflator[T any]() *conflator[T] {
+	return &conflator[T]{
+		pending:   make(map[ingestKey]T),
+		conflated: make(map[string]uint64),
+		ready:     make(chan struct{}, 1),
+	}
+}
+
+// put queues v for key, replacing the update for key still waiting, if
+// any, in its place.
+func (c *conflator[T]) put(key ingestKey, v T) {
+	c.mu.Lock()
+	if _, ok := c.pending[key]; ok {
+		c.conflated[key.source]++
+	} else {
+		c.order = append(c.order, key)
+	}
+	c.pending[key] = v
+	c.mu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns every waiting update, oldest key first.
+func (c *conflator[T]) take() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]T, 0, len(c.order))
+	for _, key := range c.order {
+		out = append(out, c.pending[key])
+		delete(c.pending, key)
+	}
+	c.order = c.order[:0]
+	return out
+}
+
+func (c *conflator[T]) depth() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.order)
+}
+
+func (c *conflator[T]) counts() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]uint64, len(c.conflated))
+	for source, n := range c.conflated {
+		out[source] = n
+	}
+	return out
+}
+
+// tradeQueue holds up to size trades until the scanner gets to them. Trades
+// can't be conflated, so a full queue drops its oldest trade, counted per
+// source as dropped.
+type tradeQueue struct {
+	mu      sync.Mutex
+	size    int
+	trades  []exchanges.TradeData
+	dropped map[string]uint64
+	ready   chan struct{}
+}
+
+func newTradeQueue(size int) *tradeQueue {
+	if size < 1 {
+		size = 1
+	}
+	return &tradeQueue{size: size, dropped: make(map[string]uint64), ready: make(chan struct{}, 1)}
+}
+
+func (q *tradeQueue) put(t exchanges.TradeData) {
+	q.mu.Lock()
+	if len(q.trades) == q.size {
+		q.dropped[q.trades[0].Source]++
+		q.trades = q.trades[1:]
+	}
+	q.trades = append(q.trades, t)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns every waiting trade, oldest first.
+func (q *tradeQueue) take() []exchanges.TradeData {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.trades
+	q.trades = nil
+	return out
+}
+
+func (q *tradeQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.trades)
+}
+
+// ingest moves updates from a feed channel into put until ctx is done.
+// put never blocks, so neither do the connectors sending on ch for longer
+// than it takes to hand an update over.
+func ingest[T any](ctx context.Context, ch <-chan T, put func(T)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-ch:
+			put(v)
+		}
+	}
+}
+
+// IngestCounts returns, per source, the book and price updates replaced by
+// a newer one before the scanner processed them, and the trades dropped
+// from a full queue.
+func (s *Scanner) IngestCounts() (conflated, dropped map[string]uint64) {
+	conflated = make(map[string]uint64)
+	for _, c := range []map[string]uint64{s.pendingPrices.counts(), s.pendingBooks.counts()} {
+		for source, n := range c {
+			conflated[source] += n
+		}
+	}
+
+	s.pendingTrades.mu.Lock()
+	dropped = make(map[string]uint64, len(s.pendingTrades.dropped))
+	for source, n := range s.pendingTrades.dropped {
+		dropped[source] = n
+	}
+	s.pendingTrades.mu.Unlock()
+	return conflated, dropped
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+func TestConflatorKeepsLatestInOrder(t *testing.T) {
+	c := newConflator[exchanges.PriceData]()
+	put := func(symbol, source string, price float64) {
+		c.put(ingestKey{symbol, source}, exchanges.PriceData{Symbol: symbol, Source: source, Price: price})
+	}
+	put("BTCUSDT", "okx_futures", 1)
+	put("ETHUSDT", "okx_futures", 2)
+	put("BTCUSDT", "okx_futures", 3)
+	put("BTCUSDT", "gate_futures", 4)
+
+	if d := c.depth(); d != 3 {
+		t.Fatalf("depth = %d, want 3", d)
+	}
+	got := c.take()
+	if len(got) != 3 || got[0].Price != 3 || got[1].Price != 2 || got[2].Price != 4 {
+		t.Fatalf("took %+v, want the latest BTC okx quote first, then ETH okx and BTC gate", got)
+	}
+	if n := c.counts()["okx_futures"]; n != 1 {
+		t.Errorf("conflated %d okx_futures updates, want 1", n)
+	}
+	if len(c.take()) != 0 || c.depth() != 0 {
+		t.Error("updates left after take")
+	}
+}
+
+// TestSlowScannerDoesNotBlockConnectors blocks the scanner in its handlers
+// and expects a connector to send far more than the feed buffers hold,
+// with the latest book processed once the handlers return.
+func TestSlowScannerDoesNotBlockConnectors(t *testing.T) {
+	const books, trades = 1000, 50
+	sent := make(chan struct{})
+	feed := Connector{
+		Name: "test",
+		Run: func(ctx context.Context, set *exchanges.SymbolSet, feeds Feeds) {
+			for i := 1; i <= trades; i++ {
+				feeds.Trades <- exchanges.TradeData{Symbol: "TONUSDT", Source: "okx_futures", Price: float64(i)}
+			}
+			for i := 1; i <= books; i++ {
+				feeds.Orderbooks <- exchanges.OrderbookData{Symbol: "TONUSDT", Source: "okx_futures", BestBid: float64(i), BestAsk: float64(i)}
+			}
+			close(sent)
+		},
+	}
+	s := New(WithSymbols("TONUSDT"), WithBufferSize(10), WithConnectors(feed))
+
+	release := make(chan struct{})
+	s.Subscribe(Handlers{
+		OnOrderbook: func(exchanges.OrderbookData) { <-release },
+		OnTrade:     func(exchanges.TradeData) { <-release },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connector blocked with the scanner busy (queue depths %v)", s.QueueDepths())
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Prices()["TONUSDT"]["okx_futures"] != books {
+		if time.Now().After(deadline) {
+			t.Fatalf("latest price %v, want the last book's %d", s.Prices()["TONUSDT"]["okx_futures"], books)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	conflated, dropped := s.IngestCounts()
+	if conflated["okx_futures"] == 0 || dropped["okx_futures"] == 0 {
+		t.Errorf("conflated %d and dropped %d updates, want both counted", conflated["okx_futures"], dropped["okx_futures"])
+	}
+	var st SourceStatus
+	for _, src := range s.Sources() {
+		if src.Source == "okx_futures" {
+			st = src
+		}
+	}
+	if st.Conflated != conflated["okx_futures"] || st.Dropped != dropped["okx_futures"] {
+		t.Errorf("sources report conflated %d and dropped %d, want %d and %d", st.Conflated, st.Dropped, conflated["okx_futures"], dropped["okx_futures"])
+	}
+}
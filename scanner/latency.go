@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindowSize is the number of delay samples kept per source.
+const latencyWindowSize = 512
+
+// LatencyStats summarises the observed feed delay of one source. Delay is
+// local arrival time minus the venue's event time, so it mixes network
+// latency with clock skew. ClockSkewMs is estimated as the smallest delay in
+// the window: it absorbs the network floor and is negative when the venue's
+// clock runs ahead of ours.
+type LatencyStats struct {
+	Source      string  `json:"source"`
+	Samples     int     `json:"samples"`
+	P50Ms       float64 `json:"p50_ms"`
+	P90Ms       float64 `json:"p90_ms"`
+	P99Ms       float64 `json:"p99_ms"`
+	ClockSkewMs float64 `json:"clock_skew_ms"`
+}
+
+// delayWindow is a ring of delay samples. It keeps the smallest one, the
+// skew estimate, up to date as samples come and go, so quote ages can be
+// adjusted without sorting the window on every update.
+type delayWindow struct {
+	samples []int64
+	next    int
+	min     int64
+}
+
+func (w *delayWindow) add(delay int64) {
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, delay)
+		if len(w.samples) == 1 || delay < w.min {
+			w.min = delay
+		}
+		return
+	}
+	evicted := w.samples[w.next]
+	w.samples[w.next] = delay
+	w.next = (w.next + 1) % latencyWindowSize
+	switch {
+	case delay <= w.min:
+		w.min = delay
+	case evicted == w.min:
+		// The smallest sample left the window: find the next one.
+		w.min = w.samples[0]
+		for _, d := range w.samples[1:] {
+			if d < w.min {
+				w.min = d
+			}
+		}
+	}
+}
+
+// latencyTracker keeps a rolling window of feed delays per source for the
+// venues that stamp their messages.
+type latencyTracker struct {
+	mu      sync.Mutex
+	windows map[string]*delayWindow
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{windows: make(map[string]*delayWindow)}
+}
+
+// Observe records one message. Messages without a venue timestamp carry no
+// latency information and are ignored.
+func (t *latencyTracker) Observe(source string, exchangeTime, receivedAt int64) {
+	if exchangeTime <= 0 || receivedAt <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	w, ok := t.windows[source]
+	if !ok {
+		w = &delayWindow{}
+		t.windows[source] = w
+	}
+	w.add(receivedAt - exchangeTime)
+	t.mu.Unlock()
+}
+
+// Stats returns the current percentiles and skew estimate for source.
+func (t *latencyTracker) Stats(source string) (LatencyStats, bool) {
+	t.mu.Lock()
+	w, ok := t.windows[source]
+	if !ok || len(w.samples) == 0 {
+		t.mu.Unlock()
+		return LatencyStats{}, false
+	}
+	sorted := make([]int64, len(w.samples))
+	copy(sorted, w.samples)
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return LatencyStats{
+		Source:      source,
+		Samples:     len(sorted),
+		P50Ms:       float64(percentile(sorted, 0.50)),
+		P90Ms:       float64(percentile(sorted, 0.90)),
+		P99Ms:       float64(percentile(sorted, 0.99)),
+		ClockSkewMs: float64(sorted[0]),
+	}, true
+}
+
+// skew returns the smallest delay in source's window, the ClockSkewMs of
+// its Stats.
+func (t *latencyTracker) skew(source string) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.windows[source]
+	if !ok || len(w.samples) == 0 {
+		return 0, false
+	}
+	return w.min, true
+}
+
+// All returns stats for every source that has reported a timestamp, sorted by
+// source name.
+func (t *latencyTracker) All() []LatencyStats {
+	t.mu.Lock()
+	sources := make([]string, 0, len(t.windows))
+	for source := range t.windows {
+		sources = append(sources, source)
+	}
+	t.mu.Unlock()
+
+	sort.Strings(sources)
+	out := make([]LatencyStats, 0, len(sources))
+	for _, source := range sources {
+		if st, ok := t.Stats(source); ok {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// QuoteAge returns how old a quote is once feed latency is accounted for.
+// For stamped sources this is the time since the venue produced it, shifted
+// by the source's estimated skew; otherwise it falls back to arrival age.
+func (t *latencyTracker) QuoteAge(source string, q quoteTime, now time.Time) time.Duration {
+	age := now.Sub(q.receivedAt)
+	if q.exchangeTime <= 0 {
+		return age
+	}
+
+	skew, ok := t.skew(source)
+	if !ok {
+		return age
+	}
+
+	delay := q.receivedAt.UnixMilli() - q.exchangeTime
+	if excess := delay - skew; excess > 0 {
+		age += time.Duration(excess) * time.Millisecond
+	}
+	return age
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice.
+func percentile(sorted []int64, p float64) int64 {
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
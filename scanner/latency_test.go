@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTrackerStats(t *testing.T) {
+	tr := newLatencyTracker()
+	for i := int64(1); i <= 100; i++ {
+		tr.Observe("binance_futures", 1000, 1000+i)
+	}
+	tr.Observe("vest_futures", 0, 2000) // no venue timestamp
+
+	st, ok := tr.Stats("binance_futures")
+	if !ok {
+		t.Fatalf("expected stats")
+	}
+	if st.Samples != 100 {
+		t.Fatalf("samples: got %d", st.Samples)
+	}
+	if st.P50Ms != 50 || st.P90Ms != 90 || st.P99Ms != 99 {
+		t.Fatalf("percentiles: got %v/%v/%v", st.P50Ms, st.P90Ms, st.P99Ms)
+	}
+	if st.ClockSkewMs != 1 {
+		t.Fatalf("skew: got %v", st.ClockSkewMs)
+	}
+	if _, ok := tr.Stats("vest_futures"); ok {
+		t.Fatalf("expected no stats for unstamped source")
+	}
+}
+
+func TestLatencyTrackerWindowRolls(t *testing.T) {
+	tr := newLatencyTracker()
+	for i := 0; i < latencyWindowSize; i++ {
+		tr.Observe("okx_futures", 1000, 1500)
+	}
+	for i := 0; i < latencyWindowSize; i++ {
+		tr.Observe("okx_futures", 1000, 1010)
+	}
+	st, _ := tr.Stats("okx_futures")
+	if st.P99Ms != 10 {
+		t.Fatalf("old samples not evicted: p99=%v", st.P99Ms)
+	}
+}
+
+func TestLatencyTrackerSkewFollowsWindow(t *testing.T) {
+	tr := newLatencyTracker()
+	tr.Observe("okx_futures", 1000, 1010)
+	for i := 1; i < latencyWindowSize; i++ {
+		tr.Observe("okx_futures", 1000, 1040)
+	}
+	if skew, _ := tr.skew("okx_futures"); skew != 10 {
+		t.Fatalf("skew = %d, want 10", skew)
+	}
+
+	tr.Observe("okx_futures", 1000, 1030) // evicts the 10ms sample
+	skew, _ := tr.skew("okx_futures")
+	st, _ := tr.Stats("okx_futures")
+	if skew != 30 || st.ClockSkewMs != 30 {
+		t.Fatalf("skew = %d, stats skew = %v, want 30 once the smallest sample leaves", skew, st.ClockSkewMs)
+	}
+}
+
+func TestQuoteAge(t *testing.T) {
+	tr := newLatencyTracker()
+	for i := 0; i < 10; i++ {
+		tr.Observe("okx_futures", 1000, 1020) // 20ms floor
+	}
+
+	now := time.UnixMilli(10_000)
+	cases := []struct {
+		name   string
+		source string
+		q      quoteTime
+		want   time.Duration
+	}{
+		{name: "unstamped", source: "vest_futures", q: quoteTime{receivedAt: time.UnixMilli(9_000)}, want: time.Second},
+		{name: "on-floor", source: "okx_futures", q: quoteTime{exchangeTime: 8_980, receivedAt: time.UnixMilli(9_000)}, want: time.Second},
+		{name: "delayed", source: "okx_futures", q: quoteTime{exchangeTime: 8_480, receivedAt: time.UnixMilli(9_000)}, want: 1500 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tr.QuoteAge(tc.source, tc.q, now); got != tc.want {
+				t.Fatalf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,748 @@
+// Package scanner detects cross-venue arbitrage and DeDust basis trades from
+// exchange price feeds.
+//
+// A Scanner is built with options, given connectors that push market data
+// into its feeds, and run under a context:
+//
+//	sc := scanner.New(
+//		scanner.WithSymbols("TONUSDT"),
+//		scanner.WithConnectors(scanner.DefaultConnectors()...),
+//	)
+//	sc.Subscribe(scanner.Handlers{
+//		OnArbitrage: func(o scanner.ArbitrageOpportunity) { log.Println(o) },
+//	})
+//	err := sc.Run(ctx)
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// Defaults used when the matching option isn't given.
+const (
+	DefaultMinProfitPct  = 0.05
+	DefaultAlertCooldown = 10 * time.Second
+	// DefaultMaxQuoteAge is the latency-adjusted age after which a quote is
+	// no longer used for spread and opportunity calculations.
+	DefaultMaxQuoteAge = 5 * time.Second
+	DefaultBufferSize  = 1000
+)
+
+// ArbitrageOpportunity is a spread between the cheapest and dearest fresh
+// quotes for a symbol whose profit after taker fees on both legs exceeds
+// the minimum profit.
+type ArbitrageOpportunity struct {
+	Symbol         string  `json:"symbol"`
+	BuySource      string  `json:"buy_source"`
+	SellSource     string  `json:"sell_source"`
+	BuyPrice       float64 `json:"buy_price"`
+	SellPrice      float64 `json:"sell_price"`
+	ProfitPct      float64 `json:"profit_pct"`
+	FeesPct        float64 `json:"fees_pct"`
+	NetProfitPct   float64 `json:"net_profit_pct"`
+	BuyQuoteAgeMs  int64   `json:"buy_quote_age_ms"`
+	SellQuoteAgeMs int64   `json:"sell_quote_age_ms"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+// BasisTradeOpportunity is buying on DeDust and shorting the highest priced
+// perpetual.
+type BasisTradeOpportunity struct {
+	Symbol       string  `json:"symbol"`
+	DeDustPrice  float64 `json:"dedust_price"`
+	ShortSource  string  `json:"short_source"`
+	ShortPrice   float64 `json:"short_price"`
+	ProfitPct    float64 `json:"profit_pct"`
+	FeesPct      float64 `json:"fees_pct"`
+	NetProfitPct float64 `json:"net_profit_pct"`
+	Timestamp    int64   `json:"timestamp"`
+}
+
+// Spreads is the spread matrix between the fresh quotes for a symbol,
+// emitted after every price update that leaves at least two of them.
+type Spreads struct {
+	Symbol  string                        `json:"symbol"`
+	Prices  map[string]float64            `json:"prices"`
+	Spreads map[string]map[string]float64 `json:"spreads"`
+}
+
+// quoteTime records when the latest price for a symbol and source was made
+// by the venue and when it reached us.
+type quoteTime struct {
+	exchangeTime int64
+	receivedAt   time.Time
+}
+
+// Feeds are the channels connectors push market data into. The scanner
+// drains them into its ingest queues as they fill, so sends don't wait on
+// processing.
+type Feeds struct {
+	Prices     chan<- exchanges.PriceData
+	Orderbooks chan<- exchanges.OrderbookData
+	Trades     chan<- exchanges.TradeData
+}
+
+// Connector is a market data source. Run streams data for the symbols in
+// the set into feeds until ctx is cancelled. Symbols, when set, replaces the
+// scanner's symbol list for this connector.
+//
+// Live connectors follow changes to the set on their open connection; the
+// rest are cancelled and started again when it changes. Disabled connectors
+// are registered but not started until enabled with SetVenue.
+type Connector struct {
+	Name     string
+	Symbols  []string
+	Live     bool
+	Disabled bool
+	Run      func(ctx context.Context, symbols *exchanges.SymbolSet, feeds Feeds)
+}
+
+// Fees is a venue's trading fee schedule, in percent of notional.
+type Fees struct {
+	TakerPct float64
+	MakerPct float64
+}
+
+// FeedFunc is the signature of the exchanges.Connect* functions.
+type FeedFunc func(ctx context.Context, symbols *exchanges.SymbolSet, priceChan chan<- exchanges.PriceData, orderbookChan chan<- exchanges.OrderbookData, tradeChan chan<- exchanges.TradeData)
+
+// FromFeedFunc adapts an exchanges.Connect* function that reads its symbols
+// once when it starts.
+func FromFeedFunc(name string, fn FeedFunc) Connector {
+	return Connector{
+		Name: name,
+		Run: func(ctx context.Context, symbols *exchanges.SymbolSet, feeds Feeds) {
+			fn(ctx, symbols, feeds.Prices, feeds.Orderbooks, feeds.Trades)
+		},
+	}
+}
+
+// FromLiveFeedFunc adapts an exchanges.Connect* function that subscribes and
+// unsubscribes as its symbols change.
+func FromLiveFeedFunc(name string, fn FeedFunc) Connector {
+	c := FromFeedFunc(name, fn)
+	c.Live = true
+	return c
+}
+
+// Handlers receive the scanner's events. They run on the scanner's
+// processing goroutines and must not block; nil handlers are skipped.
+type Handlers struct {
+	OnPrice     func(exchanges.PriceData)
+	OnOrderbook func(exchanges.OrderbookData)
+	OnTrade     func(exchanges.TradeData)
+	OnSpreads   func(Spreads)
+	OnArbitrage func(ArbitrageOpportunity)
+	OnBasis     func(BasisTradeOpportunity)
+	// OnHealth is called when a source becomes degraded or recovers.
+	OnHealth func(SourceHealth)
+}
+
+// config is guarded by Scanner.cfgMu once the scanner is built; symbols and
+// the thresholds can change while it runs.
+type config struct {
+	symbols       []string
+	minProfitPct  float64
+	alertCooldown time.Duration
+	maxQuoteAge   time.Duration
+	bufferSize    int
+	connectors    []Connector
+	fees          map[string]Fees
+	health        HealthThresholds
+}
+
+// Option configures a Scanner.
+type Option func(*config)
+
+// WithSymbols sets the symbols connectors subscribe to, e.g. "TONUSDT".
+func WithSymbols(symbols ...string) Option {
+	return func(c *config) { c.symbols = append([]string(nil), symbols...) }
+}
+
+// WithMinProfit sets the profit in percent, after fees, above which an
+// arbitrage opportunity is emitted.
+func WithMinProfit(pct float64) Option {
+	return func(c *config) { c.minProfitPct = pct }
+}
+
+// WithAlertCooldown sets how long to wait before emitting the same symbol
+// and venue pair again.
+func WithAlertCooldown(d time.Duration) Option {
+	return func(c *config) { c.alertCooldown = d }
+}
+
+// WithMaxQuoteAge sets the latency-adjusted age after which a quote is
+// ignored.
+func WithMaxQuoteAge(d time.Duration) Option {
+	return func(c *config) { c.maxQuoteAge = d }
+}
+
+// WithBufferSize sets the capacity of each feed channel and how many
+// trades wait for processing before the oldest are dropped.
+func WithBufferSize(n int) Option {
+	return func(c *config) { c.bufferSize = n }
+}
+
+// WithFees sets the fee schedule per source. Opportunities are judged on
+// their profit after taker fees on both legs; sources without an entry
+// trade for free.
+func WithFees(fees map[string]Fees) Option {
+	return func(c *config) {
+		c.fees = make(map[string]Fees, len(fees))
+		for source, f := range fees {
+			c.fees[source] = f
+		}
+	}
+}
+
+// WithHealthThresholds sets when a connected source counts as degraded.
+func WithHealthThresholds(t HealthThresholds) Option {
+	return func(c *config) { c.health = t }
+}
+
+// WithConnectors registers connectors to start on Run.
+func WithConnectors(connectors ...Connector) Option {
+	return func(c *config) { c.connectors = append(c.connectors, connectors...) }
+}
+
+// Scanner keeps the latest price per symbol and source and emits spreads
+// and opportunities as prices change.
+type Scanner struct {
+	cfg   config
+	cfgMu sync.RWMutex
+
+	venues   []*venue
+	venuesMu sync.Mutex
+	runCtx   context.Context // set while Run is running
+
+	prices      map[string]map[string]float64
+	quoteTimes  map[string]map[string]quoteTime
+	pricesMutex sync.RWMutex
+	latency     *latencyTracker
+	health      *healthMonitor
+
+	priceChan     chan exchanges.PriceData
+	orderbookChan chan exchanges.OrderbookData
+	tradeChan     chan exchanges.TradeData
+	pendingPrices *conflator[exchanges.PriceData]
+	pendingBooks  *conflator[exchanges.OrderbookData]
+	pendingTrades *tradeQueue
+
+	lastOpportunity  map[string]time.Time // Track last alert per symbol
+	opportunityMutex sync.RWMutex
+
+	handlersMu sync.RWMutex
+	handlers   map[int]Handlers
+	nextID     int
+}
+
+// New returns a Scanner configured by opts.
+func New(opts ...Option) *Scanner {
+	cfg := config{
+		minProfitPct:  DefaultMinProfitPct,
+		alertCooldown: DefaultAlertCooldown,
+		maxQuoteAge:   DefaultMaxQuoteAge,
+		bufferSize:    DefaultBufferSize,
+		health:        DefaultHealthThresholds,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	s := &Scanner{
+		cfg:             cfg,
+		prices:          make(map[string]map[string]float64),
+		quoteTimes:      make(map[string]map[string]quoteTime),
+		latency:         newLatencyTracker(),
+		health:          newHealthMonitor(),
+		priceChan:       make(chan exchanges.PriceData, cfg.bufferSize),
+		orderbookChan:   make(chan exchanges.OrderbookData, cfg.bufferSize),
+		tradeChan:       make(chan exchanges.TradeData, cfg.bufferSize),
+		pendingPrices:   newConflator[exchanges.PriceData](),
+		pendingBooks:    newConflator[exchanges.OrderbookData](),
+		pendingTrades:   newTradeQueue(cfg.bufferSize),
+		lastOpportunity: make(map[string]time.Time),
+		handlers:        make(map[int]Handlers),
+	}
+	s.Register(cfg.connectors...)
+	return s
+}
+
+// Register adds connectors. It must be called before Run.
+func (s *Scanner) Register(connectors ...Connector) {
+	s.cfgMu.RLock()
+	global := s.cfg.symbols
+	s.cfgMu.RUnlock()
+
+	s.venuesMu.Lock()
+	defer s.venuesMu.Unlock()
+	for _, c := range connectors {
+		c.Symbols = append([]string(nil), c.Symbols...)
+		v := &venue{connector: c, enabled: !c.Disabled}
+		v.set = exchanges.NewSymbolSet(v.symbols(global))
+		s.venues = append(s.venues, v)
+	}
+}
+
+// Feeds returns the channels connectors write to.
+func (s *Scanner) Feeds() Feeds {
+	return Feeds{Prices: s.priceChan, Orderbooks: s.orderbookChan, Trades: s.tradeChan}
+}
+
+// Run starts the registered connectors and processes their data until ctx
+// is cancelled. It returns ctx.Err().
+func (s *Scanner) Run(ctx context.Context) error {
+	s.venuesMu.Lock()
+	s.runCtx = ctx
+	for _, v := range s.venues {
+		if v.enabled {
+			s.startVenue(v)
+		}
+	}
+	s.venuesMu.Unlock()
+	defer func() {
+		s.venuesMu.Lock()
+		s.runCtx = nil
+		s.venuesMu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(7)
+	go func() { defer wg.Done(); s.ingestPrices(ctx) }()
+	go func() { defer wg.Done(); s.ingestOrderbooks(ctx) }()
+	go func() { defer wg.Done(); s.ingestTrades(ctx) }()
+	go func() { defer wg.Done(); s.processPrices(ctx) }()
+	go func() { defer wg.Done(); s.processOrderbooks(ctx) }()
+	go func() { defer wg.Done(); s.processTrades(ctx) }()
+	go func() { defer wg.Done(); s.monitorHealth(ctx) }()
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Subscribe registers h for every event until the returned function is
+// called.
+func (s *Scanner) Subscribe(h Handlers) (unsubscribe func()) {
+	s.handlersMu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.handlers[id] = h
+	s.handlersMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.handlersMu.Lock()
+			delete(s.handlers, id)
+			s.handlersMu.Unlock()
+		})
+	}
+}
+
+// Opportunities returns a channel of arbitrage opportunities that is closed
+// when ctx is done. Opportunities are dropped while the channel is full.
+func (s *Scanner) Opportunities(ctx context.Context, buffer int) <-chan ArbitrageOpportunity {
+	return subscribeChan(ctx, s, buffer, func(h *Handlers, fn func(ArbitrageOpportunity)) { h.OnArbitrage = fn })
+}
+
+// BasisTrades is Opportunities for basis trades.
+func (s *Scanner) BasisTrades(ctx context.Context, buffer int) <-chan BasisTradeOpportunity {
+	return subscribeChan(ctx, s, buffer, func(h *Handlers, fn func(BasisTradeOpportunity)) { h.OnBasis = fn })
+}
+
+// subscribeChan delivers one kind of event to a channel without ever
+// blocking the scanner.
+func subscribeChan[T any](ctx context.Context, s *Scanner, buffer int, set func(*Handlers, func(T))) <-chan T {
+	ch := make(chan T, buffer)
+	var mu sync.Mutex
+	closed := false
+
+	var h Handlers
+	set(&h, func(v T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- v:
+		default:
+		}
+	})
+	unsubscribe := s.Subscribe(h)
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	}()
+	return ch
+}
+
+func (s *Scanner) emit(fn func(h Handlers)) {
+	s.handlersMu.RLock()
+	handlers := make([]Handlers, 0, len(s.handlers))
+	for _, h := range s.handlers {
+		handlers = append(handlers, h)
+	}
+	s.handlersMu.RUnlock()
+
+	for _, h := range handlers {
+		fn(h)
+	}
+}
+
+func (s *Scanner) monitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.checkHealth(now)
+		}
+	}
+}
+
+// ingestPrices, ingestOrderbooks and ingestTrades count the updates
+// connectors send and queue them for processing without waiting on it.
+func (s *Scanner) ingestPrices(ctx context.Context) {
+	ingest(ctx, s.priceChan, func(p exchanges.PriceData) {
+		exchanges.Stats(p.Source).Update()
+		s.pendingPrices.put(ingestKey{p.Symbol, p.Source}, p)
+	})
+}
+
+func (s *Scanner) ingestOrderbooks(ctx context.Context) {
+	ingest(ctx, s.orderbookChan, func(ob exchanges.OrderbookData) {
+		exchanges.Stats(ob.Source).Update()
+		s.pendingBooks.put(ingestKey{ob.Symbol, ob.Source}, ob)
+	})
+}
+
+func (s *Scanner) ingestTrades(ctx context.Context) {
+	ingest(ctx, s.tradeChan, func(t exchanges.TradeData) {
+		exchanges.Stats(t.Source).Update()
+		s.pendingTrades.put(t)
+	})
+}
+
+func (s *Scanner) processPrices(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.pendingPrices.ready:
+			for _, priceData := range s.pendingPrices.take() {
+				s.UpdatePrice(exchanges.NormalizePrice(priceData))
+			}
+		}
+	}
+}
+
+func (s *Scanner) processOrderbooks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.pendingBooks.ready:
+			for _, raw := range s.pendingBooks.take() {
+				s.updateOrderbook(exchanges.NormalizeOrderbook(raw))
+			}
+		}
+	}
+}
+
+func (s *Scanner) updateOrderbook(orderbookData exchanges.OrderbookData) {
+	s.emit(func(h Handlers) {
+		if h.OnOrderbook != nil {
+			h.OnOrderbook(orderbookData)
+		}
+	})
+
+	// Calculate mid price from best bid and best ask
+	midPrice := (orderbookData.BestBid + orderbookData.BestAsk) / 2
+
+	priceData := exchanges.PriceData{
+		Symbol:     orderbookData.Symbol,
+		Source:     orderbookData.Source,
+		Price:      midPrice,
+		Timestamp:  orderbookData.Timestamp,
+		ReceivedAt: orderbookData.ReceivedAt,
+	}
+
+	s.UpdatePrice(priceData)
+}
+
+func (s *Scanner) processTrades(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.pendingTrades.ready:
+			for _, raw := range s.pendingTrades.take() {
+				tradeData := exchanges.NormalizeTrade(raw)
+				// Trades are passed to subscribers but not used for pricing
+				s.emit(func(h Handlers) {
+					if h.OnTrade != nil {
+						h.OnTrade(tradeData)
+					}
+				})
+			}
+		}
+	}
+}
+
+// UpdatePrice records a price and runs detection for its symbol on the
+// caller's goroutine. Connectors should use Feeds instead; this is for
+// sources that already run their own loop and for tests.
+func (s *Scanner) UpdatePrice(data exchanges.PriceData) {
+	receivedAt := time.Now()
+	if data.ReceivedAt > 0 {
+		receivedAt = time.UnixMilli(data.ReceivedAt)
+	}
+	s.latency.Observe(data.Source, data.Timestamp, receivedAt.UnixMilli())
+
+	s.pricesMutex.Lock()
+	if s.prices[data.Symbol] == nil {
+		s.prices[data.Symbol] = make(map[string]float64)
+		s.quoteTimes[data.Symbol] = make(map[string]quoteTime)
+	}
+	s.prices[data.Symbol][data.Source] = data.Price
+	s.quoteTimes[data.Symbol][data.Source] = quoteTime{exchangeTime: data.Timestamp, receivedAt: receivedAt}
+	s.pricesMutex.Unlock()
+
+	s.emit(func(h Handlers) {
+		if h.OnPrice != nil {
+			h.OnPrice(data)
+		}
+	})
+
+	// Copy only quotes that are still fresh once feed latency is accounted
+	// for; a stale quote on one venue produces spreads that aren't real.
+	now := time.Now()
+	s.pricesMutex.RLock()
+	fresh, ages := s.freshQuotes(data.Symbol, s.prices[data.Symbol], now)
+	s.pricesMutex.RUnlock()
+
+	s.checkArbitrage(data.Symbol, fresh, ages, now)
+	s.checkBasisTrade(data.Symbol, fresh, now)
+}
+
+// checkBasisTrade looks for a basis trade in symbol's fresh quotes.
+func (s *Scanner) checkBasisTrade(symbol string, fresh map[string]float64, now time.Time) {
+	dedustPrice, hasDeDust := fresh["DeDust"]
+	if !hasDeDust {
+		return
+	}
+
+	pricesCopy := make(map[string]float64)
+	for source, price := range fresh {
+		if source != "DeDust" {
+			pricesCopy[source] = price
+		}
+	}
+
+	// Find best short opportunity (Highest Price on Futures)
+	var bestShortPrice float64
+	var bestShortSource string
+	first := true
+
+	for source, price := range pricesCopy {
+		// Strict filter: Short leg MUST be a Futures/Perps exchange
+		// We filter out anything that is "spot" or doesn't have "futures"
+		if strings.Contains(strings.ToLower(source), "spot") {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(source), "futures") && !strings.Contains(strings.ToLower(source), "perp") &&
+			exchanges.VenueKind(source) != exchanges.Inverse {
+			continue
+		}
+
+		if first || price > bestShortPrice {
+			bestShortPrice = price
+			bestShortSource = source
+			first = false
+		}
+	}
+
+	if bestShortSource == "" {
+		return
+	}
+
+	// Basis Trade: Buy DeDust (Low), Short Futures (High)
+	if bestShortPrice > dedustPrice {
+		profitPct := ProfitPct("DeDust", bestShortSource, dedustPrice, bestShortPrice)
+
+		// Threshold for Basis Trade (can be lower or 0 if we want to stream all spreads)
+		// User mentioned "In the table we will see... filter spread"
+		// Let's stream it if there is ANY profit (>0)
+		feesPct := s.takerFees("DeDust", bestShortSource)
+		if profitPct-feesPct > 0 {
+			opportunity := BasisTradeOpportunity{
+				Symbol:       symbol,
+				DeDustPrice:  dedustPrice,
+				ShortSource:  bestShortSource,
+				ShortPrice:   bestShortPrice,
+				ProfitPct:    profitPct,
+				FeesPct:      feesPct,
+				NetProfitPct: profitPct - feesPct,
+				Timestamp:    now.UnixMilli(),
+			}
+			s.emit(func(h Handlers) {
+				if h.OnBasis != nil {
+					h.OnBasis(opportunity)
+				}
+			})
+		}
+	}
+}
+
+// checkArbitrage looks for an opportunity in symbol's fresh quotes, aged
+// as given, and emits their spreads.
+func (s *Scanner) checkArbitrage(symbol string, pricesCopy map[string]float64, ages map[string]time.Duration, now time.Time) {
+	if len(pricesCopy) < 2 {
+		return
+	}
+
+	var minPrice, maxPrice float64
+	var minSource, maxSource string
+	first := true
+
+	for source, price := range pricesCopy {
+		if first {
+			minPrice = price
+			maxPrice = price
+			minSource = source
+			maxSource = source
+			first = false
+			continue
+		}
+
+		if price < minPrice {
+			minPrice = price
+			minSource = source
+		}
+		if price > maxPrice {
+			maxPrice = price
+			maxSource = source
+		}
+	}
+
+	profitPct := ProfitPct(minSource, maxSource, minPrice, maxPrice)
+	feesPct := s.takerFees(minSource, maxSource)
+
+	s.cfgMu.RLock()
+	minProfitPct, alertCooldown := s.cfg.minProfitPct, s.cfg.alertCooldown
+	s.cfgMu.RUnlock()
+
+	// Only alert if profit is significant and we haven't alerted recently
+	if profitPct-feesPct > minProfitPct {
+		opportunityKey := fmt.Sprintf("%s_%s_%s", symbol, minSource, maxSource)
+
+		s.opportunityMutex.RLock()
+		lastAlert, exists := s.lastOpportunity[opportunityKey]
+		s.opportunityMutex.RUnlock()
+
+		// Only send alert if the cooldown has passed for this pair
+		// This prevents spam while still allowing frequent updates for crypto markets
+		if !exists || now.Sub(lastAlert) > alertCooldown {
+			s.opportunityMutex.Lock()
+			s.lastOpportunity[opportunityKey] = now
+			s.opportunityMutex.Unlock()
+
+			opportunity := ArbitrageOpportunity{
+				Symbol:         symbol,
+				BuySource:      minSource,
+				SellSource:     maxSource,
+				BuyPrice:       minPrice,
+				SellPrice:      maxPrice,
+				ProfitPct:      profitPct,
+				FeesPct:        feesPct,
+				NetProfitPct:   profitPct - feesPct,
+				BuyQuoteAgeMs:  ages[minSource].Milliseconds(),
+				SellQuoteAgeMs: ages[maxSource].Milliseconds(),
+				Timestamp:      now.UnixMilli(),
+			}
+
+			s.emit(func(h Handlers) {
+				if h.OnArbitrage != nil {
+					h.OnArbitrage(opportunity)
+				}
+			})
+		}
+	}
+
+	// Always emit current spreads for the spread matrix using the copy
+	spreads := Spreads{Symbol: symbol, Prices: pricesCopy, Spreads: ComputeSpreads(pricesCopy)}
+	s.emit(func(h Handlers) {
+		if h.OnSpreads != nil {
+			h.OnSpreads(spreads)
+		}
+	})
+}
+
+// ProfitPct is the profit in percent, before fees, of buying at buyPrice on
+// buySource and selling at sellPrice on sellSource. Linear legs make
+// (sell-buy)/buy in quote currency. An inverse contract pays out in its
+// base coin, so with one on either leg the profit is counted in coin,
+// (sell-buy)/sell: that is what an inverse pair locks in whatever the exit
+// price, and the least a mixed pair makes as the prices converge.
+func ProfitPct(buySource, sellSource string, buyPrice, sellPrice float64) float64 {
+	if exchanges.VenueKind(buySource) == exchanges.Inverse || exchanges.VenueKind(sellSource) == exchanges.Inverse {
+		return ((sellPrice - buyPrice) / sellPrice) * 100
+	}
+	return ((sellPrice - buyPrice) / buyPrice) * 100
+}
+
+// takerFees is the fee in percent for crossing the spread on both legs.
+func (s *Scanner) takerFees(buySource, sellSource string) float64 {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg.fees[buySource].TakerPct + s.cfg.fees[sellSource].TakerPct
+}
+
+// freshQuotes copies the prices for symbol whose latency-adjusted age is
+// within the configured maximum, along with those ages. The caller must hold
+// pricesMutex.
+func (s *Scanner) freshQuotes(symbol string, sourcePrices map[string]float64, now time.Time) (map[string]float64, map[string]time.Duration) {
+	maxAge := s.maxQuoteAge()
+	prices := make(map[string]float64, len(sourcePrices))
+	ages := make(map[string]time.Duration, len(sourcePrices))
+	for source, price := range sourcePrices {
+		age := s.latency.QuoteAge(source, s.quoteTimes[symbol][source], now)
+		if age > maxAge {
+			continue
+		}
+		prices[source] = price
+		ages[source] = age
+	}
+	return prices, ages
+}
+
+// ComputeSpreads returns the spread in percent for every ordered pair of
+// sources, keyed by buy source then sell source, counted as ProfitPct
+// does.
+func ComputeSpreads(sourcePrices map[string]float64) map[string]map[string]float64 {
+	spreads := make(map[string]map[string]float64)
+
+	for buySource, buyPrice := range sourcePrices {
+		spreads[buySource] = make(map[string]float64)
+		for sellSource, sellPrice := range sourcePrices {
+			if buySource != sellSource {
+				spreadPct := ProfitPct(buySource, sellSource, buyPrice, sellPrice)
+				spreads[buySource][sellSource] = spreadPct
+			}
+		}
+	}
+	return spreads
+}
@@ -0,0 +1,171 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+func TestMinProfitThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		minProfit float64
+		sellPrice float64
+		want      bool
+	}{
+		{"below default", DefaultMinProfitPct, 2.0005, false},
+		{"above default", DefaultMinProfitPct, 2.01, true},
+		{"below custom", 1, 2.01, false},
+		{"above custom", 1, 2.04, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(WithMinProfit(tt.minProfit))
+			var got []ArbitrageOpportunity
+			s.Subscribe(Handlers{OnArbitrage: func(o ArbitrageOpportunity) { got = append(got, o) }})
+
+			now := time.Now().UnixMilli()
+			s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2, ReceivedAt: now})
+			s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: tt.sellPrice, ReceivedAt: now})
+
+			if (len(got) > 0) != tt.want {
+				t.Fatalf("got %d opportunities, want any=%v", len(got), tt.want)
+			}
+			if tt.want && (got[0].BuySource != "binance_futures" || got[0].SellSource != "okx_futures") {
+				t.Fatalf("unexpected opportunity: %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	s := New()
+	calls := 0
+	unsubscribe := s.Subscribe(Handlers{OnSpreads: func(Spreads) { calls++ }})
+
+	now := time.Now().UnixMilli()
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2, ReceivedAt: now})
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2, ReceivedAt: now})
+	if calls == 0 {
+		t.Fatalf("expected spreads before unsubscribing")
+	}
+
+	unsubscribe()
+	before := calls
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.01, ReceivedAt: now})
+	if calls != before {
+		t.Fatalf("handler called after unsubscribe")
+	}
+}
+
+func TestUpdatePriceEmitsOnce(t *testing.T) {
+	s := New(WithAlertCooldown(0))
+	spreads, opportunities := 0, 0
+	s.Subscribe(Handlers{
+		OnSpreads:   func(Spreads) { spreads++ },
+		OnArbitrage: func(ArbitrageOpportunity) { opportunities++ },
+	})
+
+	now := time.Now().UnixMilli()
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2, ReceivedAt: now})
+	spreads, opportunities = 0, 0
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.05, ReceivedAt: now})
+	if spreads != 1 || opportunities != 1 {
+		t.Fatalf("one update emitted %d spreads and %d opportunities, want 1 each", spreads, opportunities)
+	}
+}
+
+func TestRunDeliversOpportunities(t *testing.T) {
+	feed := Connector{
+		Name: "test",
+		Run: func(ctx context.Context, set *exchanges.SymbolSet, feeds Feeds) {
+			symbols := set.Symbols()
+			now := time.Now().UnixMilli()
+			feeds.Prices <- exchanges.PriceData{Symbol: symbols[0], Source: "binance_futures", Price: 2, ReceivedAt: now}
+			feeds.Prices <- exchanges.PriceData{Symbol: symbols[0], Source: "gate_futures", Price: 2.02, ReceivedAt: now}
+		},
+	}
+	s := New(WithSymbols("TONUSDT"), WithConnectors(feed))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	opportunities := s.Opportunities(ctx, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	select {
+	case o := <-opportunities:
+		if o.Symbol != "TONUSDT" || o.BuySource != "binance_futures" || o.SellSource != "gate_futures" {
+			t.Fatalf("unexpected opportunity: %+v", o)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for opportunity")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run returned %v", err)
+	}
+	if _, ok := <-opportunities; ok {
+		// A buffered value may remain; the channel must close after it.
+		if _, ok := <-opportunities; ok {
+			t.Fatalf("channel not closed after cancel")
+		}
+	}
+}
+
+func TestFeesReduceProfit(t *testing.T) {
+	s := New(WithMinProfit(0.3), WithFees(map[string]Fees{
+		"binance_futures": {TakerPct: 0.05},
+		"okx_futures":     {TakerPct: 0.05},
+	}))
+	var got []ArbitrageOpportunity
+	s.Subscribe(Handlers{OnArbitrage: func(o ArbitrageOpportunity) { got = append(got, o) }})
+
+	now := time.Now().UnixMilli()
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2, ReceivedAt: now})
+	// 0.35% gross is 0.25% after fees, below the threshold.
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.007, ReceivedAt: now})
+	if len(got) != 0 {
+		t.Fatalf("expected no opportunity after fees, got %+v", got[0])
+	}
+
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.01, ReceivedAt: now})
+	if len(got) == 0 {
+		t.Fatalf("expected an opportunity")
+	}
+	if o := got[0]; o.FeesPct != 0.1 || o.NetProfitPct < 0.39 || o.NetProfitPct > 0.41 {
+		t.Fatalf("fees: got %v, net %v", o.FeesPct, o.NetProfitPct)
+	}
+}
+
+func TestProfitPctInverse(t *testing.T) {
+	tests := []struct {
+		name       string
+		buy, sell  string
+		buyPrice   float64
+		sellPrice  float64
+		wantProfit float64
+	}{
+		{"linear", "binance_futures", "okx_futures", 100, 101, 1},
+		{"inverse pair", "binance_inverse", "okx_inverse", 99, 100, 1},
+		{"inverse buy leg", "bybit_inverse", "okx_futures", 99, 100, 1},
+		{"inverse sell leg", "binance_futures", "kraken_inverse", 99, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProfitPct(tt.buy, tt.sell, tt.buyPrice, tt.sellPrice)
+			if got < tt.wantProfit-1e-9 || got > tt.wantProfit+1e-9 {
+				t.Fatalf("got %v want %v", got, tt.wantProfit)
+			}
+			if spread := ComputeSpreads(map[string]float64{tt.buy: tt.buyPrice, tt.sell: tt.sellPrice})[tt.buy][tt.sell]; spread != got {
+				t.Fatalf("spread matrix: got %v want %v", spread, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package scanner
+
+import (
+	"sort"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// Quote is the latest price from one source for a symbol.
+type Quote struct {
+	Symbol       string
+	Source       string
+	Price        float64
+	ExchangeTime int64 // venue event time, Unix ms; 0 if the venue sends none
+	ReceivedAt   time.Time
+	// Age is the latency-adjusted age; Fresh reports whether it is recent
+	// enough to be used for spreads and opportunities.
+	Age   time.Duration
+	Fresh bool
+}
+
+// Quotes returns the latest quote per source for symbol, or for every
+// symbol when symbol is empty, sorted by symbol then source.
+func (s *Scanner) Quotes(symbol string) []Quote {
+	now := time.Now()
+	maxAge := s.maxQuoteAge()
+	var out []Quote
+
+	s.pricesMutex.RLock()
+	for sym, sourcePrices := range s.prices {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		for source, price := range sourcePrices {
+			q := s.quoteTimes[sym][source]
+			age := s.latency.QuoteAge(source, q, now)
+			out = append(out, Quote{
+				Symbol:       sym,
+				Source:       source,
+				Price:        price,
+				ExchangeTime: q.exchangeTime,
+				ReceivedAt:   q.receivedAt,
+				Age:          age,
+				Fresh:        age <= maxAge,
+			})
+		}
+	}
+	s.pricesMutex.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Symbol != out[j].Symbol {
+			return out[i].Symbol < out[j].Symbol
+		}
+		return out[i].Source < out[j].Source
+	})
+	return out
+}
+
+// Prices returns a copy of the latest price per symbol and source,
+// regardless of age.
+func (s *Scanner) Prices() map[string]map[string]float64 {
+	s.pricesMutex.RLock()
+	defer s.pricesMutex.RUnlock()
+
+	out := make(map[string]map[string]float64, len(s.prices))
+	for symbol, prices := range s.prices {
+		out[symbol] = make(map[string]float64, len(prices))
+		for source, price := range prices {
+			out[symbol][source] = price
+		}
+	}
+	return out
+}
+
+// FreshPrices returns the prices for every symbol that are recent enough to
+// trade on, the same set spreads and opportunities are computed from.
+func (s *Scanner) FreshPrices() map[string]map[string]float64 {
+	now := time.Now()
+	s.pricesMutex.RLock()
+	defer s.pricesMutex.RUnlock()
+
+	out := make(map[string]map[string]float64, len(s.prices))
+	for symbol, sourcePrices := range s.prices {
+		fresh, _ := s.freshQuotes(symbol, sourcePrices, now)
+		if len(fresh) > 0 {
+			out[symbol] = fresh
+		}
+	}
+	return out
+}
+
+// SourceStatus describes one data source.
+type SourceStatus struct {
+	Source    string `json:"source"`
+	Connected bool   `json:"connected"`
+	// Status is HealthOK, HealthDegraded or HealthDisconnected as of the
+	// last health check, with the reason a degraded source is degraded.
+	Status       string `json:"status,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
+	Reconnects   uint64 `json:"reconnects"`
+	// Received frames are parsed into Messages by kind, rejected as
+	// ParseErrors or counted as Unknown; Updates is the prices, books and
+	// trades they produced.
+	Received    uint64 `json:"received"`
+	ParseErrors uint64 `json:"parse_errors"`
+	Unknown     uint64 `json:"unknown"`
+	Updates     uint64 `json:"updates"`
+	// Conflated books and prices were replaced by a newer one for the
+	// same symbol before the scanner processed them; Dropped trades fell
+	// off a full queue.
+	Conflated   uint64            `json:"conflated"`
+	Dropped     uint64            `json:"dropped"`
+	Messages    map[string]uint64 `json:"messages,omitempty"`
+	LastMessage int64             `json:"last_message,omitempty"` // Unix ms of the last frame
+	LastUpdate  map[string]int64  `json:"last_update,omitempty"`  // Unix ms per symbol
+	// BackoffMs is how long the connector waits before reconnecting, until
+	// RetryAt (Unix ms); zero unless it is waiting.
+	BackoffMs     int64                `json:"backoff_ms,omitempty"`
+	RetryAt       int64                `json:"retry_at,omitempty"`
+	Subscriptions []SubscriptionStatus `json:"subscriptions,omitempty"`
+	Errors        []SourceError        `json:"errors,omitempty"` // most recent last
+	Latency       *LatencyStats        `json:"latency,omitempty"`
+	// Shards are the connections a source spreads its symbols over, for
+	// venues that limit how many one connection carries.
+	Shards []ShardStatus `json:"shards,omitempty"`
+}
+
+// ShardStatus describes one of a source's connections and the symbols it
+// carries.
+type ShardStatus struct {
+	Shard       int      `json:"shard"`
+	Symbols     []string `json:"symbols"`
+	Connected   bool     `json:"connected"`
+	Reconnects  uint64   `json:"reconnects"`
+	Received    uint64   `json:"received"`
+	LastMessage int64    `json:"last_message,omitempty"` // Unix ms of the last frame
+	BackoffMs   int64    `json:"backoff_ms,omitempty"`
+	RetryAt     int64    `json:"retry_at,omitempty"`
+	LastError   string   `json:"last_error,omitempty"`
+}
+
+// SubscriptionStatus is the venue's answer to a subscription: pending,
+// active, or rejected with the venue's error code and message, and
+// disabled once rejected too often.
+type SubscriptionStatus struct {
+	Channel string `json:"channel"`
+	State   string `json:"state"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Since   int64  `json:"since"` // Unix ms
+}
+
+// SourceError is one of a source's recent connection, read or
+// subscription errors.
+type SourceError struct {
+	Time  int64  `json:"time"` // Unix ms
+	Error string `json:"error"`
+}
+
+// Sources combines connector counters, ingest counts, last update times and
+// latency into one report per source, sorted by name.
+func (s *Scanner) Sources() []SourceStatus {
+	bySource := make(map[string]*SourceStatus)
+	get := func(source string) *SourceStatus {
+		st, ok := bySource[source]
+		if !ok {
+			st = &SourceStatus{Source: source}
+			bySource[source] = st
+		}
+		return st
+	}
+
+	for _, stats := range exchanges.AllStats() {
+		st := get(stats.Source)
+		st.Connected = stats.Connected
+		st.Reconnects = stats.Reconnects
+		st.Received = stats.Received
+		st.ParseErrors = stats.ParseErrors
+		st.Unknown = stats.Unknown
+		st.Updates = stats.Updates
+		st.Messages = stats.Messages
+		if !stats.LastFrame.IsZero() {
+			st.LastMessage = stats.LastFrame.UnixMilli()
+		}
+		if stats.Backoff > 0 {
+			st.BackoffMs = stats.Backoff.Milliseconds()
+			st.RetryAt = stats.RetryAt.UnixMilli()
+		}
+		for _, sub := range stats.Subscriptions {
+			st.Subscriptions = append(st.Subscriptions, SubscriptionStatus{
+				Channel: sub.Channel, State: sub.State, Code: sub.Code, Error: sub.Error, Since: sub.Since.UnixMilli(),
+			})
+		}
+		for _, e := range stats.Errors {
+			st.Errors = append(st.Errors, SourceError{Time: e.Time.UnixMilli(), Error: e.Error})
+		}
+		for _, sh := range stats.Shards {
+			shard := ShardStatus{
+				Shard: sh.Shard, Symbols: sh.Symbols, Connected: sh.Connected,
+				Reconnects: sh.Reconnects, Received: sh.Received,
+			}
+			if !sh.LastFrame.IsZero() {
+				shard.LastMessage = sh.LastFrame.UnixMilli()
+			}
+			if sh.Backoff > 0 {
+				shard.BackoffMs = sh.Backoff.Milliseconds()
+				shard.RetryAt = sh.RetryAt.UnixMilli()
+			}
+			if len(sh.Errors) > 0 {
+				shard.LastError = sh.Errors[len(sh.Errors)-1].Error
+			}
+			st.Shards = append(st.Shards, shard)
+		}
+	}
+	conflated, dropped := s.IngestCounts()
+	for source, n := range conflated {
+		get(source).Conflated = n
+	}
+	for source, n := range dropped {
+		get(source).Dropped = n
+	}
+	for _, h := range s.Health() {
+		st := get(h.Source)
+		st.Status = h.Status
+		st.StatusReason = h.Reason
+	}
+
+	s.pricesMutex.RLock()
+	for symbol, sources := range s.quoteTimes {
+		for source, q := range sources {
+			st := get(source)
+			if st.LastUpdate == nil {
+				st.LastUpdate = make(map[string]int64)
+			}
+			st.LastUpdate[symbol] = q.receivedAt.UnixMilli()
+		}
+	}
+	s.pricesMutex.RUnlock()
+
+	for _, lat := range s.latency.All() {
+		lat := lat
+		get(lat.Source).Latency = &lat
+	}
+
+	out := make([]SourceStatus, 0, len(bySource))
+	for _, st := range bySource {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+// Latency returns feed latency stats for every source that stamps its
+// messages, sorted by source.
+func (s *Scanner) Latency() []LatencyStats {
+	return s.latency.All()
+}
+
+// QueueDepths returns the number of updates waiting in each feed, in its
+// channel or for processing: at most one price and one book per symbol and
+// source, and up to the buffer size of trades.
+func (s *Scanner) QueueDepths() map[string]int {
+	return map[string]int{
+		"price":     len(s.priceChan) + s.pendingPrices.depth(),
+		"orderbook": len(s.orderbookChan) + s.pendingBooks.depth(),
+		"trade":     len(s.tradeChan) + s.pendingTrades.depth(),
+	}
+}
@@ -0,0 +1,272 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// Thresholds are the detection settings that can be changed while the
+// scanner runs.
+type Thresholds struct {
+	MinProfitPct  float64
+	AlertCooldown time.Duration
+	MaxQuoteAge   time.Duration
+	Fees          map[string]Fees
+}
+
+// VenueStatus describes a registered connector.
+type VenueStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+	Live    bool   `json:"live"`
+	// Symbols is what the connector is subscribed to; OwnSymbols reports
+	// whether that is its own list rather than the scanner's.
+	Symbols    []string `json:"symbols"`
+	OwnSymbols bool     `json:"own_symbols"`
+}
+
+// venue is a registered connector and its running instance, if any. It is
+// guarded by Scanner.venuesMu.
+type venue struct {
+	connector Connector
+	enabled   bool
+	set       *exchanges.SymbolSet
+	cancel    context.CancelFunc // nil when not running
+	gen       int
+}
+
+// symbols returns the venue's own list, or global if it has none.
+func (v *venue) symbols(global []string) []string {
+	if len(v.connector.Symbols) > 0 {
+		return v.connector.Symbols
+	}
+	return global
+}
+
+// startVenue runs v's connector under the context given to Run. The caller
+// must hold venuesMu.
+func (s *Scanner) startVenue(v *venue) {
+	if s.runCtx == nil || v.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.runCtx)
+	v.cancel = cancel
+	v.gen++
+	gen := v.gen
+
+	go func() {
+		v.connector.Run(ctx, v.set, s.Feeds())
+		cancel()
+
+		s.venuesMu.Lock()
+		if v.gen == gen {
+			v.cancel = nil
+		}
+		s.venuesMu.Unlock()
+	}()
+}
+
+// stopVenue cancels v's connector. The caller must hold venuesMu.
+func (s *Scanner) stopVenue(v *venue) {
+	if v.cancel != nil {
+		v.cancel()
+		v.cancel = nil
+	}
+}
+
+// retarget points v at its current symbols. Live connectors pick the change
+// up on their connection; the rest are restarted. The caller must hold
+// venuesMu.
+func (s *Scanner) retarget(v *venue, global []string) {
+	next := v.symbols(global)
+	if strings.Join(next, ",") == strings.Join(v.set.Symbols(), ",") {
+		return
+	}
+	v.set.Set(next)
+	if v.cancel != nil && !v.connector.Live {
+		s.stopVenue(v)
+		s.startVenue(v)
+	}
+}
+
+func (s *Scanner) findVenue(name string) *venue {
+	for _, v := range s.venues {
+		if v.connector.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Symbols returns the scanner's symbol list.
+func (s *Scanner) Symbols() []string {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return append([]string(nil), s.cfg.symbols...)
+}
+
+// SetSymbols replaces the symbol list of every connector without its own
+// and forgets prices for symbols no running connector tracks anymore.
+func (s *Scanner) SetSymbols(symbols ...string) {
+	global := append([]string(nil), symbols...)
+	s.cfgMu.Lock()
+	s.cfg.symbols = global
+	s.cfgMu.Unlock()
+
+	s.venuesMu.Lock()
+	for _, v := range s.venues {
+		s.retarget(v, global)
+	}
+	s.venuesMu.Unlock()
+	s.dropUntracked()
+}
+
+// SetVenue enables or disables a connector and sets its own symbol list;
+// nil symbols make it follow the scanner's list. Disabling a connector
+// stops it and forgets its prices.
+func (s *Scanner) SetVenue(name string, enabled bool, symbols []string) error {
+	global := s.Symbols()
+
+	s.venuesMu.Lock()
+	v := s.findVenue(name)
+	if v == nil {
+		s.venuesMu.Unlock()
+		return fmt.Errorf("unknown venue %q", name)
+	}
+	v.connector.Symbols = append([]string(nil), symbols...)
+	v.enabled = enabled
+	if enabled {
+		s.retarget(v, global)
+		s.startVenue(v)
+	} else {
+		s.stopVenue(v)
+		v.set.Set(v.symbols(global))
+	}
+	s.venuesMu.Unlock()
+
+	if !enabled {
+		s.dropSource(name)
+	}
+	s.dropUntracked()
+	return nil
+}
+
+// RestartVenue reconnects a running connector, e.g. after its endpoints
+// changed.
+func (s *Scanner) RestartVenue(name string) error {
+	s.venuesMu.Lock()
+	defer s.venuesMu.Unlock()
+	v := s.findVenue(name)
+	if v == nil {
+		return fmt.Errorf("unknown venue %q", name)
+	}
+	if v.cancel != nil {
+		s.stopVenue(v)
+		s.startVenue(v)
+	}
+	return nil
+}
+
+// Venues returns the registered connectors in registration order.
+func (s *Scanner) Venues() []VenueStatus {
+	s.venuesMu.Lock()
+	defer s.venuesMu.Unlock()
+	out := make([]VenueStatus, 0, len(s.venues))
+	for _, v := range s.venues {
+		out = append(out, VenueStatus{
+			Name:       v.connector.Name,
+			Enabled:    v.enabled,
+			Running:    v.cancel != nil,
+			Live:       v.connector.Live,
+			Symbols:    v.set.Symbols(),
+			OwnSymbols: len(v.connector.Symbols) > 0,
+		})
+	}
+	return out
+}
+
+// Thresholds returns the current detection settings.
+func (s *Scanner) Thresholds() Thresholds {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	fees := make(map[string]Fees, len(s.cfg.fees))
+	for source, f := range s.cfg.fees {
+		fees[source] = f
+	}
+	return Thresholds{
+		MinProfitPct:  s.cfg.minProfitPct,
+		AlertCooldown: s.cfg.alertCooldown,
+		MaxQuoteAge:   s.cfg.maxQuoteAge,
+		Fees:          fees,
+	}
+}
+
+// SetThresholds replaces the detection settings. They apply from the next
+// price update.
+func (s *Scanner) SetThresholds(t Thresholds) {
+	fees := make(map[string]Fees, len(t.Fees))
+	for source, f := range t.Fees {
+		fees[source] = f
+	}
+	s.cfgMu.Lock()
+	s.cfg.minProfitPct = t.MinProfitPct
+	s.cfg.alertCooldown = t.AlertCooldown
+	s.cfg.maxQuoteAge = t.MaxQuoteAge
+	s.cfg.fees = fees
+	s.cfgMu.Unlock()
+}
+
+func (s *Scanner) maxQuoteAge() time.Duration {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg.maxQuoteAge
+}
+
+// dropSource forgets every price from source.
+func (s *Scanner) dropSource(source string) {
+	s.pricesMutex.Lock()
+	defer s.pricesMutex.Unlock()
+	for symbol := range s.prices {
+		delete(s.prices[symbol], source)
+		delete(s.quoteTimes[symbol], source)
+		if len(s.prices[symbol]) == 0 {
+			delete(s.prices, symbol)
+			delete(s.quoteTimes, symbol)
+		}
+	}
+}
+
+// dropUntracked forgets prices for symbols no enabled connector is
+// subscribed to. Prices pushed through UpdatePrice by hand, with no
+// connectors registered, are kept.
+func (s *Scanner) dropUntracked() {
+	tracked := make(map[string]bool)
+	s.venuesMu.Lock()
+	if len(s.venues) == 0 {
+		s.venuesMu.Unlock()
+		return
+	}
+	for _, v := range s.venues {
+		if !v.enabled {
+			continue
+		}
+		for _, symbol := range v.set.Symbols() {
+			tracked[symbol] = true
+		}
+	}
+	s.venuesMu.Unlock()
+
+	s.pricesMutex.Lock()
+	defer s.pricesMutex.Unlock()
+	for symbol := range s.prices {
+		if !tracked[symbol] {
+			delete(s.prices, symbol)
+			delete(s.quoteTimes, symbol)
+		}
+	}
+}
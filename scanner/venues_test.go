@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// recordingConnector reports every start and the symbols it started with.
+func recordingConnector(name string, live bool, starts chan<- []string) Connector {
+	return Connector{
+		Name: name,
+		Live: live,
+		Run: func(ctx context.Context, set *exchanges.SymbolSet, feeds Feeds) {
+			starts <- set.Symbols()
+			<-ctx.Done()
+		},
+	}
+}
+
+func expectStart(t *testing.T, starts <-chan []string, want string) {
+	t.Helper()
+	select {
+	case got := <-starts:
+		if joined := strings.Join(got, ","); joined != want {
+			t.Fatalf("started with %q, want %q", joined, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("connector not started, want %q", want)
+	}
+}
+
+func expectNoStart(t *testing.T, starts <-chan []string) {
+	t.Helper()
+	select {
+	case got := <-starts:
+		t.Fatalf("unexpected start with %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSetSymbolsRestartsOnlyPollingConnectors(t *testing.T) {
+	liveStarts := make(chan []string, 4)
+	restartStarts := make(chan []string, 4)
+	s := New(WithSymbols("TONUSDT"), WithConnectors(
+		recordingConnector("live", true, liveStarts),
+		recordingConnector("polling", false, restartStarts),
+	))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	expectStart(t, liveStarts, "TONUSDT")
+	expectStart(t, restartStarts, "TONUSDT")
+
+	s.SetSymbols("TONUSDT", "BTCUSDT")
+	expectStart(t, restartStarts, "TONUSDT,BTCUSDT")
+	expectNoStart(t, liveStarts)
+
+	for _, v := range s.Venues() {
+		if strings.Join(v.Symbols, ",") != "TONUSDT,BTCUSDT" || !v.Running {
+			t.Fatalf("venue %s: %+v", v.Name, v)
+		}
+	}
+}
+
+func TestSetVenue(t *testing.T) {
+	starts := make(chan []string, 4)
+	s := New(WithSymbols("TONUSDT"), WithConnectors(
+		Connector{Name: "other", Run: func(ctx context.Context, set *exchanges.SymbolSet, feeds Feeds) { <-ctx.Done() }},
+		func() Connector {
+			c := recordingConnector("okx_futures", false, starts)
+			c.Disabled = true
+			return c
+		}(),
+	))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	expectNoStart(t, starts)
+
+	if err := s.SetVenue("okx_futures", true, []string{"BTCUSDT"}); err != nil {
+		t.Fatalf("SetVenue: %v", err)
+	}
+	expectStart(t, starts, "BTCUSDT")
+
+	now := time.Now().UnixMilli()
+	s.UpdatePrice(exchanges.PriceData{Symbol: "BTCUSDT", Source: "okx_futures", Price: 60000, ReceivedAt: now})
+	if err := s.SetVenue("okx_futures", false, nil); err != nil {
+		t.Fatalf("SetVenue: %v", err)
+	}
+	if prices := s.Prices(); len(prices) != 0 {
+		t.Fatalf("prices kept for disabled venue: %v", prices)
+	}
+	if err := s.SetVenue("nope", true, nil); err == nil {
+		t.Fatalf("expected an error for an unknown venue")
+	}
+}
+
+func TestSetThresholds(t *testing.T) {
+	s := New(WithMinProfit(5))
+	var got []ArbitrageOpportunity
+	s.Subscribe(Handlers{OnArbitrage: func(o ArbitrageOpportunity) { got = append(got, o) }})
+
+	now := time.Now().UnixMilli()
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2, ReceivedAt: now})
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.02, ReceivedAt: now})
+	if len(got) != 0 {
+		t.Fatalf("opportunity below threshold: %+v", got[0])
+	}
+
+	th := s.Thresholds()
+	th.MinProfitPct = 0.5
+	s.SetThresholds(th)
+	s.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.02, ReceivedAt: now})
+	if len(got) != 1 {
+		t.Fatalf("got %d opportunities after lowering the threshold", len(got))
+	}
+}
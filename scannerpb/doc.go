@@ -0,0 +1,5 @@
+// Package scannerpb holds the protobuf messages and gRPC service for the
+// scanner's streaming API. The .pb.go files are generated from scanner.proto.
+package scannerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative scanner.proto
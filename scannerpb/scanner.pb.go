@@ -0,0 +1,1597 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.28.0
+// source: scanner.proto
+
+package scannerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Channel int32
+
+const (
+	Channel_CHANNEL_UNSPECIFIED Channel = 0
+	Channel_CHANNEL_PRICES      Channel = 1
+	Channel_CHANNEL_SPREADS     Channel = 2
+	Channel_CHANNEL_ARBITRAGE   Channel = 3
+	Channel_CHANNEL_BASIS       Channel = 4
+	// Orderbook tops and trades are high volume and only sent when asked for.
+	Channel_CHANNEL_ORDERBOOK Channel = 5
+	Channel_CHANNEL_TRADES    Channel = 6
+)
+
+// Enum value maps for Channel.
+var (
+	Channel_name = map[int32]string{
+		0: "CHANNEL_UNSPECIFIED",
+		1: "CHANNEL_PRICES",
+		2: "CHANNEL_SPREADS",
+		3: "CHANNEL_ARBITRAGE",
+		4: "CHANNEL_BASIS",
+		5: "CHANNEL_ORDERBOOK",
+		6: "CHANNEL_TRADES",
+	}
+	Channel_value = map[string]int32{
+		"CHANNEL_UNSPECIFIED": 0,
+		"CHANNEL_PRICES":      1,
+		"CHANNEL_SPREADS":     2,
+		"CHANNEL_ARBITRAGE":   3,
+		"CHANNEL_BASIS":       4,
+		"CHANNEL_ORDERBOOK":   5,
+		"CHANNEL_TRADES":      6,
+	}
+)
+
+func (x Channel) Enum() *Channel {
+	p := new(Channel)
+	*p = x
+	return p
+}
+
+func (x Channel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Channel) Descriptor() protoreflect.EnumDescriptor {
+	return file_scanner_proto_enumTypes[0].Descriptor()
+}
+
+func (Channel) Type() protoreflect.EnumType {
+	return &file_scanner_proto_enumTypes[0]
+}
+
+func (x Channel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Channel.Descriptor instead.
+func (Channel) EnumDescriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{0}
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Channels to receive. Empty means prices, spreads, arbitrage and basis.
+	Channels []Channel `protobuf:"varint,1,rep,packed,name=channels,proto3,enum=scanner.v1.Channel" json:"channels,omitempty"`
+	// Symbols to receive, e.g. "TONUSDT". Empty means every symbol.
+	Symbols []string `protobuf:"bytes,2,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	// Minimum profit after fees for arbitrage and basis opportunities.
+	MinProfitPct float64 `protobuf:"fixed64,3,opt,name=min_profit_pct,json=minProfitPct,proto3" json:"min_profit_pct,omitempty"`
+	// Minimum interval between updates per channel and symbol for prices,
+	// spreads, orderbook tops and trades. Zero sends every update.
+	ThrottleMs int64 `protobuf:"varint,4,opt,name=throttle_ms,json=throttleMs,proto3" json:"throttle_ms,omitempty"`
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscribeRequest) GetChannels() []Channel {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetMinProfitPct() float64 {
+	if x != nil {
+		return x.MinProfitPct
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetThrottleMs() int64 {
+	if x != nil {
+		return x.ThrottleMs
+	}
+	return 0
+}
+
+type GetSnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Symbols to include. Empty means every symbol.
+	Symbols []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+}
+
+func (x *GetSnapshotRequest) Reset() {
+	*x = GetSnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSnapshotRequest) ProtoMessage() {}
+
+func (x *GetSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*GetSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetSnapshotRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+// Event is one streamed update.
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Payload:
+	//	*Event_Price
+	//	*Event_OrderbookTop
+	//	*Event_Trade
+	//	*Event_Spreads
+	//	*Event_Arbitrage
+	//	*Event_Basis
+	Payload isEvent_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *Event) GetPayload() isEvent_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetPrice() *Price {
+	if x, ok := x.GetPayload().(*Event_Price); ok {
+		return x.Price
+	}
+	return nil
+}
+
+func (x *Event) GetOrderbookTop() *OrderbookTop {
+	if x, ok := x.GetPayload().(*Event_OrderbookTop); ok {
+		return x.OrderbookTop
+	}
+	return nil
+}
+
+func (x *Event) GetTrade() *Trade {
+	if x, ok := x.GetPayload().(*Event_Trade); ok {
+		return x.Trade
+	}
+	return nil
+}
+
+func (x *Event) GetSpreads() *Spreads {
+	if x, ok := x.GetPayload().(*Event_Spreads); ok {
+		return x.Spreads
+	}
+	return nil
+}
+
+func (x *Event) GetArbitrage() *ArbitrageOpportunity {
+	if x, ok := x.GetPayload().(*Event_Arbitrage); ok {
+		return x.Arbitrage
+	}
+	return nil
+}
+
+func (x *Event) GetBasis() *BasisTradeOpportunity {
+	if x, ok := x.GetPayload().(*Event_Basis); ok {
+		return x.Basis
+	}
+	return nil
+}
+
+type isEvent_Payload interface {
+	isEvent_Payload()
+}
+
+type Event_Price struct {
+	Price *Price `protobuf:"bytes,1,opt,name=price,proto3,oneof"`
+}
+
+type Event_OrderbookTop struct {
+	OrderbookTop *OrderbookTop `protobuf:"bytes,2,opt,name=orderbook_top,json=orderbookTop,proto3,oneof"`
+}
+
+type Event_Trade struct {
+	Trade *Trade `protobuf:"bytes,3,opt,name=trade,proto3,oneof"`
+}
+
+type Event_Spreads struct {
+	Spreads *Spreads `protobuf:"bytes,4,opt,name=spreads,proto3,oneof"`
+}
+
+type Event_Arbitrage struct {
+	Arbitrage *ArbitrageOpportunity `protobuf:"bytes,5,opt,name=arbitrage,proto3,oneof"`
+}
+
+type Event_Basis struct {
+	Basis *BasisTradeOpportunity `protobuf:"bytes,6,opt,name=basis,proto3,oneof"`
+}
+
+func (*Event_Price) isEvent_Payload() {}
+
+func (*Event_OrderbookTop) isEvent_Payload() {}
+
+func (*Event_Trade) isEvent_Payload() {}
+
+func (*Event_Spreads) isEvent_Payload() {}
+
+func (*Event_Arbitrage) isEvent_Payload() {}
+
+func (*Event_Basis) isEvent_Payload() {}
+
+// Times are Unix milliseconds. exchange_time is the venue's event time and
+// is zero when the venue does not send one.
+type Price struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol       string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Source       string  `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Price        float64 `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	ExchangeTime int64   `protobuf:"varint,4,opt,name=exchange_time,json=exchangeTime,proto3" json:"exchange_time,omitempty"`
+	ReceivedAt   int64   `protobuf:"varint,5,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+}
+
+func (x *Price) Reset() {
+	*x = Price{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Price) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Price) ProtoMessage() {}
+
+func (x *Price) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Price.ProtoReflect.Descriptor instead.
+func (*Price) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Price) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Price) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Price) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Price) GetExchangeTime() int64 {
+	if x != nil {
+		return x.ExchangeTime
+	}
+	return 0
+}
+
+func (x *Price) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+type OrderbookTop struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol       string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Source       string  `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	BestBid      float64 `protobuf:"fixed64,3,opt,name=best_bid,json=bestBid,proto3" json:"best_bid,omitempty"`
+	BestAsk      float64 `protobuf:"fixed64,4,opt,name=best_ask,json=bestAsk,proto3" json:"best_ask,omitempty"`
+	ExchangeTime int64   `protobuf:"varint,5,opt,name=exchange_time,json=exchangeTime,proto3" json:"exchange_time,omitempty"`
+	ReceivedAt   int64   `protobuf:"varint,6,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	// Sizes at the top of book in base asset units, 0 when the venue sends
+	// none.
+	BidSize float64 `protobuf:"fixed64,7,opt,name=bid_size,json=bidSize,proto3" json:"bid_size,omitempty"`
+	AskSize float64 `protobuf:"fixed64,8,opt,name=ask_size,json=askSize,proto3" json:"ask_size,omitempty"`
+}
+
+func (x *OrderbookTop) Reset() {
+	*x = OrderbookTop{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OrderbookTop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderbookTop) ProtoMessage() {}
+
+func (x *OrderbookTop) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderbookTop.ProtoReflect.Descriptor instead.
+func (*OrderbookTop) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderbookTop) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderbookTop) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *OrderbookTop) GetBestBid() float64 {
+	if x != nil {
+		return x.BestBid
+	}
+	return 0
+}
+
+func (x *OrderbookTop) GetBestAsk() float64 {
+	if x != nil {
+		return x.BestAsk
+	}
+	return 0
+}
+
+func (x *OrderbookTop) GetExchangeTime() int64 {
+	if x != nil {
+		return x.ExchangeTime
+	}
+	return 0
+}
+
+func (x *OrderbookTop) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+func (x *OrderbookTop) GetBidSize() float64 {
+	if x != nil {
+		return x.BidSize
+	}
+	return 0
+}
+
+func (x *OrderbookTop) GetAskSize() float64 {
+	if x != nil {
+		return x.AskSize
+	}
+	return 0
+}
+
+type Trade struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Source string  `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Price  float64 `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	// In base asset units, as a decimal string.
+	Quantity string `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// "buy" or "sell".
+	Side         string `protobuf:"bytes,5,opt,name=side,proto3" json:"side,omitempty"`
+	ExchangeTime int64  `protobuf:"varint,6,opt,name=exchange_time,json=exchangeTime,proto3" json:"exchange_time,omitempty"`
+	ReceivedAt   int64  `protobuf:"varint,7,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Trade) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Trade) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Trade) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Trade) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *Trade) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *Trade) GetExchangeTime() int64 {
+	if x != nil {
+		return x.ExchangeTime
+	}
+	return 0
+}
+
+func (x *Trade) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+type Spread struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BuySource  string  `protobuf:"bytes,1,opt,name=buy_source,json=buySource,proto3" json:"buy_source,omitempty"`
+	SellSource string  `protobuf:"bytes,2,opt,name=sell_source,json=sellSource,proto3" json:"sell_source,omitempty"`
+	SpreadPct  float64 `protobuf:"fixed64,3,opt,name=spread_pct,json=spreadPct,proto3" json:"spread_pct,omitempty"`
+}
+
+func (x *Spread) Reset() {
+	*x = Spread{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Spread) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Spread) ProtoMessage() {}
+
+func (x *Spread) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Spread.ProtoReflect.Descriptor instead.
+func (*Spread) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Spread) GetBuySource() string {
+	if x != nil {
+		return x.BuySource
+	}
+	return ""
+}
+
+func (x *Spread) GetSellSource() string {
+	if x != nil {
+		return x.SellSource
+	}
+	return ""
+}
+
+func (x *Spread) GetSpreadPct() float64 {
+	if x != nil {
+		return x.SpreadPct
+	}
+	return 0
+}
+
+// Spreads is the pairwise spread matrix between fresh quotes for a symbol.
+type Spreads struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol  string             `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Spreads []*Spread          `protobuf:"bytes,2,rep,name=spreads,proto3" json:"spreads,omitempty"`
+	Prices  map[string]float64 `protobuf:"bytes,3,rep,name=prices,proto3" json:"prices,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+}
+
+func (x *Spreads) Reset() {
+	*x = Spreads{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Spreads) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Spreads) ProtoMessage() {}
+
+func (x *Spreads) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Spreads.ProtoReflect.Descriptor instead.
+func (*Spreads) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Spreads) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Spreads) GetSpreads() []*Spread {
+	if x != nil {
+		return x.Spreads
+	}
+	return nil
+}
+
+func (x *Spreads) GetPrices() map[string]float64 {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+type ArbitrageOpportunity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol         string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	BuySource      string  `protobuf:"bytes,2,opt,name=buy_source,json=buySource,proto3" json:"buy_source,omitempty"`
+	SellSource     string  `protobuf:"bytes,3,opt,name=sell_source,json=sellSource,proto3" json:"sell_source,omitempty"`
+	BuyPrice       float64 `protobuf:"fixed64,4,opt,name=buy_price,json=buyPrice,proto3" json:"buy_price,omitempty"`
+	SellPrice      float64 `protobuf:"fixed64,5,opt,name=sell_price,json=sellPrice,proto3" json:"sell_price,omitempty"`
+	ProfitPct      float64 `protobuf:"fixed64,6,opt,name=profit_pct,json=profitPct,proto3" json:"profit_pct,omitempty"`
+	BuyQuoteAgeMs  int64   `protobuf:"varint,7,opt,name=buy_quote_age_ms,json=buyQuoteAgeMs,proto3" json:"buy_quote_age_ms,omitempty"`
+	SellQuoteAgeMs int64   `protobuf:"varint,8,opt,name=sell_quote_age_ms,json=sellQuoteAgeMs,proto3" json:"sell_quote_age_ms,omitempty"`
+	Timestamp      int64   `protobuf:"varint,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	FeesPct        float64 `protobuf:"fixed64,10,opt,name=fees_pct,json=feesPct,proto3" json:"fees_pct,omitempty"`
+	NetProfitPct   float64 `protobuf:"fixed64,11,opt,name=net_profit_pct,json=netProfitPct,proto3" json:"net_profit_pct,omitempty"`
+}
+
+func (x *ArbitrageOpportunity) Reset() {
+	*x = ArbitrageOpportunity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArbitrageOpportunity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArbitrageOpportunity) ProtoMessage() {}
+
+func (x *ArbitrageOpportunity) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArbitrageOpportunity.ProtoReflect.Descriptor instead.
+func (*ArbitrageOpportunity) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ArbitrageOpportunity) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ArbitrageOpportunity) GetBuySource() string {
+	if x != nil {
+		return x.BuySource
+	}
+	return ""
+}
+
+func (x *ArbitrageOpportunity) GetSellSource() string {
+	if x != nil {
+		return x.SellSource
+	}
+	return ""
+}
+
+func (x *ArbitrageOpportunity) GetBuyPrice() float64 {
+	if x != nil {
+		return x.BuyPrice
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetSellPrice() float64 {
+	if x != nil {
+		return x.SellPrice
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetProfitPct() float64 {
+	if x != nil {
+		return x.ProfitPct
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetBuyQuoteAgeMs() int64 {
+	if x != nil {
+		return x.BuyQuoteAgeMs
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetSellQuoteAgeMs() int64 {
+	if x != nil {
+		return x.SellQuoteAgeMs
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetFeesPct() float64 {
+	if x != nil {
+		return x.FeesPct
+	}
+	return 0
+}
+
+func (x *ArbitrageOpportunity) GetNetProfitPct() float64 {
+	if x != nil {
+		return x.NetProfitPct
+	}
+	return 0
+}
+
+type BasisTradeOpportunity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Symbol       string  `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	DedustPrice  float64 `protobuf:"fixed64,2,opt,name=dedust_price,json=dedustPrice,proto3" json:"dedust_price,omitempty"`
+	ShortSource  string  `protobuf:"bytes,3,opt,name=short_source,json=shortSource,proto3" json:"short_source,omitempty"`
+	ShortPrice   float64 `protobuf:"fixed64,4,opt,name=short_price,json=shortPrice,proto3" json:"short_price,omitempty"`
+	ProfitPct    float64 `protobuf:"fixed64,5,opt,name=profit_pct,json=profitPct,proto3" json:"profit_pct,omitempty"`
+	Timestamp    int64   `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	FeesPct      float64 `protobuf:"fixed64,7,opt,name=fees_pct,json=feesPct,proto3" json:"fees_pct,omitempty"`
+	NetProfitPct float64 `protobuf:"fixed64,8,opt,name=net_profit_pct,json=netProfitPct,proto3" json:"net_profit_pct,omitempty"`
+}
+
+func (x *BasisTradeOpportunity) Reset() {
+	*x = BasisTradeOpportunity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BasisTradeOpportunity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasisTradeOpportunity) ProtoMessage() {}
+
+func (x *BasisTradeOpportunity) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasisTradeOpportunity.ProtoReflect.Descriptor instead.
+func (*BasisTradeOpportunity) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BasisTradeOpportunity) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *BasisTradeOpportunity) GetDedustPrice() float64 {
+	if x != nil {
+		return x.DedustPrice
+	}
+	return 0
+}
+
+func (x *BasisTradeOpportunity) GetShortSource() string {
+	if x != nil {
+		return x.ShortSource
+	}
+	return ""
+}
+
+func (x *BasisTradeOpportunity) GetShortPrice() float64 {
+	if x != nil {
+		return x.ShortPrice
+	}
+	return 0
+}
+
+func (x *BasisTradeOpportunity) GetProfitPct() float64 {
+	if x != nil {
+		return x.ProfitPct
+	}
+	return 0
+}
+
+func (x *BasisTradeOpportunity) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *BasisTradeOpportunity) GetFeesPct() float64 {
+	if x != nil {
+		return x.FeesPct
+	}
+	return 0
+}
+
+func (x *BasisTradeOpportunity) GetNetProfitPct() float64 {
+	if x != nil {
+		return x.NetProfitPct
+	}
+	return 0
+}
+
+type SourceStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Source      string            `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Connected   bool              `protobuf:"varint,2,opt,name=connected,proto3" json:"connected,omitempty"`
+	Reconnects  uint64            `protobuf:"varint,3,opt,name=reconnects,proto3" json:"reconnects,omitempty"`
+	ParseErrors uint64            `protobuf:"varint,4,opt,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty"`
+	Messages    map[string]uint64 `protobuf:"bytes,5,rep,name=messages,proto3" json:"messages,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Last update per symbol, Unix ms.
+	LastUpdate map[string]int64 `protobuf:"bytes,6,rep,name=last_update,json=lastUpdate,proto3" json:"last_update,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *SourceStatus) Reset() {
+	*x = SourceStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SourceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceStatus) ProtoMessage() {}
+
+func (x *SourceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceStatus.ProtoReflect.Descriptor instead.
+func (*SourceStatus) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SourceStatus) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SourceStatus) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *SourceStatus) GetReconnects() uint64 {
+	if x != nil {
+		return x.Reconnects
+	}
+	return 0
+}
+
+func (x *SourceStatus) GetParseErrors() uint64 {
+	if x != nil {
+		return x.ParseErrors
+	}
+	return 0
+}
+
+func (x *SourceStatus) GetMessages() map[string]uint64 {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *SourceStatus) GetLastUpdate() map[string]int64 {
+	if x != nil {
+		return x.LastUpdate
+	}
+	return nil
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prices        []*Price                 `protobuf:"bytes,1,rep,name=prices,proto3" json:"prices,omitempty"`
+	Spreads       []*Spreads               `protobuf:"bytes,2,rep,name=spreads,proto3" json:"spreads,omitempty"`
+	Opportunities []*ArbitrageOpportunity  `protobuf:"bytes,3,rep,name=opportunities,proto3" json:"opportunities,omitempty"`
+	BasisTrades   []*BasisTradeOpportunity `protobuf:"bytes,4,rep,name=basis_trades,json=basisTrades,proto3" json:"basis_trades,omitempty"`
+	Sources       []*SourceStatus          `protobuf:"bytes,5,rep,name=sources,proto3" json:"sources,omitempty"`
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scanner_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Snapshot) GetPrices() []*Price {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+func (x *Snapshot) GetSpreads() []*Spreads {
+	if x != nil {
+		return x.Spreads
+	}
+	return nil
+}
+
+func (x *Snapshot) GetOpportunities() []*ArbitrageOpportunity {
+	if x != nil {
+		return x.Opportunities
+	}
+	return nil
+}
+
+func (x *Snapshot) GetBasisTrades() []*BasisTradeOpportunity {
+	if x != nil {
+		return x.BasisTrades
+	}
+	return nil
+}
+
+func (x *Snapshot) GetSources() []*SourceStatus {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+var File_scanner_proto protoreflect.FileDescriptor
+
+var file_scanner_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0a, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0xa4, 0x01, 0x0a, 0x10,
+	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x2f, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0e, 0x32, 0x13, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x08, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x6d,
+	0x69, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0c, 0x6d, 0x69, 0x6e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x50, 0x63,
+	0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x5f, 0x6d, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65,
+	0x4d, 0x73, 0x22, 0x2e, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x79, 0x6d, 0x62,
+	0x6f, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73, 0x79, 0x6d, 0x62, 0x6f,
+	0x6c, 0x73, 0x22, 0xd7, 0x02, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x05,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x63,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x48, 0x00,
+	0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x3f, 0x0a, 0x0d, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x62, 0x6f, 0x6f, 0x6b, 0x5f, 0x74, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x62, 0x6f, 0x6f, 0x6b, 0x54, 0x6f, 0x70, 0x48, 0x00, 0x52, 0x0c, 0x6f, 0x72, 0x64, 0x65,
+	0x72, 0x62, 0x6f, 0x6f, 0x6b, 0x54, 0x6f, 0x70, 0x12, 0x29, 0x0a, 0x05, 0x74, 0x72, 0x61, 0x64,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x64, 0x65, 0x48, 0x00, 0x52, 0x05, 0x74, 0x72,
+	0x61, 0x64, 0x65, 0x12, 0x2f, 0x0a, 0x07, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x48, 0x00, 0x52, 0x07, 0x73, 0x70, 0x72,
+	0x65, 0x61, 0x64, 0x73, 0x12, 0x40, 0x0a, 0x09, 0x61, 0x72, 0x62, 0x69, 0x74, 0x72, 0x61, 0x67,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x72, 0x62, 0x69, 0x74, 0x72, 0x61, 0x67, 0x65, 0x4f, 0x70,
+	0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e, 0x69, 0x74, 0x79, 0x48, 0x00, 0x52, 0x09, 0x61, 0x72, 0x62,
+	0x69, 0x74, 0x72, 0x61, 0x67, 0x65, 0x12, 0x39, 0x0a, 0x05, 0x62, 0x61, 0x73, 0x69, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x61, 0x73, 0x69, 0x73, 0x54, 0x72, 0x61, 0x64, 0x65, 0x4f, 0x70, 0x70,
+	0x6f, 0x72, 0x74, 0x75, 0x6e, 0x69, 0x74, 0x79, 0x48, 0x00, 0x52, 0x05, 0x62, 0x61, 0x73, 0x69,
+	0x73, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x93, 0x01, 0x0a,
+	0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x23, 0x0a, 0x0d,
+	0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0c, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
+	0x41, 0x74, 0x22, 0xf0, 0x01, 0x0a, 0x0c, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x62, 0x6f, 0x6f, 0x6b,
+	0x54, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x62, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x62, 0x65, 0x73, 0x74, 0x42, 0x69, 0x64, 0x12, 0x19,
+	0x0a, 0x08, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x61, 0x73, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x07, 0x62, 0x65, 0x73, 0x74, 0x41, 0x73, 0x6b, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f,
+	0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12,
+	0x19, 0x0a, 0x08, 0x62, 0x69, 0x64, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x07, 0x62, 0x69, 0x64, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x73,
+	0x6b, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x61, 0x73,
+	0x6b, 0x53, 0x69, 0x7a, 0x65, 0x22, 0xc3, 0x01, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x64, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74,
+	0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x71, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x73, 0x69, 0x64, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x65, 0x78,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x22, 0x67, 0x0a, 0x06, 0x53,
+	0x70, 0x72, 0x65, 0x61, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x75, 0x79, 0x5f, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x75, 0x79, 0x53, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x6c, 0x6c, 0x5f, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x5f,
+	0x70, 0x63, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x73, 0x70, 0x72, 0x65, 0x61,
+	0x64, 0x50, 0x63, 0x74, 0x22, 0xc3, 0x01, 0x0a, 0x07, 0x53, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x2c, 0x0a, 0x07, 0x73, 0x70, 0x72, 0x65,
+	0x61, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x63, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x72, 0x65, 0x61, 0x64, 0x52, 0x07, 0x73,
+	0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x12, 0x37, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x2e, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x1a,
+	0x39, 0x0a, 0x0b, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xfc, 0x02, 0x0a, 0x14, 0x41,
+	0x72, 0x62, 0x69, 0x74, 0x72, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e,
+	0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x1d, 0x0a, 0x0a, 0x62,
+	0x75, 0x79, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x62, 0x75, 0x79, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65,
+	0x6c, 0x6c, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x62,
+	0x75, 0x79, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08,
+	0x62, 0x75, 0x79, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x6c, 0x6c,
+	0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x73, 0x65,
+	0x6c, 0x6c, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x66, 0x69,
+	0x74, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x70, 0x72, 0x6f,
+	0x66, 0x69, 0x74, 0x50, 0x63, 0x74, 0x12, 0x27, 0x0a, 0x10, 0x62, 0x75, 0x79, 0x5f, 0x71, 0x75,
+	0x6f, 0x74, 0x65, 0x5f, 0x61, 0x67, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0d, 0x62, 0x75, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x41, 0x67, 0x65, 0x4d, 0x73, 0x12,
+	0x29, 0x0a, 0x11, 0x73, 0x65, 0x6c, 0x6c, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x5f, 0x61, 0x67,
+	0x65, 0x5f, 0x6d, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x73, 0x65, 0x6c, 0x6c,
+	0x51, 0x75, 0x6f, 0x74, 0x65, 0x41, 0x67, 0x65, 0x4d, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x19, 0x0a, 0x08, 0x66, 0x65, 0x65, 0x73,
+	0x5f, 0x70, 0x63, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x66, 0x65, 0x65, 0x73,
+	0x50, 0x63, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x6e, 0x65, 0x74, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69,
+	0x74, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x6e, 0x65, 0x74,
+	0x50, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x50, 0x63, 0x74, 0x22, 0x94, 0x02, 0x0a, 0x15, 0x42, 0x61,
+	0x73, 0x69, 0x73, 0x54, 0x72, 0x61, 0x64, 0x65, 0x4f, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e,
+	0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x64,
+	0x65, 0x64, 0x75, 0x73, 0x74, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x0b, 0x64, 0x65, 0x64, 0x75, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x21,
+	0x0a, 0x0c, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x53, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x5f, 0x70, 0x63, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x50, 0x63,
+	0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12,
+	0x19, 0x0a, 0x08, 0x66, 0x65, 0x65, 0x73, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x07, 0x66, 0x65, 0x65, 0x73, 0x50, 0x63, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x6e, 0x65,
+	0x74, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0c, 0x6e, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x74, 0x50, 0x63, 0x74,
+	0x22, 0x92, 0x03, 0x0a, 0x0c, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x6f,
+	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x72, 0x65, 0x63,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61, 0x72, 0x73, 0x65,
+	0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x70,
+	0x61, 0x72, 0x73, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x42, 0x0a, 0x08, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x73,
+	0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x49,
+	0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x4c, 0x61,
+	0x73, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x6c,
+	0x61, 0x73, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x1a, 0x3b, 0x0a, 0x0d, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3d, 0x0a, 0x0f, 0x4c, 0x61, 0x73, 0x74, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa6, 0x02, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x72, 0x69, 0x63, 0x65, 0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x2d, 0x0a,
+	0x07, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x72, 0x65,
+	0x61, 0x64, 0x73, 0x52, 0x07, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x73, 0x12, 0x46, 0x0a, 0x0d,
+	0x6f, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x72, 0x62, 0x69, 0x74, 0x72, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x70, 0x6f, 0x72, 0x74,
+	0x75, 0x6e, 0x69, 0x74, 0x79, 0x52, 0x0d, 0x6f, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x12, 0x44, 0x0a, 0x0c, 0x62, 0x61, 0x73, 0x69, 0x73, 0x5f, 0x74, 0x72,
+	0x61, 0x64, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x63, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x73, 0x69, 0x73, 0x54, 0x72, 0x61,
+	0x64, 0x65, 0x4f, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x75, 0x6e, 0x69, 0x74, 0x79, 0x52, 0x0b, 0x62,
+	0x61, 0x73, 0x69, 0x73, 0x54, 0x72, 0x61, 0x64, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x07, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x63,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x07, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x2a, 0xa0,
+	0x01, 0x0a, 0x07, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x48,
+	0x41, 0x4e, 0x4e, 0x45, 0x4c, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x43, 0x48, 0x41, 0x4e, 0x4e, 0x45, 0x4c, 0x5f, 0x50,
+	0x52, 0x49, 0x43, 0x45, 0x53, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x48, 0x41, 0x4e, 0x4e,
+	0x45, 0x4c, 0x5f, 0x53, 0x50, 0x52, 0x45, 0x41, 0x44, 0x53, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11,
+	0x43, 0x48, 0x41, 0x4e, 0x4e, 0x45, 0x4c, 0x5f, 0x41, 0x52, 0x42, 0x49, 0x54, 0x52, 0x41, 0x47,
+	0x45, 0x10, 0x03, 0x12, 0x11, 0x0a, 0x0d, 0x43, 0x48, 0x41, 0x4e, 0x4e, 0x45, 0x4c, 0x5f, 0x42,
+	0x41, 0x53, 0x49, 0x53, 0x10, 0x04, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x48, 0x41, 0x4e, 0x4e, 0x45,
+	0x4c, 0x5f, 0x4f, 0x52, 0x44, 0x45, 0x52, 0x42, 0x4f, 0x4f, 0x4b, 0x10, 0x05, 0x12, 0x12, 0x0a,
+	0x0e, 0x43, 0x48, 0x41, 0x4e, 0x4e, 0x45, 0x4c, 0x5f, 0x54, 0x52, 0x41, 0x44, 0x45, 0x53, 0x10,
+	0x06, 0x32, 0x8e, 0x01, 0x0a, 0x07, 0x53, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x3e, 0x0a,
+	0x09, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x1c, 0x2e, 0x73, 0x63, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x73, 0x63, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x12, 0x43, 0x0a,
+	0x0b, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x1e, 0x2e, 0x73,
+	0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x73,
+	0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x42, 0x25, 0x5a, 0x23, 0x66, 0x75, 0x74, 0x75, 0x72, 0x65, 0x73, 0x2d, 0x61, 0x72,
+	0x62, 0x69, 0x74, 0x72, 0x61, 0x67, 0x65, 0x2d, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2f,
+	0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_scanner_proto_rawDescOnce sync.Once
+	file_scanner_proto_rawDescData = file_scanner_proto_rawDesc
+)
+
+func file_scanner_proto_rawDescGZIP() []byte {
+	file_scanner_proto_rawDescOnce.Do(func() {
+		file_scanner_proto_rawDescData = protoimpl.X.CompressGZIP(file_scanner_proto_rawDescData)
+	})
+	return file_scanner_proto_rawDescData
+}
+
+var file_scanner_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_scanner_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_scanner_proto_goTypes = []any{
+	(Channel)(0),                  // 0: scanner.v1.Channel
+	(*SubscribeRequest)(nil),      // 1: scanner.v1.SubscribeRequest
+	(*GetSnapshotRequest)(nil),    // 2: scanner.v1.GetSnapshotRequest
+	(*Event)(nil),                 // 3: scanner.v1.Event
+	(*Price)(nil),                 // 4: scanner.v1.Price
+	(*OrderbookTop)(nil),          // 5: scanner.v1.OrderbookTop
+	(*Trade)(nil),                 // 6: scanner.v1.Trade
+	(*Spread)(nil),                // 7: scanner.v1.Spread
+	(*Spreads)(nil),               // 8: scanner.v1.Spreads
+	(*ArbitrageOpportunity)(nil),  // 9: scanner.v1.ArbitrageOpportunity
+	(*BasisTradeOpportunity)(nil), // 10: scanner.v1.BasisTradeOpportunity
+	(*SourceStatus)(nil),          // 11: scanner.v1.SourceStatus
+	(*Snapshot)(nil),              // 12: scanner.v1.Snapshot
+	nil,                           // 13: scanner.v1.Spreads.PricesEntry
+	nil,                           // 14: scanner.v1.SourceStatus.MessagesEntry
+	nil,                           // 15: scanner.v1.SourceStatus.LastUpdateEntry
+}
+var file_scanner_proto_depIdxs = []int32{
+	0,  // 0: scanner.v1.SubscribeRequest.channels:type_name -> scanner.v1.Channel
+	4,  // 1: scanner.v1.Event.price:type_name -> scanner.v1.Price
+	5,  // 2: scanner.v1.Event.orderbook_top:type_name -> scanner.v1.OrderbookTop
+	6,  // 3: scanner.v1.Event.trade:type_name -> scanner.v1.Trade
+	8,  // 4: scanner.v1.Event.spreads:type_name -> scanner.v1.Spreads
+	9,  // 5: scanner.v1.Event.arbitrage:type_name -> scanner.v1.ArbitrageOpportunity
+	10, // 6: scanner.v1.Event.basis:type_name -> scanner.v1.BasisTradeOpportunity
+	7,  // 7: scanner.v1.Spreads.spreads:type_name -> scanner.v1.Spread
+	13, // 8: scanner.v1.Spreads.prices:type_name -> scanner.v1.Spreads.PricesEntry
+	14, // 9: scanner.v1.SourceStatus.messages:type_name -> scanner.v1.SourceStatus.MessagesEntry
+	15, // 10: scanner.v1.SourceStatus.last_update:type_name -> scanner.v1.SourceStatus.LastUpdateEntry
+	4,  // 11: scanner.v1.Snapshot.prices:type_name -> scanner.v1.Price
+	8,  // 12: scanner.v1.Snapshot.spreads:type_name -> scanner.v1.Spreads
+	9,  // 13: scanner.v1.Snapshot.opportunities:type_name -> scanner.v1.ArbitrageOpportunity
+	10, // 14: scanner.v1.Snapshot.basis_trades:type_name -> scanner.v1.BasisTradeOpportunity
+	11, // 15: scanner.v1.Snapshot.sources:type_name -> scanner.v1.SourceStatus
+	1,  // 16: scanner.v1.Scanner.Subscribe:input_type -> scanner.v1.SubscribeRequest
+	2,  // 17: scanner.v1.Scanner.GetSnapshot:input_type -> scanner.v1.GetSnapshotRequest
+	3,  // 18: scanner.v1.Scanner.Subscribe:output_type -> scanner.v1.Event
+	12, // 19: scanner.v1.Scanner.GetSnapshot:output_type -> scanner.v1.Snapshot
+	18, // [18:20] is the sub-list for method output_type
+	16, // [16:18] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_scanner_proto_init() }
+func file_scanner_proto_init() {
+	if File_scanner_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_scanner_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SubscribeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetSnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Price); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*OrderbookTop); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Trade); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Spread); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Spreads); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*ArbitrageOpportunity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*BasisTradeOpportunity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*SourceStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_scanner_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*Snapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_scanner_proto_msgTypes[2].OneofWrappers = []any{
+		(*Event_Price)(nil),
+		(*Event_OrderbookTop)(nil),
+		(*Event_Trade)(nil),
+		(*Event_Spreads)(nil),
+		(*Event_Arbitrage)(nil),
+		(*Event_Basis)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_scanner_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_scanner_proto_goTypes,
+		DependencyIndexes: file_scanner_proto_depIdxs,
+		EnumInfos:         file_scanner_proto_enumTypes,
+		MessageInfos:      file_scanner_proto_msgTypes,
+	}.Build()
+	File_scanner_proto = out.File
+	file_scanner_proto_rawDesc = nil
+	file_scanner_proto_goTypes = nil
+	file_scanner_proto_depIdxs = nil
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"futures-arbitrage-scanner/config"
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+)
+
+// AdminConfig is the configuration in force and what each connector is
+// doing with it.
+type AdminConfig struct {
+	Symbols           []string     `json:"symbols"`
+	MinProfitPct      float64      `json:"min_profit_pct"`
+	AlertCooldown     string       `json:"alert_cooldown"`
+	MaxQuoteAge       string       `json:"max_quote_age"`
+	BroadcastInterval string       `json:"broadcast_interval"`
+	Venues            []AdminVenue `json:"venues"`
+}
+
+// AdminVenue is one connector's state, fees and endpoints.
+type AdminVenue struct {
+	scanner.VenueStatus
+	TakerFeePct float64 `json:"taker_fee_pct"`
+	MakerFeePct float64 `json:"maker_fee_pct"`
+	WSURL       string  `json:"ws_url,omitempty"`
+	RESTURL     string  `json:"rest_url,omitempty"`
+}
+
+// venueUpdate is the body of PUT /api/admin/venues/{venue}; omitted fields
+// are left alone and an empty symbols list follows the global list.
+type venueUpdate struct {
+	Enabled *bool     `json:"enabled"`
+	Symbols *[]string `json:"symbols"`
+	Fees    *struct {
+		TakerPct float64 `json:"taker_pct"`
+		MakerPct float64 `json:"maker_pct"`
+	} `json:"fees"`
+}
+
+// thresholdsUpdate is the body of PUT /api/admin/thresholds; durations use
+// Go syntax, e.g. "30s".
+type thresholdsUpdate struct {
+	MinProfitPct  *float64 `json:"min_profit_pct"`
+	AlertCooldown *string  `json:"alert_cooldown"`
+	MaxQuoteAge   *string  `json:"max_quote_age"`
+}
+
+type symbolsBody struct {
+	Symbols []string `json:"symbols"`
+}
+
+// registerAdmin mounts the admin endpoints on mux. Every change is
+// validated like the config file and answered with the resulting config.
+func (s *Server) registerAdmin(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/admin/config", s.handleAdminConfig)
+	mux.HandleFunc("POST /api/admin/reload", s.handleAdminReload)
+	mux.HandleFunc("PUT /api/admin/symbols", s.handleSetSymbols)
+	mux.HandleFunc("POST /api/admin/symbols", s.handleAddSymbols)
+	mux.HandleFunc("DELETE /api/admin/symbols/{symbol}", s.handleRemoveSymbol)
+	mux.HandleFunc("PUT /api/admin/venues/{venue}", s.handleUpdateVenue)
+	mux.HandleFunc("PUT /api/admin/thresholds", s.handleUpdateThresholds)
+}
+
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := s.config.Current()
+	out := AdminConfig{
+		Symbols:           cfg.Symbols,
+		MinProfitPct:      cfg.MinProfitPct,
+		AlertCooldown:     cfg.AlertCooldown.String(),
+		MaxQuoteAge:       cfg.MaxQuoteAge.String(),
+		BroadcastInterval: cfg.BroadcastInterval.String(),
+	}
+	for _, st := range s.scanner.Venues() {
+		v := cfg.Venues[st.Name]
+		endpoints := exchanges.EndpointsFor(st.Name)
+		out.Venues = append(out.Venues, AdminVenue{
+			VenueStatus: st,
+			TakerFeePct: v.Fees.TakerPct,
+			MakerFeePct: v.Fees.MakerPct,
+			WSURL:       endpoints.WS,
+			RESTURL:     endpoints.REST,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (s *Server) handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.config.Reload(); err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.handleAdminConfig(w, r)
+}
+
+func (s *Server) handleSetSymbols(w http.ResponseWriter, r *http.Request) {
+	var body symbolsBody
+	if !decodeAdminBody(w, r, &body) {
+		return
+	}
+	s.updateConfig(w, r, func(c *config.Config) { c.Symbols = body.Symbols })
+}
+
+func (s *Server) handleAddSymbols(w http.ResponseWriter, r *http.Request) {
+	var body symbolsBody
+	if !decodeAdminBody(w, r, &body) {
+		return
+	}
+	s.updateConfig(w, r, func(c *config.Config) { c.Symbols = append(c.Symbols, body.Symbols...) })
+}
+
+func (s *Server) handleRemoveSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	s.updateConfig(w, r, func(c *config.Config) {
+		kept := c.Symbols[:0]
+		for _, sym := range c.Symbols {
+			if sym != symbol {
+				kept = append(kept, sym)
+			}
+		}
+		c.Symbols = kept
+	})
+}
+
+func (s *Server) handleUpdateVenue(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("venue")
+	known := false
+	for _, st := range s.scanner.Venues() {
+		if st.Name == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		writeAPIError(w, http.StatusNotFound, "unknown venue "+name)
+		return
+	}
+
+	var body venueUpdate
+	if !decodeAdminBody(w, r, &body) {
+		return
+	}
+	s.updateConfig(w, r, func(c *config.Config) {
+		v := c.Venues[name]
+		if body.Enabled != nil {
+			enabled := *body.Enabled
+			v.Enabled = &enabled
+		}
+		if body.Symbols != nil {
+			v.Symbols = *body.Symbols
+		}
+		if body.Fees != nil {
+			v.Fees = config.Fees{TakerPct: body.Fees.TakerPct, MakerPct: body.Fees.MakerPct}
+		}
+		c.Venues[name] = v
+	})
+}
+
+func (s *Server) handleUpdateThresholds(w http.ResponseWriter, r *http.Request) {
+	var body thresholdsUpdate
+	if !decodeAdminBody(w, r, &body) {
+		return
+	}
+	var cooldown, maxAge time.Duration
+	var err error
+	if body.AlertCooldown != nil {
+		if cooldown, err = time.ParseDuration(*body.AlertCooldown); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "alert_cooldown: "+err.Error())
+			return
+		}
+	}
+	if body.MaxQuoteAge != nil {
+		if maxAge, err = time.ParseDuration(*body.MaxQuoteAge); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "max_quote_age: "+err.Error())
+			return
+		}
+	}
+
+	s.updateConfig(w, r, func(c *config.Config) {
+		if body.MinProfitPct != nil {
+			c.MinProfitPct = *body.MinProfitPct
+		}
+		if body.AlertCooldown != nil {
+			c.AlertCooldown = cooldown
+		}
+		if body.MaxQuoteAge != nil {
+			c.MaxQuoteAge = maxAge
+		}
+	})
+}
+
+// updateConfig applies fn through the config manager and answers with the
+// new config, or with the validation errors.
+func (s *Server) updateConfig(w http.ResponseWriter, r *http.Request, fn func(*config.Config)) {
+	if err := s.config.Update(fn); err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	s.handleAdminConfig(w, r)
+}
+
+func decodeAdminBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"futures-arbitrage-scanner/config"
+	"futures-arbitrage-scanner/scanner"
+)
+
+func TestAdminRefusedWithoutAuth(t *testing.T) {
+	auth, err := NewAuthenticator("", "", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	cfg := config.Default()
+	sc := scanner.New(cfg.ScannerOptions()...)
+	s := New(sc, Options{Auth: auth, Config: config.NewManager("", cfg, sc)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/thresholds", strings.NewReader(`{"min_profit_pct":5}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body.String())
+	}
+	if th := sc.Thresholds(); th.MinProfitPct == 5 {
+		t.Fatalf("thresholds changed without credentials: %+v", th)
+	}
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeysFile(t, path, `{"keys":[
+		{"id":"reader","key":"read-key","scope":"read"},
+		{"id":"ops","key":"admin-key","scope":"admin"}
+	]}`)
+	auth, err := NewAuthenticator(path, "", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	cfg := config.Default()
+	sc := scanner.New(cfg.ScannerOptions()...)
+	s := New(sc, Options{Auth: auth, Config: config.NewManager("", cfg, sc)})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		body   string
+		want   int
+	}{
+		{"read key", http.MethodGet, "/api/admin/config", "read-key", "", http.StatusForbidden},
+		{"get config", http.MethodGet, "/api/admin/config", "admin-key", "", http.StatusOK},
+		{"add symbols", http.MethodPost, "/api/admin/symbols", "admin-key", `{"symbols":["btcusdt"]}`, http.StatusOK},
+		{"bad symbol", http.MethodPost, "/api/admin/symbols", "admin-key", `{"symbols":["BTC-USDT"]}`, http.StatusUnprocessableEntity},
+		{"remove symbol", http.MethodDelete, "/api/admin/symbols/TONUSDT", "admin-key", "", http.StatusOK},
+		{"disable venue", http.MethodPut, "/api/admin/venues/gate_futures", "admin-key", `{"enabled":false}`, http.StatusOK},
+		{"unknown venue", http.MethodPut, "/api/admin/venues/nope", "admin-key", `{"enabled":false}`, http.StatusNotFound},
+		{"unknown field", http.MethodPut, "/api/admin/thresholds", "admin-key", `{"min_profit":1}`, http.StatusBadRequest},
+		{"thresholds", http.MethodPut, "/api/admin/thresholds", "admin-key", `{"min_profit_pct":0.2,"alert_cooldown":"30s"}`, http.StatusOK},
+		{"negative threshold", http.MethodPut, "/api/admin/thresholds", "admin-key", `{"min_profit_pct":-1}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	if got := strings.Join(sc.Symbols(), ","); got != "BTCUSDT" {
+		t.Fatalf("scanner symbols = %s", got)
+	}
+	th := sc.Thresholds()
+	if th.MinProfitPct != 0.2 || th.AlertCooldown.String() != "30s" {
+		t.Fatalf("thresholds not applied: %+v", th)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+	req.Header.Set("X-API-Key", "admin-key")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var got AdminConfig
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, v := range got.Venues {
+		if v.Name == "gate_futures" && v.Enabled {
+			t.Fatalf("gate_futures still enabled: %+v", v)
+		}
+	}
+}
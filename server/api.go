@@ -0,0 +1,470 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// page is the JSON envelope for list endpoints.
+type page[T any] struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Items  []T `json:"items"`
+}
+
+// PriceRow is one source's latest price for a symbol.
+type PriceRow struct {
+	Symbol       string  `json:"symbol"`
+	Source       string  `json:"source"`
+	Price        float64 `json:"price"`
+	ExchangeTime int64   `json:"exchange_time,omitempty"` // venue event time, Unix ms
+	ReceivedAt   int64   `json:"received_at"`             // Unix ms
+	AgeMs        int64   `json:"age_ms"`
+	Fresh        bool    `json:"fresh"`
+}
+
+// SpreadRow is the spread between two sources' fresh prices for a symbol.
+type SpreadRow struct {
+	Symbol     string  `json:"symbol"`
+	BuySource  string  `json:"buy_source"`
+	SellSource string  `json:"sell_source"`
+	BuyPrice   float64 `json:"buy_price"`
+	SellPrice  float64 `json:"sell_price"`
+	SpreadPct  float64 `json:"spread_pct"`
+}
+
+// SymbolInfo lists the sources currently quoting a symbol.
+type SymbolInfo struct {
+	Symbol  string   `json:"symbol"`
+	Sources []string `json:"sources"`
+}
+
+// registerAPI mounts the REST endpoints on mux. Every list endpoint accepts
+// limit and offset for pagination and format=csv for a CSV download.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/api/symbols", getOnly(s.handleSymbols))
+	mux.HandleFunc("/api/prices", getOnly(s.handlePrices))
+	mux.HandleFunc("/api/spreads", getOnly(s.handleSpreads))
+	mux.HandleFunc("/api/opportunities", getOnly(s.handleOpportunities))
+	mux.HandleFunc("/api/basis", getOnly(s.handleBasis))
+	mux.HandleFunc("/api/sources", getOnly(s.handleSources))
+	mux.HandleFunc("/api/markets", getOnly(s.handleMarkets))
+}
+
+func getOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	prices := s.scanner.Prices()
+	rows := make([]SymbolInfo, 0, len(prices))
+	for symbol, sourcePrices := range prices {
+		sources := make([]string, 0, len(sourcePrices))
+		for source := range sourcePrices {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		rows = append(rows, SymbolInfo{Symbol: symbol, Sources: sources})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Symbol < rows[j].Symbol })
+
+	writeRows(w, r, rows, []string{"symbol", "sources"}, func(row SymbolInfo) []string {
+		return []string{row.Symbol, strings.Join(row.Sources, ";")}
+	})
+}
+
+func (s *Server) handlePrices(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+
+	quotes := s.scanner.Quotes(symbol)
+	rows := make([]PriceRow, 0, len(quotes))
+	for _, q := range quotes {
+		rows = append(rows, PriceRow{
+			Symbol:       q.Symbol,
+			Source:       q.Source,
+			Price:        q.Price,
+			ExchangeTime: q.ExchangeTime,
+			ReceivedAt:   q.ReceivedAt.UnixMilli(),
+			AgeMs:        q.Age.Milliseconds(),
+			Fresh:        q.Fresh,
+		})
+	}
+
+	header := []string{"symbol", "source", "price", "exchange_time", "received_at", "age_ms", "fresh"}
+	writeRows(w, r, rows, header, func(row PriceRow) []string {
+		return []string{
+			row.Symbol,
+			row.Source,
+			formatFloat(row.Price),
+			strconv.FormatInt(row.ExchangeTime, 10),
+			strconv.FormatInt(row.ReceivedAt, 10),
+			strconv.FormatInt(row.AgeMs, 10),
+			strconv.FormatBool(row.Fresh),
+		}
+	})
+}
+
+func (s *Server) handleSpreads(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+
+	var rows []SpreadRow
+	for sym, fresh := range s.scanner.FreshPrices() {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		for buySource, sells := range scanner.ComputeSpreads(fresh) {
+			for sellSource, spreadPct := range sells {
+				rows = append(rows, SpreadRow{
+					Symbol:     sym,
+					BuySource:  buySource,
+					SellSource: sellSource,
+					BuyPrice:   fresh[buySource],
+					SellPrice:  fresh[sellSource],
+					SpreadPct:  spreadPct,
+				})
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Symbol != rows[j].Symbol {
+			return rows[i].Symbol < rows[j].Symbol
+		}
+		return rows[i].SpreadPct > rows[j].SpreadPct
+	})
+
+	header := []string{"symbol", "buy_source", "sell_source", "buy_price", "sell_price", "spread_pct"}
+	writeRows(w, r, rows, header, func(row SpreadRow) []string {
+		return []string{
+			row.Symbol,
+			row.BuySource,
+			row.SellSource,
+			formatFloat(row.BuyPrice),
+			formatFloat(row.SellPrice),
+			formatFloat(row.SpreadPct),
+		}
+	})
+}
+
+// opportunityQuery holds the filters shared by /api/opportunities and
+// /api/basis. Zero values mean "no filter".
+type opportunityQuery struct {
+	symbol    string
+	venue     string
+	since     int64
+	until     int64
+	minProfit float64
+	maxProfit float64
+}
+
+func parseOpportunityQuery(r *http.Request) (opportunityQuery, error) {
+	q := r.URL.Query()
+	oq := opportunityQuery{
+		symbol: strings.ToUpper(strings.TrimSpace(q.Get("symbol"))),
+		venue:  strings.TrimSpace(q.Get("venue")),
+	}
+
+	var err error
+	if oq.since, err = parseTimeParam(q.Get("since")); err != nil {
+		return oq, fmt.Errorf("invalid since: %v", err)
+	}
+	if oq.until, err = parseTimeParam(q.Get("until")); err != nil {
+		return oq, fmt.Errorf("invalid until: %v", err)
+	}
+	if v := q.Get("min_profit"); v != "" {
+		if oq.minProfit, err = strconv.ParseFloat(v, 64); err != nil {
+			return oq, fmt.Errorf("invalid min_profit %q", v)
+		}
+	}
+	if v := q.Get("max_profit"); v != "" {
+		if oq.maxProfit, err = strconv.ParseFloat(v, 64); err != nil {
+			return oq, fmt.Errorf("invalid max_profit %q", v)
+		}
+	}
+	return oq, nil
+}
+
+// parseTimeParam accepts Unix milliseconds or RFC 3339.
+func parseTimeParam(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither Unix ms nor RFC 3339", v)
+	}
+	return t.UnixMilli(), nil
+}
+
+// match reports whether an opportunity passes the filters. profitPct is
+// its profit after fees, as min_profit and max_profit apply to.
+func (q opportunityQuery) match(symbol string, timestamp int64, profitPct float64, venues ...string) bool {
+	if q.symbol != "" && symbol != q.symbol {
+		return false
+	}
+	if q.since > 0 && timestamp < q.since {
+		return false
+	}
+	if q.until > 0 && timestamp > q.until {
+		return false
+	}
+	if profitPct < q.minProfit {
+		return false
+	}
+	if q.maxProfit > 0 && profitPct > q.maxProfit {
+		return false
+	}
+	if q.venue == "" {
+		return true
+	}
+	for _, v := range venues {
+		if strings.EqualFold(v, q.venue) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleOpportunities lists recent arbitrage alerts, newest first. venue
+// matches either leg.
+func (s *Server) handleOpportunities(w http.ResponseWriter, r *http.Request) {
+	oq, err := parseOpportunityQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := s.history.arbitrageItems()
+	rows := make([]scanner.ArbitrageOpportunity, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		o := items[i]
+		if oq.match(o.Symbol, o.Timestamp, o.NetProfitPct, o.BuySource, o.SellSource) {
+			rows = append(rows, o)
+		}
+	}
+
+	header := []string{"timestamp", "symbol", "buy_source", "sell_source", "buy_price", "sell_price", "profit_pct", "fees_pct", "net_profit_pct", "buy_quote_age_ms", "sell_quote_age_ms"}
+	writeRows(w, r, rows, header, func(o scanner.ArbitrageOpportunity) []string {
+		return []string{
+			strconv.FormatInt(o.Timestamp, 10),
+			o.Symbol,
+			o.BuySource,
+			o.SellSource,
+			formatFloat(o.BuyPrice),
+			formatFloat(o.SellPrice),
+			formatFloat(o.ProfitPct),
+			formatFloat(o.FeesPct),
+			formatFloat(o.NetProfitPct),
+			strconv.FormatInt(o.BuyQuoteAgeMs, 10),
+			strconv.FormatInt(o.SellQuoteAgeMs, 10),
+		}
+	})
+}
+
+// handleBasis lists recent basis trade alerts, newest first. venue matches
+// the short leg.
+func (s *Server) handleBasis(w http.ResponseWriter, r *http.Request) {
+	oq, err := parseOpportunityQuery(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := s.history.basisItems()
+	rows := make([]scanner.BasisTradeOpportunity, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		o := items[i]
+		if oq.match(o.Symbol, o.Timestamp, o.NetProfitPct, o.ShortSource) {
+			rows = append(rows, o)
+		}
+	}
+
+	header := []string{"timestamp", "symbol", "dedust_price", "short_source", "short_price", "profit_pct", "fees_pct", "net_profit_pct"}
+	writeRows(w, r, rows, header, func(o scanner.BasisTradeOpportunity) []string {
+		return []string{
+			strconv.FormatInt(o.Timestamp, 10),
+			o.Symbol,
+			formatFloat(o.DeDustPrice),
+			o.ShortSource,
+			formatFloat(o.ShortPrice),
+			formatFloat(o.ProfitPct),
+			formatFloat(o.FeesPct),
+			formatFloat(o.NetProfitPct),
+		}
+	})
+}
+
+func (s *Server) handleSources(w http.ResponseWriter, r *http.Request) {
+	rows := s.scanner.Sources()
+
+	header := []string{"source", "connected", "status", "reconnects", "received", "parse_errors", "unknown", "updates", "conflated", "dropped", "messages", "last_message", "backoff_ms", "last_error", "shards", "p50_ms", "p99_ms", "clock_skew_ms"}
+	writeRows(w, r, rows, header, func(st scanner.SourceStatus) []string {
+		var messages uint64
+		for _, n := range st.Messages {
+			messages += n
+		}
+		var lastError string
+		if len(st.Errors) > 0 {
+			lastError = st.Errors[len(st.Errors)-1].Error
+		}
+		var shards string
+		if len(st.Shards) > 0 {
+			connected := 0
+			for _, sh := range st.Shards {
+				if sh.Connected {
+					connected++
+				}
+			}
+			shards = fmt.Sprintf("%d/%d", connected, len(st.Shards))
+		}
+		var p50, p99, skew string
+		if st.Latency != nil {
+			p50 = formatFloat(st.Latency.P50Ms)
+			p99 = formatFloat(st.Latency.P99Ms)
+			skew = formatFloat(st.Latency.ClockSkewMs)
+		}
+		return []string{
+			st.Source,
+			strconv.FormatBool(st.Connected),
+			st.Status,
+			strconv.FormatUint(st.Reconnects, 10),
+			strconv.FormatUint(st.Received, 10),
+			strconv.FormatUint(st.ParseErrors, 10),
+			strconv.FormatUint(st.Unknown, 10),
+			strconv.FormatUint(st.Updates, 10),
+			strconv.FormatUint(st.Conflated, 10),
+			strconv.FormatUint(st.Dropped, 10),
+			strconv.FormatUint(messages, 10),
+			strconv.FormatInt(st.LastMessage, 10),
+			strconv.FormatInt(st.BackoffMs, 10),
+			lastError,
+			shards,
+			p50,
+			p99,
+			skew,
+		}
+	})
+}
+
+// handleMarkets lists what market discovery found, by venue then symbol,
+// filtered on venue and symbol.
+func (s *Server) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	venue := strings.TrimSpace(r.URL.Query().Get("venue"))
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+
+	var rows []exchanges.Market
+	for _, v := range exchanges.InstrumentVenues() {
+		if venue != "" && !strings.EqualFold(v, venue) {
+			continue
+		}
+		for _, m := range exchanges.Markets(v) {
+			if symbol == "" || m.Symbol == symbol {
+				rows = append(rows, m)
+			}
+		}
+	}
+
+	header := []string{"venue", "symbol", "native", "kind", "tick_size", "lot_size", "multiplier", "status"}
+	writeRows(w, r, rows, header, func(m exchanges.Market) []string {
+		return []string{
+			m.Venue,
+			m.Symbol,
+			m.Native,
+			string(m.Kind),
+			formatFloat(m.TickSize),
+			formatFloat(m.LotSize),
+			formatFloat(m.Multiplier),
+			m.Status,
+		}
+	})
+}
+
+// writeRows paginates rows and writes them as JSON, or as CSV when the
+// request asks for format=csv.
+func writeRows[T any](w http.ResponseWriter, r *http.Request, rows []T, header []string, csvRow func(T) []string) {
+	q := r.URL.Query()
+
+	limit := defaultPageLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+			return
+		}
+		limit = min(n, maxPageLimit)
+	}
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset %q", v))
+			return
+		}
+		offset = n
+	}
+
+	total := len(rows)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	items := rows[start:end]
+	if items == nil {
+		items = []T{}
+	}
+
+	switch format := strings.ToLower(q.Get("format")); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page[T]{Total: total, Offset: offset, Limit: limit, Items: items}); err != nil {
+			log.Printf("API encode error (%s): %v", r.URL.Path, err)
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		for _, row := range items {
+			cw.Write(csvRow(row))
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			log.Printf("API CSV error (%s): %v", r.URL.Path, err)
+		}
+	default:
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", format))
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
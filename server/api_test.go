@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+)
+
+func newAPITestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s := newTestServer()
+	mux := http.NewServeMux()
+	s.registerAPI(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func getJSON(t *testing.T, url string, out interface{}) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPIPricesAndSpreads(t *testing.T) {
+	s, srv := newAPITestServer(t)
+	now := time.Now().UnixMilli()
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2.00, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.02, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "BTCUSDT", Source: "okx_futures", Price: 60000, ReceivedAt: now})
+
+	var symbols page[SymbolInfo]
+	getJSON(t, srv.URL+"/api/symbols", &symbols)
+	if symbols.Total != 2 || symbols.Items[1].Symbol != "TONUSDT" || len(symbols.Items[1].Sources) != 2 {
+		t.Fatalf("unexpected symbols: %+v", symbols)
+	}
+
+	var prices page[PriceRow]
+	getJSON(t, srv.URL+"/api/prices?symbol=tonusdt", &prices)
+	if prices.Total != 2 || prices.Items[0].Source != "binance_futures" || !prices.Items[0].Fresh {
+		t.Fatalf("unexpected prices: %+v", prices)
+	}
+
+	var spreads page[SpreadRow]
+	getJSON(t, srv.URL+"/api/spreads?symbol=TONUSDT", &spreads)
+	if spreads.Total != 2 {
+		t.Fatalf("expected 2 spreads, got %+v", spreads)
+	}
+	if top := spreads.Items[0]; top.BuySource != "binance_futures" || top.SellSource != "okx_futures" {
+		t.Fatalf("expected widest spread first, got %+v", top)
+	}
+}
+
+func TestAPIOpportunityFilters(t *testing.T) {
+	s, srv := newAPITestServer(t)
+	s.history.addArbitrage(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", BuySource: "okx_futures", SellSource: "gate_futures", ProfitPct: 0.2, FeesPct: 0.1, NetProfitPct: 0.1, Timestamp: 1000})
+	s.history.addArbitrage(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", BuySource: "binance_futures", SellSource: "okx_futures", ProfitPct: 0.4, FeesPct: 0.1, NetProfitPct: 0.3, Timestamp: 2000})
+	s.history.addArbitrage(scanner.ArbitrageOpportunity{Symbol: "BTCUSDT", BuySource: "bybit_futures", SellSource: "gate_futures", ProfitPct: 0.3, FeesPct: 0.1, NetProfitPct: 0.2, Timestamp: 3000})
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int64 // timestamps, newest first
+	}{
+		{"all", "", []int64{3000, 2000, 1000}},
+		{"symbol", "?symbol=TONUSDT", []int64{2000, 1000}},
+		{"venue either leg", "?venue=okx_futures", []int64{2000, 1000}},
+		{"min profit after fees", "?min_profit=0.15", []int64{3000, 2000}},
+		{"max profit after fees", "?max_profit=0.25", []int64{3000, 1000}},
+		{"time range", "?since=1500&until=2500", []int64{2000}},
+		{"pagination", "?limit=1&offset=1", []int64{2000}},
+		{"offset past end", "?offset=10", []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got page[scanner.ArbitrageOpportunity]
+			if code := getJSON(t, srv.URL+"/api/opportunities"+tt.query, &got); code != http.StatusOK {
+				t.Fatalf("status %d", code)
+			}
+			if len(got.Items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d: %+v", len(got.Items), len(tt.want), got.Items)
+			}
+			for i, o := range got.Items {
+				if o.Timestamp != tt.want[i] {
+					t.Errorf("item %d: timestamp %d, want %d", i, o.Timestamp, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAPIBadRequests(t *testing.T) {
+	_, srv := newAPITestServer(t)
+
+	tests := []string{
+		"/api/opportunities?since=yesterday",
+		"/api/opportunities?min_profit=lots",
+		"/api/basis?limit=0",
+		"/api/prices?offset=-1",
+		"/api/sources?format=xml",
+	}
+
+	for _, path := range tests {
+		if code := getJSON(t, srv.URL+path, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, code)
+		}
+	}
+}
+
+func TestAPICSVExport(t *testing.T) {
+	s, srv := newAPITestServer(t)
+	s.history.addBasis(scanner.BasisTradeOpportunity{Symbol: "TONUSDT", DeDustPrice: 2, ShortSource: "okx_futures", ShortPrice: 2.01, ProfitPct: 0.5, FeesPct: 0.1, NetProfitPct: 0.4, Timestamp: 1000})
+
+	resp, err := http.Get(srv.URL + "/api/basis?format=csv")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("content type %q", ct)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 2 || records[0][0] != "timestamp" || records[1][3] != "okx_futures" {
+		t.Fatalf("unexpected csv: %v", records)
+	}
+	header, row := records[0], records[1]
+	if got := strings.Join(header[len(header)-2:], ","); got != "fees_pct,net_profit_pct" {
+		t.Fatalf("csv header ends with %q, want the fee columns", got)
+	}
+	if row[len(row)-2] != "0.1" || row[len(row)-1] != "0.4" {
+		t.Fatalf("csv row %v, want fees 0.1 and net profit 0.4", row)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s := New(scanner.New(scanner.WithSymbols("TONUSDT", "BTCUSDT")), Options{})
+	mux := http.NewServeMux()
+	s.RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	if code := getJSON(t, srv.URL+"/health/live", nil); code != http.StatusOK {
+		t.Fatalf("/health/live = %d, want 200", code)
+	}
+
+	var health struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+	}
+	if code := getJSON(t, srv.URL+"/health", &health); code != http.StatusOK || health.Status != "ok" || health.Version != version {
+		t.Fatalf("/health = %d %+v, want ok with version %q", code, health, version)
+	}
+
+	now := time.Now().UnixMilli()
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2.00, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.01, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "BTCUSDT", Source: "okx_futures", Price: 60000, ReceivedAt: now})
+	if code := getJSON(t, srv.URL+"/health/ready", nil); code != http.StatusServiceUnavailable {
+		t.Fatalf("/health/ready with one BTCUSDT venue = %d, want 503", code)
+	}
+
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "BTCUSDT", Source: "bybit_futures", Price: 60010, ReceivedAt: now})
+	var ready struct {
+		Status  string                    `json:"status"`
+		Symbols []scanner.SymbolReadiness `json:"symbols"`
+	}
+	if code := getJSON(t, srv.URL+"/health/ready", &ready); code != http.StatusOK || ready.Status != "ready" {
+		t.Fatalf("/health/ready = %d %+v, want ready", code, ready)
+	}
+	for _, r := range ready.Symbols {
+		if len(r.Fresh) != 2 {
+			t.Errorf("%s fresh venues %v, want 2", r.Symbol, r.Fresh)
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authReloadInterval is how often the keys file is checked for changes.
+const authReloadInterval = 5 * time.Second
+
+// scope is what a credential may do. Admin includes read.
+type scope int
+
+const (
+	scopeRead scope = iota
+	scopeAdmin
+)
+
+func parseScope(v string) (scope, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "read":
+		return scopeRead, nil
+	case "admin":
+		return scopeAdmin, nil
+	default:
+		return 0, fmt.Errorf("unknown scope %q", v)
+	}
+}
+
+func (sc scope) String() string {
+	if sc == scopeAdmin {
+		return "admin"
+	}
+	return "read"
+}
+
+// apiKey is one entry of the keys file. Either the key itself or its
+// SHA-256 hex digest may be stored.
+type apiKey struct {
+	ID        string `json:"id"`
+	Key       string `json:"key,omitempty"`
+	KeySHA256 string `json:"key_sha256,omitempty"`
+	Scope     string `json:"scope"`
+	MaxConns  int    `json:"max_conns"`
+}
+
+// keysFile is the JSON document named by AUTH_KEYS_FILE:
+//
+//	{
+//	  "keys": [{"id": "notebook", "key_sha256": "…", "scope": "read", "max_conns": 2}],
+//	  "revoked_tokens": ["alice"],
+//	  "revoked_token_ids": ["9f86d081884c7d65"]
+//	}
+//
+// revoked_tokens lists token subjects that are no longer accepted, and
+// revoked_token_ids single tokens by their id. With only a token secret
+// configured, a file without keys holds just the revocations.
+type keysFile struct {
+	Keys            []apiKey `json:"keys"`
+	RevokedTokens   []string `json:"revoked_tokens"`
+	RevokedTokenIDs []string `json:"revoked_token_ids"`
+}
+
+// TokenClaims is the payload of a signed token. Every token expires; ID
+// names it for revocation.
+type TokenClaims struct {
+	ID       string `json:"jti,omitempty"`
+	Subject  string `json:"sub"`
+	Scope    string `json:"scope"`
+	Expires  int64  `json:"exp"` // Unix seconds
+	MaxConns int    `json:"max_conns,omitempty"`
+}
+
+// principal is an authenticated caller. id is "key:<id>" or "token:<sub>";
+// tokenID is the token's own id.
+type principal struct {
+	id       string
+	tokenID  string
+	scope    scope
+	maxConns int
+}
+
+// authConn is a live streaming connection held by a principal. It is
+// cancelled when the principal's credential is revoked.
+type authConn struct {
+	tokenID string
+	cancel  context.CancelFunc
+}
+
+var (
+	errNoCredential = errors.New("missing credential")
+	errBadToken     = errors.New("invalid token")
+	errRevoked      = errors.New("credential revoked")
+	errUnknownKey   = errors.New("unknown API key")
+)
+
+// Authenticator checks API keys and signed tokens, enforces per-credential
+// connection limits and the origin allowlist. With neither a keys file nor a
+// token secret configured it lets everyone in with admin scope.
+type Authenticator struct {
+	mu         sync.RWMutex
+	keys       map[string]principal // by SHA-256 hex of the key
+	revoked    map[string]bool      // token subjects
+	revokedIDs map[string]bool      // token ids
+	conns      map[string]map[*authConn]struct{}
+
+	secret  []byte
+	path    string
+	modTime time.Time
+	origins map[string]bool // nil allows every origin
+}
+
+// NewAuthenticator loads the keys file at path, if any. secret signs tokens
+// and origins is a comma-separated browser origin allowlist; empty values
+// disable each feature.
+func NewAuthenticator(path, secret, origins string) (*Authenticator, error) {
+	a := &Authenticator{
+		keys:       make(map[string]principal),
+		revoked:    make(map[string]bool),
+		revokedIDs: make(map[string]bool),
+		conns:      make(map[string]map[*authConn]struct{}),
+		secret:     []byte(secret),
+		path:       path,
+	}
+	for _, o := range splitList(origins) {
+		if o == "*" {
+			a.origins = nil
+			break
+		}
+		if a.origins == nil {
+			a.origins = make(map[string]bool)
+		}
+		a.origins[strings.TrimRight(strings.ToLower(o), "/")] = true
+	}
+	if path != "" {
+		if err := a.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Enabled reports whether credentials are required.
+func (a *Authenticator) Enabled() bool {
+	return a.path != "" || len(a.secret) > 0
+}
+
+// reload re-reads the keys file and closes connections whose key was
+// removed or whose token, or its subject, was revoked.
+func (a *Authenticator) reload() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return fmt.Errorf("auth keys file: %v", err)
+	}
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("auth keys file: %v", err)
+	}
+	var kf keysFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return fmt.Errorf("auth keys file %s: %v", a.path, err)
+	}
+
+	keys := make(map[string]principal, len(kf.Keys))
+	for i, k := range kf.Keys {
+		if k.ID == "" {
+			return fmt.Errorf("auth keys file %s: key %d has no id", a.path, i)
+		}
+		sc, err := parseScope(k.Scope)
+		if err != nil {
+			return fmt.Errorf("auth keys file %s: key %q: %v", a.path, k.ID, err)
+		}
+		digest := strings.ToLower(k.KeySHA256)
+		if k.Key != "" {
+			digest = hashKey(k.Key)
+		}
+		if len(digest) != sha256.Size*2 {
+			return fmt.Errorf("auth keys file %s: key %q needs key or a hex key_sha256", a.path, k.ID)
+		}
+		keys[digest] = principal{id: "key:" + k.ID, scope: sc, maxConns: k.MaxConns}
+	}
+	revoked := make(map[string]bool, len(kf.RevokedTokens))
+	for _, sub := range kf.RevokedTokens {
+		revoked[sub] = true
+	}
+	revokedIDs := make(map[string]bool, len(kf.RevokedTokenIDs))
+	for _, id := range kf.RevokedTokenIDs {
+		revokedIDs[id] = true
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.revoked = revoked
+	a.revokedIDs = revokedIDs
+	a.modTime = info.ModTime()
+
+	valid := make(map[string]bool, len(keys))
+	for _, p := range keys {
+		valid[p.id] = true
+	}
+	var cut []*authConn
+	for id, conns := range a.conns {
+		sub, isToken := strings.CutPrefix(id, "token:")
+		for c := range conns {
+			if (isToken && (revoked[sub] || revokedIDs[c.tokenID])) || (!isToken && !valid[id]) {
+				cut = append(cut, c)
+			}
+		}
+	}
+	a.mu.Unlock()
+
+	for _, c := range cut {
+		c.cancel()
+	}
+	if len(cut) > 0 {
+		log.Printf("Auth: closed %d connections with revoked credentials", len(cut))
+	}
+	return nil
+}
+
+// Watch reloads the keys file whenever it changes. A bad edit is logged and
+// the previous keys stay in force.
+func (a *Authenticator) Watch() {
+	if a.path == "" {
+		return
+	}
+	ticker := time.NewTicker(authReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		info, err := os.Stat(a.path)
+		if err != nil {
+			log.Printf("Auth keys file: %v", err)
+			continue
+		}
+		a.mu.RLock()
+		changed := !info.ModTime().Equal(a.modTime)
+		a.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := a.reload(); err != nil {
+			log.Printf("Auth reload failed, keeping previous keys: %v", err)
+			continue
+		}
+		log.Printf("Auth keys reloaded from %s", a.path)
+	}
+}
+
+// authenticate resolves a credential, which is either an API key or a
+// signed token.
+func (a *Authenticator) authenticate(cred string) (principal, error) {
+	if !a.Enabled() {
+		return principal{id: "anonymous", scope: scopeAdmin}, nil
+	}
+	if cred == "" {
+		return principal{}, errNoCredential
+	}
+
+	a.mu.RLock()
+	p, ok := a.keys[hashKey(cred)]
+	a.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	if len(a.secret) == 0 || !strings.Contains(cred, ".") {
+		return principal{}, errUnknownKey
+	}
+	claims, err := verifyToken(a.secret, cred, time.Now())
+	if err != nil {
+		return principal{}, err
+	}
+	a.mu.RLock()
+	revoked := a.revoked[claims.Subject] || (claims.ID != "" && a.revokedIDs[claims.ID])
+	a.mu.RUnlock()
+	if revoked {
+		return principal{}, errRevoked
+	}
+	sc, err := parseScope(claims.Scope)
+	if err != nil {
+		return principal{}, errBadToken
+	}
+	return principal{id: "token:" + claims.Subject, tokenID: claims.ID, scope: sc, maxConns: claims.MaxConns}, nil
+}
+
+// acquire reserves a streaming connection slot for p. The returned context
+// is cancelled if p's credential is revoked; release must be called when the
+// connection ends.
+func (a *Authenticator) acquire(ctx context.Context, p principal) (context.Context, func(), error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	conns := a.conns[p.id]
+	if p.maxConns > 0 && len(conns) >= p.maxConns {
+		return nil, nil, fmt.Errorf("connection limit of %d reached", p.maxConns)
+	}
+	if conns == nil {
+		conns = make(map[*authConn]struct{})
+		a.conns[p.id] = conns
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c := &authConn{tokenID: p.tokenID, cancel: cancel}
+	conns[c] = struct{}{}
+
+	release := func() {
+		cancel()
+		a.mu.Lock()
+		delete(a.conns[p.id], c)
+		if len(a.conns[p.id]) == 0 {
+			delete(a.conns, p.id)
+		}
+		a.mu.Unlock()
+	}
+	return ctx, release, nil
+}
+
+// checkOrigin implements the browser origin allowlist. Requests without an
+// Origin header come from non-browser clients and are allowed.
+func (a *Authenticator) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if a.origins == nil || origin == "" {
+		return true
+	}
+	return a.origins[strings.TrimRight(strings.ToLower(origin), "/")]
+}
+
+// credentialFromRequest reads the credential from the Authorization or
+// X-API-Key header, or from the api_key/token query parameters for browser
+// WebSocket and EventSource clients, which can't set headers.
+func credentialFromRequest(r *http.Request) string {
+	if v := r.Header.Get("Authorization"); v != "" {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if v := r.Header.Get("X-API-Key"); v != "" {
+		return v
+	}
+	if v := r.URL.Query().Get("api_key"); v != "" {
+		return v
+	}
+	return r.URL.Query().Get("token")
+}
+
+func (a *Authenticator) check(w http.ResponseWriter, r *http.Request, need scope) (principal, bool) {
+	if !a.checkOrigin(r) {
+		writeAPIError(w, http.StatusForbidden, "origin not allowed")
+		return principal{}, false
+	}
+	// Only an allowlisted origin is named back; without an allowlist no
+	// origin is, rather than every one.
+	if origin := r.Header.Get("Origin"); origin != "" && a.origins != nil {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+	p, err := a.authenticate(credentialFromRequest(r))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scanner"`)
+		writeAPIError(w, http.StatusUnauthorized, err.Error())
+		return principal{}, false
+	}
+	if p.scope < need {
+		writeAPIError(w, http.StatusForbidden, fmt.Sprintf("%s scope required", need))
+		return principal{}, false
+	}
+	return p, true
+}
+
+// require wraps a request/response handler with authentication.
+func (a *Authenticator) require(need scope, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := a.check(w, r, need); ok {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// stream wraps a long-lived streaming handler: on top of authentication it
+// counts the connection against the credential's limit and cancels the
+// request context if the credential is revoked.
+func (a *Authenticator) stream(need scope, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := a.check(w, r, need)
+		if !ok {
+			return
+		}
+		ctx, release, err := a.acquire(r.Context(), p)
+		if err != nil {
+			writeAPIError(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		defer release()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// grpcOptions returns interceptors applying the same checks to gRPC calls.
+// The credential goes in the "authorization" (Bearer) or "x-api-key"
+// metadata.
+func (a *Authenticator) grpcOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (interface{}, error) {
+			if _, err := a.grpcPrincipal(ctx); err != nil {
+				return nil, err
+			}
+			return h(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			p, err := a.grpcPrincipal(ss.Context())
+			if err != nil {
+				return err
+			}
+			ctx, release, err := a.acquire(ss.Context(), p)
+			if err != nil {
+				return status.Error(codes.ResourceExhausted, err.Error())
+			}
+			defer release()
+			return h(srv, &authStream{ServerStream: ss, ctx: ctx})
+		}),
+	}
+}
+
+func (a *Authenticator) grpcPrincipal(ctx context.Context) (principal, error) {
+	var cred string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			cred = strings.TrimSpace(strings.TrimPrefix(v[0], "Bearer "))
+		} else if v := md.Get("x-api-key"); len(v) > 0 {
+			cred = v[0]
+		}
+	}
+	p, err := a.authenticate(cred)
+	if err != nil {
+		return principal{}, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return p, nil
+}
+
+// authStream swaps in a context that is cancelled on revocation.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context { return s.ctx }
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenID returns a random token id.
+func NewTokenID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SignToken returns "<payload>.<signature>", both base64url encoded, where
+// the signature is HMAC-SHA256 of the encoded payload. A token needs an
+// expiry; one without an ID gets a random one.
+func SignToken(secret []byte, claims TokenClaims) (string, error) {
+	if claims.Subject == "" {
+		return "", errors.New("token subject is required")
+	}
+	if claims.Expires <= 0 {
+		return "", errors.New("token expiry is required")
+	}
+	if claims.ID == "" {
+		claims.ID = NewTokenID()
+	}
+	if _, err := parseScope(claims.Scope); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifyToken(secret []byte, token string, now time.Time) (TokenClaims, error) {
+	var claims TokenClaims
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errBadToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return claims, errBadToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return claims, errBadToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claims, errBadToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return claims, errBadToken
+	}
+	if claims.Expires <= 0 {
+		return claims, errors.New("token has no expiry")
+	}
+	if now.Unix() >= claims.Expires {
+		return claims, errors.New("token expired")
+	}
+	return claims, nil
+}
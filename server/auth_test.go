@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeysFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write keys file: %v", err)
+	}
+}
+
+// signPayload signs a raw payload the way SignToken does, so tests can build
+// tokens SignToken refuses to.
+func signPayload(secret []byte, payload string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyToken(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1_700_000_000, 0)
+	valid, _ := SignToken(secret, TokenClaims{Subject: "alice", Scope: "read", Expires: now.Unix() + 60})
+	expired, _ := SignToken(secret, TokenClaims{Subject: "alice", Scope: "read", Expires: now.Unix() - 1})
+	otherKey, _ := SignToken([]byte("other"), TokenClaims{Subject: "alice", Scope: "read", Expires: now.Unix() + 60})
+	noExpiry := signPayload(secret, `{"sub":"alice","scope":"read"}`)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"expired", expired, true},
+		{"no expiry", noExpiry, true},
+		{"wrong secret", otherKey, true},
+		{"tampered", valid[:len(valid)-2] + "xx", true},
+		{"no signature", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifyToken(secret, tt.token, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject != "alice" {
+				t.Fatalf("subject %q", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorScopesAndOrigins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeysFile(t, path, `{"keys":[
+		{"id":"reader","key":"read-key","scope":"read"},
+		{"id":"ops","key_sha256":"`+hashKey("admin-key")+`","scope":"admin"}
+	]}`)
+	a, err := NewAuthenticator(path, "", "https://dash.example.com")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	open, err := NewAuthenticator(path, "", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		auth     *Authenticator
+		need     scope
+		header   map[string]string
+		query    string
+		want     int
+		wantACAO string // Access-Control-Allow-Origin
+	}{
+		{"no credential", a, scopeRead, nil, "", http.StatusUnauthorized, ""},
+		{"unknown key", a, scopeRead, map[string]string{"X-API-Key": "nope"}, "", http.StatusUnauthorized, ""},
+		{"read key", a, scopeRead, map[string]string{"X-API-Key": "read-key"}, "", http.StatusOK, ""},
+		{"key in query", a, scopeRead, nil, "?api_key=read-key", http.StatusOK, ""},
+		{"bearer admin", a, scopeAdmin, map[string]string{"Authorization": "Bearer admin-key"}, "", http.StatusOK, ""},
+		{"read key on admin", a, scopeAdmin, map[string]string{"X-API-Key": "read-key"}, "", http.StatusForbidden, ""},
+		{"allowed origin", a, scopeRead, map[string]string{"X-API-Key": "read-key", "Origin": "https://dash.example.com"}, "", http.StatusOK, "https://dash.example.com"},
+		{"foreign origin", a, scopeRead, map[string]string{"X-API-Key": "read-key", "Origin": "https://evil.example.com"}, "", http.StatusForbidden, ""},
+		{"origin without allowlist", open, scopeRead, map[string]string{"X-API-Key": "read-key", "Origin": "https://evil.example.com"}, "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/prices"+tt.query, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			tt.auth.require(tt.need, ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantACAO {
+				t.Fatalf("Access-Control-Allow-Origin %q, want %q", got, tt.wantACAO)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorConnectionLimitAndRevocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeysFile(t, path, `{"keys":[{"id":"bot","key":"bot-key","max_conns":1}]}`)
+	a, err := NewAuthenticator(path, "tok-secret", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	p, err := a.authenticate("bot-key")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	ctx, release, err := a.acquire(context.Background(), p)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if _, _, err := a.acquire(context.Background(), p); err == nil {
+		t.Fatal("second connection should hit the limit")
+	}
+
+	expires := time.Now().Add(time.Hour).Unix()
+	token, _ := SignToken([]byte("tok-secret"), TokenClaims{Subject: "carol", Scope: "read", Expires: expires})
+	tp, err := a.authenticate(token)
+	if err != nil {
+		t.Fatalf("token authenticate: %v", err)
+	}
+	tokenCtx, tokenRelease, _ := a.acquire(context.Background(), tp)
+	defer tokenRelease()
+
+	// dave holds two tokens; only the one revoked by id must go.
+	daveOld, _ := SignToken([]byte("tok-secret"), TokenClaims{ID: "dave-1", Subject: "dave", Scope: "read", Expires: expires})
+	daveNew, _ := SignToken([]byte("tok-secret"), TokenClaims{ID: "dave-2", Subject: "dave", Scope: "read", Expires: expires})
+	dp, err := a.authenticate(daveOld)
+	if err != nil {
+		t.Fatalf("token authenticate: %v", err)
+	}
+	daveOldCtx, daveOldRelease, _ := a.acquire(context.Background(), dp)
+	defer daveOldRelease()
+	dp, _ = a.authenticate(daveNew)
+	daveNewCtx, daveNewRelease, _ := a.acquire(context.Background(), dp)
+	defer daveNewRelease()
+
+	// Remove the key, revoke the token subject and one token id; those
+	// live connections must be cut and new attempts refused.
+	writeKeysFile(t, path, `{"keys":[],"revoked_tokens":["carol"],"revoked_token_ids":["dave-1"]}`)
+	if err := a.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	for name, c := range map[string]context.Context{"key": ctx, "token": tokenCtx, "token id": daveOldCtx} {
+		select {
+		case <-c.Done():
+		default:
+			t.Errorf("%s connection not cancelled", name)
+		}
+	}
+	if daveNewCtx.Err() != nil {
+		t.Error("connection of an unrevoked token id was cancelled")
+	}
+	if _, err := a.authenticate("bot-key"); err == nil {
+		t.Error("removed key still accepted")
+	}
+	if _, err := a.authenticate(token); err != errRevoked {
+		t.Errorf("revoked token: err = %v", err)
+	}
+	if _, err := a.authenticate(daveOld); err != errRevoked {
+		t.Errorf("revoked token id: err = %v", err)
+	}
+	if _, err := a.authenticate(daveNew); err != nil {
+		t.Errorf("unrevoked token id: err = %v", err)
+	}
+
+	release()
+	if len(a.conns) != 2 {
+		t.Errorf("expected only the token connections tracked, got %d", len(a.conns))
+	}
+}
+
+func TestAuthenticatorDisabled(t *testing.T) {
+	a, err := NewAuthenticator("", "", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	p, err := a.authenticate("")
+	if err != nil || p.scope != scopeAdmin {
+		t.Fatalf("disabled auth should admit everyone: %+v, %v", p, err)
+	}
+}
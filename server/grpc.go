@@ -0,0 +1,354 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+	"futures-arbitrage-scanner/scannerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Orderbook tops and trades are only streamed over gRPC, and only to
+// subscribers that ask for them.
+const (
+	channelOrderbook = "orderbook"
+	channelTrades    = "trades"
+)
+
+var grpcChannels = map[scannerpb.Channel]string{
+	scannerpb.Channel_CHANNEL_PRICES:    channelPrices,
+	scannerpb.Channel_CHANNEL_SPREADS:   channelSpreads,
+	scannerpb.Channel_CHANNEL_ARBITRAGE: channelArbitrage,
+	scannerpb.Channel_CHANNEL_BASIS:     channelBasis,
+	scannerpb.Channel_CHANNEL_ORDERBOOK: channelOrderbook,
+	scannerpb.Channel_CHANNEL_TRADES:    channelTrades,
+}
+
+// grpcSub is one Subscribe stream.
+type grpcSub struct {
+	filter *clientFilter
+	send   chan *scannerpb.Event
+}
+
+// grpcBroker fans typed events out to gRPC subscribers. Like the WebSocket
+// hub it never blocks the caller: a full queue drops its oldest event.
+type grpcBroker struct {
+	mu   sync.RWMutex
+	subs map[*grpcSub]struct{}
+}
+
+func newGRPCBroker() *grpcBroker {
+	return &grpcBroker{subs: make(map[*grpcSub]struct{})}
+}
+
+// active reports whether anyone is subscribed, so callers can skip building
+// events nobody will read.
+func (b *grpcBroker) active() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+func (b *grpcBroker) add(filter *clientFilter) *grpcSub {
+	sub := &grpcSub{filter: filter, send: make(chan *scannerpb.Event, clientQueueSize)}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *grpcBroker) remove(sub *grpcSub) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+func (b *grpcBroker) publish(r route, ev *scannerpb.Event) {
+	now := time.Now()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.filter.allow(r, now) {
+			continue
+		}
+		select {
+		case sub.send <- ev:
+			continue
+		default:
+		}
+		select {
+		case <-sub.send:
+		default:
+		}
+		wsDropped.WithLabelValues("grpc_drop_oldest").Inc()
+		select {
+		case sub.send <- ev:
+		default:
+		}
+	}
+}
+
+func (s *Server) streamPrice(data exchanges.PriceData) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelPrices, symbol: data.Symbol}, &scannerpb.Event{
+		Payload: &scannerpb.Event_Price{Price: &scannerpb.Price{
+			Symbol:       data.Symbol,
+			Source:       data.Source,
+			Price:        data.Price,
+			ExchangeTime: data.Timestamp,
+			ReceivedAt:   data.ReceivedAt,
+		}},
+	})
+}
+
+func (s *Server) streamOrderbook(data exchanges.OrderbookData) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelOrderbook, symbol: data.Symbol}, &scannerpb.Event{
+		Payload: &scannerpb.Event_OrderbookTop{OrderbookTop: &scannerpb.OrderbookTop{
+			Symbol:       data.Symbol,
+			Source:       data.Source,
+			BestBid:      data.BestBid,
+			BestAsk:      data.BestAsk,
+			ExchangeTime: data.Timestamp,
+			ReceivedAt:   data.ReceivedAt,
+			BidSize:      data.BidSize,
+			AskSize:      data.AskSize,
+		}},
+	})
+}
+
+func (s *Server) streamTrade(data exchanges.TradeData) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelTrades, symbol: data.Symbol}, &scannerpb.Event{
+		Payload: &scannerpb.Event_Trade{Trade: &scannerpb.Trade{
+			Symbol:       data.Symbol,
+			Source:       data.Source,
+			Price:        data.Price,
+			Quantity:     formatQuantity(data.Quantity),
+			Side:         data.Side,
+			ExchangeTime: data.Timestamp,
+			ReceivedAt:   data.ReceivedAt,
+		}},
+	})
+}
+
+// formatQuantity writes a trade size in full, or nothing if it's unknown.
+func formatQuantity(q float64) string {
+	if q == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+func (s *Server) streamSpreads(spreads scanner.Spreads) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelSpreads, symbol: spreads.Symbol}, &scannerpb.Event{
+		Payload: &scannerpb.Event_Spreads{Spreads: spreadsToProto(spreads)},
+	})
+}
+
+func (s *Server) streamArbitrage(o scanner.ArbitrageOpportunity) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelArbitrage, symbol: o.Symbol, profitPct: o.NetProfitPct}, &scannerpb.Event{
+		Payload: &scannerpb.Event_Arbitrage{Arbitrage: arbitrageToProto(o)},
+	})
+}
+
+func (s *Server) streamBasis(o scanner.BasisTradeOpportunity) {
+	if !s.streams.active() {
+		return
+	}
+	s.streams.publish(route{channel: channelBasis, symbol: o.Symbol, profitPct: o.NetProfitPct}, &scannerpb.Event{
+		Payload: &scannerpb.Event_Basis{Basis: basisToProto(o)},
+	})
+}
+
+func spreadsToProto(spreads scanner.Spreads) *scannerpb.Spreads {
+	out := &scannerpb.Spreads{Symbol: spreads.Symbol, Prices: spreads.Prices}
+	for buySource, sells := range spreads.Spreads {
+		for sellSource, spreadPct := range sells {
+			out.Spreads = append(out.Spreads, &scannerpb.Spread{
+				BuySource:  buySource,
+				SellSource: sellSource,
+				SpreadPct:  spreadPct,
+			})
+		}
+	}
+	sort.Slice(out.Spreads, func(i, j int) bool {
+		a, b := out.Spreads[i], out.Spreads[j]
+		if a.BuySource != b.BuySource {
+			return a.BuySource < b.BuySource
+		}
+		return a.SellSource < b.SellSource
+	})
+	return out
+}
+
+func arbitrageToProto(o scanner.ArbitrageOpportunity) *scannerpb.ArbitrageOpportunity {
+	return &scannerpb.ArbitrageOpportunity{
+		Symbol:         o.Symbol,
+		BuySource:      o.BuySource,
+		SellSource:     o.SellSource,
+		BuyPrice:       o.BuyPrice,
+		SellPrice:      o.SellPrice,
+		ProfitPct:      o.ProfitPct,
+		BuyQuoteAgeMs:  o.BuyQuoteAgeMs,
+		SellQuoteAgeMs: o.SellQuoteAgeMs,
+		Timestamp:      o.Timestamp,
+		FeesPct:        o.FeesPct,
+		NetProfitPct:   o.NetProfitPct,
+	}
+}
+
+func basisToProto(o scanner.BasisTradeOpportunity) *scannerpb.BasisTradeOpportunity {
+	return &scannerpb.BasisTradeOpportunity{
+		Symbol:       o.Symbol,
+		DedustPrice:  o.DeDustPrice,
+		ShortSource:  o.ShortSource,
+		ShortPrice:   o.ShortPrice,
+		ProfitPct:    o.ProfitPct,
+		Timestamp:    o.Timestamp,
+		FeesPct:      o.FeesPct,
+		NetProfitPct: o.NetProfitPct,
+	}
+}
+
+// grpcService implements scannerpb.ScannerServer on top of the scanner.
+type grpcService struct {
+	scannerpb.UnimplementedScannerServer
+	server *Server
+}
+
+// filterFromSubscribe turns a SubscribeRequest into the same filter /ws
+// clients use.
+func filterFromSubscribe(req *scannerpb.SubscribeRequest) (*clientFilter, error) {
+	if req.GetThrottleMs() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "throttle_ms must not be negative")
+	}
+	if req.GetMinProfitPct() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "min_profit_pct must not be negative")
+	}
+
+	channels := make([]string, 0, len(req.GetChannels()))
+	for _, ch := range req.GetChannels() {
+		name, ok := grpcChannels[ch]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown channel %v", ch)
+		}
+		channels = append(channels, name)
+	}
+	if len(channels) == 0 {
+		channels = allChannels
+	}
+
+	f := newClientFilter()
+	f.explicit = true
+	symbols := normalizeSymbols(req.GetSymbols())
+	for _, ch := range channels {
+		f.subscribeLocked(ch, symbols)
+	}
+	f.throttle = time.Duration(req.GetThrottleMs()) * time.Millisecond
+	f.minProfitPct = req.GetMinProfitPct()
+	return f, nil
+}
+
+func (g *grpcService) Subscribe(req *scannerpb.SubscribeRequest, stream grpc.ServerStreamingServer[scannerpb.Event]) error {
+	filter, err := filterFromSubscribe(req)
+	if err != nil {
+		return err
+	}
+
+	sub := g.server.streams.add(filter)
+	defer g.server.streams.remove(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev := <-sub.send:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *grpcService) GetSnapshot(ctx context.Context, req *scannerpb.GetSnapshotRequest) (*scannerpb.Snapshot, error) {
+	s := g.server
+	want := make(map[string]bool)
+	for _, symbol := range normalizeSymbols(req.GetSymbols()) {
+		want[symbol] = true
+	}
+	included := func(symbol string) bool { return len(want) == 0 || want[symbol] }
+
+	snap := &scannerpb.Snapshot{}
+	for _, q := range s.scanner.Quotes("") {
+		if included(q.Symbol) {
+			snap.Prices = append(snap.Prices, &scannerpb.Price{
+				Symbol:       q.Symbol,
+				Source:       q.Source,
+				Price:        q.Price,
+				ExchangeTime: q.ExchangeTime,
+				ReceivedAt:   q.ReceivedAt.UnixMilli(),
+			})
+		}
+	}
+
+	for symbol, fresh := range s.scanner.FreshPrices() {
+		if included(symbol) && len(fresh) >= 2 {
+			spreads := scanner.Spreads{Symbol: symbol, Prices: fresh, Spreads: scanner.ComputeSpreads(fresh)}
+			snap.Spreads = append(snap.Spreads, spreadsToProto(spreads))
+		}
+	}
+	sort.Slice(snap.Spreads, func(i, j int) bool { return snap.Spreads[i].Symbol < snap.Spreads[j].Symbol })
+
+	for _, o := range s.history.arbitrageItems() {
+		if included(o.Symbol) {
+			snap.Opportunities = append(snap.Opportunities, arbitrageToProto(o))
+		}
+	}
+	for _, o := range s.history.basisItems() {
+		if included(o.Symbol) {
+			snap.BasisTrades = append(snap.BasisTrades, basisToProto(o))
+		}
+	}
+
+	for _, st := range s.scanner.Sources() {
+		snap.Sources = append(snap.Sources, &scannerpb.SourceStatus{
+			Source:      st.Source,
+			Connected:   st.Connected,
+			Reconnects:  st.Reconnects,
+			ParseErrors: st.ParseErrors,
+			Messages:    st.Messages,
+			LastUpdate:  st.LastUpdate,
+		})
+	}
+	return snap, nil
+}
+
+// GRPCServer returns a gRPC server exposing the scanner service, guarded
+// by the server's Authenticator.
+func (s *Server) GRPCServer() *grpc.Server {
+	srv := grpc.NewServer(s.auth.grpcOptions()...)
+	scannerpb.RegisterScannerServer(srv, &grpcService{server: s})
+	return srv
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+	"futures-arbitrage-scanner/scannerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCTestClient(t *testing.T, s *Server) scannerpb.ScannerClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := s.GRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return scannerpb.NewScannerClient(conn)
+}
+
+func TestGRPCSubscribeFilters(t *testing.T) {
+	s := newTestServer()
+	client := newGRPCTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Subscribe(ctx, &scannerpb.SubscribeRequest{
+		Channels:     []scannerpb.Channel{scannerpb.Channel_CHANNEL_ARBITRAGE, scannerpb.Channel_CHANNEL_TRADES},
+		Symbols:      []string{"tonusdt"},
+		MinProfitPct: 0.2,
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.streams.active() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.3, FeesPct: 0.2, NetProfitPct: 0.1})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "BTCUSDT", ProfitPct: 0.5, NetProfitPct: 0.5})
+	s.streamPrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", BuySource: "okx_futures", ProfitPct: 0.4, FeesPct: 0.1, NetProfitPct: 0.3})
+	s.streamTrade(exchanges.TradeData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2, Side: "buy"})
+
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if got := ev.GetArbitrage(); got == nil || got.NetProfitPct != 0.3 || got.BuySource != "okx_futures" {
+		t.Fatalf("expected filtered arbitrage event, got %v", ev)
+	}
+
+	ev, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if got := ev.GetTrade(); got == nil || got.Side != "buy" {
+		t.Fatalf("expected trade event, got %v", ev)
+	}
+}
+
+func TestGRPCSubscribeRejectsBadRequest(t *testing.T) {
+	client := newGRPCTestClient(t, newTestServer())
+
+	stream, err := client.Subscribe(context.Background(), &scannerpb.SubscribeRequest{ThrottleMs: -1})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCGetSnapshot(t *testing.T) {
+	s := newTestServer()
+	client := newGRPCTestClient(t, s)
+
+	now := time.Now().UnixMilli()
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "binance_futures", Price: 2.00, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "TONUSDT", Source: "okx_futures", Price: 2.02, ReceivedAt: now})
+	s.scanner.UpdatePrice(exchanges.PriceData{Symbol: "BTCUSDT", Source: "okx_futures", Price: 60000, ReceivedAt: now})
+
+	snap, err := client.GetSnapshot(context.Background(), &scannerpb.GetSnapshotRequest{Symbols: []string{"TONUSDT"}})
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(snap.Prices) != 2 || snap.Prices[0].Source != "binance_futures" {
+		t.Fatalf("unexpected prices: %v", snap.Prices)
+	}
+	if len(snap.Spreads) != 1 || len(snap.Spreads[0].Spreads) != 2 {
+		t.Fatalf("unexpected spreads: %v", snap.Spreads)
+	}
+	if len(snap.Opportunities) != 1 || snap.Opportunities[0].SellSource != "okx_futures" {
+		t.Fatalf("expected the 1%% alert, got %v", snap.Opportunities)
+	}
+}
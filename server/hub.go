@@ -0,0 +1,452 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// clientQueueSize bounds how many messages may wait for a slow client.
+	clientQueueSize = 256
+
+	// writeWait is the deadline for a single write to a client.
+	writeWait = 10 * time.Second
+
+	// pongWait is how long a client may stay silent before it is dropped;
+	// pings go out often enough that a healthy client always answers in time.
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+
+	// replayBufferSize is how many recent messages are kept for clients
+	// resuming after a reconnect.
+	replayBufferSize = 4096
+)
+
+// overflowPolicy decides what happens when a client's queue is full.
+type overflowPolicy int
+
+const (
+	// dropOldest discards the oldest queued message to make room.
+	dropOldest overflowPolicy = iota
+	// disconnectSlow closes clients that can't keep up.
+	disconnectSlow
+)
+
+func parseOverflowPolicy(v string) overflowPolicy {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "drop-oldest", "drop_oldest":
+		return dropOldest
+	case "disconnect":
+		return disconnectSlow
+	default:
+		log.Printf("Unknown WS_OVERFLOW_POLICY %q, using drop-oldest", v)
+		return dropOldest
+	}
+}
+
+// outbound is a message already encoded for the wire.
+type outbound struct {
+	seq      uint64
+	msgType  string
+	data     []byte
+	queuedAt time.Time
+}
+
+// hubClient is one streaming connection, over WebSocket or SSE. The hub only
+// ever enqueues to send; the connection's write loop is the sole writer.
+type hubClient struct {
+	hub       *wsHub
+	conn      *websocket.Conn // nil for SSE clients
+	remote    string
+	filter    *clientFilter
+	send      chan outbound
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// syncing is set, under the hub's publishMu, while the client's
+	// snapshot is being built; published messages skip it until the
+	// snapshot and the messages after it are queued.
+	syncing bool
+}
+
+// replayEntry is a published message kept for resuming clients. prev is the
+// seq of the replayed message before it, so a resume can tell whether it
+// missed any that are no longer buffered.
+type replayEntry struct {
+	seq     uint64
+	prev    uint64
+	msgType string
+	r       route
+	data    []byte
+}
+
+// resumePoint is where a reconnecting client left off. Sequence numbers
+// restart with the process, so the epoch must match for a resume to work.
+type resumePoint struct {
+	epoch int64
+	seq   uint64
+}
+
+// wsHub fans messages out to clients without ever blocking the caller.
+type wsHub struct {
+	mu       sync.RWMutex
+	clients  map[*hubClient]struct{}
+	overflow overflowPolicy
+
+	// publishMu orders sequence assignment, the replay buffer and fan-out,
+	// so every client receives messages in sequence order. Every message
+	// takes the next seq; replaySeq is the seq of the last one kept for
+	// replay.
+	publishMu sync.Mutex
+	seq       uint64
+	replaySeq uint64
+	epoch     int64
+	replay    *ring[replayEntry]
+
+	// snapshot builds the state a new client starts from, trimmed to its
+	// subscriptions.
+	snapshot func(f *clientFilter) map[string]interface{}
+}
+
+func newWSHub(overflow overflowPolicy) *wsHub {
+	return &wsHub{
+		clients:  make(map[*hubClient]struct{}),
+		overflow: overflow,
+		epoch:    time.Now().UnixMilli(),
+		replay:   newRing[replayEntry](replayBufferSize),
+	}
+}
+
+// Count returns the number of connected clients.
+func (h *wsHub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// register adds a client and brings it up to date before any live message
+// can reach it: a client resuming within the replay window gets the messages
+// it missed, everyone else gets a snapshot.
+func (h *wsHub) register(conn *websocket.Conn, remote string, filter *clientFilter, resume *resumePoint) *hubClient {
+	c := &hubClient{
+		hub:    h,
+		conn:   conn,
+		remote: remote,
+		filter: filter,
+		send:   make(chan outbound, clientQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	h.publishMu.Lock()
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	if resume != nil && h.canReplayLocked(*resume) {
+		h.replayLocked(c, *resume)
+		h.publishMu.Unlock()
+		return c
+	}
+	c.syncing = true
+	h.publishMu.Unlock()
+
+	h.sendSnapshot(c, resume != nil)
+	return c
+}
+
+// canReplayLocked reports whether every replayed message after resume is
+// still buffered.
+func (h *wsHub) canReplayLocked(resume resumePoint) bool {
+	if resume.epoch != h.epoch || resume.seq > h.seq {
+		return false
+	}
+	entries := h.replay.items()
+	return len(entries) == 0 || entries[0].prev <= resume.seq
+}
+
+// replayLocked queues the buffered messages after resume that the client is
+// subscribed to.
+func (h *wsHub) replayLocked(c *hubClient, resume resumePoint) {
+	now := time.Now()
+	for _, e := range h.replay.items() {
+		if e.seq <= resume.seq || !c.filter.accepts(e.r) {
+			continue
+		}
+		c.enqueue(outbound{seq: e.seq, msgType: e.msgType, data: e.data, queuedAt: now})
+	}
+}
+
+// sendSnapshot queues a snapshot for c. resumeFailed tells the client that
+// it asked to resume but the missed messages are no longer available.
+//
+// The snapshot takes its seq up front and is built without holding
+// publishMu, so fan-out to other clients carries on meanwhile. Messages
+// published in between skip c and are replayed right after the snapshot;
+// if they have already left the replay buffer, the snapshot is built again.
+func (h *wsHub) sendSnapshot(c *hubClient, resumeFailed bool) {
+	for {
+		h.publishMu.Lock()
+		c.syncing = true
+		h.seq++
+		at := resumePoint{epoch: h.epoch, seq: h.seq}
+		h.publishMu.Unlock()
+
+		message := map[string]interface{}{}
+		if h.snapshot != nil {
+			message = h.snapshot(c.filter)
+		}
+		message["type"] = "snapshot"
+		message["seq"] = at.seq
+		message["epoch"] = at.epoch
+		if resumeFailed {
+			message["resume_failed"] = true
+		}
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Printf("WebSocket encode error (snapshot): %v", err)
+		}
+
+		h.publishMu.Lock()
+		if !h.canReplayLocked(at) {
+			h.publishMu.Unlock()
+			continue
+		}
+		if err == nil {
+			c.enqueue(outbound{seq: at.seq, msgType: "snapshot", data: data, queuedAt: time.Now()})
+		}
+		h.replayLocked(c, at)
+		c.syncing = false
+		h.publishMu.Unlock()
+		return
+	}
+}
+
+func (h *wsHub) unregister(c *hubClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	c.close()
+}
+
+func (h *wsHub) snapshotClients() []*hubClient {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*hubClient, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// Publish stamps message with the next sequence number, keeps it for
+// replay and queues it for every client whose subscriptions accept r.
+func (h *wsHub) Publish(msgType string, r route, message map[string]interface{}) {
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	h.seq++
+	message["seq"] = h.seq
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("WebSocket encode error (%s): %v", msgType, err)
+		return
+	}
+	h.replay.push(replayEntry{seq: h.seq, prev: h.replaySeq, msgType: msgType, r: r, data: data})
+	h.replaySeq = h.seq
+
+	now := time.Now()
+	out := outbound{seq: h.seq, msgType: msgType, data: data, queuedAt: now}
+	for _, c := range h.snapshotClients() {
+		if !c.syncing && c.filter.allow(r, now) {
+			c.enqueue(out)
+		}
+	}
+}
+
+// PublishPrices sends the price table, trimmed to the symbols each client
+// subscribed to. Clients with the same selection share one encoding, and
+// its seq. Prices are state rather than events, so they are not kept for
+// replay; a resumed client picks them up on the next tick.
+func (h *wsHub) PublishPrices(prices map[string]map[string]float64) {
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	now := time.Now()
+	encoded := make(map[string]*outbound)
+
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, c := range h.snapshotClients() {
+		if c.syncing {
+			continue
+		}
+		var selected []string
+		for _, symbol := range symbols {
+			if c.filter.allow(route{channel: channelPrices, symbol: symbol}, now) {
+				selected = append(selected, symbol)
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+
+		key := strings.Join(selected, ",")
+		out, ok := encoded[key]
+		if !ok {
+			subset := make(map[string]map[string]float64, len(selected))
+			for _, symbol := range selected {
+				subset[symbol] = prices[symbol]
+			}
+			h.seq++
+			data, err := json.Marshal(map[string]interface{}{
+				"type":   "prices",
+				"seq":    h.seq,
+				"prices": subset,
+			})
+			if err != nil {
+				log.Printf("WebSocket encode error (prices): %v", err)
+				return
+			}
+			out = &outbound{seq: h.seq, msgType: "prices", data: data, queuedAt: now}
+			encoded[key] = out
+		}
+		c.enqueue(*out)
+	}
+}
+
+// reply queues a message for this client only, bypassing its filter. It
+// takes the next seq but isn't kept for replay.
+func (c *hubClient) reply(msgType string, message map[string]interface{}) {
+	c.hub.publishMu.Lock()
+	defer c.hub.publishMu.Unlock()
+	c.hub.replyLocked(c, msgType, message)
+}
+
+func (h *wsHub) replyLocked(c *hubClient, msgType string, message map[string]interface{}) {
+	h.seq++
+	message["seq"] = h.seq
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("WebSocket encode error (%s): %v", msgType, err)
+		return
+	}
+	c.enqueue(outbound{seq: h.seq, msgType: msgType, data: data, queuedAt: time.Now()})
+}
+
+// enqueue never blocks. A full queue is handled per the hub's overflow policy.
+func (c *hubClient) enqueue(out outbound) {
+	select {
+	case c.send <- out:
+		return
+	default:
+	}
+
+	if c.hub.overflow == disconnectSlow {
+		wsDropped.WithLabelValues("disconnect").Inc()
+		log.Printf("Client %s too slow, disconnecting", c.remote)
+		c.close()
+		return
+	}
+
+	// Make room by discarding the oldest message, then retry once. Another
+	// broadcaster may win the freed slot, in which case this message is the
+	// one dropped.
+	select {
+	case <-c.send:
+		wsDropped.WithLabelValues("drop_oldest").Inc()
+	default:
+	}
+	select {
+	case c.send <- out:
+	default:
+		wsDropped.WithLabelValues("drop_oldest").Inc()
+	}
+}
+
+func (c *hubClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
+// writePump drains the client's queue and keeps the connection alive with
+// pings. It owns all writes to the connection; when a write fails it closes
+// the connection, which ends readPump and the handler unregisters the client.
+func (c *hubClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case out := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, out.data); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				return
+			}
+			broadcastDuration.WithLabelValues(out.msgType).Observe(time.Since(out.queuedAt).Seconds())
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump applies the client's subscription commands and returns once the
+// client goes away. Every command is answered with an ack or an error.
+func (c *hubClient) readPump() {
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		cmd, err := parseClientCommand(data)
+		if err == nil && cmd.Op == "snapshot" {
+			c.hub.sendSnapshot(c, false)
+			continue
+		}
+		if err == nil {
+			err = c.filter.apply(cmd)
+		}
+		if err != nil {
+			c.reply("error", map[string]interface{}{
+				"type":  "error",
+				"op":    cmd.Op,
+				"error": err.Error(),
+			})
+			continue
+		}
+		c.reply("ack", map[string]interface{}{
+			"type": "ack",
+			"op":   cmd.Op,
+		})
+	}
+}
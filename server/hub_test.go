@@ -0,0 +1,248 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/scanner"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientEnqueueDropsOldest(t *testing.T) {
+	h := newWSHub(dropOldest)
+	c := &hubClient{hub: h, send: make(chan outbound, 2), done: make(chan struct{})}
+
+	for _, typ := range []string{"a", "b", "c"} {
+		c.enqueue(outbound{msgType: typ})
+	}
+
+	if got := (<-c.send).msgType; got != "b" {
+		t.Fatalf("first queued: got %q want b", got)
+	}
+	if got := (<-c.send).msgType; got != "c" {
+		t.Fatalf("second queued: got %q want c", got)
+	}
+}
+
+// dialScanner connects a WebSocket client to s with the given query string
+// and waits until the hub has registered it.
+func dialScanner(t *testing.T, s *Server, srv *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	before := s.hub.Count()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+query, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.hub.Count() == before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return conn
+}
+
+type testMessage struct {
+	Type         string                       `json:"type"`
+	Seq          uint64                       `json:"seq"`
+	Epoch        int64                        `json:"epoch"`
+	ResumeFailed bool                         `json:"resume_failed"`
+	Opportunity  scanner.ArbitrageOpportunity `json:"opportunity"`
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) (testMessage, []byte) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var msg testMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return msg, data
+}
+
+func TestBroadcastReachesClient(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	conn := dialScanner(t, s, srv, "")
+	defer conn.Close()
+
+	if msg, data := readMessage(t, conn); msg.Type != "snapshot" || msg.Epoch == 0 {
+		t.Fatalf("expected snapshot first, got: %s", data)
+	}
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.1})
+
+	msg, data := readMessage(t, conn)
+	if msg.Type != "arbitrage" || msg.Opportunity.Symbol != "TONUSDT" {
+		t.Fatalf("unexpected message: %s", data)
+	}
+}
+
+func TestResumeReplaysMissedMessages(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.1})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "BTCUSDT", ProfitPct: 0.2})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.3})
+
+	query := fmt.Sprintf("?resume_from=1&epoch=%d&symbols=TONUSDT", s.hub.epoch)
+	conn := dialScanner(t, s, srv, query)
+	defer conn.Close()
+
+	msg, data := readMessage(t, conn)
+	if msg.Type != "arbitrage" || msg.Seq != 3 || msg.Opportunity.ProfitPct != 0.3 {
+		t.Fatalf("expected replayed seq 3, got: %s", data)
+	}
+}
+
+// TestResumeAcrossUnreplayedMessages has prices and a reply go out between
+// two events, and expects a client that saw the first event to resume from
+// it even though the replay buffer only holds the ones after.
+func TestResumeAcrossUnreplayedMessages(t *testing.T) {
+	s := newTestServer()
+	s.hub.replay = newRing[replayEntry](2)
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.1})
+	seen := s.hub.seq
+	s.hub.PublishPrices(map[string]map[string]float64{"TONUSDT": {"okx_futures": 2}})
+	other := &hubClient{hub: s.hub, send: make(chan outbound, 1), done: make(chan struct{})}
+	other.reply("ack", map[string]interface{}{"type": "ack"})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.2})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.3})
+
+	conn := dialScanner(t, s, srv, fmt.Sprintf("?resume_from=%d&epoch=%d", seen, s.hub.epoch))
+	defer conn.Close()
+
+	last := seen
+	for _, want := range []float64{0.2, 0.3} {
+		msg, data := readMessage(t, conn)
+		if msg.Type != "arbitrage" || msg.Seq <= last || msg.Opportunity.ProfitPct != want {
+			t.Fatalf("expected replayed opportunity %v after seq %d, got: %s", want, last, data)
+		}
+		last = msg.Seq
+	}
+}
+
+// TestEveryMessageTakesItsOwnSeq expects a client to see strictly
+// increasing seqs across snapshots, acks, prices and events.
+func TestEveryMessageTakesItsOwnSeq(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	conn := dialScanner(t, s, srv, "")
+	defer conn.Close()
+
+	snapshot, _ := readMessage(t, conn)
+	if err := conn.WriteJSON(map[string]interface{}{"op": "subscribe", "channels": []string{"prices", "arbitrage"}}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ack, data := readMessage(t, conn)
+	if ack.Type != "ack" {
+		t.Fatalf("expected ack, got: %s", data)
+	}
+	s.hub.PublishPrices(map[string]map[string]float64{"TONUSDT": {"okx_futures": 2}})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.1})
+	prices, _ := readMessage(t, conn)
+	event, _ := readMessage(t, conn)
+
+	seqs := []uint64{snapshot.Seq, ack.Seq, prices.Seq, event.Seq}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] <= seqs[i-1] {
+			t.Fatalf("seqs %v, want strictly increasing", seqs)
+		}
+	}
+}
+
+// TestSnapshotDoesNotBlockPublish holds a snapshot mid-build and expects
+// publishing to carry on, with the message published meanwhile delivered
+// after the snapshot.
+func TestSnapshotDoesNotBlockPublish(t *testing.T) {
+	h := newWSHub(dropOldest)
+	building, release := make(chan struct{}), make(chan struct{})
+	h.snapshot = func(*clientFilter) map[string]interface{} {
+		close(building)
+		<-release
+		return map[string]interface{}{}
+	}
+
+	registered := make(chan *hubClient)
+	go func() { registered <- h.register(nil, "test", newClientFilter(), nil) }()
+	<-building
+
+	published := make(chan struct{})
+	go func() {
+		h.Publish("arbitrage", route{channel: channelArbitrage, symbol: "TONUSDT"}, map[string]interface{}{"type": "arbitrage"})
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publish blocked while a snapshot was being built")
+	}
+	close(release)
+	c := <-registered
+
+	first, second := <-c.send, <-c.send
+	if first.msgType != "snapshot" || second.msgType != "arbitrage" || second.seq <= first.seq {
+		t.Fatalf("got %s (seq %d) then %s (seq %d), want the snapshot then the event after it",
+			first.msgType, first.seq, second.msgType, second.seq)
+	}
+}
+
+func TestResumeFallsBackToSnapshot(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.1})
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"stale epoch", fmt.Sprintf("?resume_from=0&epoch=%d", s.hub.epoch-1)},
+		{"future seq", fmt.Sprintf("?resume_from=99&epoch=%d", s.hub.epoch)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialScanner(t, s, srv, tt.query)
+			defer conn.Close()
+
+			msg, data := readMessage(t, conn)
+			if msg.Type != "snapshot" || !msg.ResumeFailed {
+				t.Fatalf("expected snapshot with resume_failed, got: %s", data)
+			}
+		})
+	}
+}
+
+func TestWebSocketRejectsBadQuery(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer srv.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"?channels=bogus", nil)
+	if err == nil {
+		t.Fatal("expected dial to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp)
+	}
+}
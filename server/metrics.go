@@ -0,0 +1,206 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	broadcastDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scanner_broadcast_duration_seconds",
+		Help:    "Time from queueing a message for a WebSocket client to writing it.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"type"})
+
+	wsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scanner_ws_dropped_messages_total",
+		Help: "Messages dropped for slow WebSocket clients, by overflow policy.",
+	}, []string{"policy"})
+
+	opportunitiesEmitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scanner_opportunities_total",
+		Help: "Arbitrage opportunities emitted, by symbol and venue pair.",
+	}, []string{"symbol", "buy_source", "sell_source"})
+
+	bestSpread = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scanner_best_spread_pct",
+		Help: "Current best cross-venue spread in percent, by symbol.",
+	}, []string{"symbol"})
+)
+
+var (
+	messagesDesc = prometheus.NewDesc(
+		"scanner_messages_received_total",
+		"Messages received from each source, by message type.",
+		[]string{"source", "type"}, nil,
+	)
+	parseErrorsDesc = prometheus.NewDesc(
+		"scanner_parse_errors_total",
+		"Messages a connector failed to decode.",
+		[]string{"source"}, nil,
+	)
+	framesDesc = prometheus.NewDesc(
+		"scanner_frames_received_total",
+		"Raw frames received from each source, before parsing.",
+		[]string{"source"}, nil,
+	)
+	unknownDesc = prometheus.NewDesc(
+		"scanner_unknown_messages_total",
+		"Messages of a type the connector doesn't handle.",
+		[]string{"source"}, nil,
+	)
+	updatesDesc = prometheus.NewDesc(
+		"scanner_updates_total",
+		"Prices, books and trades that reached the scanner from each source.",
+		[]string{"source"}, nil,
+	)
+	degradedDesc = prometheus.NewDesc(
+		"scanner_source_degraded",
+		"Whether a connected source's messages have stopped parsing or updating.",
+		[]string{"source"}, nil,
+	)
+	reconnectsDesc = prometheus.NewDesc(
+		"scanner_reconnects_total",
+		"Connections established after the first one.",
+		[]string{"source"}, nil,
+	)
+	connectedDesc = prometheus.NewDesc(
+		"scanner_source_connected",
+		"Whether the connector currently has a live connection.",
+		[]string{"source"}, nil,
+	)
+	channelDepthDesc = prometheus.NewDesc(
+		"scanner_channel_depth",
+		"Updates waiting in each ingestion feed, queued or in its channel.",
+		[]string{"channel"}, nil,
+	)
+	conflatedDesc = prometheus.NewDesc(
+		"scanner_conflated_updates_total",
+		"Books and prices replaced by a newer one for the same symbol before processing.",
+		[]string{"source"}, nil,
+	)
+	droppedDesc = prometheus.NewDesc(
+		"scanner_dropped_trades_total",
+		"Trades dropped from a full ingestion queue.",
+		[]string{"source"}, nil,
+	)
+	lastUpdateAgeDesc = prometheus.NewDesc(
+		"scanner_last_update_age_seconds",
+		"Seconds since the last price update, by source and symbol.",
+		[]string{"source", "symbol"}, nil,
+	)
+	feedLatencyDesc = prometheus.NewDesc(
+		"scanner_feed_latency_ms",
+		"Rolling percentiles of arrival time minus venue event time.",
+		[]string{"source", "quantile"}, nil,
+	)
+	clockSkewDesc = prometheus.NewDesc(
+		"scanner_clock_skew_ms",
+		"Estimated offset between our clock and the venue's, including the network floor.",
+		[]string{"source"}, nil,
+	)
+	wsClientsDesc = prometheus.NewDesc(
+		"scanner_ws_clients",
+		"Connected streaming clients (WebSocket and SSE).",
+		nil, nil,
+	)
+)
+
+// scannerCollector exports state the scanner and connectors already keep,
+// so it is read at scrape time instead of being mirrored into metrics.
+type scannerCollector struct {
+	server *Server
+}
+
+func (c scannerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- messagesDesc
+	ch <- parseErrorsDesc
+	ch <- framesDesc
+	ch <- unknownDesc
+	ch <- updatesDesc
+	ch <- degradedDesc
+	ch <- reconnectsDesc
+	ch <- connectedDesc
+	ch <- channelDepthDesc
+	ch <- conflatedDesc
+	ch <- droppedDesc
+	ch <- lastUpdateAgeDesc
+	ch <- feedLatencyDesc
+	ch <- clockSkewDesc
+	ch <- wsClientsDesc
+}
+
+func (c scannerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, st := range exchanges.AllStats() {
+		for kind, n := range st.Messages {
+			ch <- prometheus.MustNewConstMetric(messagesDesc, prometheus.CounterValue, float64(n), st.Source, kind)
+		}
+		ch <- prometheus.MustNewConstMetric(parseErrorsDesc, prometheus.CounterValue, float64(st.ParseErrors), st.Source)
+		ch <- prometheus.MustNewConstMetric(framesDesc, prometheus.CounterValue, float64(st.Received), st.Source)
+		ch <- prometheus.MustNewConstMetric(unknownDesc, prometheus.CounterValue, float64(st.Unknown), st.Source)
+		ch <- prometheus.MustNewConstMetric(updatesDesc, prometheus.CounterValue, float64(st.Updates), st.Source)
+		ch <- prometheus.MustNewConstMetric(reconnectsDesc, prometheus.CounterValue, float64(st.Reconnects), st.Source)
+
+		connected := 0.0
+		if st.Connected {
+			connected = 1
+		}
+		ch <- prometheus.MustNewConstMetric(connectedDesc, prometheus.GaugeValue, connected, st.Source)
+	}
+
+	sc := c.server.scanner
+	for _, h := range sc.Health() {
+		degraded := 0.0
+		if h.Status == scanner.HealthDegraded {
+			degraded = 1
+		}
+		ch <- prometheus.MustNewConstMetric(degradedDesc, prometheus.GaugeValue, degraded, h.Source)
+	}
+	for channel, depth := range sc.QueueDepths() {
+		ch <- prometheus.MustNewConstMetric(channelDepthDesc, prometheus.GaugeValue, float64(depth), channel)
+	}
+	conflated, dropped := sc.IngestCounts()
+	for source, n := range conflated {
+		ch <- prometheus.MustNewConstMetric(conflatedDesc, prometheus.CounterValue, float64(n), source)
+	}
+	for source, n := range dropped {
+		ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(n), source)
+	}
+
+	now := time.Now()
+	for _, q := range sc.Quotes("") {
+		ch <- prometheus.MustNewConstMetric(lastUpdateAgeDesc, prometheus.GaugeValue, now.Sub(q.ReceivedAt).Seconds(), q.Source, q.Symbol)
+	}
+
+	for _, st := range sc.Latency() {
+		ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, st.P50Ms, st.Source, "0.5")
+		ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, st.P90Ms, st.Source, "0.9")
+		ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, st.P99Ms, st.Source, "0.99")
+		ch <- prometheus.MustNewConstMetric(clockSkewDesc, prometheus.GaugeValue, st.ClockSkewMs, st.Source)
+	}
+
+	ch <- prometheus.MustNewConstMetric(wsClientsDesc, prometheus.GaugeValue, float64(c.server.hub.Count()))
+}
+
+// metricsHandler registers the scanner's metrics on a fresh registry and
+// returns the /metrics handler.
+func metricsHandler(s *Server) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		scannerCollector{server: s},
+		broadcastDuration,
+		wsDropped,
+		opportunitiesEmitted,
+		bestSpread,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,33 @@
+package server
+
+// ring is a fixed-size buffer that overwrites its oldest element once full.
+// It is not safe for concurrent use; owners guard it with their own lock.
+type ring[T any] struct {
+	buf  []T
+	next int
+	full bool
+}
+
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{buf: make([]T, size)}
+}
+
+func (r *ring[T]) push(v T) {
+	r.buf[r.next] = v
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// items returns the buffered elements, oldest first.
+func (r *ring[T]) items() []T {
+	if !r.full {
+		out := make([]T, r.next)
+		copy(out, r.buf[:r.next])
+		return out
+	}
+	out := make([]T, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
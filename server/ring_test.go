@@ -0,0 +1,32 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		pushes []int
+		want   []int
+	}{
+		{"empty", 3, nil, []int{}},
+		{"partial", 3, []int{1, 2}, []int{1, 2}},
+		{"full", 3, []int{1, 2, 3}, []int{1, 2, 3}},
+		{"wrapped", 3, []int{1, 2, 3, 4, 5}, []int{3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRing[int](tt.size)
+			for _, v := range tt.pushes {
+				r.push(v)
+			}
+			if got := r.items(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package server exposes a scanner over WebSocket, Server-Sent Events, a
+// REST API, gRPC and Prometheus metrics.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"futures-arbitrage-scanner/config"
+	"futures-arbitrage-scanner/scanner"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultPricesInterval is how often the price table is pushed to clients.
+const DefaultPricesInterval = 200 * time.Millisecond
+
+// Options configures a Server.
+type Options struct {
+	// Auth guards every endpoint. Nil leaves the server open.
+	Auth *Authenticator
+	// OverflowPolicy is what happens when a client falls behind:
+	// "drop-oldest" (default) or "disconnect".
+	OverflowPolicy string
+	// PricesInterval is how often the price table is pushed; zero means
+	// DefaultPricesInterval.
+	PricesInterval time.Duration
+	// Config enables the admin endpoints under /api/admin/, which change
+	// the running configuration through it. Nil leaves them off.
+	Config *config.Manager
+}
+
+// Server fans scanner events out to streaming clients and answers queries
+// about the scanner's state.
+type Server struct {
+	scanner  *scanner.Scanner
+	auth     *Authenticator
+	config   *config.Manager
+	hub      *wsHub
+	history  *opportunityHistory
+	streams  *grpcBroker
+	upgrader websocket.Upgrader
+
+	pricesInterval time.Duration
+}
+
+// New returns a Server for sc and subscribes it to sc's events.
+func New(sc *scanner.Scanner, opts Options) *Server {
+	auth := opts.Auth
+	if auth == nil {
+		auth, _ = NewAuthenticator("", "", "")
+	}
+
+	s := &Server{
+		scanner: sc,
+		auth:    auth,
+		config:  opts.Config,
+		hub:     newWSHub(parseOverflowPolicy(opts.OverflowPolicy)),
+		history: newOpportunityHistory(opportunityHistorySize),
+		streams: newGRPCBroker(),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: auth.checkOrigin,
+		},
+		pricesInterval: opts.PricesInterval,
+	}
+	if s.pricesInterval <= 0 {
+		s.pricesInterval = DefaultPricesInterval
+	}
+	s.hub.snapshot = s.buildSnapshot
+
+	sc.Subscribe(scanner.Handlers{
+		OnPrice:     s.streamPrice,
+		OnOrderbook: s.streamOrderbook,
+		OnTrade:     s.streamTrade,
+		OnSpreads:   s.broadcastSpreads,
+		OnArbitrage: s.broadcastOpportunity,
+		OnBasis:     s.broadcastBasisTrade,
+		OnHealth:    s.broadcastHealth,
+	})
+	return s
+}
+
+// RegisterRoutes mounts /ws, /events, /api/ and /metrics on mux, /health,
+// /health/live and /health/ready without credentials, and /api/admin/ for
+// admin credentials when a config manager is set. Without credentials
+// configured the admin API answers 403.
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	api := http.NewServeMux()
+	s.registerAPI(api)
+	if s.config != nil && s.auth.Enabled() {
+		admin := http.NewServeMux()
+		s.registerAdmin(admin)
+		mux.Handle("/api/admin/", s.auth.require(scopeAdmin, admin))
+	} else if s.config != nil {
+		log.Printf("Admin API disabled: it needs API keys or a token secret")
+		mux.HandleFunc("/api/admin/", func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(w, http.StatusForbidden, "admin API requires credentials")
+		})
+	}
+
+	mux.Handle("/ws", s.auth.stream(scopeRead, http.HandlerFunc(s.handleWebSocket)))
+	mux.Handle("/events", s.auth.stream(scopeRead, http.HandlerFunc(s.handleEvents)))
+	mux.Handle("/api/", s.auth.require(scopeRead, api))
+	mux.Handle("/metrics", s.auth.require(scopeRead, metricsHandler(s)))
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/health/live", s.handleLive)
+	mux.HandleFunc("/health/ready", s.handleReady)
+}
+
+// Run pushes the price table to clients until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pricesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if prices := s.scanner.Prices(); len(prices) > 0 {
+				s.hub.PublishPrices(prices)
+			}
+		}
+	}
+}
+
+func (s *Server) broadcastBasisTrade(opportunity scanner.BasisTradeOpportunity) {
+	s.history.addBasis(opportunity)
+	s.streamBasis(opportunity)
+
+	message := map[string]interface{}{
+		"type":        "basis_trade",
+		"opportunity": opportunity,
+	}
+	s.hub.Publish("basis_trade", route{channel: channelBasis, symbol: opportunity.Symbol, profitPct: opportunity.NetProfitPct}, message)
+}
+
+func (s *Server) broadcastOpportunity(opportunity scanner.ArbitrageOpportunity) {
+	opportunitiesEmitted.WithLabelValues(opportunity.Symbol, opportunity.BuySource, opportunity.SellSource).Inc()
+	s.history.addArbitrage(opportunity)
+	s.streamArbitrage(opportunity)
+
+	message := map[string]interface{}{
+		"type":        "arbitrage",
+		"opportunity": opportunity,
+	}
+	s.hub.Publish("arbitrage", route{channel: channelArbitrage, symbol: opportunity.Symbol, profitPct: opportunity.NetProfitPct}, message)
+}
+
+func (s *Server) broadcastHealth(health scanner.SourceHealth) {
+	message := map[string]interface{}{
+		"type":   "source_health",
+		"health": health,
+	}
+	s.hub.Publish("source_health", route{channel: channelHealth}, message)
+}
+
+// version is the build's module version or, for a build from a checkout,
+// its VCS revision.
+var version = buildVersion()
+
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "devel"
+}
+
+// handleHealth reports "degraded" with the degraded sources while any
+// connected source's messages stop parsing or updating, else "ok", along
+// with the build version.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	degraded := []scanner.SourceHealth{}
+	for _, h := range s.scanner.Health() {
+		if h.Status == scanner.HealthDegraded {
+			degraded = append(degraded, h)
+		}
+	}
+	status := "ok"
+	if len(degraded) > 0 {
+		status = "degraded"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   status,
+		"version":  version,
+		"degraded": degraded,
+	})
+}
+
+// handleLive answers as long as the process serves HTTP.
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleReady answers 503 until every symbol has fresh quotes from enough
+// venues to be scanned, listing the fresh venues per symbol either way.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ready, symbols := s.scanner.Readiness()
+	status := "ready"
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		status = "not_ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":           status,
+		"min_fresh_venues": s.scanner.HealthThresholds().MinFreshVenues,
+		"symbols":          symbols,
+	})
+}
+
+func (s *Server) broadcastSpreads(spreads scanner.Spreads) {
+	bestSpread.WithLabelValues(spreads.Symbol).Set(maxSpread(spreads.Spreads))
+	s.streamSpreads(spreads)
+
+	message := map[string]interface{}{
+		"type":    "spreads",
+		"symbol":  spreads.Symbol,
+		"spreads": spreads.Spreads,
+		"prices":  spreads.Prices,
+	}
+	s.hub.Publish("spreads", route{channel: channelSpreads, symbol: spreads.Symbol}, message)
+}
+
+func maxSpread(matrix map[string]map[string]float64) float64 {
+	best := 0.0
+	for _, sells := range matrix {
+		for _, pct := range sells {
+			if pct > best {
+				best = pct
+			}
+		}
+	}
+	return best
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	log.Printf("WebSocket connection attempt from %s", r.RemoteAddr)
+
+	filter, err := filterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resume, err := resumeFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error from %s: %v", r.RemoteAddr, err)
+		return
+	}
+
+	client := s.hub.register(conn, r.RemoteAddr, filter, resume)
+	log.Printf("WebSocket client connected from %s. Total clients: %d", r.RemoteAddr, s.hub.Count())
+
+	// The request context is cancelled if the client's credential is revoked.
+	go func() {
+		select {
+		case <-r.Context().Done():
+			client.close()
+		case <-client.done:
+		}
+	}()
+
+	go client.writePump()
+	client.readPump()
+
+	s.hub.unregister(client)
+	log.Printf("WebSocket client disconnected. Total clients: %d", s.hub.Count())
+}
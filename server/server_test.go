@@ -0,0 +1,30 @@
+package server
+
+import (
+	"testing"
+
+	"futures-arbitrage-scanner/scanner"
+)
+
+func newTestServer() *Server {
+	return New(scanner.New(), Options{})
+}
+
+func TestMaxSpread(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix map[string]map[string]float64
+		want   float64
+	}{
+		{"empty", nil, 0},
+		{"negative only", map[string]map[string]float64{"a": {"b": -0.2}}, 0},
+		{"best of several", map[string]map[string]float64{"a": {"b": 0.1, "c": 0.4}, "b": {"a": 0.3}}, 0.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxSpread(tt.matrix); got != tt.want {
+				t.Errorf("maxSpread() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"sync"
+
+	"futures-arbitrage-scanner/scanner"
+)
+
+// opportunityHistorySize is how many recent alerts of each kind are kept
+// for snapshots.
+const opportunityHistorySize = 500
+
+// opportunityHistory keeps the most recent arbitrage and basis alerts.
+type opportunityHistory struct {
+	mu        sync.RWMutex
+	arbitrage *ring[scanner.ArbitrageOpportunity]
+	basis     *ring[scanner.BasisTradeOpportunity]
+}
+
+func newOpportunityHistory(size int) *opportunityHistory {
+	return &opportunityHistory{
+		arbitrage: newRing[scanner.ArbitrageOpportunity](size),
+		basis:     newRing[scanner.BasisTradeOpportunity](size),
+	}
+}
+
+func (h *opportunityHistory) addArbitrage(o scanner.ArbitrageOpportunity) {
+	h.mu.Lock()
+	h.arbitrage.push(o)
+	h.mu.Unlock()
+}
+
+func (h *opportunityHistory) addBasis(o scanner.BasisTradeOpportunity) {
+	h.mu.Lock()
+	h.basis.push(o)
+	h.mu.Unlock()
+}
+
+func (h *opportunityHistory) arbitrageItems() []scanner.ArbitrageOpportunity {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.arbitrage.items()
+}
+
+func (h *opportunityHistory) basisItems() []scanner.BasisTradeOpportunity {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.basis.items()
+}
+
+// buildSnapshot returns the current state trimmed to what f subscribes to:
+// prices, the spread matrix per symbol, recent alerts and source status.
+func (s *Server) buildSnapshot(f *clientFilter) map[string]interface{} {
+	prices := make(map[string]map[string]float64)
+	for symbol, sourcePrices := range s.scanner.Prices() {
+		if f.wants(channelPrices, symbol) {
+			prices[symbol] = sourcePrices
+		}
+	}
+
+	spreads := make(map[string]interface{})
+	for symbol, fresh := range s.scanner.FreshPrices() {
+		if f.wants(channelSpreads, symbol) && len(fresh) >= 2 {
+			spreads[symbol] = map[string]interface{}{
+				"spreads": scanner.ComputeSpreads(fresh),
+				"prices":  fresh,
+			}
+		}
+	}
+
+	opportunities := make([]scanner.ArbitrageOpportunity, 0)
+	for _, o := range s.history.arbitrageItems() {
+		if f.accepts(route{channel: channelArbitrage, symbol: o.Symbol, profitPct: o.NetProfitPct}) {
+			opportunities = append(opportunities, o)
+		}
+	}
+
+	basisTrades := make([]scanner.BasisTradeOpportunity, 0)
+	for _, o := range s.history.basisItems() {
+		if f.accepts(route{channel: channelBasis, symbol: o.Symbol, profitPct: o.NetProfitPct}) {
+			basisTrades = append(basisTrades, o)
+		}
+	}
+
+	return map[string]interface{}{
+		"prices":        prices,
+		"spreads":       spreads,
+		"opportunities": opportunities,
+		"basis_trades":  basisTrades,
+		"sources":       s.scanner.Sources(),
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sseHeartbeat keeps idle streams alive through proxies that close
+	// silent connections.
+	sseHeartbeat = 15 * time.Second
+
+	// sseRetryMs is the reconnect delay suggested to EventSource clients.
+	sseRetryMs = 3000
+)
+
+// handleEvents streams the same messages as /ws as Server-Sent Events. The
+// filter is fixed at connect time by the query parameters /ws accepts. Each
+// event's id is "<epoch>-<seq>", so a reconnecting client that sends it back
+// in Last-Event-ID resumes where it left off.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := filterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resume, err := resumeFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if resume, err = parseEventID(id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMs)
+	flusher.Flush()
+
+	client := s.hub.register(nil, r.RemoteAddr, filter, resume)
+	log.Printf("SSE client connected from %s. Total clients: %d", r.RemoteAddr, s.hub.Count())
+	defer func() {
+		s.hub.unregister(client)
+		log.Printf("SSE client disconnected. Total clients: %d", s.hub.Count())
+	}()
+
+	rc := http.NewResponseController(w)
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.done:
+			return
+		case out := <-client.send:
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			if _, err := fmt.Fprintf(w, "id: %d-%d\nevent: %s\ndata: %s\n\n", s.hub.epoch, out.seq, out.msgType, out.data); err != nil {
+				log.Printf("SSE write error: %v", err)
+				return
+			}
+			flusher.Flush()
+			broadcastDuration.WithLabelValues(out.msgType).Observe(time.Since(out.queuedAt).Seconds())
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseEventID reads an "<epoch>-<seq>" event id back into a resume point.
+func parseEventID(id string) (*resumePoint, error) {
+	epochStr, seqStr, ok := strings.Cut(strings.TrimSpace(id), "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", id)
+	}
+	epoch, err := strconv.ParseInt(epochStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", id)
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", id)
+	}
+	return &resumePoint{epoch: epoch, seq: seq}, nil
+}
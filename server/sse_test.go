@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/scanner"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if ev.event != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openEvents(t *testing.T, url, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestEventsStreamAndResume(t *testing.T) {
+	s := newTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleEvents))
+	defer srv.Close()
+
+	resp, r := openEvents(t, srv.URL+"?channels=arbitrage&symbols=TONUSDT", "")
+	if ev := readEvent(t, r); ev.event != "snapshot" {
+		t.Fatalf("expected snapshot first, got %+v", ev)
+	}
+
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "BTCUSDT", ProfitPct: 0.1})
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.2})
+	ev := readEvent(t, r)
+	if ev.event != "arbitrage" || !strings.Contains(ev.data, `"TONUSDT"`) {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	resp.Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.hub.Count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.broadcastOpportunity(scanner.ArbitrageOpportunity{Symbol: "TONUSDT", ProfitPct: 0.3})
+
+	resp, r = openEvents(t, srv.URL+"?channels=arbitrage&symbols=TONUSDT", ev.id)
+	defer resp.Body.Close()
+	if ev := readEvent(t, r); ev.event != "arbitrage" || !strings.Contains(ev.data, `"profit_pct":0.3`) {
+		t.Fatalf("expected replayed alert, got %+v", ev)
+	}
+}
+
+func TestParseEventID(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    resumePoint
+		wantErr bool
+	}{
+		{"1700000000000-42", resumePoint{epoch: 1700000000000, seq: 42}, false},
+		{" 5-0 ", resumePoint{epoch: 5, seq: 0}, false},
+		{"42", resumePoint{}, true},
+		{"abc-1", resumePoint{}, true},
+		{"1-xyz", resumePoint{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEventID(tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEventID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != tt.want {
+			t.Errorf("parseEventID(%q) = %+v, want %+v", tt.id, *got, tt.want)
+		}
+	}
+}
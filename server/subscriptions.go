@@ -0,0 +1,333 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Channels a client can subscribe to. Each maps to one outgoing message type.
+const (
+	channelPrices    = "prices"
+	channelSpreads   = "spreads"
+	channelArbitrage = "arbitrage"
+	channelBasis     = "basis"
+	channelHealth    = "health"
+)
+
+var allChannels = []string{channelPrices, channelSpreads, channelArbitrage, channelBasis, channelHealth}
+
+func validChannel(ch string) bool {
+	for _, c := range allChannels {
+		if c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// route describes what a message is about so each client's filter can decide
+// whether to deliver it. profitPct, the profit after fees, is only
+// meaningful on the opportunity channels.
+type route struct {
+	channel   string
+	symbol    string
+	profitPct float64
+}
+
+// clientCommand is a JSON command sent by a client over /ws.
+//
+//	{"op":"subscribe","channels":["spreads","arbitrage"],"symbols":["TONUSDT"]}
+//	{"op":"unsubscribe","channels":["prices"]}
+//	{"op":"set_throttle","interval_ms":1000}
+//	{"op":"set_min_profit","min_profit_pct":0.1}
+//	{"op":"snapshot"}
+type clientCommand struct {
+	Op           string   `json:"op"`
+	Channels     []string `json:"channels"`
+	Symbols      []string `json:"symbols"`
+	IntervalMs   int64    `json:"interval_ms"`
+	MinProfitPct float64  `json:"min_profit_pct"`
+}
+
+// channelSub is a subscription to one channel, either for every symbol or
+// for an explicit set.
+type channelSub struct {
+	all     bool
+	symbols map[string]bool
+}
+
+// clientFilter holds one client's subscriptions and delivery settings. A new
+// client receives everything until its first subscribe or unsubscribe.
+type clientFilter struct {
+	mu           sync.Mutex
+	explicit     bool
+	channels     map[string]*channelSub
+	throttle     time.Duration
+	minProfitPct float64
+	lastSent     map[string]time.Time
+}
+
+func newClientFilter() *clientFilter {
+	return &clientFilter{
+		channels: make(map[string]*channelSub),
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+func (f *clientFilter) subscribedLocked(channel, symbol string) bool {
+	if !f.explicit {
+		return true
+	}
+	sub, ok := f.channels[channel]
+	if !ok {
+		return false
+	}
+	return sub.all || symbol == "" || sub.symbols[symbol]
+}
+
+// wants reports whether the client is subscribed to channel and symbol.
+func (f *clientFilter) wants(channel, symbol string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subscribedLocked(channel, symbol)
+}
+
+// accepts reports whether a message on r matches the client's subscriptions
+// and minimum profit, without applying or advancing the throttle.
+func (f *clientFilter) accepts(r route) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acceptsLocked(r)
+}
+
+func (f *clientFilter) acceptsLocked(r route) bool {
+	if !f.subscribedLocked(r.channel, r.symbol) {
+		return false
+	}
+	switch r.channel {
+	case channelArbitrage, channelBasis:
+		return r.profitPct >= f.minProfitPct
+	}
+	return true
+}
+
+// allow decides whether a message on r should be sent now. Price and spread
+// updates are rate limited per symbol when the client has set a throttle;
+// opportunities are filtered by the client's minimum profit.
+func (f *clientFilter) allow(r route, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.acceptsLocked(r) {
+		return false
+	}
+
+	switch r.channel {
+	case channelArbitrage, channelBasis, channelHealth:
+		return true
+	}
+
+	if f.throttle > 0 {
+		key := r.channel + ":" + r.symbol
+		if last, ok := f.lastSent[key]; ok && now.Sub(last) < f.throttle {
+			return false
+		}
+		f.lastSent[key] = now
+	}
+	return true
+}
+
+// apply executes a client command against the filter.
+func (f *clientFilter) apply(cmd clientCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch cmd.Op {
+	case "subscribe", "unsubscribe":
+		channels := cmd.Channels
+		if len(channels) == 0 {
+			channels = allChannels
+		}
+		for _, ch := range channels {
+			if !validChannel(ch) {
+				return fmt.Errorf("unknown channel %q", ch)
+			}
+		}
+		symbols := normalizeSymbols(cmd.Symbols)
+
+		if !f.explicit {
+			// Leaving the default receive-everything mode: subscribe starts
+			// from nothing, unsubscribe starts from everything.
+			f.explicit = true
+			if cmd.Op == "unsubscribe" {
+				for _, ch := range allChannels {
+					f.channels[ch] = &channelSub{all: true}
+				}
+			}
+		}
+
+		if cmd.Op == "subscribe" {
+			for _, ch := range channels {
+				f.subscribeLocked(ch, symbols)
+			}
+			return nil
+		}
+		for _, ch := range channels {
+			if err := f.unsubscribeLocked(ch, symbols); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "set_throttle":
+		if cmd.IntervalMs < 0 {
+			return fmt.Errorf("interval_ms must not be negative")
+		}
+		f.throttle = time.Duration(cmd.IntervalMs) * time.Millisecond
+		return nil
+
+	case "set_min_profit":
+		if cmd.MinProfitPct < 0 {
+			return fmt.Errorf("min_profit_pct must not be negative")
+		}
+		f.minProfitPct = cmd.MinProfitPct
+		return nil
+
+	default:
+		return fmt.Errorf("unknown op %q", cmd.Op)
+	}
+}
+
+func (f *clientFilter) subscribeLocked(channel string, symbols []string) {
+	sub, ok := f.channels[channel]
+	if !ok {
+		sub = &channelSub{symbols: make(map[string]bool)}
+		f.channels[channel] = sub
+	}
+	if len(symbols) == 0 {
+		sub.all = true
+		return
+	}
+	if sub.symbols == nil {
+		sub.symbols = make(map[string]bool)
+	}
+	for _, sym := range symbols {
+		sub.symbols[sym] = true
+	}
+}
+
+func (f *clientFilter) unsubscribeLocked(channel string, symbols []string) error {
+	sub, ok := f.channels[channel]
+	if !ok {
+		return nil
+	}
+	if len(symbols) == 0 {
+		delete(f.channels, channel)
+		return nil
+	}
+	if sub.all {
+		return fmt.Errorf("channel %q is subscribed for all symbols; unsubscribe the channel instead", channel)
+	}
+	for _, sym := range symbols {
+		delete(sub.symbols, sym)
+	}
+	if len(sub.symbols) == 0 {
+		delete(f.channels, channel)
+	}
+	return nil
+}
+
+func normalizeSymbols(symbols []string) []string {
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// filterFromQuery builds a client's initial filter from connection query
+// parameters, so a client can choose its subscriptions before the snapshot:
+//
+//	?channels=spreads,arbitrage&symbols=TONUSDT&throttle_ms=500&min_profit=0.1
+func filterFromQuery(q url.Values) (*clientFilter, error) {
+	f := newClientFilter()
+
+	channels := splitList(q.Get("channels"))
+	symbols := splitList(q.Get("symbols"))
+	if len(channels) > 0 || len(symbols) > 0 {
+		if err := f.apply(clientCommand{Op: "subscribe", Channels: channels, Symbols: symbols}); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("throttle_ms"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid throttle_ms %q", v)
+		}
+		if err := f.apply(clientCommand{Op: "set_throttle", IntervalMs: ms}); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("min_profit"); v != "" {
+		pct, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min_profit %q", v)
+		}
+		if err := f.apply(clientCommand{Op: "set_min_profit", MinProfitPct: pct}); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// resumeFromQuery reads the resume_from and epoch parameters a reconnecting
+// client sends. It returns nil when the client isn't resuming.
+func resumeFromQuery(q url.Values) (*resumePoint, error) {
+	v := q.Get("resume_from")
+	if v == "" {
+		return nil, nil
+	}
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resume_from %q", v)
+	}
+	epoch, err := strconv.ParseInt(q.Get("epoch"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid epoch %q", q.Get("epoch"))
+	}
+	return &resumePoint{epoch: epoch, seq: seq}, nil
+}
+
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseClientCommand decodes one inbound client frame.
+func parseClientCommand(data []byte) (clientCommand, error) {
+	var cmd clientCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return cmd, fmt.Errorf("invalid command: %v", err)
+	}
+	cmd.Op = strings.ToLower(strings.TrimSpace(cmd.Op))
+	return cmd, nil
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientFilterSubscriptions(t *testing.T) {
+	type check struct {
+		r    route
+		want bool
+	}
+	cases := []struct {
+		name   string
+		cmds   []clientCommand
+		checks []check
+	}{
+		{
+			name: "default-receives-everything",
+			checks: []check{
+				{r: route{channel: channelPrices, symbol: "BTCUSDT"}, want: true},
+				{r: route{channel: channelArbitrage, symbol: "TONUSDT", profitPct: 0.01}, want: true},
+			},
+		},
+		{
+			name: "subscribe-narrows",
+			cmds: []clientCommand{{Op: "subscribe", Channels: []string{"spreads"}, Symbols: []string{"tonusdt"}}},
+			checks: []check{
+				{r: route{channel: channelSpreads, symbol: "TONUSDT"}, want: true},
+				{r: route{channel: channelSpreads, symbol: "BTCUSDT"}, want: false},
+				{r: route{channel: channelPrices, symbol: "TONUSDT"}, want: false},
+			},
+		},
+		{
+			name: "unsubscribe-from-default",
+			cmds: []clientCommand{{Op: "unsubscribe", Channels: []string{"prices"}}},
+			checks: []check{
+				{r: route{channel: channelPrices, symbol: "TONUSDT"}, want: false},
+				{r: route{channel: channelSpreads, symbol: "TONUSDT"}, want: true},
+			},
+		},
+		{
+			name: "unsubscribe-symbol",
+			cmds: []clientCommand{
+				{Op: "subscribe", Channels: []string{"arbitrage"}, Symbols: []string{"TONUSDT", "BTCUSDT"}},
+				{Op: "unsubscribe", Channels: []string{"arbitrage"}, Symbols: []string{"BTCUSDT"}},
+			},
+			checks: []check{
+				{r: route{channel: channelArbitrage, symbol: "TONUSDT"}, want: true},
+				{r: route{channel: channelArbitrage, symbol: "BTCUSDT"}, want: false},
+			},
+		},
+		{
+			name: "min-profit",
+			cmds: []clientCommand{{Op: "set_min_profit", MinProfitPct: 0.2}},
+			checks: []check{
+				{r: route{channel: channelArbitrage, symbol: "TONUSDT", profitPct: 0.1}, want: false},
+				{r: route{channel: channelBasis, symbol: "TONUSDT", profitPct: 0.3}, want: true},
+				{r: route{channel: channelSpreads, symbol: "TONUSDT"}, want: true},
+			},
+		},
+	}
+
+	now := time.Now()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newClientFilter()
+			for _, cmd := range tc.cmds {
+				if err := f.apply(cmd); err != nil {
+					t.Fatalf("apply(%+v): %v", cmd, err)
+				}
+			}
+			for _, c := range tc.checks {
+				if got := f.allow(c.r, now); got != c.want {
+					t.Fatalf("allow(%+v): got %v want %v", c.r, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClientFilterThrottle(t *testing.T) {
+	f := newClientFilter()
+	if err := f.apply(clientCommand{Op: "set_throttle", IntervalMs: 1000}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	start := time.Now()
+	r := route{channel: channelSpreads, symbol: "TONUSDT"}
+	if !f.allow(r, start) {
+		t.Fatalf("first update should pass")
+	}
+	if f.allow(r, start.Add(500*time.Millisecond)) {
+		t.Fatalf("update inside the interval should be dropped")
+	}
+	if !f.allow(route{channel: channelSpreads, symbol: "BTCUSDT"}, start.Add(500*time.Millisecond)) {
+		t.Fatalf("throttle is per symbol")
+	}
+	if !f.allow(r, start.Add(1100*time.Millisecond)) {
+		t.Fatalf("update after the interval should pass")
+	}
+}
+
+func TestClientFilterRejectsBadCommands(t *testing.T) {
+	cases := []clientCommand{
+		{Op: "subscribe", Channels: []string{"orderbook"}},
+		{Op: "set_throttle", IntervalMs: -1},
+		{Op: "set_min_profit", MinProfitPct: -0.5},
+		{Op: "bogus"},
+	}
+	for _, cmd := range cases {
+		if err := newClientFilter().apply(cmd); err == nil {
+			t.Fatalf("apply(%+v): expected error", cmd)
+		}
+	}
+
+	f := newClientFilter()
+	f.apply(clientCommand{Op: "subscribe", Channels: []string{"spreads"}})
+	if err := f.apply(clientCommand{Op: "unsubscribe", Channels: []string{"spreads"}, Symbols: []string{"TONUSDT"}}); err == nil {
+		t.Fatalf("expected error removing a symbol from a wildcard subscription")
+	}
+}
@@ -0,0 +1,238 @@
+package simulator
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/exchanges/mockvenue"
+	"futures-arbitrage-scanner/scanner"
+)
+
+// priceOnly venues publish a price rather than a book, as their real
+// connectors do.
+var priceOnly = map[string]bool{"DeDust": true, "pyth": true}
+
+// venueProtocols maps the venues Serve can stand in for to the mockvenue
+// protocol they speak. Venues whose subscriptions don't name their symbols
+// (paradex's markets_summary, polled venues) and lighter, which needs
+// market ids from discovery, stay in process.
+var venueProtocols = map[string]string{
+	"binance_futures":     "binance",
+	"binance_spot":        "binance",
+	"binance_inverse":     "binance",
+	"bybit_futures":       "bybit",
+	"bybit_spot":          "bybit",
+	"bybit_inverse":       "bybit",
+	"okx_futures":         "okx",
+	"okx_inverse":         "okx",
+	"gate_futures":        "gate",
+	"hyperliquid_futures": "hyperliquid",
+	"kraken_futures":      "kraken",
+	"kraken_inverse":      "kraken",
+	"vest_futures":        "vest",
+}
+
+// Connector returns a connector named venue that emits the simulated
+// market on the scanner's feeds for the symbols venue trades.
+func (s *Simulator) Connector(venue string) scanner.Connector {
+	return scanner.Connector{
+		Name: venue,
+		Live: true,
+		Run: func(ctx context.Context, symbols *exchanges.SymbolSet, feeds scanner.Feeds) {
+			s.run(ctx, venue, symbols, feeds)
+		},
+	}
+}
+
+// Connectors swaps each of connectors for its simulated counterpart,
+// keeping its symbols and whether it is disabled. Those named in keep are
+// left as they are.
+func (s *Simulator) Connectors(connectors []scanner.Connector, keep ...string) []scanner.Connector {
+	kept := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		kept[name] = true
+	}
+	out := make([]scanner.Connector, 0, len(connectors))
+	for _, c := range connectors {
+		if !kept[c.Name] {
+			sim := s.Connector(c.Name)
+			sim.Symbols, sim.Disabled = c.Symbols, c.Disabled
+			c = sim
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *Simulator) run(ctx context.Context, venue string, symbols *exchanges.SymbolSet, feeds scanner.Feeds) {
+	stats := exchanges.Stats(venue)
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+	defer stats.Disconnected()
+
+	up := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.Down(venue, now) {
+				if up {
+					log.Printf("Simulator: %s outage", venue)
+					stats.Disconnected()
+					up = false
+				}
+				continue
+			}
+			if !up {
+				stats.Connected()
+				up = true
+			}
+			for _, symbol := range exchanges.SupportedSymbols(venue, symbols.Symbols()) {
+				q := s.Quote(venue, symbol, now)
+				time.AfterFunc(s.Latency(venue), func() { s.emit(ctx, stats, q, feeds) })
+			}
+		}
+	}
+}
+
+// emit sends q on feeds as the venue's connector would, stamped with the
+// time it was made and the time it arrived.
+func (s *Simulator) emit(ctx context.Context, stats *exchanges.SourceStats, q Quote, feeds scanner.Feeds) {
+	receivedAt := time.Now().UnixMilli()
+	bid, ask := q.Bids[0], q.Asks[0]
+
+	if priceOnly[q.Venue] {
+		stats.Message("price")
+		send(ctx, feeds.Prices, exchanges.PriceData{
+			Symbol: q.Symbol, Source: q.Venue, Price: (bid.Price + ask.Price) / 2,
+			Timestamp: q.Time.UnixMilli(), ReceivedAt: receivedAt,
+		})
+		return
+	}
+
+	stats.Message("book")
+	send(ctx, feeds.Orderbooks, exchanges.OrderbookData{
+		Symbol: q.Symbol, Source: q.Venue, BestBid: bid.Price, BestAsk: ask.Price,
+		BidSize: venueSize(q.Venue, bid.Size, bid.Price), AskSize: venueSize(q.Venue, ask.Size, ask.Price),
+		Timestamp: q.Time.UnixMilli(), ReceivedAt: receivedAt,
+	})
+	if t := q.Trade; t != nil {
+		stats.Message("trade")
+		side := "sell"
+		if t.Buy {
+			side = "buy"
+		}
+		send(ctx, feeds.Trades, exchanges.TradeData{
+			Symbol: q.Symbol, Source: q.Venue, Price: t.Price, Quantity: venueSize(q.Venue, t.Size, t.Price),
+			Side: side, Timestamp: q.Time.UnixMilli(), ReceivedAt: receivedAt,
+		})
+	}
+}
+
+// venueSize turns a size in base asset into what venue sends: inverse
+// venues count one-dollar contracts, the unit the scanner assumes for them
+// without market metadata.
+func venueSize(venue string, size, price float64) float64 {
+	if exchanges.VenueKind(venue) == exchanges.Inverse {
+		return math.Round(size * price)
+	}
+	return size
+}
+
+func send[T any](ctx context.Context, ch chan<- T, v T) {
+	select {
+	case ch <- v:
+	case <-ctx.Done():
+	}
+}
+
+// Served reports whether Serve can stand in for venue.
+func Served(venue string) bool {
+	_, ok := venueProtocols[venue]
+	return ok
+}
+
+// Serve starts a fake server on localhost for each of venues that Served
+// supports, points the venue's websocket endpoint at it and publishes the
+// market there until ctx is done. It returns the venues it serves; the
+// rest need Connector.
+func (s *Simulator) Serve(ctx context.Context, venues []string) ([]string, error) {
+	var served []string
+	for _, venue := range venues {
+		proto, ok := venueProtocols[venue]
+		if !ok {
+			continue
+		}
+		srv, err := mockvenue.New(proto)
+		if err != nil {
+			return served, err
+		}
+		if err := srv.Start("127.0.0.1:0"); err != nil {
+			return served, err
+		}
+		exchanges.SetEndpoints(venue, exchanges.Endpoints{WS: srv.URL() + "/ws"})
+		log.Printf("Simulator: serving %s at %s", venue, srv.URL())
+
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+		go s.publish(ctx, venue, srv)
+		served = append(served, venue)
+	}
+	return served, nil
+}
+
+// publish feeds srv the market for whatever its clients subscribed to, and
+// takes it down during outages.
+func (s *Simulator) publish(ctx context.Context, venue string, srv *mockvenue.Server) {
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+
+	down := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if d := s.Down(venue, now); d != down {
+				log.Printf("Simulator: %s down=%v", venue, d)
+				srv.SetDown(d)
+				down = d
+			}
+			if down {
+				continue
+			}
+			for _, native := range srv.Subscriptions() {
+				symbol, ok := exchanges.CanonicalSymbol(venue, native)
+				if !ok {
+					continue
+				}
+				q := s.Quote(venue, symbol, now)
+				time.AfterFunc(s.Latency(venue), func() {
+					srv.PublishBook(mockBook(native, q))
+					if t := q.Trade; t != nil {
+						srv.PublishTrade(mockvenue.Trade{
+							Symbol: native, ID: t.ID, Price: t.Price, Size: venueSize(venue, t.Size, t.Price), Buy: t.Buy, Time: q.Time,
+						})
+					}
+				})
+			}
+		}
+	}
+}
+
+func mockBook(native string, q Quote) mockvenue.Book {
+	b := mockvenue.Book{Symbol: native, Time: q.Time}
+	for _, l := range q.Bids {
+		b.Bids = append(b.Bids, mockvenue.Level{Price: l.Price, Size: venueSize(q.Venue, l.Size, l.Price)})
+	}
+	for _, l := range q.Asks {
+		b.Asks = append(b.Asks, mockvenue.Level{Price: l.Price, Size: venueSize(q.Venue, l.Size, l.Price)})
+	}
+	return b
+}
@@ -0,0 +1,318 @@
+// Package simulator makes up markets for running the scanner without the
+// venues: on a plane, in CI or in a sandbox. Every venue's price for a
+// symbol follows a random walk correlated with the others, with spreads,
+// latency and outages injected on a schedule. The market reaches the
+// scanner either through connectors that emit on the usual feeds, or
+// through fake venue servers the real connectors dial.
+package simulator
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+)
+
+// Defaults for the zero Config fields.
+const (
+	DefaultTick          = 250 * time.Millisecond
+	DefaultVolatility    = 0.0005
+	DefaultCorrelation   = 0.9
+	DefaultHalfSpreadPct = 0.01
+	DefaultDepthLevels   = 5
+	DefaultDepthNotional = 25000
+	DefaultTradeRate     = 0.3
+	DefaultLatency       = 50 * time.Millisecond
+)
+
+// reversion is how much of a venue's distance from the common price it
+// gives back each tick, keeping correlated venues from wandering apart.
+const reversion = 0.2
+
+// startPrices are where the walk starts for a few familiar bases; others
+// start at 1.
+var startPrices = map[string]float64{
+	"BTC": 60000, "ETH": 3000, "SOL": 150, "BNB": 550, "XRP": 0.5, "DOGE": 0.15, "TON": 5, "PEPE": 0.00001,
+}
+
+// Config describes the market. Zero fields take the defaults above.
+type Config struct {
+	// Tick is how often venues quote.
+	Tick time.Duration `yaml:"tick"`
+	// Volatility is the standard deviation of the common log return per
+	// tick.
+	Volatility float64 `yaml:"volatility"`
+	// Correlation, between 0 and 1, is how closely venues' moves follow
+	// the common one.
+	Correlation float64 `yaml:"correlation"`
+	// HalfSpreadPct is the distance from mid to best bid and ask, in
+	// percent.
+	HalfSpreadPct float64 `yaml:"half_spread_pct"`
+	// Depth shapes the book: how many levels and how much quote currency
+	// each holds on average.
+	Depth Depth `yaml:"depth"`
+	// TradeRate is the chance per tick that a venue prints a trade.
+	TradeRate float64 `yaml:"trade_rate"`
+	// Prices overrides the start price per canonical symbol.
+	Prices map[string]float64 `yaml:"prices"`
+	// Latency delays each venue's quotes, keyed by venue; "*" applies to
+	// the rest. Jitter adds up to that much again at random.
+	Latency map[string]time.Duration `yaml:"latency"`
+	Jitter  time.Duration            `yaml:"jitter"`
+	// Spreads and Outages are injected on a schedule.
+	Spreads []Spread `yaml:"spreads"`
+	Outages []Outage `yaml:"outages"`
+	// Seed makes a run repeatable; 0 seeds from the clock.
+	Seed int64 `yaml:"seed"`
+}
+
+// Depth shapes the simulated books.
+type Depth struct {
+	Levels   int     `yaml:"levels"`
+	Notional float64 `yaml:"notional"`
+}
+
+// Spread moves one venue's prices by Pct percent for For out of every
+// Every, from Every after the start. Empty Symbol means every symbol; zero
+// Every means always.
+type Spread struct {
+	Venue  string        `yaml:"venue"`
+	Symbol string        `yaml:"symbol"`
+	Pct    float64       `yaml:"pct"`
+	Every  time.Duration `yaml:"every"`
+	For    time.Duration `yaml:"for"`
+}
+
+// Outage takes a venue down for For out of every Every, from Every after
+// the start. Zero Every means the venue never comes up.
+type Outage struct {
+	Venue string        `yaml:"venue"`
+	Every time.Duration `yaml:"every"`
+	For   time.Duration `yaml:"for"`
+}
+
+// active reports whether a window of length d repeating every period is
+// open at elapsed time since the start.
+func active(elapsed, period, d time.Duration) bool {
+	if period <= 0 {
+		return true
+	}
+	if elapsed < period {
+		return false
+	}
+	return elapsed%period < d
+}
+
+func (c Config) withDefaults() Config {
+	if c.Tick <= 0 {
+		c.Tick = DefaultTick
+	}
+	if c.Volatility <= 0 {
+		c.Volatility = DefaultVolatility
+	}
+	if c.Correlation <= 0 {
+		c.Correlation = DefaultCorrelation
+	}
+	if c.Correlation > 1 {
+		c.Correlation = 1
+	}
+	if c.HalfSpreadPct <= 0 {
+		c.HalfSpreadPct = DefaultHalfSpreadPct
+	}
+	if c.Depth.Levels <= 0 {
+		c.Depth.Levels = DefaultDepthLevels
+	}
+	if c.Depth.Notional <= 0 {
+		c.Depth.Notional = DefaultDepthNotional
+	}
+	if c.TradeRate <= 0 {
+		c.TradeRate = DefaultTradeRate
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	return c
+}
+
+// Quote is a venue's simulated book for a canonical symbol, in base asset
+// and prices per unit of it.
+type Quote struct {
+	Venue  string
+	Symbol string
+	Bids   []Level
+	Asks   []Level
+	// Trade is set when the venue printed a trade this tick.
+	Trade *Trade
+	Time  time.Time
+}
+
+// Level is one price level.
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// Trade is a simulated trade.
+type Trade struct {
+	ID    int64
+	Price float64
+	Size  float64
+	Buy   bool
+}
+
+// Simulator is a simulated market. It is safe for concurrent use.
+type Simulator struct {
+	cfg   Config
+	start time.Time
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	symbols map[string]*walk
+	tradeID int64
+}
+
+// walk is the state of one symbol: the common price and each venue's.
+type walk struct {
+	fair   float64
+	venues map[string]float64
+	order  []string
+	last   time.Time
+}
+
+// New returns a market described by cfg, starting now.
+func New(cfg Config) *Simulator {
+	cfg = cfg.withDefaults()
+	return &Simulator{
+		cfg:     cfg,
+		start:   time.Now(),
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		symbols: make(map[string]*walk),
+	}
+}
+
+// Config returns the market's settings with defaults filled in.
+func (s *Simulator) Config() Config {
+	return s.cfg
+}
+
+func (s *Simulator) startPrice(symbol string) float64 {
+	if p, ok := s.cfg.Prices[symbol]; ok && p > 0 {
+		return p
+	}
+	base, _, _ := exchanges.ParseSymbol(symbol)
+	if p, ok := startPrices[base]; ok {
+		return p
+	}
+	return 1
+}
+
+// advance moves w forward tick by tick up to now. The caller must hold mu.
+func (s *Simulator) advance(w *walk, now time.Time) {
+	vol, rho := s.cfg.Volatility, s.cfg.Correlation
+	own := math.Sqrt(1 - rho*rho)
+	for !w.last.Add(s.cfg.Tick).After(now) {
+		common := vol * s.rng.NormFloat64()
+		w.fair *= math.Exp(common)
+		// Venues draw in a fixed order so a seed replays the same market.
+		for _, venue := range w.order {
+			p := w.venues[venue] * math.Exp(rho*common+own*vol*s.rng.NormFloat64())
+			w.venues[venue] = p + reversion*(w.fair-p)
+		}
+		w.last = w.last.Add(s.cfg.Tick)
+	}
+}
+
+// Down reports whether venue is in a scheduled outage at t.
+func (s *Simulator) Down(venue string, t time.Time) bool {
+	elapsed := t.Sub(s.start)
+	for _, o := range s.cfg.Outages {
+		if o.Venue == venue && active(elapsed, o.Every, o.For) {
+			return true
+		}
+	}
+	return false
+}
+
+// injectedPct is the spread injected into venue's symbol at t, in percent.
+func (s *Simulator) injectedPct(venue, symbol string, t time.Time) float64 {
+	elapsed := t.Sub(s.start)
+	pct := 0.0
+	for _, sp := range s.cfg.Spreads {
+		if sp.Venue != venue || (sp.Symbol != "" && sp.Symbol != symbol) {
+			continue
+		}
+		if active(elapsed, sp.Every, sp.For) {
+			pct += sp.Pct
+		}
+	}
+	return pct
+}
+
+// Latency returns the delay for a quote from venue, jitter included.
+func (s *Simulator) Latency(venue string) time.Duration {
+	d, ok := s.cfg.Latency[venue]
+	if !ok {
+		d, ok = s.cfg.Latency["*"]
+	}
+	if !ok {
+		d = DefaultLatency
+	}
+	if s.cfg.Jitter > 0 {
+		s.mu.Lock()
+		d += time.Duration(s.rng.Int63n(int64(s.cfg.Jitter) + 1))
+		s.mu.Unlock()
+	}
+	return d
+}
+
+// Quote returns venue's book for a canonical symbol at t, with a trade on
+// some ticks.
+func (s *Simulator) Quote(venue, symbol string, t time.Time) Quote {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.symbols[symbol]
+	if !ok {
+		p := s.startPrice(symbol)
+		w = &walk{fair: p, venues: make(map[string]float64), last: t}
+		s.symbols[symbol] = w
+	}
+	s.advance(w, t)
+	p, ok := w.venues[venue]
+	if !ok {
+		p = w.fair
+		w.venues[venue] = p
+		w.order = append(w.order, venue)
+	}
+
+	mid := p * (1 + s.injectedPct(venue, symbol, t)/100)
+	half := mid * s.cfg.HalfSpreadPct / 100
+	q := Quote{Venue: venue, Symbol: symbol, Time: t}
+	for i := 0; i < s.cfg.Depth.Levels; i++ {
+		step := half * float64(2*i+1)
+		q.Bids = append(q.Bids, Level{Price: mid - step, Size: s.levelSize(mid)})
+		q.Asks = append(q.Asks, Level{Price: mid + step, Size: s.levelSize(mid)})
+	}
+
+	if s.rng.Float64() < s.cfg.TradeRate {
+		s.tradeID++
+		buy := s.rng.Intn(2) == 0
+		price := q.Bids[0].Price
+		if buy {
+			price = q.Asks[0].Price
+		}
+		q.Trade = &Trade{ID: s.tradeID, Price: price, Size: s.levelSize(mid) * s.rng.Float64() / 4, Buy: buy}
+	}
+	return q
+}
+
+// levelSize is a random size around the configured notional at price. The
+// caller must hold mu.
+func (s *Simulator) levelSize(price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	return s.cfg.Depth.Notional / price * (0.5 + s.rng.Float64())
+}
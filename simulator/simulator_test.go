@@ -0,0 +1,210 @@
+package simulator
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"futures-arbitrage-scanner/exchanges"
+	"futures-arbitrage-scanner/scanner"
+)
+
+func mid(q Quote) float64 {
+	return (q.Bids[0].Price + q.Asks[0].Price) / 2
+}
+
+func TestQuoteRepeatsWithSeed(t *testing.T) {
+	cfg := Config{Seed: 7}
+	a, b := New(cfg), New(cfg)
+	b.start = a.start
+	for i := 1; i <= 20; i++ {
+		now := a.start.Add(time.Duration(i) * a.cfg.Tick)
+		for _, venue := range []string{"binance_futures", "okx_futures"} {
+			qa, qb := a.Quote(venue, "BTCUSDT", now), b.Quote(venue, "BTCUSDT", now)
+			if mid(qa) != mid(qb) {
+				t.Fatalf("tick %d %s: mids %v and %v differ with the same seed", i, venue, mid(qa), mid(qb))
+			}
+		}
+	}
+}
+
+func TestQuoteCorrelation(t *testing.T) {
+	s := New(Config{Seed: 1, Volatility: 0.001})
+	venues := []string{"binance_futures", "bybit_futures", "okx_futures"}
+	for i := 0; i <= 2000; i++ {
+		now := s.start.Add(time.Duration(i) * s.cfg.Tick)
+		var lo, hi float64
+		for j, venue := range venues {
+			m := mid(s.Quote(venue, "ETHUSDT", now))
+			if j == 0 || m < lo {
+				lo = m
+			}
+			if j == 0 || m > hi {
+				hi = m
+			}
+		}
+		// Venues revert to the common price, so they never drift far apart
+		// even as it wanders.
+		if gap := (hi - lo) / lo * 100; gap > 0.5 {
+			t.Fatalf("tick %d: venues %.3f%% apart", i, gap)
+		}
+	}
+}
+
+func TestQuoteBook(t *testing.T) {
+	s := New(Config{Seed: 1, HalfSpreadPct: 0.05, Depth: Depth{Levels: 3, Notional: 1000}, TradeRate: 1})
+	q := s.Quote("gate_futures", "SOLUSDT", s.start)
+	if len(q.Bids) != 3 || len(q.Asks) != 3 {
+		t.Fatalf("levels = %d/%d, want 3/3", len(q.Bids), len(q.Asks))
+	}
+	if got := mid(q); got != 150 {
+		t.Errorf("mid = %v, want the SOL start price 150", got)
+	}
+	if got := (q.Asks[0].Price - q.Bids[0].Price) / mid(q) * 100; math.Abs(got-0.1) > 1e-9 {
+		t.Errorf("spread = %v%%, want 0.1%%", got)
+	}
+	for i := 1; i < 3; i++ {
+		if q.Bids[i].Price >= q.Bids[i-1].Price || q.Asks[i].Price <= q.Asks[i-1].Price {
+			t.Fatalf("book not sorted best first: %+v %+v", q.Bids, q.Asks)
+		}
+	}
+	if q.Trade == nil {
+		t.Fatal("no trade with trade_rate 1")
+	}
+}
+
+func TestInjectedSpreadAndOutage(t *testing.T) {
+	s := New(Config{
+		Seed:    1,
+		Spreads: []Spread{{Venue: "okx_futures", Symbol: "BTCUSDT", Pct: 2, Every: time.Minute, For: 10 * time.Second}},
+		Outages: []Outage{{Venue: "bybit_futures", Every: time.Minute, For: 5 * time.Second}},
+	})
+	tests := []struct {
+		elapsed time.Duration
+		spread  bool
+		down    bool
+	}{
+		{0, false, false},
+		{30 * time.Second, false, false},
+		{time.Minute, true, true},
+		{time.Minute + 7*time.Second, true, false},
+		{time.Minute + 20*time.Second, false, false},
+		{2*time.Minute + time.Second, true, true},
+	}
+	for _, tt := range tests {
+		now := s.start.Add(tt.elapsed)
+		okx, binance := mid(s.Quote("okx_futures", "BTCUSDT", now)), mid(s.Quote("binance_futures", "BTCUSDT", now))
+		if got := (okx-binance)/binance*100 > 1; got != tt.spread {
+			t.Errorf("at %v: spread injected = %v, want %v (okx %v, binance %v)", tt.elapsed, got, tt.spread, okx, binance)
+		}
+		if got := s.Down("bybit_futures", now); got != tt.down {
+			t.Errorf("at %v: bybit down = %v, want %v", tt.elapsed, got, tt.down)
+		}
+		if s.Down("okx_futures", now) {
+			t.Errorf("at %v: okx down without an outage", tt.elapsed)
+		}
+	}
+}
+
+func TestLatency(t *testing.T) {
+	s := New(Config{Latency: map[string]time.Duration{"pyth": time.Second, "*": 10 * time.Millisecond}, Jitter: 5 * time.Millisecond})
+	if got := s.Latency("pyth"); got < time.Second || got > time.Second+5*time.Millisecond {
+		t.Errorf("pyth latency = %v, want 1s plus up to 5ms", got)
+	}
+	if got := s.Latency("gate_futures"); got < 10*time.Millisecond || got > 15*time.Millisecond {
+		t.Errorf("gate latency = %v, want 10ms plus up to 5ms", got)
+	}
+	if got := New(Config{}).Latency("gate_futures"); got != DefaultLatency {
+		t.Errorf("default latency = %v, want %v", got, DefaultLatency)
+	}
+}
+
+func feeds() (scanner.Feeds, chan exchanges.PriceData, chan exchanges.OrderbookData, chan exchanges.TradeData) {
+	prices := make(chan exchanges.PriceData, 100)
+	books := make(chan exchanges.OrderbookData, 100)
+	trades := make(chan exchanges.TradeData, 100)
+	return scanner.Feeds{Prices: prices, Orderbooks: books, Trades: trades}, prices, books, trades
+}
+
+func TestConnector(t *testing.T) {
+	s := New(Config{Seed: 1, Tick: 10 * time.Millisecond, Latency: map[string]time.Duration{"*": 0}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, prices, books, _ := feeds()
+	symbols := exchanges.NewSymbolSet([]string{"BTCUSDT"})
+	go s.Connector("gate_futures").Run(ctx, symbols, f)
+	go s.Connector("pyth").Run(ctx, symbols, f)
+
+	select {
+	case b := <-books:
+		if b.Source != "gate_futures" || b.Symbol != "BTCUSDT" || b.BestBid >= b.BestAsk || b.BidSize <= 0 {
+			t.Errorf("book = %+v", b)
+		}
+		if b.ReceivedAt < b.Timestamp {
+			t.Errorf("received at %d before its timestamp %d", b.ReceivedAt, b.Timestamp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no book from gate_futures")
+	}
+	select {
+	case p := <-prices:
+		if p.Source != "pyth" || math.Abs(p.Price-60000)/60000 > 0.01 {
+			t.Errorf("price = %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no price from pyth")
+	}
+}
+
+func TestConnectors(t *testing.T) {
+	s := New(Config{})
+	in := []scanner.Connector{
+		{Name: "binance_futures", Symbols: []string{"BTCUSDT"}},
+		{Name: "pyth", Disabled: true},
+	}
+	out := s.Connectors(in, "binance_futures")
+	if out[0].Run != nil {
+		t.Error("kept connector was replaced")
+	}
+	if out[1].Run == nil || !out[1].Live || !out[1].Disabled {
+		t.Errorf("pyth = %+v, want a live simulated connector, still disabled", out[1])
+	}
+}
+
+// TestServe runs the real Binance connector against the fake server.
+func TestServe(t *testing.T) {
+	s := New(Config{Seed: 1, Tick: 20 * time.Millisecond, Latency: map[string]time.Duration{"*": 0}, TradeRate: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer exchanges.SetEndpoints("binance_futures", exchanges.Endpoints{})
+
+	served, err := s.Serve(ctx, []string{"binance_futures", "pyth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(served) != 1 || served[0] != "binance_futures" {
+		t.Fatalf("served %v, want only binance_futures", served)
+	}
+
+	_, prices, books, trades := feeds()
+	go exchanges.ConnectBinanceFutures(ctx, exchanges.NewSymbolSet([]string{"ETHUSDT"}), prices, books, trades)
+
+	select {
+	case b := <-books:
+		if b.Source != "binance_futures" || b.Symbol != "ETHUSDT" || math.Abs(b.BestBid-3000)/3000 > 0.01 || b.BidSize <= 0 {
+			t.Errorf("book = %+v", b)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no book through the fake server")
+	}
+	select {
+	case tr := <-trades:
+		if tr.Symbol != "ETHUSDT" || tr.Quantity <= 0 {
+			t.Errorf("trade = %+v", tr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trade through the fake server")
+	}
+}